@@ -0,0 +1,71 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"time"
+)
+
+//DefaultAgeBuckets are a day, a week, a month and a year.
+var DefaultAgeBuckets = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, 365 * 24 * time.Hour}
+
+//AgeStat is how many queued files were last modified within MaxAge, and after the bucket before it,
+//and how many bytes they hold. MaxAge is 0 for the last bucket holding everything older.
+type AgeStat struct {
+	MaxAge time.Duration
+	Count  int
+	Bytes  int64
+}
+
+//AgeStats returns the histogram of file ages of the last Walk, one AgeStat for every AgeBuckets and one
+//for everything older. Ages are from when the walk started, files modified after that count as new.
+//It returns nil if AgeBuckets was not set. It should not be called while walking.
+func (sw *Skywalker) AgeStats() []AgeStat {
+	if sw.ages == nil {
+		return nil
+	}
+	stats := make([]AgeStat, len(sw.ages.stats))
+	copy(stats, sw.ages.stats)
+	return stats
+}
+
+//ascending reports whether every bucket is positive and older than the one before it.
+func ascending(buckets []time.Duration) bool {
+	var last time.Duration
+	for _, bound := range buckets {
+		if bound <= last {
+			return false
+		}
+		last = bound
+	}
+	return true
+}
+
+//ages is the histogram being filled in by a walk. Only the walking goroutine uses it.
+type ages struct {
+	start time.Time
+	stats []AgeStat
+}
+
+func newAges(buckets []time.Duration) *ages {
+	a := &ages{start: time.Now(), stats: make([]AgeStat, len(buckets)+1)}
+	for i, bound := range buckets {
+		a.stats[i].MaxAge = bound
+	}
+	return a
+}
+
+func (a *ages) add(info os.FileInfo) {
+	age := a.start.Sub(info.ModTime())
+	i := 0
+	for ; i < len(a.stats)-1; i++ {
+		if age <= a.stats[i].MaxAge {
+			break
+		}
+	}
+	a.stats[i].Count++
+	a.stats[i].Bytes += info.Size()
+}
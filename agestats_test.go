@@ -0,0 +1,55 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgeStats(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"new.txt":       "1",
+		"week.txt":      "22",
+		"month/old.txt": "333",
+		"ancient.txt":   "4444",
+		"ancient2.txt":  "55555",
+	})
+	day := 24 * time.Hour
+	for rel, age := range map[string]time.Duration{
+		"week.txt":      3 * day,
+		"month/old.txt": 20 * day,
+		"ancient.txt":   2 * 365 * day,
+		"ancient2.txt":  3 * 365 * day,
+	} {
+		when := time.Now().Add(-age)
+		assert.NoError(os.Chtimes(filepath.Join(tmp, filepath.FromSlash(rel)), when, when))
+	}
+	sw := skywalker.New(tmp, NewTW())
+	assert.NoError(sw.Walk())
+	assert.Nil(sw.AgeStats())
+
+	sw.AgeBuckets = skywalker.DefaultAgeBuckets
+	assert.NoError(sw.Walk())
+	assert.Equal([]skywalker.AgeStat{
+		{MaxAge: day, Count: 1, Bytes: 1},
+		{MaxAge: 7 * day, Count: 1, Bytes: 2},
+		{MaxAge: 30 * day, Count: 1, Bytes: 3},
+		{MaxAge: 365 * day, Count: 0, Bytes: 0},
+		{MaxAge: 0, Count: 2, Bytes: 9},
+	}, sw.AgeStats())
+
+	sw.AgeBuckets = []time.Duration{day, day}
+	var configErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &configErr))
+}
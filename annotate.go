@@ -0,0 +1,46 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+//Annotations are extra facts about a file found right before it is handed to the Worker.
+//They are only filled in when the Skywalker is asked for them.
+type Annotations struct {
+	//Encoding is set when DetectEncoding is true. It is EUnknown for directories and unreadable files.
+	Encoding Encoding
+	//Language is set when DetectLanguage is true. See the DetectLanguage function.
+	Language string
+}
+
+//annotate fills in the Annotations of w. It returns false if w should not be handed to the Worker.
+func (sw *Skywalker) annotate(w item) (Annotations, bool) {
+	var a Annotations
+	if w.info.IsDir() {
+		return a, true
+	}
+	encoding := sw.DetectEncoding || len(sw.Encodings) > 0
+	if !encoding && !sw.DetectLanguage {
+		return a, true
+	}
+	prefix, err := sw.readPrefix(w.path)
+	if encoding && err == nil {
+		a.Encoding = SniffEncoding(prefix)
+	}
+	if len(sw.Encodings) > 0 && !hasEncoding(sw.Encodings, a.Encoding) {
+		return a, false
+	}
+	if sw.DetectLanguage {
+		a.Language = DetectLanguage(w.path, prefix)
+	}
+	return a, true
+}
+
+func hasEncoding(list []Encoding, e Encoding) bool {
+	for _, l := range list {
+		if l == e {
+			return true
+		}
+	}
+	return false
+}
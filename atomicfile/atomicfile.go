@@ -0,0 +1,147 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Package atomicfile writes files by writing to a temporary file and renaming it into place.
+//Readers never see a partially written file and concurrent writers never share a temporary file.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+//SyncPolicy is used to specify how hard to try making a write survive a crash.
+type SyncPolicy int
+
+const (
+	//SPNone is used to leave flushing to the operating system.
+	SPNone SyncPolicy = iota
+	//SPFile is used to fsync the file before it is renamed into place.
+	SPFile
+	//SPDir is used to fsync the file and then the directory after the rename so the rename itself is durable.
+	SPDir
+)
+
+//File is a temporary file that replaces Path once it is committed.
+//Write to it like any other file then call Commit or Abort.
+type File struct {
+	*os.File
+
+	//Path is the file that will be replaced.
+	Path string
+
+	policy SyncPolicy
+	perm   os.FileMode
+	owner  *owner
+	done   bool
+}
+
+//Create starts an atomic write of path.
+//If path already exists its permissions (and owner where supported) are kept, otherwise perm is used.
+func Create(path string, perm os.FileMode, policy SyncPolicy) (*File, error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	var own *owner
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+		own = fileOwner(info)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		File:   tmp,
+		Path:   path,
+		policy: policy,
+		perm:   perm,
+		owner:  own,
+	}, nil
+}
+
+//Commit replaces Path with everything written so far.
+//The temporary file is removed if anything goes wrong.
+func (f *File) Commit() error {
+	if f.done {
+		return &os.PathError{Op: "commit", Path: f.Path, Err: os.ErrClosed}
+	}
+	f.done = true
+	tmp := f.Name()
+	err := f.commit(tmp)
+	if err != nil {
+		f.File.Close()
+		os.Remove(tmp)
+		return &os.PathError{Op: "commit", Path: f.Path, Err: err}
+	}
+	return nil
+}
+
+func (f *File) commit(tmp string) error {
+	if f.policy >= SPFile {
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := f.File.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, f.perm); err != nil {
+		return err
+	}
+	if f.owner != nil {
+		if err := f.owner.apply(tmp); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return err
+	}
+	if f.policy >= SPDir {
+		return syncDir(filepath.Dir(f.Path))
+	}
+	return nil
+}
+
+//Abort throws away everything written and leaves Path untouched.
+//It is safe to call after Commit which makes it handy to defer.
+func (f *File) Abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	f.File.Close()
+	return os.Remove(f.Name())
+}
+
+//Close is the same as Abort. Call Commit to keep what was written.
+func (f *File) Close() error {
+	return f.Abort()
+}
+
+//Write atomically replaces path with whatever fn writes.
+//Nothing is replaced if fn returns an error.
+func Write(path string, perm os.FileMode, policy SyncPolicy, fn func(w io.Writer) error) error {
+	f, err := Create(path, perm, policy)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if err = fn(f); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+//WriteFile atomically replaces path with data.
+func WriteFile(path string, data []byte, perm os.FileMode, policy SyncPolicy) error {
+	return Write(path, perm, policy, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,25 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package atomicfile
+
+import "os"
+
+type owner struct{}
+
+func fileOwner(os.FileInfo) *owner {
+	return nil
+}
+
+func (o *owner) apply(string) error {
+	return nil
+}
+
+//syncDir does nothing as directories can not be synced here.
+func syncDir(string) error {
+	return nil
+}
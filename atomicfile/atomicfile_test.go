@@ -0,0 +1,65 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package atomicfile_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFile(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	assert.NoError(atomicfile.WriteFile(path, []byte("first"), 0600, atomicfile.SPDir))
+	assert.NoError(os.Chmod(path, 0640))
+	assert.NoError(atomicfile.WriteFile(path, []byte("second"), 0600, atomicfile.SPFile))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("second", string(data))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.NoError(err)
+		assert.Equal(os.FileMode(0640), info.Mode().Perm(), "Should keep the permissions of the replaced file")
+	}
+	assertOnlyFile(t, dir, "file.txt")
+}
+
+func TestWriteAborted(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	assert.NoError(atomicfile.WriteFile(path, []byte("original"), 0666, atomicfile.SPNone))
+
+	failed := errors.New("transform failed")
+	err := atomicfile.Write(path, 0666, atomicfile.SPNone, func(w io.Writer) error {
+		w.Write([]byte("half written"))
+		return failed
+	})
+	assert.Equal(failed, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("original", string(data))
+	assertOnlyFile(t, dir, "file.txt")
+}
+
+func assertOnlyFile(t *testing.T, dir, name string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "Temporary files should be cleaned up")
+	if len(entries) == 1 {
+		assert.Equal(t, name, entries[0].Name())
+	}
+}
@@ -0,0 +1,43 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package atomicfile
+
+import (
+	"os"
+	"syscall"
+)
+
+type owner struct {
+	uid, gid int
+}
+
+func fileOwner(info os.FileInfo) *owner {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return &owner{uid: int(st.Uid), gid: int(st.Gid)}
+}
+
+//apply gives path the same owner as the file it replaces.
+//Only root can give files away so a permission error is not a failure.
+func (o *owner) apply(path string) error {
+	if err := os.Lchown(path, o.uid, o.gid); err != nil && !os.IsPermission(err) {
+		return err
+	}
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
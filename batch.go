@@ -0,0 +1,182 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//DefaultBatchSize is how many paths a BatchWorker gets at most if BatchSize is not set.
+const DefaultBatchSize = 100
+
+//BatchWorker is a Worker that is handed the paths of a directory together, e.g. to write them in one database
+//transaction or append them to an archive in one go. WorkBatch is called instead of Work with up to BatchSize
+//paths that are all in dir. Paths are held back until a batch is full or the walk left their directory, so a
+//batch can span the subdirectories found in between. Paths handed to Enqueue are not held back and come alone.
+type BatchWorker interface {
+	Worker
+	WorkBatch(dir string, paths []string)
+}
+
+//batch is a directory's paths waiting for more.
+type batch struct {
+	dir   string
+	items []item
+}
+
+//batcher groups the paths of a directory before they are sent on. The directories of the batches it holds are
+//always inside each other as the walk goes depth first. Only the walking goroutine uses it.
+type batcher struct {
+	size     int
+	dir      func(string) string
+	sep      string
+	dispatch func(item)
+	stack    []*batch
+}
+
+func (sw *Skywalker) newBatcher(dispatch func(item)) *batcher {
+	b := &batcher{size: sw.batchSize(), dir: filepath.Dir, sep: string(filepath.Separator), dispatch: dispatch}
+	if sw.FS != nil {
+		b.dir, b.sep = pathpkg.Dir, "/"
+	}
+	return b
+}
+
+func (sw *Skywalker) batchSize() int {
+	if sw.BatchSize == 0 {
+		return DefaultBatchSize
+	}
+	return sw.BatchSize
+}
+
+//push adds w to the batch of its directory, sending on the batches of every directory the walk left.
+func (b *batcher) push(w item) {
+	dir := b.dir(w.path)
+	for len(b.stack) > 0 {
+		top := b.stack[len(b.stack)-1]
+		if dir == top.dir || b.inside(top.dir, dir) {
+			break
+		}
+		b.pop()
+	}
+	var top *batch
+	if n := len(b.stack); n > 0 && b.stack[n-1].dir == dir {
+		top = b.stack[n-1]
+	} else {
+		top = &batch{dir: dir}
+		b.stack = append(b.stack, top)
+	}
+	top.items = append(top.items, w)
+	if len(top.items) >= b.size {
+		b.send(top)
+	}
+}
+
+func (b *batcher) inside(dir, path string) bool {
+	if b.sep == "/" && dir == "." {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, b.sep)+b.sep)
+}
+
+func (b *batcher) pop() {
+	top := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	b.send(top)
+}
+
+//send hands the paths of bt to a worker as a single item.
+func (b *batcher) send(bt *batch) {
+	if len(bt.items) == 0 {
+		return
+	}
+	items := bt.items
+	bt.items = nil
+	b.dispatch(item{path: bt.dir, batch: items})
+}
+
+//flush sends every batch that is still waiting.
+func (b *batcher) flush() {
+	for len(b.stack) > 0 {
+		b.pop()
+	}
+}
+
+//workBatch hands the paths of items to the BatchWorker in one call. Everything work does for a single path
+//but calling the Worker is still done for each of them. Paths left out by Encodings are not handed on, but as
+//WorkBatch only takes paths the annotations of the others are dropped.
+func (sw *Skywalker) workBatch(bw BatchWorker, dir string, items []item) {
+	started := time.Now()
+	errs := make([]error, len(items))
+	paths := make([]string, 0, len(items))
+	//batched are the indexes of the items whose paths are handed to the worker.
+	batched := make([]int, 0, len(items))
+	keys := make([]string, len(items))
+	for i, w := range items {
+		sw.countWalked(w)
+		if errs[i] = sw.ctxErr(); errs[i] != nil {
+			continue
+		}
+		if sw.isCanceled(w.path) {
+			errs[i] = ErrCanceled
+			continue
+		}
+		if sw.Known != nil && fileType(w.info).IsRegular() && sw.known(w.path) {
+			sw.emit(Event{Kind: EKSkipped, Path: w.path, Root: w.root, Info: w.info, Reason: RKnown})
+			continue
+		}
+		if len(sw.ContentTypeList) > 0 && fileType(w.info).IsRegular() && !sw.contentTypeOK(w.path) {
+			sw.emit(Event{Kind: EKSkipped, Path: w.path, Root: w.root, Info: w.info, Reason: RContentType})
+			continue
+		}
+		if sw.Journal != nil && fileType(w.info).IsRegular() {
+			if keys[i] = sw.journalKey(w); keys[i] != "" && sw.Journal.Contains(keys[i]) {
+				sw.emit(Event{Kind: EKSkipped, Path: w.path, Root: w.root, Info: w.info, Reason: RJournaled})
+				continue
+			}
+		}
+		if errs[i] = sw.rateWait(); errs[i] != nil {
+			continue
+		}
+		if _, ok := sw.annotate(w); !ok {
+			continue
+		}
+		paths = append(paths, w.path)
+		batched = append(batched, i)
+	}
+	if len(paths) > 0 {
+		if err := sw.protect(dir, func() { bw.WorkBatch(dir, paths) }); err != nil {
+			sw.workErr(dir, err)
+			for _, i := range batched {
+				errs[i] = err
+			}
+		}
+		for _, i := range batched {
+			if errs[i] == nil && keys[i] != "" {
+				sw.journal(keys[i])
+			}
+		}
+	}
+	for i, w := range items {
+		w := w
+		if sw.segments != nil {
+			sw.segments.worked(w.root, w.path, w.info.IsDir(), started)
+		}
+		if w.future != nil {
+			w.future.resolve(Result{Err: errs[i]})
+		}
+		sw.countDone(w)
+		ev := Event{Kind: EKDone, Path: w.path, Root: w.root, Info: w.info, Err: errs[i], Duration: time.Since(started)}
+		sw.deliver(w, func() {
+			if sw.progress != nil && w.walked {
+				sw.worked(w)
+			}
+			sw.emit(ev)
+		})
+	}
+}
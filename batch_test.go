@@ -0,0 +1,84 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchWorker struct {
+	sync.Mutex
+	batches [][]string
+	dirs    []string
+}
+
+func (bw *batchWorker) Work(path string) {}
+
+func (bw *batchWorker) WorkBatch(dir string, paths []string) {
+	bw.Lock()
+	defer bw.Unlock()
+	bw.dirs = append(bw.dirs, dir)
+	bw.batches = append(bw.batches, paths)
+}
+
+func TestBatchWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a/1": "", "a/2": "", "a/3": "", "a/m/x": "", "a/z": "",
+		"b/1": "",
+		"c":   "",
+	})
+	bw := &batchWorker{}
+	sw := skywalker.New(tmp, bw)
+	sw.BatchSize = 2
+	var done int
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKDone {
+			bw.Lock()
+			done++
+			bw.Unlock()
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Equal(7, done)
+	found := make(map[string]bool)
+	batches := make(map[string]int)
+	for i, paths := range bw.batches {
+		assert.True(len(paths) > 0 && len(paths) <= 2, "Expected 1 or 2 paths in a batch but got %d", len(paths))
+		for _, path := range paths {
+			assert.Equal(bw.dirs[i], filepath.Dir(path))
+			found[path] = true
+		}
+		rel, _ := filepath.Rel(sw.Root, bw.dirs[i])
+		batches[filepath.ToSlash(rel)]++
+	}
+	assert.Len(found, 7)
+	//a holds 4 files, so 2 full batches, the rest are sent as the walk leaves their directory.
+	assert.Equal(map[string]int{"a": 2, "a/m": 1, "b": 1, ".": 1}, batches)
+}
+
+func TestBatchWorkerEncodings(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "a", "b.txt": "b", "c.bin": "\x00\x01\x02"})
+	bw := &batchWorker{}
+	sw := skywalker.New(tmp, bw)
+	sw.FilesOnly = true
+	sw.Encodings = []skywalker.Encoding{skywalker.EASCII}
+	assert.NoError(sw.Walk())
+	var paths []string
+	for _, batch := range bw.batches {
+		paths = append(paths, batch...)
+	}
+	sort.Strings(paths)
+	assert.Equal([]string{filepath.Join(tmp, "a.txt"), filepath.Join(tmp, "b.txt")}, paths)
+}
@@ -0,0 +1,50 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+//ErrCanceled is handed to FinishChunks for a file whose subtree was canceled before all of its chunks were done.
+var ErrCanceled = errors.New("subtree was canceled")
+
+//CancelSubtree stops the current walk from going any further into the directory at path, e.g. once the
+//user of a disk analyzer navigates away from it. Nothing more below it is found and anything below it
+//still waiting in the queue is dropped. Work that already started is not interrupted.
+//path may be relative to Root. It is safe to call from any goroutine, including workers, and
+//cancellations are forgotten when the next Walk starts.
+func (sw *Skywalker) CancelSubtree(path string) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(sw.Root, path)
+	}
+	path = filepath.Clean(path)
+	sw.cancelMutex.Lock()
+	defer sw.cancelMutex.Unlock()
+	old, _ := sw.canceled.Load().([]string)
+	canceled := make([]string, len(old), len(old)+1)
+	copy(canceled, old)
+	sw.canceled.Store(append(canceled, path))
+}
+
+//isCanceled reports whether path is in a canceled subtree.
+func (sw *Skywalker) isCanceled(path string) bool {
+	canceled, _ := sw.canceled.Load().([]string)
+	for _, dir := range canceled {
+		if path == dir || strings.HasPrefix(path, dir) && (strings.HasSuffix(dir, string(filepath.Separator)) || path[len(dir)] == filepath.Separator) {
+			return true
+		}
+	}
+	return false
+}
+
+//resetCanceled forgets every canceled subtree.
+func (sw *Skywalker) resetCanceled() {
+	sw.cancelMutex.Lock()
+	sw.canceled.Store([]string(nil))
+	sw.cancelMutex.Unlock()
+}
@@ -0,0 +1,54 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//CancelingWorker cancels the subtree of the directory named cancel as soon as it is worked on.
+type CancelingWorker struct {
+	*TestWorker
+	sw     *skywalker.Skywalker
+	cancel string
+}
+
+func (cw *CancelingWorker) Work(path string) {
+	cw.TestWorker.Work(path)
+	if filepath.Base(path) == cw.cancel {
+		cw.sw.CancelSubtree(cw.cancel)
+	}
+}
+
+func TestCancelSubtree(t *testing.T) {
+	assert := assert.New(t)
+	cw := &CancelingWorker{TestWorker: NewTW(), cancel: "sub"}
+	sw := skywalker.New(root, cw)
+	cw.sw = sw
+	sw.NumWorkers = 1
+	sw.QueueSize = 0
+	sw.FilesOnly = false
+	assert.NoError(sw.Walk())
+
+	sub := filepath.Join(sw.Root, "sub")
+	_, ok := cw.found[sub]
+	assert.True(ok, "The canceled directory itself was already being worked on")
+	for path := range cw.found {
+		assert.False(strings.HasPrefix(path, sub+string(filepath.Separator)), "%s is in the canceled subtree", path)
+	}
+	_, ok = cw.found[filepath.Join(sw.Root, "subfolder", "just.txt")]
+	assert.True(ok, "Siblings sharing the prefix should still be walked")
+
+	tw := NewTW()
+	sw.Worker = tw
+	assert.NoError(sw.Walk())
+	_, ok = tw.found[filepath.Join(sub, "just.txt")]
+	assert.True(ok, "Cancellations should be forgotten by the next walk")
+}
@@ -0,0 +1,160 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+)
+
+//CASWorker is a Worker that stores every file it is given in a content addressed store in Dest, keyed by the hex
+//encoded digest of its contents, e.g. objects/ab/cdef... for a digest starting with abcdef. Objects already in the
+//store are not written again, so a tree is only stored once no matter how often it or files in it are walked.
+//Digests maps every stored file to its digest, which is all that is needed to put the tree back together.
+//Directories are ignored so it is best used with FilesOnly.
+type CASWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//Dest is the directory of the store. Objects are kept in its objects directory.
+	Dest string
+
+	//Hash is used to key the objects. Defaults to sha256.New, see LookupHash for others.
+	//Stores should stick to the one they were started with.
+	Hash func() hash.Hash
+
+	//Sync is how hard new objects are pushed to disk before they count as stored.
+	Sync atomicfile.SyncPolicy
+
+	//Limiter caps how fast files are read if it is set.
+	Limiter *ByteLimiter
+
+	root rootRel
+
+	mutex   sync.Mutex
+	digests map[string]string
+	added   int
+}
+
+//NewCASWorker creates a CASWorker that stores the files found in root in the store at dest.
+func NewCASWorker(root, dest string) *CASWorker {
+	return &CASWorker{
+		Root:    root,
+		Dest:    dest,
+		digests: make(map[string]string),
+	}
+}
+
+//UseHash sets Hash, see Skywalker.HashName.
+func (cw *CASWorker) UseHash(newHash func() hash.Hash) {
+	cw.Hash = newHash
+}
+
+//Work stores the file at path.
+func (cw *CASWorker) Work(path string) {
+	cw.WorkErr(path)
+}
+
+//WorkErr is Work returning why the file at path could not be stored, so the walk returns it as well.
+func (cw *CASWorker) WorkErr(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+	rel, err := cw.root.rel(cw.Root, path)
+	if err != nil {
+		return err
+	}
+	sum, err := checksum(path, cw.newHash(), cw.Limiter)
+	if err != nil {
+		return err
+	}
+	digest := hex.EncodeToString(sum)
+	added := false
+	if _, err = os.Stat(cw.ObjectPath(digest)); os.IsNotExist(err) {
+		added, err = true, cw.store(path, digest)
+	}
+	if err != nil {
+		return err
+	}
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	cw.digests[filepath.ToSlash(rel)] = digest
+	if added {
+		cw.added++
+	}
+	return nil
+}
+
+func (cw *CASWorker) newHash() hash.Hash {
+	if cw.Hash == nil {
+		return sha256.New()
+	}
+	return cw.Hash()
+}
+
+//store writes the file at path into the store as the object for digest.
+//The contents are hashed again while copying so a file that changed since it was hashed is never stored under the wrong key.
+func (cw *CASWorker) store(path, digest string) error {
+	object := cw.ObjectPath(digest)
+	if err := os.MkdirAll(filepath.Dir(object), 0777); err != nil {
+		return err
+	}
+	in, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := atomicfile.Create(object, 0444, cw.Sync)
+	if err != nil {
+		return err
+	}
+	defer out.Abort()
+	h := cw.newHash()
+	if _, err = io.Copy(io.MultiWriter(out, h), cw.Limiter.Reader(in)); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != digest {
+		return ErrChecksumMismatch
+	}
+	return out.Commit()
+}
+
+//ObjectPath returns where the object with the hex encoded digest is kept in the store.
+func (cw *CASWorker) ObjectPath(digest string) string {
+	if len(digest) <= 2 {
+		return filepath.Join(cw.Dest, "objects", digest)
+	}
+	return filepath.Join(cw.Dest, "objects", digest[:2], digest[2:])
+}
+
+//Digests returns the hex encoded digest of every file stored so far by its path relative to Root, using "/".
+func (cw *CASWorker) Digests() map[string]string {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	digests := make(map[string]string, len(cw.digests))
+	for rel, digest := range cw.digests {
+		digests[rel] = digest
+	}
+	return digests
+}
+
+//Added returns how many files were written to the store so far, every other one was already in it.
+//Identical files found at the same time can each be written.
+func (cw *CASWorker) Added() int {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return cw.added
+}
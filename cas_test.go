@@ -0,0 +1,50 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCASWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp, store := t.TempDir(), t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a/one.txt": "one",
+		"b/one.txt": "one",
+		"two.txt":   "two",
+	})
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+
+	cw := skywalker.NewCASWorker(tmp, store)
+	assert.NoError(skywalker.New(tmp, cw).Walk())
+	assert.Equal(map[string]string{
+		"a/one.txt": sum("one"),
+		"b/one.txt": sum("one"),
+		"two.txt":   sum("two"),
+	}, cw.Digests())
+	assert.True(cw.Added() >= 2)
+	object := cw.ObjectPath(sum("one"))
+	assert.Equal(filepath.Join(store, "objects", sum("one")[:2], sum("one")[2:]), object)
+	data, err := os.ReadFile(object)
+	assert.NoError(err)
+	assert.Equal("one", string(data))
+
+	writeFiles(t, tmp, map[string]string{"three.txt": "three"})
+	cw = skywalker.NewCASWorker(tmp, store)
+	assert.NoError(skywalker.New(tmp, cw).Walk())
+	assert.Len(cw.Digests(), 4)
+	assert.Equal(1, cw.Added())
+}
@@ -0,0 +1,249 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//Chunk is a range of a file handed to a ChunkWorker.
+type Chunk struct {
+	Path string
+	Info os.FileInfo
+	//Index is which chunk of the file this is, counting from 0, out of Count.
+	Index int
+	Count int
+	//Offset and Length are the range of the file to process.
+	Offset int64
+	Length int64
+}
+
+//ChunkWorker is a Worker that can process a file in ranges.
+//If the Skywalker's Worker is a ChunkWorker and ChunkSize is set, every file larger than ChunkSize is split into
+//chunks of ChunkSize bytes that are handed to WorkChunk by different workers. Once every chunk is done
+//FinishChunks is called with what WorkChunk returned for each of them in order.
+//Smaller files are handed to Work as usual.
+type ChunkWorker interface {
+	Worker
+	//WorkChunk processes a single range. It is called concurrently, also for chunks of the same file.
+	WorkChunk(c Chunk) (interface{}, error)
+	//FinishChunks is called once for every file that was split, from the worker that finished its last chunk.
+	//err is the first error returned by WorkChunk for the file, values of chunks that failed are nil.
+	//It is ErrCanceled or the error of the walk's context if the rest of the chunks were dropped.
+	FinishChunks(path string, info os.FileInfo, values []interface{}, err error)
+}
+
+//chunkJob is a file split into chunks keeping what every chunk returned until the last one is done.
+type chunkJob struct {
+	path   string
+	info   os.FileInfo
+	mutex  sync.Mutex
+	values []interface{}
+	left   int
+	err    error
+	//work is how long every chunk took put together.
+	work time.Duration
+}
+
+//chunkPart is the piece of a chunkJob an item stands for.
+type chunkPart struct {
+	job    *chunkJob
+	index  int
+	offset int64
+	length int64
+}
+
+//chunked reports whether w has to be split. The Worker has to be a ChunkWorker.
+func (sw *Skywalker) chunked(w item) bool {
+	return w.chunk == nil && w.info != nil && w.info.Mode().IsRegular() && w.info.Size() > sw.ChunkSize
+}
+
+//split sends every chunk of w on to dispatch.
+func (sw *Skywalker) split(w item, dispatch func(item)) {
+	size := w.info.Size()
+	count := int((size + sw.ChunkSize - 1) / sw.ChunkSize)
+	job := &chunkJob{path: w.path, info: w.info, values: make([]interface{}, count), left: count}
+	for i := 0; i < count; i++ {
+		part := &chunkPart{job: job, index: i, offset: int64(i) * sw.ChunkSize, length: sw.ChunkSize}
+		if part.offset+part.length > size {
+			part.length = size - part.offset
+		}
+		dispatch(item{path: w.path, info: w.info, root: w.root, chunk: part, walked: w.walked, future: w.future, seq: w.seq})
+	}
+}
+
+//workChunk processes a single chunk calling FinishChunks if it was the last one of its file.
+func (sw *Skywalker) workChunk(cw ChunkWorker, w item) {
+	part, job := w.chunk, w.chunk.job
+	started := time.Now()
+	var val interface{}
+	err := sw.ctxErr()
+	if err == nil && sw.isCanceled(w.path) {
+		err = ErrCanceled
+	}
+	if err == nil {
+		err = sw.rateWait()
+	}
+	if err == nil {
+		perr := sw.protect(w.path, func() {
+			val, err = cw.WorkChunk(Chunk{
+				Path:   w.path,
+				Info:   w.info,
+				Index:  part.index,
+				Count:  len(job.values),
+				Offset: part.offset,
+				Length: part.length,
+			})
+		})
+		if perr != nil {
+			err = perr
+			sw.workErr(w.path, err)
+		}
+	}
+	job.mutex.Lock()
+	job.values[part.index] = val
+	if err != nil && job.err == nil {
+		job.err = err
+	}
+	job.left--
+	job.work += time.Since(started)
+	last := job.left == 0
+	job.mutex.Unlock()
+	if last {
+		sw.deliver(w, func() {
+			cw.FinishChunks(job.path, job.info, job.values, job.err)
+			sw.countDone(w)
+			if sw.progress != nil && w.walked {
+				sw.worked(w)
+			}
+			if w.future != nil {
+				w.future.resolve(Result{Value: job.values, Err: job.err})
+			}
+			sw.emit(Event{Kind: EKDone, Path: w.path, Root: w.root, Info: w.info, Err: job.err, Duration: job.work})
+		})
+	}
+}
+
+//TreeHashLeaf is the size of the leaves of the tree hash computed by TreeHashWorker.
+const TreeHashLeaf = 1 << 20
+
+//TreeHashWorker is a ChunkWorker that computes the SHA-256 tree hash of every file, the checksum used by
+//Amazon Glacier and S3 Glacier multipart uploads. Every TreeHashLeaf bytes are hashed on their own, then
+//pairs of hashes are hashed together until one is left, so chunks of a file can be hashed concurrently.
+//ChunkSize must be a multiple of TreeHashLeaf. Directories are ignored so it is best used with FilesOnly.
+type TreeHashWorker struct {
+	//OnHash is called with the tree hash of every file if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnHash func(path string, sum []byte)
+
+	//OnError is called with every file that could not be hashed if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnError func(path string, err error)
+
+	mutex  sync.Mutex
+	hashes map[string][]byte
+}
+
+//NewTreeHashWorker creates a TreeHashWorker.
+func NewTreeHashWorker() *TreeHashWorker {
+	return &TreeHashWorker{hashes: make(map[string][]byte)}
+}
+
+//Work hashes the whole file at path.
+func (th *TreeHashWorker) Work(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		th.finish(path, nil, err)
+		return
+	}
+	if !info.Mode().IsRegular() {
+		return
+	}
+	leaves, err := th.WorkChunk(Chunk{Path: path, Info: info, Count: 1, Length: info.Size()})
+	th.FinishChunks(path, info, []interface{}{leaves}, err)
+}
+
+//WorkChunk returns the leaf hashes of the range in c.
+func (th *TreeHashWorker) WorkChunk(c Chunk) (interface{}, error) {
+	if c.Offset%TreeHashLeaf != 0 {
+		return nil, &ConfigError{Field: "ChunkSize", Msg: "must be a multiple of TreeHashLeaf"}
+	}
+	file, err := Open(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := io.NewSectionReader(file, c.Offset, c.Length)
+	var leaves [][]byte
+	buf := make([]byte, TreeHashLeaf)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || len(leaves) == 0 {
+			sum := sha256.Sum256(buf[:n])
+			leaves = append(leaves, sum[:])
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return leaves, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//FinishChunks combines the leaf hashes of every chunk into the tree hash.
+func (th *TreeHashWorker) FinishChunks(path string, info os.FileInfo, values []interface{}, err error) {
+	if err != nil {
+		th.finish(path, nil, err)
+		return
+	}
+	var level [][]byte
+	for _, v := range values {
+		level = append(level, v.([][]byte)...)
+	}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			sum := sha256.Sum256(append(append([]byte{}, level[i]...), level[i+1]...))
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+	th.finish(path, level[0], nil)
+}
+
+func (th *TreeHashWorker) finish(path string, sum []byte, err error) {
+	if err != nil {
+		if th.OnError != nil {
+			th.OnError(path, err)
+		}
+		return
+	}
+	th.mutex.Lock()
+	th.hashes[path] = sum
+	th.mutex.Unlock()
+	if th.OnHash != nil {
+		th.OnHash(path, sum)
+	}
+}
+
+//Hashes returns the tree hash of every file hashed so far.
+func (th *TreeHashWorker) Hashes() map[string][]byte {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+	hashes := make(map[string][]byte, len(th.hashes))
+	for path, sum := range th.hashes {
+		hashes[path] = sum
+	}
+	return hashes
+}
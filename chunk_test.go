@@ -0,0 +1,75 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//treeHash is the reference tree hash computed in one go.
+func treeHash(data []byte) string {
+	var level [][]byte
+	for i := 0; i < len(data) || i == 0; i += skywalker.TreeHashLeaf {
+		end := i + skywalker.TreeHashLeaf
+		if end > len(data) {
+			end = len(data)
+		}
+		sum := sha256.Sum256(data[i:end])
+		level = append(level, sum[:])
+	}
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			sum := sha256.Sum256(append(append([]byte{}, level[i]...), level[i+1]...))
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+	return hex.EncodeToString(level[0])
+}
+
+func TestTreeHashWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	big := strings.Repeat("0123456789abcdef", 5*skywalker.TreeHashLeaf/16+1000)
+	files := map[string]string{"big.bin": big, "small.txt": "small", "empty": ""}
+	writeFiles(t, tmp, files)
+
+	th := skywalker.NewTreeHashWorker()
+	sw := skywalker.New(tmp, th)
+	sw.FilesOnly = true
+	sw.ChunkSize = 2 * skywalker.TreeHashLeaf
+	sw.LargeFileSize = skywalker.TreeHashLeaf
+	assert.NoError(sw.Walk())
+	hashes := th.Hashes()
+	assert.Len(hashes, 3)
+	for name, data := range files {
+		assert.Equal(treeHash([]byte(data)), hex.EncodeToString(hashes[filepath.Join(sw.Root, name)]), name)
+	}
+
+	var failed []string
+	th = skywalker.NewTreeHashWorker()
+	th.OnError = func(path string, err error) {
+		var cfgErr *skywalker.ConfigError
+		assert.True(errors.As(err, &cfgErr))
+		failed = append(failed, filepath.Base(path))
+	}
+	sw.Worker = th
+	sw.ChunkSize = skywalker.TreeHashLeaf + 1
+	assert.NoError(sw.Walk())
+	assert.Equal([]string{"big.bin"}, failed)
+}
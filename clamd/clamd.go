@@ -0,0 +1,281 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Package clamd scans files found by skywalker with a ClamAV daemon, or anything else that speaks its
+//INSTREAM protocol, making skywalker the enumeration half of an on-demand virus scan.
+package clamd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+)
+
+//ErrClosed is returned when scanning with a Client that has been closed.
+var ErrClosed = errors.New("clamd client is closed")
+
+//ScanError is the error the daemon reported for a stream, for example when it is larger than its StreamMaxLength.
+type ScanError struct {
+	Msg string
+}
+
+func (se *ScanError) Error() string {
+	return "clamd: " + se.Msg
+}
+
+//Client scans streams over a pool of connections to the daemon.
+//Every connection is kept open in an IDSESSION so files do not pay for a new connection each.
+type Client struct {
+	//Network and Address are where the daemon listens, for example "unix" and "/run/clamav/clamd.ctl"
+	//or "tcp" and "localhost:3310".
+	Network string
+	Address string
+
+	//Timeout limits how long a single scan, including sending the file, may take. 0 means no limit.
+	Timeout time.Duration
+
+	//ChunkSize is how many bytes are sent in each INSTREAM chunk. Defaults to 64KB.
+	ChunkSize int
+
+	idle   chan *conn
+	slots  chan struct{}
+	mutex  sync.Mutex
+	closed bool
+}
+
+type conn struct {
+	net.Conn
+	r  *bufio.Reader
+	id int
+}
+
+//NewClient creates a Client keeping at most maxConns connections to the daemon at address.
+//maxConns should not be more than the daemon's MaxThreads or scans will queue up inside it.
+func NewClient(network, address string, maxConns int) *Client {
+	if maxConns < 1 {
+		maxConns = 1
+	}
+	return &Client{
+		Network:   network,
+		Address:   address,
+		Timeout:   time.Minute,
+		ChunkSize: 64 * 1024,
+		idle:      make(chan *conn, maxConns),
+		slots:     make(chan struct{}, maxConns),
+	}
+}
+
+//get returns an idle connection or opens a new one, waiting if maxConns are already in use.
+func (c *Client) get() (*conn, error) {
+	c.slots <- struct{}{}
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+	nc, err := net.DialTimeout(c.Network, c.Address, c.Timeout)
+	if err != nil {
+		<-c.slots
+		return nil, err
+	}
+	if _, err = nc.Write([]byte("zIDSESSION\x00")); err != nil {
+		nc.Close()
+		<-c.slots
+		return nil, err
+	}
+	return &conn{Conn: nc, r: bufio.NewReader(nc)}, nil
+}
+
+//put hands cn back to the pool, closing it instead if the scan failed or the client is closed.
+//The daemon drops the connection after most errors so it is not worth reusing.
+func (c *Client) put(cn *conn, broken bool) {
+	c.mutex.Lock()
+	closed := c.closed
+	c.mutex.Unlock()
+	if broken || closed {
+		cn.Close()
+	} else {
+		c.idle <- cn
+	}
+	<-c.slots
+}
+
+//Scan sends everything in r to the daemon and returns the name of the virus it found or "" if it is clean.
+func (c *Client) Scan(r io.Reader) (string, error) {
+	c.mutex.Lock()
+	closed := c.closed
+	c.mutex.Unlock()
+	if closed {
+		return "", ErrClosed
+	}
+	cn, err := c.get()
+	if err != nil {
+		return "", err
+	}
+	virus, err := c.scan(cn, r)
+	c.put(cn, err != nil)
+	return virus, err
+}
+
+func (c *Client) scan(cn *conn, r io.Reader) (string, error) {
+	if c.Timeout > 0 {
+		cn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+	if _, err := cn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+	cn.id++
+	buf := make([]byte, 4+c.ChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, werr := cn.Write(buf[:4+n]); werr != nil {
+				//The daemon closes the connection when the stream is too large, its reply says why.
+				return readReply(cn, werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if _, err := cn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return readReply(cn, err)
+	}
+	return readReply(cn, nil)
+}
+
+//readReply reads the daemon's reply, returning werr if there is none.
+//Replies in a session look like "1: stream: OK", "1: stream: Eicar-Signature FOUND" or "1: <reason> ERROR".
+func readReply(cn *conn, werr error) (string, error) {
+	line, err := cn.r.ReadString(0)
+	if err != nil {
+		if werr != nil {
+			return "", werr
+		}
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\x00")
+	if i := strings.Index(line, ": "); i >= 0 && strings.Trim(line[:i], "0123456789") == "" {
+		line = line[i+2:]
+	}
+	line = strings.TrimPrefix(line, "stream: ")
+	switch {
+	case line == "OK":
+		return "", werr
+	case strings.HasSuffix(line, " FOUND"):
+		return strings.TrimSuffix(line, " FOUND"), nil
+	case strings.HasSuffix(line, " ERROR"):
+		return "", &ScanError{Msg: strings.TrimSuffix(line, " ERROR")}
+	}
+	return "", fmt.Errorf("clamd: unexpected reply %q", line)
+}
+
+//Close ends the session on every idle connection. Connections in use are closed when their scan finishes.
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	c.closed = true
+	c.mutex.Unlock()
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Write([]byte("zEND\x00"))
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+//Result is what the daemon said about a single file.
+type Result struct {
+	Path string
+	//Virus is the name of what was found. It is empty for clean files.
+	Virus string
+}
+
+//Infected reports whether the daemon found something.
+func (r Result) Infected() bool {
+	return r.Virus != ""
+}
+
+//Worker is a skywalker.ResultWorker that scans every file with a Client.
+//WorkResult returns a Result for every regular file so clean files are recorded as well.
+type Worker struct {
+	Client *Client
+
+	//OnResult is called with the Result of every file if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnResult func(Result)
+
+	//OnError is called with every file that could not be scanned if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnError func(path string, err error)
+
+	mutex    sync.Mutex
+	infected []Result
+}
+
+//NewWorker creates a Worker scanning with client.
+func NewWorker(client *Client) *Worker {
+	return &Worker{Client: client}
+}
+
+//Work scans the file at path.
+func (w *Worker) Work(path string) {
+	w.WorkResult(skywalker.WorkItem{Path: path})
+}
+
+//WorkResult scans the file in item.
+func (w *Worker) WorkResult(item skywalker.WorkItem) (interface{}, error) {
+	if item.Info != nil && !item.Info.Mode().IsRegular() {
+		return nil, nil
+	}
+	res, err := w.scan(item.Path)
+	if err != nil {
+		if w.OnError != nil {
+			w.OnError(item.Path, err)
+		}
+		return nil, err
+	}
+	if res.Infected() {
+		w.mutex.Lock()
+		w.infected = append(w.infected, res)
+		w.mutex.Unlock()
+	}
+	if w.OnResult != nil {
+		w.OnResult(res)
+	}
+	return res, nil
+}
+
+func (w *Worker) scan(path string) (Result, error) {
+	file, err := skywalker.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer file.Close()
+	virus, err := w.Client.Scan(file)
+	return Result{Path: path, Virus: virus}, err
+}
+
+//Infected returns every infected file found so far.
+func (w *Worker) Infected() []Result {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	infected := make([]Result, len(w.infected))
+	copy(infected, w.infected)
+	return infected
+}
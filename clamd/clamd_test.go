@@ -0,0 +1,132 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package clamd_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/dixonwille/skywalker/clamd"
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeClamd speaks enough of the clamd protocol for the tests. Streams containing "EICAR" are infected
+//and ones larger than maxStream are rejected.
+type fakeClamd struct {
+	net.Listener
+	maxStream int
+	sessions  int32
+}
+
+func newFakeClamd(t *testing.T, maxStream int) *fakeClamd {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	fc := &fakeClamd{Listener: l, maxStream: maxStream}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go fc.serve(c)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return fc
+}
+
+func (fc *fakeClamd) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	id := 0
+	for {
+		cmd, err := r.ReadString(0)
+		if err != nil {
+			return
+		}
+		switch cmd {
+		case "zIDSESSION\x00":
+			atomic.AddInt32(&fc.sessions, 1)
+		case "zEND\x00":
+			return
+		case "zINSTREAM\x00":
+			id++
+			var data []byte
+			for {
+				var size uint32
+				if binary.Read(r, binary.BigEndian, &size) != nil {
+					return
+				}
+				if size == 0 {
+					break
+				}
+				chunk := make([]byte, size)
+				if _, err := io.ReadFull(r, chunk); err != nil {
+					return
+				}
+				data = append(data, chunk...)
+				if len(data) > fc.maxStream {
+					fmt.Fprintf(c, "%d: INSTREAM size limit exceeded. ERROR\x00", id)
+					return
+				}
+			}
+			if bytes.Contains(data, []byte("EICAR")) {
+				fmt.Fprintf(c, "%d: stream: Eicar-Signature FOUND\x00", id)
+			} else {
+				fmt.Fprintf(c, "%d: stream: OK\x00", id)
+			}
+		}
+	}
+}
+
+func TestWorker(t *testing.T) {
+	assert := assert.New(t)
+	fc := newFakeClamd(t, 1024)
+	tmp := t.TempDir()
+	for i := 0; i < 20; i++ {
+		assert.NoError(os.WriteFile(filepath.Join(tmp, fmt.Sprintf("clean%d.txt", i)), []byte("hello"), 0644))
+	}
+	assert.NoError(os.WriteFile(filepath.Join(tmp, "virus.com"), []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR"), 0644))
+	assert.NoError(os.WriteFile(filepath.Join(tmp, "huge.bin"), bytes.Repeat([]byte{1}, 4096), 0644))
+
+	client := clamd.NewClient("tcp", fc.Addr().String(), 2)
+	client.ChunkSize = 100
+	defer client.Close()
+	w := clamd.NewWorker(client)
+	store := skywalker.NewMemoryStore()
+	sw := skywalker.New(tmp, w)
+	sw.FilesOnly = true
+	sw.Results = store
+	assert.NoError(sw.Walk())
+
+	infected := w.Infected()
+	if assert.Len(infected, 1) {
+		assert.Equal("virus.com", filepath.Base(infected[0].Path))
+		assert.Equal("Eicar-Signature", infected[0].Virus)
+	}
+	results := store.Results()
+	assert.Len(results, 22)
+	var se *clamd.ScanError
+	huge := results[filepath.Join(sw.Root, "huge.bin")]
+	assert.True(errors.As(huge.Err, &se), "Expected a ScanError but got %v", huge.Err)
+	assert.True(strings.Contains(se.Msg, "size limit"))
+	assert.False(results[filepath.Join(sw.Root, "clean0.txt")].Value.(clamd.Result).Infected())
+	assert.True(atomic.LoadInt32(&fc.sessions) <= 3, "Connections should be reused")
+
+	assert.NoError(client.Close())
+	_, err := client.Scan(strings.NewReader("x"))
+	assert.Equal(clamd.ErrClosed, err)
+}
@@ -0,0 +1,176 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Command skywalker runs skywalker's provided workers from the command line.
+//
+//	skywalker verify [-workers N] [-all] [-hash NAME] MANIFEST [ROOT]
+//
+//verify checks every file under ROOT, the current directory by default, against a checksum manifest
+//like the ones written by sha256sum. It prints one JSON object per line for every mismatched, missing,
+//extra or unreadable file and exits with 1 if there were any. The hash is guessed from the length of the
+//checksums unless -hash names one, like xxhash64 or blake3.
+//
+//	skywalker lint [-workers N] [-max-path N] [-max-name N] [ROOT]
+//
+//lint checks whether every path under ROOT would survive being copied to Windows or a case insensitive
+//filesystem. It prints one JSON object per line for every issue and exits with 1 if there were any.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dixonwille/skywalker"
+	_ "github.com/dixonwille/skywalker/fasthash"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func usage(stderr io.Writer) int {
+	fmt.Fprintln(stderr, "usage: skywalker verify [-workers N] [-all] [-hash NAME] MANIFEST [ROOT]")
+	fmt.Fprintln(stderr, "       skywalker lint [-workers N] [-max-path N] [-max-name N] [ROOT]")
+	return 2
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		return usage(stderr)
+	}
+	switch args[0] {
+	case "verify":
+		return verify(args[1:], stdout, stderr)
+	case "lint":
+		return lint(args[1:], stdout, stderr)
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	return usage(stderr)
+}
+
+//verifyLine is a line of output. Paths that are not valid UTF-8 are also written base64 encoded in path_raw
+//as JSON strings can not hold them.
+type verifyLine struct {
+	Path     string `json:"path"`
+	PathRaw  []byte `json:"path_raw,omitempty"`
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func verify(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	workers := flags.Int("workers", 20, "how many files to verify at a time")
+	all := flags.Bool("all", false, "also print files that verified")
+	hashName := flags.String("hash", "", "hash the manifest was made with, one of "+strings.Join(skywalker.HashNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usage(stderr)
+	}
+	root := "."
+	if flags.NArg() == 2 {
+		root = flags.Arg(1)
+	}
+	m, err := skywalker.LoadManifest(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	vw := skywalker.NewVerifyWorker(root, m)
+	if *hashName != "" {
+		if vw.Hash, err = skywalker.LookupHash(*hashName); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	sw := skywalker.New(root, vw)
+	sw.NumWorkers = *workers
+	if err = sw.Walk(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	enc := json.NewEncoder(stdout)
+	code := 0
+	for _, res := range vw.Results() {
+		if res.Status == skywalker.VSOK && !*all {
+			continue
+		}
+		if res.Status != skywalker.VSOK {
+			code = 1
+		}
+		line := verifyLine{Path: res.Path, PathRaw: rawPath(res.Path), Status: res.Status.String(), Expected: res.Expected, Actual: res.Actual}
+		if res.Err != nil {
+			line.Error = res.Err.Error()
+		}
+		if err = enc.Encode(line); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	return code
+}
+
+//lintLine is a line of output of lint.
+type lintLine struct {
+	Path    string `json:"path"`
+	PathRaw []byte `json:"path_raw,omitempty"`
+	Issue   string `json:"issue"`
+	Other   string `json:"other,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+func lint(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	workers := flags.Int("workers", 20, "how many paths to check at a time")
+	maxPath := flags.Int("max-path", skywalker.DefaultMaxPathLength, "longest path allowed, 0 for no limit")
+	maxName := flags.Int("max-name", skywalker.DefaultMaxNameLength, "longest name allowed, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		return usage(stderr)
+	}
+	root := "."
+	if flags.NArg() == 1 {
+		root = flags.Arg(0)
+	}
+	pw := skywalker.NewPortabilityWorker(root)
+	pw.MaxPathLength, pw.MaxNameLength = *maxPath, *maxName
+	sw := skywalker.New(root, pw)
+	sw.NumWorkers = *workers
+	sw.FilesOnly = false
+	if err := sw.Walk(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	enc := json.NewEncoder(stdout)
+	code := 0
+	for _, issue := range pw.Issues() {
+		code = 1
+		line := lintLine{Path: issue.Path, PathRaw: rawPath(issue.Path), Issue: issue.Kind.String(), Other: issue.Other, Detail: issue.Detail}
+		if err := enc.Encode(line); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	return code
+}
+
+//rawPath returns the bytes of path if it is not valid UTF-8.
+func rawPath(path string) []byte {
+	if utf8.ValidString(path) {
+		return nil
+	}
+	return []byte(path)
+}
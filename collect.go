@@ -0,0 +1,68 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package skywalker
+
+import (
+	"context"
+	"sync"
+)
+
+//CollectWorker is an ErrorWorker that keeps what Fn returns for every path, so a Worker producing values
+//does not need a slice and a mutex of its own. Values of paths Fn failed on are not kept.
+type CollectWorker[T any] struct {
+	Fn func(path string) (T, error)
+
+	mutex  sync.Mutex
+	values []T
+}
+
+//NewCollectWorker creates a CollectWorker calling fn on every path.
+func NewCollectWorker[T any](fn func(path string) (T, error)) *CollectWorker[T] {
+	return &CollectWorker[T]{Fn: fn}
+}
+
+//Work keeps what Fn returns for path.
+func (cw *CollectWorker[T]) Work(path string) {
+	cw.WorkErr(path)
+}
+
+//WorkErr is Work returning the error of Fn, so the walk returns it as well.
+func (cw *CollectWorker[T]) WorkErr(path string) error {
+	value, err := cw.Fn(path)
+	if err != nil {
+		return err
+	}
+	cw.mutex.Lock()
+	cw.values = append(cw.values, value)
+	cw.mutex.Unlock()
+	return nil
+}
+
+//Values returns a copy of every value kept so far, in the order the workers finished.
+func (cw *CollectWorker[T]) Values() []T {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return append([]T(nil), cw.values...)
+}
+
+//WalkCollect is WalkCollectContext with context.Background().
+func WalkCollect[T any](sw *Skywalker, fn func(path string) (T, error)) ([]T, error) {
+	return WalkCollectContext(context.Background(), sw, fn)
+}
+
+//WalkCollectContext walks sw calling fn on every path instead of the Worker and returns every value fn returned,
+//in no particular order, along with what WalkContext returned. Errors from fn are returned in WorkErrors and
+//their values are left out. The Worker of sw is put back once the walk is done.
+func WalkCollectContext[T any](ctx context.Context, sw *Skywalker, fn func(path string) (T, error)) ([]T, error) {
+	cw := NewCollectWorker(fn)
+	worker := sw.Worker
+	sw.Worker = cw
+	defer func() { sw.Worker = worker }()
+	err := sw.WalkContext(ctx)
+	return cw.Values(), err
+}
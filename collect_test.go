@@ -0,0 +1,49 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package skywalker_test
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkCollect(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+		"empty":     "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sizes, err := skywalker.WalkCollect(sw, func(path string) (int64, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		if info.Size() == 0 {
+			return 0, errEmpty
+		}
+		return info.Size(), nil
+	})
+	var workErrs skywalker.WorkErrors
+	if assert.True(errors.As(err, &workErrs)) {
+		assert.Len(workErrs, 1)
+		assert.ErrorIs(workErrs[0], errEmpty)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	assert.Equal([]int64{2, 3}, sizes)
+	assert.Equal(skywalker.Worker(tw), sw.Worker)
+	assert.Empty(tw.found)
+}
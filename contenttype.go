@@ -0,0 +1,64 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+//ContentTypePrefix is how many bytes at the start of a file are used to detect its content type,
+//all http.DetectContentType looks at.
+const ContentTypePrefix = 512
+
+//contentTypeOK reports whether the content type sniffed from the first ContentTypePrefix bytes of the file at
+//path passes ContentTypeList. A file that can not be read has no content type.
+func (sw *Skywalker) contentTypeOK(path string) bool {
+	ct := sw.sniffContentType(path)
+	inList := ct != "" && hasContentType(sw.ContentTypeList, ct)
+	return inList == (sw.ContentTypeListType == LTWhitelist)
+}
+
+//sniffContentType returns the media type of the file at path without its parameters, or "" if it can not be read.
+func (sw *Skywalker) sniffContentType(path string) string {
+	file, err := sw.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	prefix := make([]byte, ContentTypePrefix)
+	n, err := io.ReadFull(file, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	ct := http.DetectContentType(prefix[:n])
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	return strings.TrimSpace(ct)
+}
+
+//hasContentType reports whether ct is in list, where image/* holds every image type.
+func hasContentType(list []string, ct string) bool {
+	for _, l := range list {
+		l = strings.ToLower(l)
+		if l == ct || strings.HasSuffix(l, "/*") && strings.HasPrefix(ct, l[:len(l)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+//validContentTypes reports whether every entry of list looks like a MIME type.
+func validContentTypes(list []string) bool {
+	for _, l := range list {
+		i := strings.IndexByte(l, '/')
+		if i <= 0 || i == len(l)-1 || strings.ContainsAny(l, "; ") {
+			return false
+		}
+	}
+	return true
+}
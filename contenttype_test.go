@@ -0,0 +1,68 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentTypeList(t *testing.T) {
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"real.jpg":    "\xff\xd8\xff\xe0 jpeg",
+		"photo.txt":   "\xff\xd8\xff\xe0 jpeg",
+		"fake.jpg":    "just text",
+		"img/pic.png": "\x89PNG\r\n\x1a\n png",
+	})
+	for _, tc := range []struct {
+		listType skywalker.ListType
+		list     []string
+		want     []string
+	}{
+		{skywalker.LTWhitelist, []string{"image/jpeg"}, []string{"photo.txt", "real.jpg"}},
+		{skywalker.LTWhitelist, []string{"IMAGE/*"}, []string{"img/pic.png", "photo.txt", "real.jpg"}},
+		{skywalker.LTBlacklist, []string{"text/plain"}, []string{"img/pic.png", "photo.txt", "real.jpg"}},
+	} {
+		assert := assert.New(t)
+		tw := NewTW()
+		sw := skywalker.New(tmp, tw)
+		sw.FilesOnly = true
+		sw.ContentTypeListType = tc.listType
+		sw.ContentTypeList = tc.list
+		var skipped int
+		sw.OnEvent = func(ev skywalker.Event) {
+			if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RContentType {
+				tw.Lock()
+				skipped++
+				tw.Unlock()
+			}
+		}
+		assert.NoError(sw.Walk())
+		var found []string
+		for path := range tw.found {
+			rel, err := filepath.Rel(sw.Root, path)
+			assert.NoError(err)
+			found = append(found, filepath.ToSlash(rel))
+		}
+		sort.Strings(found)
+		assert.Equal(tc.want, found, "%v", tc.list)
+		assert.Equal(4-len(tc.want), skipped)
+	}
+}
+
+func TestContentTypeListConfig(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(t.TempDir(), NewTW())
+	sw.ContentTypeList = []string{"jpeg"}
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.Walk(), &ce) {
+		assert.Equal("ContentTypeList", ce.Field)
+	}
+}
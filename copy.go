@@ -0,0 +1,307 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//PartialSuffix is added to the name of a file while it is being copied.
+//A file with this suffix is left behind if a copy is interrupted and is used to resume the copy later.
+const PartialSuffix = ".skywalker-partial"
+
+//ErrChecksumMismatch is returned when a copied file does not have the same checksum as its source.
+var ErrChecksumMismatch = errors.New("checksum of copy does not match source")
+
+//CopyResult is what a CopyWorker did with a single path.
+type CopyResult struct {
+	//Path is the file that was copied.
+	Path string
+	//Dest is where the file was copied to.
+	Dest string
+	//Skipped is true if Dest already had the same size and modification time as Path.
+	Skipped bool
+	//Resumed is true if the copy continued from a partial copy left by an earlier run.
+	Resumed bool
+	//Checksum is the hex encoded checksum of the copy. Only set when verifying.
+	Checksum string
+	//Err is set if the file could not be copied.
+	Err error
+}
+
+//CopyWorker is a Worker that copies every file it is given into Dest keeping the path relative to Root.
+//Copies are written next to the destination with PartialSuffix and renamed into place once complete,
+//so an interrupted copy never leaves a truncated file behind and can be resumed by walking again.
+//Directories are ignored so it is best used with FilesOnly.
+type CopyWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//Dest is the directory files are copied into.
+	Dest string
+
+	//Verify compares the checksum of the copy with the source before it is renamed into place.
+	Verify bool
+
+	//Hash is used for verification. Defaults to sha256.
+	Hash func() hash.Hash
+
+	//Limiter caps how fast files are read if it is set.
+	//Share one Limiter between workers to cap all of them together.
+	Limiter *ByteLimiter
+
+	//OnCopy is called after each file is handled if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnCopy func(CopyResult)
+
+	root rootRel
+
+	mutex   sync.Mutex
+	results []CopyResult
+}
+
+//NewCopyWorker creates a CopyWorker that copies files found in root into dest.
+func NewCopyWorker(root, dest string) *CopyWorker {
+	return &CopyWorker{
+		Root: root,
+		Dest: dest,
+	}
+}
+
+//UseHash sets Hash, see Skywalker.HashName.
+func (cw *CopyWorker) UseHash(newHash func() hash.Hash) {
+	cw.Hash = newHash
+}
+
+//Work copies the file at path into Dest.
+func (cw *CopyWorker) Work(path string) {
+	res, ok := cw.copy(path)
+	if !ok {
+		return
+	}
+	cw.mutex.Lock()
+	cw.results = append(cw.results, res)
+	cw.mutex.Unlock()
+	if cw.OnCopy != nil {
+		cw.OnCopy(res)
+	}
+}
+
+//Results returns what happened to every file handled so far.
+func (cw *CopyWorker) Results() []CopyResult {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	results := make([]CopyResult, len(cw.results))
+	copy(results, cw.results)
+	return results
+}
+
+func (cw *CopyWorker) copy(path string) (CopyResult, bool) {
+	res := CopyResult{Path: path}
+	info, err := os.Stat(osPath(path))
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	if info.IsDir() {
+		return res, false
+	}
+	rel, err := cw.root.rel(cw.Root, path)
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	res.Dest = filepath.Join(cw.Dest, rel)
+	if destInfo, err := os.Stat(res.Dest); err == nil && sameFile(info, destInfo) {
+		res.Skipped = true
+		return res, true
+	}
+	if err = os.MkdirAll(filepath.Dir(res.Dest), 0777); err != nil {
+		res.Err = err
+		return res, true
+	}
+	var h hash.Hash
+	if cw.Verify {
+		h = sha256.New()
+		if cw.Hash != nil {
+			h = cw.Hash()
+		}
+	}
+	res.Resumed, res.Checksum, res.Err = copyResume(path, res.Dest, info, h, cw.Limiter)
+	return res, true
+}
+
+//sameFile is a cheap check used to decide a file was already copied.
+func sameFile(src, dst os.FileInfo) bool {
+	return !dst.IsDir() && src.Size() == dst.Size() && src.ModTime().Equal(dst.ModTime())
+}
+
+//copyResume copies src to dst through a partial file, continuing from any partial file already there.
+//If h is not nil the copy is verified against src before being renamed into place.
+//If a resumed copy fails verification it is started over once from the beginning.
+//All reads go through bl.
+func copyResume(src, dst string, info os.FileInfo, h hash.Hash, bl *ByteLimiter) (bool, string, error) {
+	partial := dst + PartialSuffix
+	resumed, err := copyPartial(src, partial, info.Size(), bl)
+	if err != nil {
+		return resumed, "", err
+	}
+	var sum string
+	if h != nil {
+		sum, err = verify(src, partial, h, bl)
+		if err == ErrChecksumMismatch && resumed {
+			resumed = false
+			if err = os.Remove(partial); err != nil {
+				return resumed, "", err
+			}
+			if _, err = copyPartial(src, partial, info.Size(), bl); err != nil {
+				return resumed, "", err
+			}
+			sum, err = verify(src, partial, h, bl)
+		}
+		if err != nil {
+			return resumed, "", err
+		}
+	}
+	if err = os.Chmod(partial, info.Mode().Perm()); err != nil {
+		return resumed, sum, err
+	}
+	if err = os.Chtimes(partial, info.ModTime(), info.ModTime()); err != nil {
+		return resumed, sum, err
+	}
+	return resumed, sum, os.Rename(partial, dst)
+}
+
+//copyPartial appends whatever is missing from partial using src. It starts over if partial is not the start
+//of src, so a partial file left by a copy of an older version is not resumed.
+//Returns true if some of the file was already there.
+func copyPartial(src, partial string, size int64, bl *ByteLimiter) (bool, error) {
+	in, err := Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return false, err
+	}
+	done, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		out.Close()
+		return false, err
+	}
+	restart := done > size
+	if !restart && done > 0 {
+		same, err := samePrefix(in, out, done, bl)
+		if err != nil {
+			out.Close()
+			return false, err
+		}
+		restart = !same
+	}
+	if restart {
+		if err = out.Truncate(0); err != nil {
+			out.Close()
+			return false, err
+		}
+		done, err = out.Seek(0, io.SeekStart)
+		if err != nil {
+			out.Close()
+			return false, err
+		}
+	}
+	if _, err = in.Seek(done, io.SeekStart); err != nil {
+		out.Close()
+		return false, err
+	}
+	if _, err = io.Copy(out, bl.Reader(in)); err != nil {
+		out.Close()
+		return done > 0, err
+	}
+	if err = out.Sync(); err != nil {
+		out.Close()
+		return done > 0, err
+	}
+	return done > 0, out.Close()
+}
+
+//samePrefix reports whether the first n bytes of a and b have the same checksum. All reads go through bl.
+func samePrefix(a, b io.ReaderAt, n int64, bl *ByteLimiter) (bool, error) {
+	var sums [2][]byte
+	for i, r := range []io.ReaderAt{a, b} {
+		h := sha256.New()
+		if _, err := io.Copy(h, bl.Reader(io.NewSectionReader(r, 0, n))); err != nil {
+			return false, err
+		}
+		sums[i] = h.Sum(nil)
+	}
+	return bytes.Equal(sums[0], sums[1]), nil
+}
+
+//verify returns the hex checksum of copied if it matches the checksum of src.
+func verify(src, copied string, h hash.Hash, bl *ByteLimiter) (string, error) {
+	want, err := checksum(src, h, bl)
+	if err != nil {
+		return "", err
+	}
+	got, err := checksum(copied, h, bl)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(want, got) {
+		return "", ErrChecksumMismatch
+	}
+	return hex.EncodeToString(got), nil
+}
+
+func checksum(path string, h hash.Hash, bl *ByteLimiter) ([]byte, error) {
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	h.Reset()
+	if _, err = io.Copy(h, bl.Reader(file)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+//copyFile copies the contents, permissions and modification time of src into dst.
+//dst is created if it does not exist and truncated if it does.
+func copyFile(src, dst string) error {
+	in, err := Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,88 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src")
+	dest := filepath.Join(tmp, "dest")
+	writeFiles(t, src, map[string]string{
+		"a/one.txt":  "the first file",
+		"b/two.log":  "the second file",
+		"c/done.pdf": "already copied",
+	})
+	writeFiles(t, dest, map[string]string{
+		"a/one.txt" + skywalker.PartialSuffix: "the fir",
+		"b/two.log" + skywalker.PartialSuffix: "garbage from an old copy",
+	})
+
+	done := skywalker.NewCopyWorker(src, dest)
+	assert.NoError(skywalker.New(filepath.Join(src, "c"), done).Walk())
+
+	cw := skywalker.NewCopyWorker(src, dest)
+	cw.Verify = true
+	assert.NoError(skywalker.New(src, cw).Walk())
+
+	results := make(map[string]skywalker.CopyResult)
+	for _, res := range cw.Results() {
+		assert.NoError(res.Err)
+		rel, _ := filepath.Rel(dest, res.Dest)
+		results[filepath.ToSlash(rel)] = res
+	}
+	assert.Len(results, 3)
+	assert.True(results["a/one.txt"].Resumed, "Should have resumed the partial copy")
+	assert.False(results["b/two.log"].Resumed, "Should have started over")
+	assert.True(results["c/done.pdf"].Skipped, "Should have skipped the completed copy")
+	assert.NotEmpty(results["a/one.txt"].Checksum)
+
+	for _, rel := range []string{"a/one.txt", "b/two.log", "c/done.pdf"} {
+		want, _ := os.ReadFile(filepath.Join(src, rel))
+		got, err := os.ReadFile(filepath.Join(dest, rel))
+		assert.NoError(err)
+		assert.Equal(string(want), string(got))
+		_, err = os.Stat(filepath.Join(dest, rel+skywalker.PartialSuffix))
+		assert.True(os.IsNotExist(err), "Partial file should be gone")
+	}
+}
+
+func TestCopyWorkerStalePartial(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src")
+	dest := filepath.Join(tmp, "dest")
+	writeFiles(t, src, map[string]string{"a/one.txt": "the first file", "b/two.txt": "the second file"})
+	writeFiles(t, dest, map[string]string{
+		//Left by a copy of an older version of the file.
+		"a/one.txt" + skywalker.PartialSuffix: "the old",
+		"b/two.txt" + skywalker.PartialSuffix: "the sec",
+	})
+
+	cw := skywalker.NewCopyWorker(src, dest)
+	assert.NoError(skywalker.New(src, cw).Walk())
+	results := make(map[string]skywalker.CopyResult)
+	for _, res := range cw.Results() {
+		assert.NoError(res.Err)
+		rel, _ := filepath.Rel(dest, res.Dest)
+		results[filepath.ToSlash(rel)] = res
+	}
+	assert.False(results["a/one.txt"].Resumed, "Should have started over")
+	assert.True(results["b/two.txt"].Resumed, "Should have resumed the partial copy")
+	for _, rel := range []string{"a/one.txt", "b/two.txt"} {
+		got, err := os.ReadFile(filepath.Join(dest, rel))
+		assert.NoError(err)
+		want, _ := os.ReadFile(filepath.Join(src, rel))
+		assert.Equal(string(want), string(got))
+	}
+}
@@ -0,0 +1,181 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+//cronField is the range of one of the five fields of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//CronSchedule is when a cron expression is due.
+type CronSchedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	//domStar and dowStar are set if the field started with *, so a day only has to match the other one.
+	//If neither did a day has to match either of them, like cron does.
+	domStar, dowStar bool
+}
+
+//ParseCron parses the five fields of a cron expression, minute, hour, day of month, month and day of week,
+//each being *, a number, a range like 1-5 or a list of them like 1,15, optionally followed by a step like */15.
+//Months and days of the week can be given by their first three letters and Sunday is both 0 and 7.
+//@yearly, @monthly, @weekly, @daily and @hourly are accepted as well. It returns a CronSyntaxError if expr is not valid.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 1 {
+		if macro, ok := cronMacros[strings.ToLower(fields[0])]; ok {
+			fields = strings.Fields(macro)
+		}
+	}
+	if len(fields) != len(cronFields) {
+		return nil, &CronSyntaxError{Expr: expr, Msg: "must have 5 fields"}
+	}
+	cs := &CronSchedule{expr: expr}
+	bits := [5]*uint64{&cs.minute, &cs.hour, &cs.dom, &cs.month, &cs.dow}
+	for i, field := range fields {
+		set, err := cronFields[i].parse(strings.ToLower(field))
+		if err != nil {
+			return nil, &CronSyntaxError{Expr: expr, Field: cronFields[i].name, Msg: err.Error()}
+		}
+		*bits[i] = set
+	}
+	if cs.dow&(1<<7) != 0 {
+		cs.dow |= 1
+	}
+	cs.domStar = strings.HasPrefix(fields[2], "*")
+	cs.dowStar = strings.HasPrefix(fields[4], "*")
+	return cs, nil
+}
+
+//parse returns the values s matches as bits.
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, step, stepped := part, 1, false
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, cronError("invalid step " + part[i+1:])
+			}
+			rng, step, stepped = part[:i], n, true
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			var err error
+			if i := strings.IndexByte(rng, '-'); i >= 0 {
+				if lo, err = f.value(rng[:i]); err != nil {
+					return 0, err
+				}
+				if hi, err = f.value(rng[i+1:]); err != nil {
+					return 0, err
+				}
+			} else {
+				if lo, err = f.value(rng); err != nil {
+					return 0, err
+				}
+				hi = lo
+				if stepped {
+					hi = f.max
+				}
+			}
+		}
+		if lo > hi {
+			return 0, cronError("invalid range " + rng)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+//value returns the number or name s is, checking it is in the range of the field.
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, cronError("invalid value " + s)
+	}
+	if v < f.min || v > f.max {
+		return 0, cronError(s + " is not between " + strconv.Itoa(f.min) + " and " + strconv.Itoa(f.max))
+	}
+	return v, nil
+}
+
+type cronError string
+
+func (e cronError) Error() string {
+	return string(e)
+}
+
+//String returns the expression cs was parsed from.
+func (cs *CronSchedule) String() string {
+	return cs.expr
+}
+
+//Next returns the first minute after t the schedule is due, in the location of t,
+//or the zero time if it is never due, like on February 30th.
+func (cs *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case cs.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !cs.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case cs.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case cs.minute&(1<<uint(t.Minute())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (cs *CronSchedule) dayMatches(t time.Time) bool {
+	dom := cs.dom&(1<<uint(t.Day())) != 0
+	dow := cs.dow&(1<<uint(t.Weekday())) != 0
+	if cs.domStar || cs.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
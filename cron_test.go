@@ -0,0 +1,66 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	assert := assert.New(t)
+	//Friday.
+	from := time.Date(2021, time.January, 1, 10, 30, 15, 0, time.UTC)
+	cases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2021, time.January, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, time.January, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2021, time.January, 2, 3, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2021, time.January, 2, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", time.Date(2021, time.January, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		//Either the day of the month or the day of the week.
+		{"0 0 15 * mon", time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"5,10 * * * *", time.Date(2021, time.January, 1, 11, 5, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		cs, err := skywalker.ParseCron(c.expr)
+		if assert.NoError(err, c.expr) {
+			assert.Equal(c.next, cs.Next(from), c.expr)
+			assert.Equal(c.expr, cs.String())
+		}
+	}
+
+	cs, err := skywalker.ParseCron("0 0 30 2 *")
+	assert.NoError(err)
+	assert.True(cs.Next(from).IsZero())
+}
+
+func TestCronSyntaxError(t *testing.T) {
+	assert := assert.New(t)
+	for expr, field := range map[string]string{
+		"* * * *":      "",
+		"60 * * * *":   "minute",
+		"* 5-2 * * *":  "hour",
+		"* * 0 * *":    "day of month",
+		"* * * foo *":  "month",
+		"* * * * */0":  "day of week",
+		"@fortnightly": "",
+	} {
+		_, err := skywalker.ParseCron(expr)
+		var cse *skywalker.CronSyntaxError
+		if assert.ErrorAs(err, &cse, expr) {
+			assert.Equal(expr, cse.Expr)
+			assert.Equal(field, cse.Field, expr)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepth(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"one.txt":             "1",
+		"a/two.txt":           "2",
+		"a/b/three.txt":       "3",
+		"a/b/c/four.txt":      "4",
+		"a/b/c/d/five.txt":    "5",
+		"x/y/three-again.txt": "3",
+	})
+	walk := func(min, max int) ([]string, []string) {
+		tw := NewTW()
+		sw := skywalker.New(tmp, tw)
+		sw.FilesOnly = false
+		sw.MinDepth, sw.MaxDepth = min, max
+		var entered []string
+		sw.EnterDir = func(dir string) error {
+			rel, _ := filepath.Rel(sw.Root, dir)
+			entered = append(entered, filepath.ToSlash(rel))
+			return nil
+		}
+		assert.NoError(sw.Walk())
+		var found []string
+		for path := range tw.found {
+			rel, _ := filepath.Rel(sw.Root, path)
+			found = append(found, filepath.ToSlash(rel))
+		}
+		sort.Strings(found)
+		sort.Strings(entered)
+		return found, entered
+	}
+
+	found, entered := walk(0, 2)
+	assert.Equal([]string{".", "a", "a/b", "a/two.txt", "one.txt", "x", "x/y"}, found)
+	assert.Equal([]string{".", "a", "x"}, entered)
+
+	found, _ = walk(3, 0)
+	assert.Equal([]string{"a/b/c", "a/b/c/d", "a/b/c/d/five.txt", "a/b/c/four.txt", "a/b/three.txt", "x/y/three-again.txt"}, found)
+
+	found, _ = walk(3, 3)
+	assert.Equal([]string{"a/b/c", "a/b/three.txt", "x/y/three-again.txt"}, found)
+
+	sw := skywalker.New(tmp, NewTW())
+	sw.MaxDepth = 1
+	m, err := sw.Matcher()
+	assert.NoError(err)
+	info, _ := os.Stat(filepath.Join(tmp, "a", "two.txt"))
+	match, reason := m.Match(filepath.Join(m.Root(), "a", "two.txt"), info)
+	assert.False(match)
+	assert.Equal(skywalker.RMaxDepth, reason)
+
+	sw.MinDepth = 2
+	var confErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &confErr))
+}
@@ -0,0 +1,182 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"strconv"
+	"strings"
+)
+
+//diffContext is how many unchanged lines surround each change in a unified diff.
+const diffContext = 3
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffLine struct {
+	kind diffKind
+	text string
+}
+
+//unifiedDiff returns the changes needed to turn before into after in unified diff format.
+//Returns an empty string if they are the same.
+func unifiedDiff(name string, before, after []byte) string {
+	if string(before) == string(after) {
+		return ""
+	}
+	ops := diffLines(splitLines(string(before)), splitLines(string(after)))
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != diffInsert {
+			aPos[i+1]++
+		}
+		if op.kind != diffDelete {
+			bPos[i+1]++
+		}
+	}
+	name = strings.Replace(name, `\`, "/", -1)
+	var sb strings.Builder
+	sb.WriteString("--- a/" + strings.TrimPrefix(name, "/") + "\n")
+	sb.WriteString("+++ b/" + strings.TrimPrefix(name, "/") + "\n")
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == diffEqual {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != diffEqual {
+				end++
+			}
+			eq := end
+			for eq < len(ops) && ops[eq].kind == diffEqual {
+				eq++
+			}
+			if eq < len(ops) && eq-end <= 2*diffContext {
+				end = eq
+				continue
+			}
+			end += diffContext
+			if end > eq {
+				end = eq
+			}
+			break
+		}
+		sb.WriteString("@@ -" + hunkRange(aPos[start], aPos[end]-aPos[start]) + " +" + hunkRange(bPos[start], bPos[end]-bPos[start]) + " @@\n")
+		for _, op := range ops[start:end] {
+			switch op.kind {
+			case diffEqual:
+				sb.WriteString(" ")
+			case diffDelete:
+				sb.WriteString("-")
+			case diffInsert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return strconv.Itoa(start) + ",0"
+	}
+	if count == 1 {
+		return strconv.Itoa(start + 1)
+	}
+	return strconv.Itoa(start+1) + "," + strconv.Itoa(count)
+}
+
+//splitLines splits s into lines keeping the line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+//diffLines finds the shortest edit from a to b using Myers' algorithm.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+	var ops []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffLine{diffEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffLine{diffInsert, b[prevY]})
+			} else {
+				ops = append(ops, diffLine{diffDelete, a[prevX]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
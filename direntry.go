@@ -0,0 +1,108 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//DirEntryWorker is a Worker that wants the fs.DirEntry read from the directory of each path.
+//The name and type come for free with it, anything else costs an lstat the first time Info is called.
+//WorkEntry is called instead of Work.
+type DirEntryWorker interface {
+	Worker
+	WorkEntry(path string, entry fs.DirEntry)
+}
+
+//walkDir walks root with filepath.WalkDir, handing walkFn an os.FileInfo that only stats the path once
+//something needs more than its name and type.
+func walkDir(root string, walkFn filepath.WalkFunc) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		var info os.FileInfo
+		if entry != nil {
+			info = &entryInfo{entry: entry}
+		}
+		return walkFn(path, info, err)
+	})
+}
+
+//entryInfo is the os.FileInfo of a directory entry. Name, IsDir and the type bits of Mode come from the entry,
+//anything else stats the path the first time it is asked for. It is safe to use concurrently.
+//If the path is gone by then it looks like an empty file of the entry's type.
+type entryInfo struct {
+	entry fs.DirEntry
+	once  sync.Once
+	info  os.FileInfo
+}
+
+func (ei *entryInfo) stat() os.FileInfo {
+	ei.once.Do(func() {
+		ei.info, _ = ei.entry.Info()
+	})
+	return ei.info
+}
+
+//statNow stats the path of info now if it is the info of a directory entry that did not yet.
+func statNow(info os.FileInfo) {
+	if ei, ok := info.(*entryInfo); ok {
+		ei.stat()
+	}
+}
+
+func (ei *entryInfo) Name() string {
+	return ei.entry.Name()
+}
+
+func (ei *entryInfo) IsDir() bool {
+	return ei.entry.IsDir()
+}
+
+func (ei *entryInfo) Size() int64 {
+	if info := ei.stat(); info != nil {
+		return info.Size()
+	}
+	return 0
+}
+
+func (ei *entryInfo) Mode() os.FileMode {
+	if info := ei.stat(); info != nil {
+		return info.Mode()
+	}
+	return ei.entry.Type()
+}
+
+func (ei *entryInfo) ModTime() time.Time {
+	if info := ei.stat(); info != nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+func (ei *entryInfo) Sys() interface{} {
+	if info := ei.stat(); info != nil {
+		return info.Sys()
+	}
+	return nil
+}
+
+//fileType returns the type bits of info's mode without a stat if info came from a directory entry.
+func fileType(info os.FileInfo) os.FileMode {
+	if ei, ok := info.(*entryInfo); ok {
+		return ei.entry.Type()
+	}
+	return info.Mode().Type()
+}
+
+//entryOf returns the directory entry info came from, or one made from info.
+func entryOf(info os.FileInfo) fs.DirEntry {
+	if ei, ok := info.(*entryInfo); ok {
+		return ei.entry
+	}
+	return fs.FileInfoToDirEntry(info)
+}
@@ -0,0 +1,52 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type EntryWorker struct {
+	*sync.Mutex
+	entries map[string]fs.DirEntry
+}
+
+func (ew *EntryWorker) Work(path string) {}
+
+func (ew *EntryWorker) WorkEntry(path string, entry fs.DirEntry) {
+	ew.Lock()
+	defer ew.Unlock()
+	ew.entries[filepath.Base(path)] = entry
+}
+
+func TestDirEntryWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+	})
+	ew := &EntryWorker{Mutex: new(sync.Mutex), entries: make(map[string]fs.DirEntry)}
+	var _ skywalker.DirEntryWorker = ew
+	sw := skywalker.New(tmp, ew)
+	sw.FilesOnly = false
+	assert.NoError(sw.Walk())
+
+	if assert.Len(ew.entries, 4) {
+		assert.True(ew.entries["sub"].IsDir())
+		assert.False(ew.entries["a.txt"].IsDir())
+		assert.Equal("b.txt", ew.entries["b.txt"].Name())
+		info, err := ew.entries["a.txt"].Info()
+		if assert.NoError(err) {
+			assert.Equal(int64(3), info.Size())
+		}
+	}
+}
@@ -0,0 +1,70 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//dirHooks keeps the directories EnterDir was called on that still need LeaveDir.
+//It is only used from the walking goroutine.
+type dirHooks struct {
+	enter func(dir string) error
+	leave func(dir string)
+	stack []string
+}
+
+//walkRoot walks root with walkFn calling EnterDir and LeaveDir around every directory it walks into,
+//including the ones reached through links matching FollowLinks.
+func (sw *Skywalker) walkRoot(root string, walkFn filepath.WalkFunc) error {
+	if sw.EnterDir == nil && sw.LeaveDir == nil {
+		return sw.dirWalker()(root, sw.followLinks(root, walkFn))
+	}
+	dh := &dirHooks{enter: sw.EnterDir, leave: sw.LeaveDir}
+	err := sw.dirWalker()(root, sw.followLinks(root, func(path string, info os.FileInfo, err error) error {
+		dh.leaveUntil(path)
+		ret := walkFn(path, info, err)
+		if ret != nil || err != nil || !info.IsDir() {
+			return ret
+		}
+		if dh.enter != nil {
+			if err := dh.enter(path); err != nil {
+				return filepath.SkipDir
+			}
+		}
+		dh.stack = append(dh.stack, path)
+		return nil
+	}))
+	dh.leaveAll()
+	return err
+}
+
+//leaveUntil calls LeaveDir on every entered directory that path is not inside of.
+func (dh *dirHooks) leaveUntil(path string) {
+	for len(dh.stack) > 0 {
+		top := dh.stack[len(dh.stack)-1]
+		if path == top || strings.HasPrefix(path, strings.TrimSuffix(top, string(filepath.Separator))+string(filepath.Separator)) {
+			return
+		}
+		dh.pop()
+	}
+}
+
+func (dh *dirHooks) pop() {
+	top := dh.stack[len(dh.stack)-1]
+	dh.stack = dh.stack[:len(dh.stack)-1]
+	if dh.leave != nil {
+		dh.leave(top)
+	}
+}
+
+//leaveAll calls LeaveDir on every directory still entered, deepest first.
+func (dh *dirHooks) leaveAll() {
+	for len(dh.stack) > 0 {
+		dh.pop()
+	}
+}
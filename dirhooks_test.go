@@ -0,0 +1,58 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnterLeaveDir(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a/1.txt":        "",
+		"a/b/2.txt":      "",
+		"c/3.txt":        "",
+		"secret/4.txt":   "",
+		"secret/d/5.txt": "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	var events []string
+	depth := 0
+	sw.EnterDir = func(dir string) error {
+		rel, _ := filepath.Rel(sw.Root, dir)
+		if rel == "secret" {
+			return errors.New("no access")
+		}
+		depth++
+		events = append(events, "enter "+filepath.ToSlash(rel))
+		return nil
+	}
+	sw.LeaveDir = func(dir string) {
+		rel, _ := filepath.Rel(sw.Root, dir)
+		depth--
+		events = append(events, "leave "+filepath.ToSlash(rel))
+	}
+	assert.NoError(sw.Walk())
+
+	assert.Equal(0, depth)
+	assert.Equal([]string{
+		"enter .",
+		"enter a",
+		"enter a/b",
+		"leave a/b",
+		"leave a",
+		"enter c",
+		"leave c",
+		"leave .",
+	}, events)
+	assert.Len(tw.found, 3)
+}
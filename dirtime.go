@@ -0,0 +1,66 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//DirTiming is how long the walk spent reading a directory.
+type DirTiming struct {
+	Path     string
+	Duration time.Duration
+}
+
+//dirTimer times how long the walk spends reading every directory. Only the walking goroutine uses it.
+type dirTimer struct {
+	sw    *Skywalker
+	dir   string
+	since time.Time
+}
+
+//track wraps walkFn so the time from walkFn returning for a directory until it is called again, which the
+//walk spends reading the directory, is counted toward it.
+func (dt *dirTimer) track(walkFn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		dt.done()
+		ret := walkFn(path, info, err)
+		if ret == nil && err == nil && info.IsDir() {
+			dt.dir, dt.since = path, time.Now()
+		}
+		return ret
+	}
+}
+
+//done counts the directory read last, once the walk moved on or is over.
+func (dt *dirTimer) done() {
+	if dt.dir == "" {
+		return
+	}
+	dt.sw.countDirTime(dt.dir, time.Since(dt.since))
+	dt.dir = ""
+}
+
+//countDirTime adds to the ReadTime and keeps dir if it is one of the SlowDirs slowest to read so far.
+func (sw *Skywalker) countDirTime(dir string, d time.Duration) {
+	ws := &sw.stats
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+	ws.stats.ReadTime += d
+	slow := ws.stats.SlowDirs
+	i := sort.Search(len(slow), func(i int) bool { return slow[i].Duration < d })
+	if i >= sw.SlowDirs {
+		return
+	}
+	if len(slow) < sw.SlowDirs {
+		slow = append(slow, DirTiming{})
+	}
+	copy(slow[i+1:], slow[i:])
+	slow[i] = DirTiming{Path: dir, Duration: d}
+	ws.stats.SlowDirs = slow
+}
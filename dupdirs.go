@@ -0,0 +1,220 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+//DirGroup is a set of directories that have identical contents.
+type DirGroup struct {
+	//Digest is the hex encoded digest every directory in the group shares.
+	Digest string
+	//Size is the total size in bytes of the files in one of the directories.
+	Size int64
+	//Dirs are the directories that are identical, sorted.
+	Dirs []string
+}
+
+type fileDigest struct {
+	sum  []byte
+	size int64
+}
+
+type dirDigest struct {
+	sum   string
+	size  int64
+	valid bool
+}
+
+//DirHashWorker is a Worker that hashes every file it is given so identical directories can be found once the walk is done.
+//A directory's digest is built from the names and digests of everything in it, so two directories
+//only share a digest if their whole subtrees are the same.
+//Only what the Skywalker hands the worker counts, so filters decide what is compared.
+//Empty directories are only seen if FilesOnly is false.
+type DirHashWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	root rootRel
+
+	mutex sync.Mutex
+	files map[string]fileDigest
+	dirs  map[string]struct{}
+	errs  map[string]error
+}
+
+//NewDirHashWorker creates a DirHashWorker for the tree at root.
+func NewDirHashWorker(root string) *DirHashWorker {
+	return &DirHashWorker{
+		Root:  root,
+		files: make(map[string]fileDigest),
+		dirs:  make(map[string]struct{}),
+		errs:  make(map[string]error),
+	}
+}
+
+//Work hashes the file at path or remembers the directory at path.
+func (dw *DirHashWorker) Work(path string) {
+	info, err := os.Lstat(path)
+	if err == nil && info.IsDir() {
+		dw.mutex.Lock()
+		dw.dirs[path] = struct{}{}
+		dw.mutex.Unlock()
+		return
+	}
+	var sum []byte
+	if err == nil {
+		sum, err = checksum(path, sha256.New(), nil)
+	}
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	if err != nil {
+		dw.errs[path] = err
+		return
+	}
+	dw.files[path] = fileDigest{sum: sum, size: info.Size()}
+}
+
+//Errors returns every file that could not be hashed.
+//Directories containing these files are never reported as duplicates.
+func (dw *DirHashWorker) Errors() map[string]error {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	errs := make(map[string]error, len(dw.errs))
+	for path, err := range dw.errs {
+		errs[path] = err
+	}
+	return errs
+}
+
+//Duplicates returns every group of identical directories, largest first.
+//A group is left out if all of its directories are inside directories that are already reported,
+//so copying a whole project tree shows up once instead of once per subdirectory.
+func (dw *DirHashWorker) Duplicates() ([]DirGroup, error) {
+	digests, err := dw.digests()
+	if err != nil {
+		return nil, err
+	}
+	byDigest := make(map[string][]string)
+	for dir, d := range digests {
+		if d.valid {
+			byDigest[d.sum] = append(byDigest[d.sum], dir)
+		}
+	}
+	var groups []DirGroup
+	for sum, dirs := range byDigest {
+		if len(dirs) < 2 {
+			continue
+		}
+		covered := 0
+		for _, dir := range dirs {
+			if parent, ok := digests[filepath.Dir(dir)]; ok && parent.valid && len(byDigest[parent.sum]) > 1 {
+				covered++
+			}
+		}
+		if covered == len(dirs) {
+			continue
+		}
+		sort.Strings(dirs)
+		groups = append(groups, DirGroup{Digest: sum, Size: digests[dirs[0]].size, Dirs: dirs})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Dirs[0] < groups[j].Dirs[0]
+	})
+	return groups, nil
+}
+
+type dirEntry struct {
+	name string
+	path string
+	dir  bool
+}
+
+//digests rolls the file digests up into a digest for every directory under Root.
+func (dw *DirHashWorker) digests() (map[string]dirDigest, error) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	root, err := dw.root.absolute(dw.Root)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]dirEntry)
+	var addDir func(dir string)
+	addDir = func(dir string) {
+		if _, ok := children[dir]; ok {
+			return
+		}
+		children[dir] = nil
+		if dir == root {
+			return
+		}
+		parent := filepath.Dir(dir)
+		if _, err := relPath(root, parent); err != nil {
+			return
+		}
+		addDir(parent)
+		children[parent] = append(children[parent], dirEntry{name: filepath.Base(dir), path: dir, dir: true})
+	}
+	addEntry := func(path string) {
+		parent := filepath.Dir(path)
+		if _, err := relPath(root, parent); err != nil {
+			return
+		}
+		addDir(parent)
+		children[parent] = append(children[parent], dirEntry{name: filepath.Base(path), path: path})
+	}
+	for dir := range dw.dirs {
+		addDir(dir)
+	}
+	for path := range dw.files {
+		addEntry(path)
+	}
+	for path := range dw.errs {
+		addEntry(path)
+	}
+	digests := make(map[string]dirDigest, len(children))
+	var digest func(dir string) dirDigest
+	digest = func(dir string) dirDigest {
+		if d, ok := digests[dir]; ok {
+			return d
+		}
+		entries := children[dir]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+		h := sha256.New()
+		d := dirDigest{valid: true}
+		for _, e := range entries {
+			var sum string
+			if e.dir {
+				sub := digest(e.path)
+				d.valid = d.valid && sub.valid
+				d.size += sub.size
+				sum = "d" + sub.sum
+			} else if f, ok := dw.files[e.path]; ok {
+				d.size += f.size
+				sum = "f" + hex.EncodeToString(f.sum)
+			} else {
+				d.valid = false
+			}
+			io.WriteString(h, e.name+"\x00"+sum+"\n")
+		}
+		d.sum = hex.EncodeToString(h.Sum(nil))
+		digests[dir] = d
+		return d
+	}
+	for dir := range children {
+		digest(dir)
+	}
+	return digests, nil
+}
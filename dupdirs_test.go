@@ -0,0 +1,46 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirHashWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a/x/one.txt": "one",
+		"a/x/two.txt": "two",
+		"a/three.txt": "three",
+		"b/x/one.txt": "one",
+		"b/x/two.txt": "two",
+		"b/three.txt": "three",
+		"c/x/one.txt": "one",
+		"c/x/two.txt": "two",
+		"c/four.txt":  "four",
+		"d/one.txt":   "one",
+		"d/two.txt":   "changed",
+	})
+
+	dw := skywalker.NewDirHashWorker(tmp)
+	sw := skywalker.New(tmp, dw)
+	assert.NoError(sw.Walk())
+	assert.Empty(dw.Errors())
+
+	groups, err := dw.Duplicates()
+	assert.NoError(err)
+	assert.Len(groups, 2)
+	if len(groups) == 2 {
+		assert.Equal([]string{filepath.Join(sw.Root, "a"), filepath.Join(sw.Root, "b")}, groups[0].Dirs)
+		assert.Equal(int64(11), groups[0].Size)
+		assert.Equal([]string{filepath.Join(sw.Root, "a/x"), filepath.Join(sw.Root, "b/x"), filepath.Join(sw.Root, "c/x")}, groups[1].Dirs)
+		assert.Equal(int64(6), groups[1].Size)
+	}
+}
@@ -0,0 +1,146 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+//EncodingPrefix is how many bytes at the start of a file are used to detect its encoding.
+const EncodingPrefix = 8 << 10
+
+//Encoding is the text encoding of a file, guessed from its first EncodingPrefix bytes.
+type Encoding int
+
+const (
+	//EUnknown is used when the encoding was not detected.
+	EUnknown Encoding = iota
+	//EBinary is used for a file that does not look like text.
+	EBinary
+	//EASCII is used for a file that only holds 7 bit characters. Empty files are ASCII too.
+	EASCII
+	//EUTF8 is used for valid UTF-8, with or without a byte order mark.
+	EUTF8
+	//EUTF16LE is used for little endian UTF-16.
+	EUTF16LE
+	//EUTF16BE is used for big endian UTF-16.
+	EUTF16BE
+	//ELatin1 is used for 8 bit text that is not valid UTF-8, like ISO 8859-1 or Windows-1252.
+	ELatin1
+)
+
+var encodingNames = [...]string{"unknown", "binary", "ascii", "utf-8", "utf-16le", "utf-16be", "latin-1"}
+
+func (e Encoding) String() string {
+	if e < 0 || int(e) >= len(encodingNames) {
+		return "unknown"
+	}
+	return encodingNames[e]
+}
+
+//IsText reports whether e is one of the text encodings.
+func (e Encoding) IsText() bool {
+	return e > EBinary
+}
+
+//SniffEncoding guesses the encoding of text starting with prefix.
+//A byte order mark wins, then UTF-16 is recognized by how its zero bytes line up.
+//Anything else with a zero byte is binary.
+func SniffEncoding(prefix []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(prefix, []byte{0xef, 0xbb, 0xbf}):
+		return EUTF8
+	case bytes.HasPrefix(prefix, []byte{0xff, 0xfe}):
+		return EUTF16LE
+	case bytes.HasPrefix(prefix, []byte{0xfe, 0xff}):
+		return EUTF16BE
+	}
+	if e := sniffUTF16(prefix); e != EUnknown {
+		return e
+	}
+	if bytes.IndexByte(prefix, 0) >= 0 {
+		return EBinary
+	}
+	ascii := true
+	for _, b := range prefix {
+		if b >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if !ascii && validUTF8Prefix(prefix) {
+		return EUTF8
+	}
+	control := 0
+	for _, b := range prefix {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' && b != 0x1b {
+			control++
+		}
+	}
+	if control*100 > len(prefix) {
+		return EBinary
+	}
+	if ascii {
+		return EASCII
+	}
+	return ELatin1
+}
+
+//sniffUTF16 recognizes UTF-16 without a byte order mark by most of the odd or even bytes being zero,
+//which is what mostly latin text looks like.
+func sniffUTF16(prefix []byte) Encoding {
+	if len(prefix) < 4 {
+		return EUnknown
+	}
+	var even, odd int
+	for i, b := range prefix {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	half := len(prefix) / 2
+	switch {
+	case odd*10 >= half*7 && even == 0:
+		return EUTF16LE
+	case even*10 >= half*7 && odd == 0:
+		return EUTF16BE
+	}
+	return EUnknown
+}
+
+//validUTF8Prefix is utf8.Valid that allows the last rune to be cut off by the end of the prefix.
+func validUTF8Prefix(prefix []byte) bool {
+	for i := len(prefix) - 1; i >= 0 && i >= len(prefix)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(prefix[i]) {
+			if !utf8.FullRune(prefix[i:]) {
+				prefix = prefix[:i]
+			}
+			break
+		}
+	}
+	return utf8.Valid(prefix)
+}
+
+//readPrefix reads up to the first EncodingPrefix bytes of the file at path.
+func (sw *Skywalker) readPrefix(path string) ([]byte, error) {
+	file, err := sw.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	prefix := make([]byte, EncodingPrefix)
+	n, err := io.ReadFull(file, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return prefix[:n], nil
+}
@@ -0,0 +1,63 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffEncoding(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     string
+		expected skywalker.Encoding
+	}{
+		{"Empty", "", skywalker.EASCII},
+		{"ASCII", "plain old text\r\n", skywalker.EASCII},
+		{"UTF-8", "café ☃", skywalker.EUTF8},
+		{"UTF-8 BOM", "\xef\xbb\xbfhi", skywalker.EUTF8},
+		{"UTF-8 Cut Off", strings.Repeat("a", 10) + "☃"[:2], skywalker.EUTF8},
+		{"UTF-16LE BOM", "\xff\xfeh\x00i\x00", skywalker.EUTF16LE},
+		{"UTF-16LE", "h\x00e\x00l\x00l\x00o\x00", skywalker.EUTF16LE},
+		{"UTF-16BE", "\x00h\x00e\x00l\x00l\x00o", skywalker.EUTF16BE},
+		{"Latin-1", "caf\xe9 cr\xe8me", skywalker.ELatin1},
+		{"Binary", "\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00", skywalker.EBinary},
+		{"Control", "\x01\x02\x03\x04text", skywalker.EBinary},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, skywalker.SniffEncoding([]byte(c.data)), "Got %s", skywalker.SniffEncoding([]byte(c.data)))
+		})
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"ascii.txt":  "hello",
+		"utf8.txt":   "héllo",
+		"latin1.txt": "h\xe9llo",
+		"image.bin":  "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	})
+	tw := &SnapshotTestWorker{TestWorker: NewTW(), snaps: make(map[string]skywalker.Snapshot)}
+	sw := skywalker.New(tmp, tw)
+	sw.Encodings = []skywalker.Encoding{skywalker.EASCII, skywalker.EUTF8}
+	assert.NoError(sw.Walk())
+
+	encodings := make(map[string]skywalker.Encoding)
+	for name, snap := range tw.snaps {
+		encodings[filepath.Base(name)] = snap.Encoding
+	}
+	assert.Equal(map[string]skywalker.Encoding{
+		"ascii.txt": skywalker.EASCII,
+		"utf8.txt":  skywalker.EUTF8,
+	}, encodings)
+}
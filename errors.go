@@ -0,0 +1,249 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+//RootNotExistError is returned by Walk when Root does not exist.
+//It unwraps to the underlying error so errors.Is(err, os.ErrNotExist) is true.
+type RootNotExistError struct {
+	Root string
+	Err  error
+}
+
+func (e *RootNotExistError) Error() string {
+	return "root does not exist: " + e.Root
+}
+
+//Unwrap returns the underlying error.
+func (e *RootNotExistError) Unwrap() error {
+	return e.Err
+}
+
+//PermissionDeniedError is returned when Path could not be read because of its permissions.
+//It unwraps to the underlying error so errors.Is(err, os.ErrPermission) is true.
+type PermissionDeniedError struct {
+	Path string
+	Err  error
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return "permission denied: " + e.Path
+}
+
+//Unwrap returns the underlying error.
+func (e *PermissionDeniedError) Unwrap() error {
+	return e.Err
+}
+
+//GlobCompileError is returned by Walk when a pattern in List is not a valid glob.
+type GlobCompileError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *GlobCompileError) Error() string {
+	return "invalid glob " + e.Pattern + ": " + e.Err.Error()
+}
+
+//Unwrap returns the underlying error.
+func (e *GlobCompileError) Unwrap() error {
+	return e.Err
+}
+
+//RegexCompileError is returned by Walk when a pattern in RegexList is not a valid regular expression.
+type RegexCompileError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *RegexCompileError) Error() string {
+	return "invalid regex " + e.Pattern + ": " + e.Err.Error()
+}
+
+//Unwrap returns the underlying error.
+func (e *RegexCompileError) Unwrap() error {
+	return e.Err
+}
+
+//FilterSyntaxError is returned by Walk when Filter is not a valid filter expression.
+type FilterSyntaxError struct {
+	Filter string
+	//Offset is how far into Filter the problem was found.
+	Offset int
+	Msg    string
+}
+
+func (e *FilterSyntaxError) Error() string {
+	return "invalid filter at offset " + strconv.Itoa(e.Offset) + ": " + e.Msg
+}
+
+//CronSyntaxError is returned by ParseCron when Expr is not a valid cron expression.
+type CronSyntaxError struct {
+	Expr string
+	//Field is the name of the field that is not valid, like "hour", or "" if the expression as a whole is not.
+	Field string
+	Msg   string
+}
+
+func (e *CronSyntaxError) Error() string {
+	if e.Field == "" {
+		return "invalid cron expression " + e.Expr + ": " + e.Msg
+	}
+	return "invalid cron expression " + e.Expr + ": " + e.Field + ": " + e.Msg
+}
+
+//ManifestError is returned when a line of a checksum manifest can not be parsed.
+type ManifestError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ManifestError) Error() string {
+	return "invalid manifest line " + strconv.Itoa(e.Line) + ": " + e.Msg
+}
+
+//UnsafeEntryError is returned when an entry of Archive would be extracted outside of its destination.
+type UnsafeEntryError struct {
+	Archive string
+	Name    string
+}
+
+func (e *UnsafeEntryError) Error() string {
+	return "unsafe entry " + e.Name + " in " + e.Archive
+}
+
+//ConfigError is returned by Walk when a field of the Skywalker has a value it can not walk with.
+type ConfigError struct {
+	Field string
+	Msg   string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid " + e.Field + ": " + e.Msg
+}
+
+//UnknownHashError is returned by LookupHash when no hash is registered as Name.
+type UnknownHashError struct {
+	Name string
+}
+
+func (e *UnknownHashError) Error() string {
+	return "unknown hash " + e.Name
+}
+
+//UnknownActionError is returned by LookupAction when no action is registered as Name.
+type UnknownActionError struct {
+	Name string
+}
+
+func (e *UnknownActionError) Error() string {
+	return "unknown action " + e.Name
+}
+
+//JobError is returned by LoadJobs when the Job named Job is not valid.
+type JobError struct {
+	Job string
+	Err error
+}
+
+func (e *JobError) Error() string {
+	return "job " + e.Job + ": " + e.Err.Error()
+}
+
+//Unwrap returns the underlying error.
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+//WorkerError is a failure a worker had while working on Path.
+type WorkerError struct {
+	Path string
+	Err  error
+}
+
+func (e *WorkerError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+//Unwrap returns the underlying error.
+func (e *WorkerError) Unwrap() error {
+	return e.Err
+}
+
+//PanicError is the failure recorded when the Worker panicked on Path. It is kept in WorkErrors like the error
+//of an ErrorWorker, so the walk goes on with the other paths.
+type PanicError struct {
+	Path string
+	//Value is what the Worker panicked with.
+	Value interface{}
+	//Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return "panic on " + e.Path + ": " + fmt.Sprint(e.Value)
+}
+
+//Unwrap returns the value the Worker panicked with if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+//WorkTimeoutError is the failure recorded when a ContextWorker was still working on Path after WorkTimeout.
+//It unwraps to context.DeadlineExceeded.
+type WorkTimeoutError struct {
+	Path    string
+	Timeout time.Duration
+	//Took is how long the worker took in all, more than Timeout if it was slow to stop once canceled.
+	Took time.Duration
+}
+
+func (e *WorkTimeoutError) Error() string {
+	return "timed out after " + e.Timeout.String() + ": " + e.Path
+}
+
+//Unwrap returns context.DeadlineExceeded.
+func (e *WorkTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+//WorkErrors is returned by Walk when an ErrorWorker failed on any path, with every failure in the order they happened.
+//errors.Is and errors.As look through all of them.
+type WorkErrors []*WorkerError
+
+func (e WorkErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return strconv.Itoa(len(e)) + " paths failed, first " + e[0].Error()
+}
+
+//Unwrap returns every failure.
+func (e WorkErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+//rootError turns a failure to read root into one of the typed errors when possible.
+func rootError(root string, err error) error {
+	switch {
+	case os.IsNotExist(err):
+		return &RootNotExistError{Root: root, Err: err}
+	case os.IsPermission(err):
+		return &PermissionDeniedError{Path: root, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootNotExistError(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(filepath.Join(root, "not/here"), NewTW())
+	err := sw.Walk()
+	var rootErr *skywalker.RootNotExistError
+	assert.True(errors.As(err, &rootErr), "Expected a RootNotExistError but got %v", err)
+	assert.True(errors.Is(err, os.ErrNotExist))
+}
+
+func TestGlobCompileError(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(root, NewTW())
+	sw.List = []string{"**.pdf", "[abc"}
+	err := sw.Walk()
+	var globErr *skywalker.GlobCompileError
+	assert.True(errors.As(err, &globErr), "Expected a GlobCompileError but got %v", err)
+	if globErr != nil {
+		assert.Equal("[abc", globErr.Pattern)
+	}
+}
+
+func TestPermissionDeniedError(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permissions can not be denied here")
+	}
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	locked := filepath.Join(tmp, "locked")
+	writeFiles(t, locked, map[string]string{"file.txt": ""})
+	assert.NoError(os.Chmod(locked, 0))
+	defer os.Chmod(locked, 0777)
+
+	err := skywalker.New(locked, NewTW()).Walk()
+	var permErr *skywalker.PermissionDeniedError
+	assert.True(errors.As(err, &permErr), "Expected a PermissionDeniedError but got %v", err)
+	assert.True(errors.Is(err, os.ErrPermission))
+
+	tw := NewTW()
+	assert.NoError(skywalker.New(tmp, tw).Walk(), "Unreadable directories below Root are skipped")
+}
+
+func TestConfigError(t *testing.T) {
+	assert := assert.New(t)
+	for _, configure := range []func(sw *skywalker.Skywalker){
+		func(sw *skywalker.Skywalker) { sw.NumWorkers = 0 },
+		func(sw *skywalker.Skywalker) { sw.NumWorkers = skywalker.MaxWorkers + 1 },
+		func(sw *skywalker.Skywalker) { sw.QueueSize = -1 },
+		func(sw *skywalker.Skywalker) { sw.Worker = nil },
+	} {
+		sw := skywalker.New(root, NewTW())
+		configure(sw)
+		var configErr *skywalker.ConfigError
+		assert.True(errors.As(sw.Walk(), &configErr), "Expected a ConfigError")
+	}
+}
+
+//ReadTimeout is left out on purpose, as a read that times out leaves its goroutine behind until it returns.
+func TestNoGoroutineLeaks(t *testing.T) {
+	assert := assert.New(t)
+	before := runtime.NumGoroutine()
+	storeErr := errors.New("store failed")
+	progressFile := filepath.Join(t.TempDir(), "progress.json")
+	for _, c := range []struct {
+		configure func(sw *skywalker.Skywalker)
+		//extra are the goroutines started besides the workers.
+		extra int
+	}{
+		{func(sw *skywalker.Skywalker) { sw.Root = filepath.Join(root, "not/here") }, 0},
+		{func(sw *skywalker.Skywalker) { sw.Overlays = []string{filepath.Join(root, "not/here")} }, 0},
+		{func(sw *skywalker.Skywalker) { sw.List = []string{"[abc"} }, 0},
+		{func(sw *skywalker.Skywalker) { sw.Filter = "size>" }, 0},
+		{func(sw *skywalker.Skywalker) { sw.Results = failingStore{err: storeErr} }, 0},
+		{func(sw *skywalker.Skywalker) { sw.ShuffleWindow = 10 }, 0},
+		{func(sw *skywalker.Skywalker) { sw.ProgressFile = progressFile }, 1},
+		{func(sw *skywalker.Skywalker) { sw.OnStats = func(skywalker.Stats) {} }, 1},
+		{func(sw *skywalker.Skywalker) {
+			sw.OnStall = func(skywalker.StallReport) {}
+			sw.StallTimeout = time.Minute
+		}, 1},
+		{func(sw *skywalker.Skywalker) { sw.MinWorkers = 1 }, 1},
+		{func(sw *skywalker.Skywalker) { sw.Less = skywalker.LargestFirst }, 1},
+	} {
+		sw := skywalker.New(root, &SizeWorker{TestWorker: NewTW()})
+		c.configure(sw)
+		assert.Equal(sw.NumWorkers+c.extra, sw.Goroutines())
+		sw.Walk()
+		sw.FindFirst("**/just.txt")
+	}
+	//Workers can still be returning right after they signal they are done.
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(before, runtime.NumGoroutine())
+}
+
+//ContextWorker cancels the walk's context once it has seen three paths.
+type ContextWorker struct {
+	cancel func()
+	worked int32
+}
+
+func (cw *ContextWorker) Work(path string) {
+	if atomic.AddInt32(&cw.worked, 1) == 3 {
+		cw.cancel()
+	}
+	time.Sleep(time.Millisecond)
+}
+
+func TestWalkContext(t *testing.T) {
+	assert := assert.New(t)
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cw := &ContextWorker{cancel: cancel}
+	sw := skywalker.New(root, cw)
+	sw.NumWorkers = 2
+	sw.QueueSize = 50
+	err := sw.WalkContext(ctx)
+	assert.True(errors.Is(err, context.Canceled), "Expected context.Canceled but got %v", err)
+	assert.True(atomic.LoadInt32(&cw.worked) < 10, "Queued paths should be dropped once the context is done")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	err = sw.WalkContext(ctx)
+	assert.True(errors.Is(err, context.DeadlineExceeded), "Expected context.DeadlineExceeded but got %v", err)
+
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(before, runtime.NumGoroutine())
+}
+
+var errPDF = errors.New("no pdfs")
+
+type PDFWorker struct {
+	*TestWorker
+}
+
+func (pw *PDFWorker) WorkErr(path string) error {
+	pw.Work(path)
+	if filepath.Ext(path) == ".pdf" {
+		return errPDF
+	}
+	return nil
+}
+
+func TestWorkErrors(t *testing.T) {
+	assert := assert.New(t)
+	var handled int32
+	pw := &PDFWorker{TestWorker: NewTW()}
+	sw := skywalker.New(root, pw)
+	sw.OnWorkError = func(path string, err error) {
+		atomic.AddInt32(&handled, 1)
+	}
+	err := sw.Walk()
+	var workErrs skywalker.WorkErrors
+	if assert.True(errors.As(err, &workErrs), "Expected WorkErrors but got %v", err) {
+		assert.Len(workErrs, 4)
+		for _, werr := range workErrs {
+			assert.Equal(".pdf", filepath.Ext(werr.Path))
+		}
+	}
+	assert.True(errors.Is(err, errPDF))
+	assert.Equal(int32(4), atomic.LoadInt32(&handled))
+	assert.Len(pw.found, 16)
+
+	sw.List = []string{"**.pdf"}
+	assert.NoError(sw.Walk())
+}
+
+type PanicWorker struct {
+	*TestWorker
+}
+
+func (pw *PanicWorker) Work(path string) {
+	if filepath.Ext(path) == ".pdf" {
+		panic(errPDF)
+	}
+	pw.TestWorker.Work(path)
+}
+
+func TestPanicError(t *testing.T) {
+	assert := assert.New(t)
+	var handled, done int32
+	pw := &PanicWorker{TestWorker: NewTW()}
+	sw := skywalker.New(root, pw)
+	sw.PanicHandler = func(pe *skywalker.PanicError) {
+		atomic.AddInt32(&handled, 1)
+		assert.NotEmpty(pe.Stack)
+	}
+	sw.OnEvent = func(ev skywalker.Event) {
+		var pe *skywalker.PanicError
+		if ev.Kind == skywalker.EKDone && errors.As(ev.Err, &pe) {
+			atomic.AddInt32(&done, 1)
+			assert.Equal(ev.Path, pe.Path)
+		}
+	}
+	err := sw.Walk()
+	var workErrs skywalker.WorkErrors
+	if assert.True(errors.As(err, &workErrs), "Expected WorkErrors but got %v", err) {
+		assert.Len(workErrs, 4)
+	}
+	var pe *skywalker.PanicError
+	assert.ErrorAs(err, &pe)
+	assert.True(errors.Is(err, errPDF))
+	assert.Equal(int32(4), atomic.LoadInt32(&handled))
+	assert.Equal(int32(4), atomic.LoadInt32(&done))
+	assert.Len(pw.found, 12)
+}
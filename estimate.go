@@ -0,0 +1,99 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"math/rand"
+	"time"
+)
+
+//WalkEstimate is a projection of what walking Root would find.
+type WalkEstimate struct {
+	//Files and Bytes are the projected number of files a walk would queue and the bytes they hold.
+	Files int64
+	Bytes int64
+	//Dirs is the projected number of directories a walk would go through.
+	Dirs int64
+	//ListTime is the projected time needed just to find every path.
+	ListTime time.Duration
+	//NumWorkers is the Skywalker's NumWorkers at the time of the estimate.
+	NumWorkers int
+	//Probes is how many random paths down the tree were sampled.
+	Probes int
+}
+
+//Duration projects how long a walk would take if each file keeps a worker busy for perFile.
+//Finding paths and working on them overlap, so it is whichever of the two takes longer.
+func (we WalkEstimate) Duration(perFile time.Duration) time.Duration {
+	workers := we.NumWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	work := time.Duration(we.Files) * perFile / time.Duration(workers)
+	if work > we.ListTime {
+		return work
+	}
+	return we.ListTime
+}
+
+//Estimate projects the size of a walk of Root without doing one.
+//Each probe starts at Root and goes down through randomly picked subdirectories to a leaf, listing
+//one directory per level with the same filters as Walk. What it finds at each level is scaled by
+//how many directories it could have picked on the way there and the probes are averaged.
+//More probes give a better estimate. It defaults to 100 probes if probes is not positive.
+//The estimate is rough for very lopsided trees, and ListTime is usually low as probes read the upper
+//directories from cache after the first one.
+func (sw *Skywalker) Estimate(probes int) (WalkEstimate, error) {
+	if probes <= 0 {
+		probes = 100
+	}
+	est := WalkEstimate{NumWorkers: sw.NumWorkers, Probes: probes}
+	if err := sw.osOnly("Estimate"); err != nil {
+		return est, err
+	}
+	m, err := sw.Matcher()
+	if err != nil {
+		return est, err
+	}
+	if _, err = sw.listDir(m, m.root); err != nil {
+		return est, rootError(m.root, err)
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var files, bytes, dirs float64
+	var listed int
+	var listTime time.Duration
+	for i := 0; i < probes; i++ {
+		dir, weight := m.root, 1.0
+		for {
+			start := time.Now()
+			entries, err := sw.listDir(m, dir)
+			listTime += time.Since(start)
+			listed++
+			dirs += weight
+			if err != nil {
+				break
+			}
+			var subdirs []string
+			for _, e := range entries {
+				if e.Info.IsDir() {
+					subdirs = append(subdirs, e.Path)
+				} else {
+					files += weight
+					bytes += weight * float64(e.Info.Size())
+				}
+			}
+			if len(subdirs) == 0 {
+				break
+			}
+			dir = subdirs[r.Intn(len(subdirs))]
+			weight *= float64(len(subdirs))
+		}
+	}
+	est.Files = int64(files / float64(probes))
+	est.Bytes = int64(bytes / float64(probes))
+	est.Dirs = int64(dirs / float64(probes))
+	est.ListTime = time.Duration(float64(listTime) / float64(listed) * dirs / float64(probes))
+	return est, nil
+}
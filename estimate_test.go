@@ -0,0 +1,44 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimate(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := make(map[string]string)
+	for _, dir := range []string{"a", "b", "c", "d"} {
+		for _, sub := range []string{"x", "y"} {
+			for _, name := range []string{"1.txt", "2.txt", "3.log"} {
+				files[dir+"/"+sub+"/"+name] = "1234"
+			}
+		}
+	}
+	writeFiles(t, tmp, files)
+	sw := skywalker.New(tmp, NewTW())
+	sw.NumWorkers = 4
+	sw.ExtList = []string{".log"}
+
+	est, err := sw.Estimate(10)
+	assert.NoError(err)
+	//The tree is perfectly balanced so every probe sees the same thing.
+	assert.Equal(int64(16), est.Files)
+	assert.Equal(int64(64), est.Bytes)
+	assert.Equal(int64(13), est.Dirs)
+	assert.Equal(10, est.Probes)
+	assert.Equal(4*time.Second, est.Duration(time.Second))
+
+	_, err = skywalker.New(tmp+"/missing", NewTW()).Estimate(0)
+	var notExist *skywalker.RootNotExistError
+	assert.True(errors.As(err, &notExist), "Expected a RootNotExistError but got %v", err)
+}
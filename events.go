@@ -0,0 +1,102 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+//EventKind is what happened during a walk.
+type EventKind int
+
+const (
+	//EKStart is sent once when a walk starts, after the configuration was checked.
+	EKStart EventKind = iota
+	//EKQueued is sent for every path handed to the workers.
+	EKQueued
+	//EKDone is sent once a worker is done with a path. Err is set if a ResultWorker returned an error
+	//or a ChunkWorker failed. Paths dropped by CancelSubtree are done with ErrCanceled.
+	EKDone
+	//EKSkipped is sent for every directory skipped with everything below it, with the Reason why.
+	//It is also sent for every file over one of the limits: RPathLength, RNameLength and RForbiddenName,
+	//for every link left out by SMSkip with RSymlink and by the workers for every file in Known with RKnown
+	//or left out by ContentTypeList with RContentType and for every file in the Journal with RJournaled.
+	EKSkipped
+	//EKError is sent for every path below a root that could not be read.
+	EKError
+	//EKFinish is sent once when a walk is over, with the error Walk returns.
+	EKFinish
+	//EKWatching is sent once by WalkAndWatch when the walk found everything and only changes are handed on.
+	EKWatching
+)
+
+var eventKindNames = [...]string{"start", "queued", "done", "skipped", "error", "finish", "watching"}
+
+func (ek EventKind) String() string {
+	if ek < 0 || int(ek) >= len(eventKindNames) {
+		return "unknown"
+	}
+	return eventKindNames[ek]
+}
+
+//Event is a single thing that happened during a walk.
+type Event struct {
+	Kind EventKind
+	Path string
+	//Root is the root Path was found in.
+	Root string
+	//Info is nil for EKStart, EKFinish and sometimes EKError.
+	Info os.FileInfo
+	Err  error
+	//Reason is only set for EKSkipped.
+	Reason Reason
+	//Duration is how long the worker took for EKDone and how long the walk took for EKFinish.
+	Duration time.Duration
+	//Value is what a ResultWorker returned for EKDone.
+	Value interface{}
+	//Labels are the Labels of the Skywalker.
+	Labels map[string]string
+}
+
+//Segment returns the immediate child of Root the event's path is in, the same as SegmentStat.Segment.
+func (e Event) Segment() string {
+	if e.Path == "" || e.Path == e.Root {
+		return ""
+	}
+	return segmentOf(e.Root, e.Path, e.Info != nil && e.Info.IsDir())
+}
+
+//emit sends ev to OnEvent if it is set.
+func (sw *Skywalker) emit(ev Event) {
+	if sw.OnEvent != nil {
+		ev.Labels = sw.Labels
+		sw.OnEvent(ev)
+	}
+}
+
+//EventBus hands every Event published to it to all of its subscribers, so several consumers like a progress
+//display and a log can follow the same walk. Set a Skywalker's OnEvent to its Publish method.
+type EventBus struct {
+	mutex       sync.RWMutex
+	subscribers []func(Event)
+}
+
+//Subscribe adds fn to the subscribers. fn is called concurrently so make sure it is thread safe.
+func (eb *EventBus) Subscribe(fn func(Event)) {
+	eb.mutex.Lock()
+	eb.subscribers = append(eb.subscribers, fn)
+	eb.mutex.Unlock()
+}
+
+//Publish hands ev to every subscriber in the order they subscribed.
+func (eb *EventBus) Publish(ev Event) {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+	for _, fn := range eb.subscribers {
+		fn(ev)
+	}
+}
@@ -0,0 +1,102 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	assert := assert.New(t)
+	var mutex sync.Mutex
+	kinds := make(map[skywalker.EventKind]int)
+	segments := make(map[string]int)
+	var skipped []skywalker.Event
+	bus := new(skywalker.EventBus)
+	bus.Subscribe(func(ev skywalker.Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		kinds[ev.Kind]++
+		switch ev.Kind {
+		case skywalker.EKQueued:
+			segments[ev.Segment()]++
+		case skywalker.EKSkipped:
+			skipped = append(skipped, ev)
+		}
+	})
+	tw := NewTW()
+	sw := skywalker.New(root, tw)
+	sw.DirList = []string{"sub"}
+	sw.OnEvent = bus.Publish
+	assert.NoError(sw.Walk())
+
+	assert.Equal(1, kinds[skywalker.EKStart])
+	assert.Equal(1, kinds[skywalker.EKFinish])
+	assert.Equal(len(tw.found), kinds[skywalker.EKQueued])
+	assert.Equal(len(tw.found), kinds[skywalker.EKDone])
+	assert.Equal(map[string]int{"the": 4, "subfolder": 4}, segments)
+	if assert.Len(skipped, 1) {
+		assert.Equal(skywalker.RDirList, skipped[0].Reason)
+		assert.Equal("sub", skipped[0].Segment())
+	}
+	assert.Equal("done", skywalker.EKDone.String())
+}
+
+type labelStore struct {
+	mutex  sync.Mutex
+	labels []map[string]string
+}
+
+func (ls *labelStore) Put(item skywalker.WorkItem, res skywalker.Result) error {
+	ls.mutex.Lock()
+	ls.labels = append(ls.labels, item.Labels)
+	ls.mutex.Unlock()
+	return nil
+}
+
+func (ls *labelStore) Flush() error { return nil }
+
+func TestLabels(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	labels := map[string]string{"tenant": "acme", "job": "42"}
+	sw := skywalker.New(tmp, &SizeWorker{NewTW()})
+	sw.Labels = labels
+	store := &labelStore{}
+	sw.Results = store
+	var buf bytes.Buffer
+	stream := skywalker.NewEventStream(&buf)
+	summary := skywalker.NewSummary()
+	var mutex sync.Mutex
+	events := 0
+	bus := new(skywalker.EventBus)
+	bus.Subscribe(stream.Handle)
+	bus.Subscribe(summary.Handle)
+	bus.Subscribe(func(ev skywalker.Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events++
+		assert.Equal(labels, ev.Labels, "Expected labels on %s", ev.Kind)
+	})
+	sw.OnEvent = bus.Publish
+	assert.NoError(sw.Walk())
+	assert.NoError(stream.Close())
+	assert.True(events > 0)
+	assert.Len(store.labels, 2)
+	for _, l := range store.labels {
+		assert.Equal(labels, l)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		assert.Contains(line, `"labels":{"job":"42","tenant":"acme"}`)
+	}
+	assert.Contains(summary.Report(sw.Stats()), "\n  job=42 tenant=acme\n")
+}
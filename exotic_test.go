@@ -0,0 +1,154 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//exoticNames are file names that are easy to get wrong when they are filtered, encoded or decoded.
+var exoticNames = []string{
+	"new\nline.txt",
+	"carriage\rreturn.txt",
+	"control\x01\x1b[31m.txt",
+	"invalid\xff\xfeutf8.txt",
+	"back\\slash.txt",
+	" leading space.txt",
+	"trailing space ",
+	"trailing dot.",
+	"tab\tand * glob?.txt",
+}
+
+//exoticTree creates a file for every exotic name the filesystem accepts and returns their relative paths.
+func exoticTree(t *testing.T) (string, []string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows does not allow most of these names")
+	}
+	tmp := t.TempDir()
+	var created []string
+	for _, name := range exoticNames {
+		for _, rel := range []string{name, "dir\n" + name[:1] + "/" + name} {
+			path := filepath.Join(tmp, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				continue
+			}
+			if err := os.WriteFile(path, []byte(rel), 0644); err != nil {
+				continue
+			}
+			created = append(created, rel)
+		}
+	}
+	if len(created) == 0 {
+		t.Skip("The filesystem does not allow any of these names")
+	}
+	return tmp, created
+}
+
+func TestExoticNames(t *testing.T) {
+	tmp, created := exoticTree(t)
+	rels := func(root string, paths map[string]struct{}) map[string]struct{} {
+		out := make(map[string]struct{}, len(paths))
+		for path := range paths {
+			rel, _ := filepath.Rel(root, path)
+			out[filepath.ToSlash(rel)] = struct{}{}
+		}
+		return out
+	}
+	expected := make(map[string]struct{})
+	for _, rel := range created {
+		expected[rel] = struct{}{}
+	}
+
+	t.Run("Walk and filters", func(t *testing.T) {
+		assert := assert.New(t)
+		tw := NewTW()
+		sw := skywalker.New(tmp, tw)
+		assert.NoError(sw.Walk())
+		assert.Equal(expected, rels(sw.Root, tw.found))
+
+		tw = NewTW()
+		sw = skywalker.New(tmp, tw)
+		sw.Filter = "!name(*.txt) && path(/dir*/*)"
+		assert.NoError(sw.Walk())
+		for rel := range rels(sw.Root, tw.found) {
+			assert.Contains([]string{"dir\nt/trailing space ", "dir\nt/trailing dot."}, rel)
+		}
+		assert.Len(tw.found, 2)
+	})
+
+	t.Run("Manifest", func(t *testing.T) {
+		assert := assert.New(t)
+		m := make(skywalker.Manifest)
+		for _, rel := range created {
+			sum := sha256.Sum256([]byte(rel))
+			m[rel] = hex.EncodeToString(sum[:])
+		}
+		buf := new(bytes.Buffer)
+		assert.NoError(skywalker.WriteManifest(buf, m))
+		read, err := skywalker.ReadManifest(buf)
+		assert.NoError(err)
+		assert.Equal(m, read)
+
+		vw := skywalker.NewVerifyWorker(tmp, read)
+		assert.NoError(skywalker.New(tmp, vw).Walk())
+		results := vw.Results()
+		assert.Len(results, len(created))
+		for _, res := range results {
+			assert.Equal(skywalker.VSOK, res.Status, "%q: %v", res.Path, res.Err)
+		}
+	})
+
+	t.Run("Event stream", func(t *testing.T) {
+		assert := assert.New(t)
+		buf := new(bytes.Buffer)
+		es := skywalker.NewEventStream(buf)
+		es.Paths = true
+		sw := skywalker.New(tmp, NewTW())
+		sw.OnEvent = es.Handle
+		assert.NoError(sw.Walk())
+		assert.NoError(es.Err())
+		found := make(map[string]struct{})
+		scanner := bufio.NewScanner(buf)
+		for scanner.Scan() {
+			var line struct {
+				Kind    string
+				Path    string
+				PathRaw []byte `json:"path_raw"`
+			}
+			assert.NoError(json.Unmarshal(scanner.Bytes(), &line))
+			if line.Kind != "done" {
+				continue
+			}
+			path := line.Path
+			if line.PathRaw != nil {
+				path = string(line.PathRaw)
+			}
+			found[path] = struct{}{}
+		}
+		assert.Equal(expected, rels(sw.Root, found))
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		assert := assert.New(t)
+		recording := new(bytes.Buffer)
+		sw := skywalker.New(tmp, NewTW())
+		sw.Record = recording
+		assert.NoError(sw.Walk())
+		tw := NewTW()
+		assert.NoError(skywalker.New(tmp, tw).Replay(recording))
+		assert.Equal(expected, rels(sw.Root, tw.found))
+	})
+}
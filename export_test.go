@@ -0,0 +1,22 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "hash"
+
+//SaveHashes returns what puts the hash registry back the way it is now, so tests can register hashes.
+func SaveHashes() (restore func()) {
+	hashMutex.Lock()
+	defer hashMutex.Unlock()
+	saved := make(map[string]func() hash.Hash, len(hashes))
+	for name, newHash := range hashes {
+		saved[name] = newHash
+	}
+	return func() {
+		hashMutex.Lock()
+		defer hashMutex.Unlock()
+		hashes = saved
+	}
+}
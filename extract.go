@@ -0,0 +1,322 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//ErrArchiveTooLarge is returned when an archive holds more than ExtractWorker.MaxBytes.
+var ErrArchiveTooLarge = errors.New("archive is larger than the extraction limit")
+
+//archiveSuffixes are the archive types an ExtractWorker knows, longest first.
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+//ExtractResult is what an ExtractWorker did with a single archive.
+type ExtractResult struct {
+	//Path is the archive that was extracted.
+	Path string
+	//Dest is the directory the archive was extracted into.
+	Dest string
+	//Files is how many files were extracted.
+	Files int
+	//Skipped is true if Dest already existed.
+	Skipped bool
+	//Err is set if the archive could not be extracted. Nothing is left in Dest if it is.
+	Err error
+}
+
+//ExtractWorker is a Worker that extracts every .zip, .tar, .tar.gz and .tgz archive it is given into Dest.
+//Each archive is extracted into its own directory named after the archive without its suffix, keeping
+//the path relative to Root. Archives are extracted into a temporary directory first and renamed into place
+//once complete, so a failed extraction leaves nothing behind.
+//Entries with absolute paths, paths that climb out with "..", or links pointing outside of the archive fail
+//the whole archive with an UnsafeEntryError. Other files are ignored.
+type ExtractWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//Dest is the directory archives are extracted into.
+	Dest string
+
+	//MaxBytes is the most an archive may hold once extracted. No limit if it is 0.
+	MaxBytes int64
+
+	//OnExtract is called after each archive is handled if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnExtract func(ExtractResult)
+
+	root rootRel
+
+	mutex   sync.Mutex
+	results []ExtractResult
+}
+
+//NewExtractWorker creates an ExtractWorker that extracts archives found in root into dest.
+func NewExtractWorker(root, dest string) *ExtractWorker {
+	return &ExtractWorker{
+		Root: root,
+		Dest: dest,
+	}
+}
+
+//Work extracts the archive at path into Dest.
+func (ew *ExtractWorker) Work(path string) {
+	res, ok := ew.extract(path)
+	if !ok {
+		return
+	}
+	ew.mutex.Lock()
+	ew.results = append(ew.results, res)
+	ew.mutex.Unlock()
+	if ew.OnExtract != nil {
+		ew.OnExtract(res)
+	}
+}
+
+//Results returns what happened to every archive handled so far.
+func (ew *ExtractWorker) Results() []ExtractResult {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	results := make([]ExtractResult, len(ew.results))
+	copy(results, ew.results)
+	return results
+}
+
+//archiveSuffix returns the archive suffix of path or "" if it is not an archive.
+func archiveSuffix(path string) string {
+	lower := strings.ToLower(path)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+func (ew *ExtractWorker) extract(path string) (ExtractResult, bool) {
+	res := ExtractResult{Path: path}
+	suffix := archiveSuffix(path)
+	if suffix == "" {
+		return res, false
+	}
+	info, err := os.Stat(osPath(path))
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	if info.IsDir() {
+		return res, false
+	}
+	rel, err := ew.root.rel(ew.Root, path)
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	res.Dest = filepath.Join(ew.Dest, rel[:len(rel)-len(suffix)])
+	if _, err = os.Lstat(res.Dest); err == nil {
+		res.Skipped = true
+		return res, true
+	}
+	if err = os.MkdirAll(filepath.Dir(res.Dest), 0777); err != nil {
+		res.Err = err
+		return res, true
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(res.Dest), "."+filepath.Base(res.Dest)+".tmp-")
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	x := &extraction{archive: path, dir: tmp, max: ew.MaxBytes}
+	if suffix == ".zip" {
+		err = x.zip()
+	} else {
+		err = x.tar(suffix != ".tar")
+	}
+	if err == nil {
+		err = os.Rename(tmp, res.Dest)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		res.Err = err
+		return res, true
+	}
+	res.Files = x.files
+	return res, true
+}
+
+//extraction is a single archive being extracted into dir.
+type extraction struct {
+	archive string
+	dir     string
+	max     int64
+	written int64
+	files   int
+}
+
+//target returns where the entry name belongs inside of dir.
+//Nothing is ever written through a link, so any directory on the way there that is a link makes it unsafe.
+func (x *extraction) target(name string) (string, error) {
+	clean := path.Clean(strings.Replace(name, `\`, "/", -1))
+	if path.IsAbs(clean) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", &UnsafeEntryError{Archive: x.archive, Name: name}
+	}
+	cur := x.dir
+	parts := strings.Split(clean, "/")
+	for _, part := range parts[:len(parts)-1] {
+		cur = filepath.Join(cur, part)
+		if info, err := os.Lstat(cur); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", &UnsafeEntryError{Archive: x.archive, Name: name}
+		}
+	}
+	return filepath.Join(x.dir, filepath.FromSlash(clean)), nil
+}
+
+//linkTarget checks that a link at dst pointing to target stays inside of dir.
+//The target is followed one part at a time and may not go through another link. It may only go up out of
+//directories that exist already, as a part that does not could still become a link further on in the archive.
+func (x *extraction) linkTarget(name, dst, target string) error {
+	if path.IsAbs(target) || filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return &UnsafeEntryError{Archive: x.archive, Name: name}
+	}
+	cur := filepath.Dir(dst)
+	for _, part := range strings.Split(strings.Replace(target, `\`, "/", -1), "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if info, err := os.Lstat(cur); err != nil || !info.IsDir() {
+				return &UnsafeEntryError{Archive: x.archive, Name: name}
+			}
+			cur = filepath.Dir(cur)
+		default:
+			cur = filepath.Join(cur, part)
+			if info, err := os.Lstat(cur); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return &UnsafeEntryError{Archive: x.archive, Name: name}
+			}
+		}
+		if _, err := relPath(x.dir, cur); err != nil {
+			return &UnsafeEntryError{Archive: x.archive, Name: name}
+		}
+	}
+	return nil
+}
+
+func (x *extraction) writeFile(dst string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if x.max > 0 {
+		r = io.LimitReader(r, x.max-x.written+1)
+	}
+	n, err := io.Copy(out, r)
+	x.written += n
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && x.max > 0 && x.written > x.max {
+		err = ErrArchiveTooLarge
+	}
+	x.files++
+	return err
+}
+
+func (x *extraction) zip() error {
+	zr, err := zip.OpenReader(osPath(x.archive))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		dst, err := x.target(f.Name)
+		if err != nil {
+			return err
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(dst, 0777)
+		case mode&os.ModeSymlink != 0:
+			err = &UnsafeEntryError{Archive: x.archive, Name: f.Name}
+		default:
+			var rc io.ReadCloser
+			if rc, err = f.Open(); err == nil {
+				err = x.writeFile(dst, rc, mode)
+				rc.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extraction) tar(gzipped bool) error {
+	file, err := Open(x.archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dst, err := x.target(hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dst, 0777)
+		case tar.TypeReg, tar.TypeRegA:
+			err = x.writeFile(dst, tr, os.FileMode(hdr.Mode))
+		case tar.TypeSymlink:
+			if err = os.MkdirAll(filepath.Dir(dst), 0777); err == nil {
+				if err = x.linkTarget(hdr.Name, dst, hdr.Linkname); err == nil {
+					err = os.Symlink(hdr.Linkname, dst)
+				}
+			}
+		case tar.TypeLink:
+			var src string
+			if src, err = x.target(hdr.Linkname); err == nil {
+				if err = os.MkdirAll(filepath.Dir(dst), 0777); err == nil {
+					err = os.Link(src, dst)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,129 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type tarEntry struct {
+	name, body, link string
+	flag             byte
+}
+
+func writeTarGz(t *testing.T, path string, entries []tarEntry) {
+	t.Helper()
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		flag := e.flag
+		if flag == 0 {
+			flag = tar.TypeReg
+		}
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: flag, Linkname: e.link}))
+		_, err = tw.Write([]byte(e.body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+}
+
+func TestExtractWorker(t *testing.T) {
+	assert := assert.New(t)
+	src, dest := t.TempDir(), t.TempDir()
+	assert.NoError(os.MkdirAll(filepath.Join(src, "uploads"), 0777))
+	writeZip(t, filepath.Join(src, "uploads", "good.zip"), map[string]string{
+		"a.txt":     "a",
+		"dir/b.txt": "b",
+	})
+	good := []tarEntry{
+		{name: "c.txt", body: "c"},
+		{name: "sub/", flag: tar.TypeDir},
+	}
+	if runtime.GOOS != "windows" {
+		good = append(good, tarEntry{name: "sub/link", link: "../c.txt", flag: tar.TypeSymlink})
+	}
+	writeTarGz(t, filepath.Join(src, "good.tar.gz"), good)
+	writeZip(t, filepath.Join(src, "climb.zip"), map[string]string{"../../evil.txt": "evil"})
+	writeTarGz(t, filepath.Join(src, "abs.tgz"), []tarEntry{{name: "/tmp/evil.txt", body: "evil"}})
+	writeTarGz(t, filepath.Join(src, "escape.tar.gz"), []tarEntry{{name: "out", link: "../..", flag: tar.TypeSymlink}})
+	writeTarGz(t, filepath.Join(src, "through.tar.gz"), []tarEntry{
+		{name: "self", link: ".", flag: tar.TypeSymlink},
+		{name: "up", link: "self/..", flag: tar.TypeSymlink},
+	})
+	//b/.. looks like it stays inside until b turns out to be a link.
+	writeTarGz(t, filepath.Join(src, "later.tar.gz"), []tarEntry{
+		{name: "a", link: "b/../x", flag: tar.TypeSymlink},
+		{name: "b", link: ".", flag: tar.TypeSymlink},
+	})
+	writeTarGz(t, filepath.Join(src, "big.tar.gz"), []tarEntry{{name: "big", body: "0123456789"}})
+	writeFiles(t, src, map[string]string{"notes.txt": "not an archive"})
+
+	ew := skywalker.NewExtractWorker(src, dest)
+	ew.MaxBytes = 5
+	assert.NoError(skywalker.New(src, ew).Walk())
+
+	results := make(map[string]skywalker.ExtractResult)
+	for _, res := range ew.Results() {
+		results[filepath.Base(res.Path)] = res
+	}
+	assert.Len(results, 8)
+	assert.NoError(results["good.zip"].Err)
+	assert.Equal(2, results["good.zip"].Files)
+	data, err := os.ReadFile(filepath.Join(dest, "uploads", "good", "dir", "b.txt"))
+	assert.NoError(err)
+	assert.Equal("b", string(data))
+	assert.NoError(results["good.tar.gz"].Err)
+	if runtime.GOOS != "windows" {
+		data, err = os.ReadFile(filepath.Join(dest, "good", "sub", "link"))
+		assert.NoError(err)
+		assert.Equal("c", string(data))
+	}
+	for _, name := range []string{"climb.zip", "abs.tgz", "escape.tar.gz", "through.tar.gz", "later.tar.gz"} {
+		var unsafe *skywalker.UnsafeEntryError
+		assert.True(errors.As(results[name].Err, &unsafe), "Expected an UnsafeEntryError for %s but got %v", name, results[name].Err)
+	}
+	assert.Equal(skywalker.ErrArchiveTooLarge, results["big.tar.gz"].Err)
+
+	entries, err := os.ReadDir(dest)
+	assert.NoError(err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal([]string{"good", "uploads"}, names)
+
+	ew = skywalker.NewExtractWorker(src, dest)
+	ew.Work(filepath.Join(src, "good.tar.gz"))
+	assert.True(ew.Results()[0].Skipped)
+}
@@ -0,0 +1,55 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+)
+
+//ExtStat is how many files with an extension were queued and how many bytes they hold.
+type ExtStat struct {
+	Count int
+	Bytes int64
+}
+
+//ExtStats returns the totals for every extension queued by the last Walk.
+//Files without an extension are under "".
+//It should not be called while walking.
+func (sw *Skywalker) ExtStats() map[string]ExtStat {
+	stats := make(map[string]ExtStat, len(sw.extStats))
+	for ext, stat := range sw.extStats {
+		stats[ext] = stat
+	}
+	return stats
+}
+
+//countExt counts a queued file. Without OnExtStat the size is left to the worker, so the walk does not have
+//to stat every file itself.
+func (sw *Skywalker) countExt(path string, info os.FileInfo) {
+	ext := filepath.Ext(path)
+	sw.extMutex.Lock()
+	defer sw.extMutex.Unlock()
+	stat := sw.extStats[ext]
+	stat.Count++
+	if sw.OnExtStat == nil {
+		sw.extStats[ext] = stat
+		return
+	}
+	stat.Bytes += info.Size()
+	sw.extStats[ext] = stat
+	sw.OnExtStat(ext, stat.Count, stat.Bytes)
+}
+
+//addExtBytes adds the size of a file counted by countExt. It is called by the workers.
+func (sw *Skywalker) addExtBytes(path string, info os.FileInfo) {
+	size := info.Size()
+	ext := filepath.Ext(path)
+	sw.extMutex.Lock()
+	defer sw.extMutex.Unlock()
+	stat := sw.extStats[ext]
+	stat.Bytes += size
+	sw.extStats[ext] = stat
+}
@@ -0,0 +1,46 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtStats(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "12345",
+		"sub/b.txt": "123",
+		"sub/c.log": "1",
+		"README":    "",
+	})
+
+	calls := 0
+	latest := make(map[string]skywalker.ExtStat)
+	sw := skywalker.New(tmp, NewTW())
+	sw.OnExtStat = func(ext string, count int, bytes int64) {
+		calls++
+		latest[ext] = skywalker.ExtStat{Count: count, Bytes: bytes}
+	}
+	assert.NoError(sw.Walk())
+
+	expected := map[string]skywalker.ExtStat{
+		".txt": {Count: 2, Bytes: 8},
+		".log": {Count: 1, Bytes: 1},
+		"":     {Count: 1, Bytes: 0},
+	}
+	assert.Equal(4, calls)
+	assert.Equal(expected, latest)
+	assert.Equal(expected, sw.ExtStats())
+
+	//Without OnExtStat the workers add up the sizes.
+	sw.OnExtStat = nil
+	assert.NoError(sw.Walk())
+	assert.Equal(expected, sw.ExtStats())
+}
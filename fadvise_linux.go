@@ -0,0 +1,31 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build linux && (amd64 || arm64 || loong64 || mips64 || mips64le || ppc64 || ppc64le || riscv64 || s390x)
+// +build linux
+// +build amd64 arm64 loong64 mips64 mips64le ppc64 ppc64le riscv64 s390x
+
+package skywalker
+
+import (
+	"syscall"
+)
+
+//fadvWillNeed is POSIX_FADV_WILLNEED.
+const fadvWillNeed = 3
+
+//willNeed asks the kernel to start reading the first length bytes of the file at path into the page cache.
+//A length of 0 means the whole file.
+func willNeed(path string, length int64) error {
+	file, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, _, errno := syscall.Syscall6(syscall.SYS_FADVISE64, file.Fd(), 0, uintptr(length), fadvWillNeed, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package skywalker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+//The parts of linux/fanotify.h the syscall package does not have.
+const (
+	fanCloexec        = 0x1
+	fanNonblock       = 0x2
+	fanReportDFIDName = 0x400 | 0x800
+
+	fanMarkAdd        = 0x1
+	fanMarkFilesystem = 0x100
+
+	fanCloseWrite = 0x8
+	fanMovedTo    = 0x80
+	fanCreate     = 0x100
+	fanQOverflow  = 0x4000
+	fanOnDir      = 0x40000000
+
+	fanInfoDFIDName = 2
+
+	//fanMetadataLen is the size of struct fanotify_event_metadata.
+	fanMetadataLen = 24
+	//maxHandleSize is MAX_HANDLE_SZ.
+	maxHandleSize = 128
+)
+
+//fanotifyMask are the fanotify events a watched filesystem reports.
+const fanotifyMask = fanCloseWrite | fanMovedTo | fanCreate | fanOnDir
+
+//fanotifyWatcher is told about changes to whole filesystems by fanotify. Events name the directory they
+//happened in by its file handle, so the handle of every watched directory is kept to find its path.
+type fanotifyWatcher struct {
+	cs   *changeSet
+	fd   int
+	file *os.File
+
+	mutex sync.Mutex
+	//dirs are the watched directories by their filesystem ID and file handle.
+	dirs map[string]string
+	//marked are the IDs of the filesystems watched.
+	marked map[[8]byte]bool
+
+	wg sync.WaitGroup
+}
+
+func newFanotifyWatcher(cs *changeSet) (watcher, error) {
+	fd, _, errno := syscall.Syscall(syscall.SYS_FANOTIFY_INIT, fanCloexec|fanNonblock|fanReportDFIDName, syscall.O_RDONLY, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	//A non-blocking file is read through the runtime's poller, so closing it stops the read.
+	fw := &fanotifyWatcher{
+		cs:     cs,
+		fd:     int(fd),
+		file:   os.NewFile(fd, "fanotify"),
+		dirs:   make(map[string]string),
+		marked: make(map[[8]byte]bool),
+	}
+	fw.wg.Add(1)
+	go fw.read()
+	return fw, nil
+}
+
+//handleKey returns the key of the directory at path in dirs and the ID of its filesystem.
+func handleKey(path string) (string, [8]byte, error) {
+	var fsid [8]byte
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", fsid, &os.PathError{Op: "statfs", Path: path, Err: err}
+	}
+	binary.LittleEndian.PutUint32(fsid[:4], uint32(st.Fsid.X__val[0]))
+	binary.LittleEndian.PutUint32(fsid[4:], uint32(st.Fsid.X__val[1]))
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return "", fsid, err
+	}
+	//struct file_handle is the size of the handle and its type followed by the handle.
+	handle := make([]byte, 8+maxHandleSize)
+	binary.LittleEndian.PutUint32(handle, maxHandleSize)
+	var mountID int32
+	_, _, errno := syscall.Syscall6(sysNameToHandleAt, uintptr(atFDCWD), uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&handle[0])), uintptr(unsafe.Pointer(&mountID)), 0, 0)
+	if errno != 0 {
+		return "", fsid, &os.PathError{Op: "name_to_handle_at", Path: path, Err: errno}
+	}
+	size := binary.LittleEndian.Uint32(handle)
+	return string(fsid[:]) + string(handle[:8+size]), fsid, nil
+}
+
+//atFDCWD is AT_FDCWD, resolving relative paths from the working directory. It is a variable to convert it to a uintptr.
+var atFDCWD = -100
+
+func (fw *fanotifyWatcher) add(path string) error {
+	key, fsid, err := handleKey(path)
+	if err != nil {
+		return err
+	}
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	if !fw.marked[fsid] {
+		p, err := syscall.BytePtrFromString(path)
+		if err != nil {
+			return err
+		}
+		_, _, errno := syscall.Syscall6(syscall.SYS_FANOTIFY_MARK, uintptr(fw.fd), fanMarkAdd|fanMarkFilesystem,
+			fanotifyMask, uintptr(atFDCWD), uintptr(unsafe.Pointer(p)), 0)
+		if errno != 0 {
+			return &os.PathError{Op: "fanotify_mark", Path: path, Err: errno}
+		}
+		fw.marked[fsid] = true
+	}
+	fw.dirs[key] = path
+	return nil
+}
+
+//read adds what the kernel reports to the changeSet until the watcher is closed.
+func (fw *fanotifyWatcher) read() {
+	defer fw.wg.Done()
+	var buf [64 * 1024]byte
+	for {
+		n, err := fw.file.Read(buf[:])
+		if err != nil {
+			return
+		}
+		for off := 0; off+fanMetadataLen <= n; {
+			size := int(binary.LittleEndian.Uint32(buf[off:]))
+			if size < fanMetadataLen || off+size > n {
+				break
+			}
+			fw.event(buf[off : off+size])
+			off += size
+		}
+	}
+}
+
+//event handles a struct fanotify_event_metadata followed by its info records.
+func (fw *fanotifyWatcher) event(ev []byte) {
+	mask := binary.LittleEndian.Uint64(ev[8:])
+	if mask&fanQOverflow != 0 {
+		fw.cs.fail(ErrWatchOverflow)
+		return
+	}
+	for off := int(binary.LittleEndian.Uint16(ev[6:])); off+4 <= len(ev); {
+		//struct fanotify_event_info_header is the type, a pad byte and the length of the record.
+		size := int(binary.LittleEndian.Uint16(ev[off+2:]))
+		if size < 4 || off+size > len(ev) {
+			return
+		}
+		if ev[off] == fanInfoDFIDName {
+			fw.dirEvent(mask, ev[off+4:off+size])
+		}
+		off += size
+	}
+}
+
+//dirEvent handles the filesystem ID, file handle of the directory and name of a FAN_EVENT_INFO_TYPE_DFID_NAME record.
+func (fw *fanotifyWatcher) dirEvent(mask uint64, info []byte) {
+	if len(info) < 16 {
+		return
+	}
+	end := 16 + int(binary.LittleEndian.Uint32(info[8:]))
+	if end > len(info) {
+		return
+	}
+	name := info[end:]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	if len(name) == 0 || string(name) == "." {
+		return
+	}
+	fw.mutex.Lock()
+	dir, ok := fw.dirs[string(info[:end])]
+	fw.mutex.Unlock()
+	if !ok {
+		return
+	}
+	path := filepath.Join(dir, string(name))
+	if mask&(fanCreate|fanCloseWrite) == fanCreate && mask&fanOnDir == 0 {
+		//New files are reported once they are written, anything else right away. Events can be merged.
+		if info, err := os.Lstat(path); err != nil || info.Mode().IsRegular() {
+			return
+		}
+	}
+	fw.cs.add(path)
+}
+
+func (fw *fanotifyWatcher) close() error {
+	err := fw.file.Close()
+	fw.wg.Wait()
+	return err
+}
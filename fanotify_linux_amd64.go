@@ -0,0 +1,8 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+//sysNameToHandleAt is the number of name_to_handle_at, which the syscall package does not have here.
+const sysNameToHandleAt = 303
@@ -0,0 +1,9 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "syscall"
+
+const sysNameToHandleAt = syscall.SYS_NAME_TO_HANDLE_AT
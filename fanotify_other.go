@@ -0,0 +1,15 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build linux && !amd64 && !arm64
+// +build linux,!amd64,!arm64
+
+package skywalker
+
+import "syscall"
+
+//newFanotifyWatcher fails as fanotify is only used on amd64 and arm64.
+func newFanotifyWatcher(*changeSet) (watcher, error) {
+	return nil, syscall.ENOSYS
+}
@@ -0,0 +1,28 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Package fasthash registers fast hashes with skywalker for workloads like deduplication that care more
+//about speed than cryptographic strength. Import it for its side effect:
+//
+//	import _ "github.com/dixonwille/skywalker/fasthash"
+//
+//It registers xxhash64, which is not cryptographic, and blake3, which is and is still faster than sha256.
+//They are kept out of skywalker itself so it does not depend on them.
+package fasthash
+
+import (
+	"hash"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dixonwille/skywalker"
+	"lukechampine.com/blake3"
+)
+
+//Blake3Size is the size in bytes of the blake3 checksums registered.
+const Blake3Size = 32
+
+func init() {
+	skywalker.RegisterHash("xxhash64", func() hash.Hash { return xxhash.New() })
+	skywalker.RegisterHash("blake3", func() hash.Hash { return blake3.New(Blake3Size, nil) })
+}
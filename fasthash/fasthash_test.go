@@ -0,0 +1,27 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package fasthash_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	_ "github.com/dixonwille/skywalker/fasthash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistered(t *testing.T) {
+	assert := assert.New(t)
+	for name, empty := range map[string]string{
+		"xxhash64": "ef46db3751d8e999",
+		"blake3":   "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262",
+	} {
+		newHash, err := skywalker.LookupHash(name)
+		if assert.NoError(err, name) {
+			assert.Equal(empty, hex.EncodeToString(newHash().Sum(nil)), name)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package skywalker
+
+import "os"
+
+//fileID returns zeros as the FileInfo does not carry a file identity here.
+func fileID(os.FileInfo) (uint64, uint64) {
+	return 0, 0
+}
+
+//fileOwner returns false as the FileInfo does not carry an owner here.
+func fileOwner(os.FileInfo) (uint32, uint32, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,51 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "os"
+
+//FileMeta is what backup and audit workers want to know about a path besides its contents.
+type FileMeta struct {
+	Path string
+	//Info is always stated, never just the directory entry.
+	Info os.FileInfo
+	//Root is the root Path was found in. It is only different from Skywalker.Root when using Overlays.
+	Root string
+	//UID and GID own the path. HasOwner is false where files do not have an owner, like on Windows.
+	UID      uint32
+	GID      uint32
+	HasOwner bool
+	//Perm are the permission bits of the mode along with setuid, setgid and sticky.
+	Perm os.FileMode
+	//Xattrs are the extended attributes by name. They are only read on Linux and never for links.
+	//XattrErr is why they could not be read, attributes that vanished while reading them are left out.
+	Xattrs   map[string][]byte
+	XattrErr error
+	//Labels are the Labels of the Skywalker.
+	Labels map[string]string
+}
+
+//ExtendedWorker is a Worker that wants the FileMeta of every path. WorkMeta is called instead of Work.
+type ExtendedWorker interface {
+	Worker
+	WorkMeta(meta FileMeta)
+}
+
+//fileMeta gathers the FileMeta of w.
+func (sw *Skywalker) fileMeta(w item) *FileMeta {
+	info := w.info
+	if ei, ok := info.(*entryInfo); ok {
+		if stated := ei.stat(); stated != nil {
+			info = stated
+		}
+	}
+	meta := &FileMeta{Path: w.path, Info: info, Root: w.root, Labels: sw.Labels}
+	meta.UID, meta.GID, meta.HasOwner = fileOwner(info)
+	meta.Perm = info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	if sw.FS == nil && info.Mode()&os.ModeSymlink == 0 {
+		meta.Xattrs, meta.XattrErr = readXattrs(w.path)
+	}
+	return meta
+}
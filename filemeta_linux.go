@@ -0,0 +1,72 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+)
+
+//readXattrs returns the extended attributes of the file at path, or nil if it has none or the filesystem
+//does not support them.
+func readXattrs(path string) (map[string][]byte, error) {
+	p := osPath(path)
+	var list []byte
+	for {
+		size, err := syscall.Listxattr(p, nil)
+		if err != nil {
+			return nil, xattrErr("listxattr", path, err)
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		list = make([]byte, size)
+		//The list can grow between asking for its size and reading it.
+		if size, err = syscall.Listxattr(p, list); err == syscall.ERANGE {
+			continue
+		} else if err != nil {
+			return nil, xattrErr("listxattr", path, err)
+		}
+		list = list[:size]
+		break
+	}
+	xattrs := make(map[string][]byte)
+	for _, name := range bytes.Split(bytes.TrimSuffix(list, []byte{0}), []byte{0}) {
+		value, err := getXattr(p, string(name))
+		if err == syscall.ENODATA {
+			continue
+		}
+		if err != nil {
+			return xattrs, xattrErr("getxattr", path, err)
+		}
+		xattrs[string(name)] = value
+	}
+	return xattrs, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := syscall.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size)
+		if size == 0 {
+			return value, nil
+		}
+		if size, err = syscall.Getxattr(path, name, value); err != syscall.ERANGE {
+			return value[:size], err
+		}
+	}
+}
+
+//xattrErr wraps err unless it only says the filesystem has no extended attributes.
+func xattrErr(op, path string, err error) error {
+	if err == syscall.ENOTSUP || err == syscall.EOPNOTSUPP {
+		return nil
+	}
+	return &os.PathError{Op: op, Path: path, Err: err}
+}
@@ -0,0 +1,31 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtendedInfoXattrs(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": ""})
+	if err := syscall.Setxattr(filepath.Join(tmp, "a.txt"), "user.origin", []byte("scanner"), 0); err != nil {
+		t.Skip("extended attributes are not supported:", err)
+	}
+	mw := &metaWorker{metas: make(map[string]skywalker.FileMeta)}
+	sw := skywalker.New(tmp, mw)
+	sw.FilesOnly = true
+	sw.ExtendedInfo = true
+	assert.NoError(sw.Walk())
+	meta := mw.metas["a.txt"]
+	assert.NoError(meta.XattrErr)
+	assert.Equal([]byte("scanner"), meta.Xattrs["user.origin"])
+}
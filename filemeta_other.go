@@ -0,0 +1,13 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package skywalker
+
+//readXattrs returns nothing as extended attributes are only read on Linux.
+func readXattrs(string) (map[string][]byte, error) {
+	return nil, nil
+}
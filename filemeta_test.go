@@ -0,0 +1,60 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type metaWorker struct {
+	mutex sync.Mutex
+	metas map[string]skywalker.FileMeta
+}
+
+func (mw *metaWorker) Work(path string) {}
+
+func (mw *metaWorker) WorkMeta(meta skywalker.FileMeta) {
+	mw.mutex.Lock()
+	mw.metas[filepath.Base(meta.Path)] = meta
+	mw.mutex.Unlock()
+}
+
+func TestExtendedInfo(t *testing.T) {
+	for _, walking := range []bool{false, true} {
+		assert := assert.New(t)
+		tmp := t.TempDir()
+		writeFiles(t, tmp, map[string]string{"a.txt": "aaa"})
+		if !assert.NoError(os.Chmod(filepath.Join(tmp, "a.txt"), 0640)) {
+			return
+		}
+		mw := &metaWorker{metas: make(map[string]skywalker.FileMeta)}
+		sw := skywalker.New(tmp, mw)
+		sw.FilesOnly = true
+		sw.ExtendedInfo = walking
+		sw.Labels = map[string]string{"job": "audit"}
+		assert.NoError(sw.Walk())
+		meta, ok := mw.metas["a.txt"]
+		if !assert.True(ok) {
+			return
+		}
+		assert.Equal(filepath.Join(sw.Root, "a.txt"), meta.Path)
+		assert.Equal(sw.Root, meta.Root)
+		assert.Equal(int64(3), meta.Info.Size())
+		assert.Equal("audit", meta.Labels["job"])
+		assert.NoError(meta.XattrErr)
+		if runtime.GOOS != "windows" {
+			assert.True(meta.HasOwner)
+			assert.Equal(uint32(os.Getuid()), meta.UID)
+			assert.Equal(os.FileMode(0640), meta.Perm)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobwas/glob"
+)
+
+//filterNode is a compiled piece of a filter expression.
+type filterNode interface {
+	eval(rel string, info os.FileInfo) bool
+}
+
+type andNode struct{ left, right filterNode }
+
+func (n andNode) eval(rel string, info os.FileInfo) bool {
+	return n.left.eval(rel, info) && n.right.eval(rel, info)
+}
+
+type orNode struct{ left, right filterNode }
+
+func (n orNode) eval(rel string, info os.FileInfo) bool {
+	return n.left.eval(rel, info) || n.right.eval(rel, info)
+}
+
+type notNode struct{ node filterNode }
+
+func (n notNode) eval(rel string, info os.FileInfo) bool {
+	return !n.node.eval(rel, info)
+}
+
+type extNode map[string]struct{}
+
+func (n extNode) eval(rel string, _ os.FileInfo) bool {
+	_, ok := n[filepath.Ext(rel)]
+	return ok
+}
+
+type globNode struct {
+	globs []glob.Glob
+	base  bool
+}
+
+func (n globNode) eval(rel string, _ os.FileInfo) bool {
+	if n.base {
+		rel = filepath.Base(rel)
+	}
+	for _, gl := range n.globs {
+		if gl.Match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+type kindNode struct {
+	types *TypeRegistry
+	kinds map[string]struct{}
+}
+
+func (n kindNode) eval(rel string, _ os.FileInfo) bool {
+	_, ok := n.kinds[n.types.TypeOf(rel)]
+	return ok
+}
+
+type typeNode byte
+
+func (n typeNode) eval(_ string, info os.FileInfo) bool {
+	switch n {
+	case 'd':
+		return info.IsDir()
+	case 'l':
+		return info.Mode()&os.ModeSymlink != 0
+	}
+	return info.Mode().IsRegular()
+}
+
+type cmpNode struct {
+	op    string
+	value int64
+	field func(os.FileInfo) int64
+}
+
+func (n cmpNode) eval(_ string, info os.FileInfo) bool {
+	v := n.field(info)
+	switch n.op {
+	case "<":
+		return v < n.value
+	case "<=":
+		return v <= n.value
+	case ">":
+		return v > n.value
+	case ">=":
+		return v >= n.value
+	case "!=":
+		return v != n.value
+	}
+	return v == n.value
+}
+
+func fileSize(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+func fileAge(info os.FileInfo) int64 {
+	return int64(time.Since(info.ModTime()))
+}
+
+//compileFilter parses a filter expression such as
+//
+//	ext(.go) && size>1KB && !path(**/vendor/**)
+//
+//Predicates are:
+//
+//	ext(.a, .b)        the extension is one of the ones listed
+//	name(glob, ...)    the base name matches one of the globs
+//	path(glob, ...)    the path relative to Root matches one of the globs (like List)
+//	type(f|d|l)        the path is a regular file, directory or symlink
+//	kind(image, ...)   the extension is registered as one of the types in the TypeRegistry
+//	size OP N[unit]    compares the size, units are B, KB, MB, GB and TB (powers of 1024)
+//	age OP N[unit]     compares the time since modification, units are s, m, h, d and w
+//
+//OP is one of <, <=, >, >=, == or !=.
+//Predicates are combined with !, && and || and grouped with parentheses.
+func compileFilter(expr string, types *TypeRegistry) (filterNode, error) {
+	p := &filterParser{expr: expr, types: types}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.expr) {
+		return nil, p.errorf("unexpected %q", p.expr[p.pos:])
+	}
+	return node, nil
+}
+
+type filterParser struct {
+	expr  string
+	pos   int
+	types *TypeRegistry
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return &FilterSyntaxError{Filter: p.expr, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) skipSpace() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *filterParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.expr[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.consume("!") {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	}
+	if p.consume("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return node, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *filterParser) parsePredicate() (filterNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.expr) && (p.expr[p.pos] >= 'a' && p.expr[p.pos] <= 'z' || p.expr[p.pos] >= 'A' && p.expr[p.pos] <= 'Z') {
+		p.pos++
+	}
+	name := strings.ToLower(p.expr[start:p.pos])
+	if name == "" {
+		if p.pos == len(p.expr) {
+			return nil, p.errorf("unexpected end of filter")
+		}
+		return nil, p.errorf("unexpected %q", p.expr[p.pos:p.pos+1])
+	}
+	switch name {
+	case "ext", "name", "path", "type", "kind":
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		return p.argNode(name, args)
+	case "size", "age":
+		return p.parseCmp(name)
+	}
+	p.pos = start
+	return nil, p.errorf("unknown predicate %q", name)
+}
+
+//parseArgs reads a comma separated argument list. Commas inside {} or [] belong to the glob.
+func (p *filterParser) parseArgs() ([]string, error) {
+	if !p.consume("(") {
+		return nil, p.errorf("expected (")
+	}
+	var args []string
+	depth, start := 0, p.pos
+	for ; p.pos < len(p.expr); p.pos++ {
+		switch c := p.expr[p.pos]; c {
+		case '\\':
+			p.pos++
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',', ')':
+			if depth > 0 {
+				continue
+			}
+			arg := strings.TrimSpace(p.expr[start:p.pos])
+			if arg == "" {
+				return nil, p.errorf("empty argument")
+			}
+			args = append(args, arg)
+			start = p.pos + 1
+			if c == ')' {
+				p.pos++
+				return args, nil
+			}
+		}
+	}
+	return nil, p.errorf("expected )")
+}
+
+func (p *filterParser) argNode(name string, args []string) (filterNode, error) {
+	switch name {
+	case "ext":
+		exts := make(extNode, len(args))
+		for _, a := range args {
+			exts[a] = struct{}{}
+		}
+		return exts, nil
+	case "kind":
+		kinds := kindNode{types: p.types, kinds: make(map[string]struct{}, len(args))}
+		for _, a := range args {
+			kinds.kinds[a] = struct{}{}
+		}
+		return kinds, nil
+	case "type":
+		if len(args) != 1 || len(args[0]) != 1 || !strings.Contains("fdl", args[0]) {
+			return nil, p.errorf("type takes one of f, d or l")
+		}
+		return typeNode(args[0][0]), nil
+	}
+	node := globNode{base: name == "name"}
+	for _, a := range args {
+		if !node.base {
+			a = cleanGlob(a)
+		}
+		gl, err := glob.Compile(a, filepath.Separator)
+		if err != nil {
+			return nil, p.errorf("invalid glob %q", a)
+		}
+		node.globs = append(node.globs, gl)
+	}
+	return node, nil
+}
+
+func (p *filterParser) parseCmp(name string) (filterNode, error) {
+	var op string
+	for _, o := range []string{"<=", ">=", "==", "!=", "<", ">", "="} {
+		if p.consume(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected comparison after %q", name)
+	}
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(" \t()&|!", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	raw := p.expr[start:p.pos]
+	node := cmpNode{op: op, field: fileSize}
+	var err error
+	if name == "age" {
+		node.field = fileAge
+		node.value, err = parseAge(raw)
+	} else {
+		node.value, err = parseSize(raw)
+	}
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid %s %q", name, raw)
+	}
+	return node, nil
+}
+
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40,
+}
+
+var ageUnits = map[string]float64{
+	"s": float64(time.Second),
+	"m": float64(time.Minute),
+	"h": float64(time.Hour),
+	"d": float64(24 * time.Hour),
+	"w": float64(7 * 24 * time.Hour),
+}
+
+func parseSize(raw string) (int64, error) {
+	return parseUnit(raw, sizeUnits)
+}
+
+func parseAge(raw string) (int64, error) {
+	return parseUnit(raw, ageUnits)
+}
+
+//parseUnit parses a number followed by one of units.
+func parseUnit(raw string, units map[string]float64) (int64, error) {
+	i := 0
+	for i < len(raw) && (raw[i] >= '0' && raw[i] <= '9' || raw[i] == '.') {
+		i++
+	}
+	n, err := strconv.ParseFloat(raw[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+	mult, ok := units[strings.ToLower(raw[i:])]
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+	return int64(n * mult), nil
+}
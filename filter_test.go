@@ -0,0 +1,145 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		name     string
+		filter   string
+		expected []string
+	}{
+		{"Ext Not Path", "ext(.txt, .pdf) && !path(**/sub/**)", []string{
+			"the/just.txt", "the/few.pdf", "subfolder/just.txt", "subfolder/few.pdf",
+		}},
+		{"Name Or", "name(a.*) || (name(f*) && ext(.pdf)) && size < 1KB", []string{
+			"the/a.log", "the/few.pdf",
+			"subfolder/a.log", "subfolder/few.pdf",
+			"sub/a.log", "sub/few.pdf",
+			"sub/folder/subfolder/a.log", "sub/folder/subfolder/few.pdf",
+		}},
+		{"Brace Glob", "path(/{the,subfolder}/*.{txt,log}) && type(f) && age >= 0s", []string{
+			"the/just.txt", "the/a.log", "subfolder/just.txt", "subfolder/a.log",
+		}},
+		{"Kind", "kind(document, archive) && !path(**/sub/**)", []string{
+			"the/few.pdf", "subfolder/few.pdf",
+		}},
+		{"Nothing", "size>1.5mb", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert := assert.New(t)
+			tw := NewTW()
+			sw := skywalker.New(root, tw)
+			sw.Filter = c.filter
+			assert.NoError(sw.Walk())
+			assert.Equal(len(c.expected), len(tw.found), "Not the expected number of results")
+			for _, e := range c.expected {
+				path, _ := filepath.Abs(filepath.Join(root, e))
+				_, ok := tw.found[path]
+				assert.True(ok, "Could not find %s", e)
+			}
+		})
+	}
+}
+
+func TestSize(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"empty.txt":      "",
+		"small.txt":      "12",
+		"dir/medium.txt": "12345",
+		"large.txt":      "1234567890",
+	})
+	walk := func(min, max int64) []string {
+		tw := NewTW()
+		sw := skywalker.New(tmp, tw)
+		sw.MinSize, sw.MaxSize = min, max
+		assert.NoError(sw.Walk())
+		var found []string
+		for path := range tw.found {
+			rel, _ := filepath.Rel(sw.Root, path)
+			found = append(found, filepath.ToSlash(rel))
+		}
+		sort.Strings(found)
+		return found
+	}
+	assert.Equal([]string{"dir/medium.txt", "large.txt"}, walk(5, 0))
+	assert.Equal([]string{"dir/medium.txt", "empty.txt", "small.txt"}, walk(0, 5))
+	assert.Equal([]string{"dir/medium.txt", "small.txt"}, walk(1, 9))
+
+	sw := skywalker.New(tmp, NewTW())
+	sw.MinSize, sw.MaxSize = 10, 5
+	var confErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &confErr))
+}
+
+func TestModTime(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"old.txt":     "old",
+		"dir/mid.txt": "mid",
+		"new.txt":     "new",
+	})
+	now := time.Now()
+	for rel, age := range map[string]time.Duration{"old.txt": 48 * time.Hour, "dir/mid.txt": 12 * time.Hour, "new.txt": time.Minute} {
+		at := now.Add(-age)
+		assert.NoError(os.Chtimes(filepath.Join(tmp, rel), at, at))
+	}
+	walk := func(after, before time.Time) []string {
+		tw := NewTW()
+		sw := skywalker.New(tmp, tw)
+		sw.ModifiedAfter, sw.ModifiedBefore = after, before
+		assert.NoError(sw.Walk())
+		var found []string
+		for path := range tw.found {
+			rel, _ := filepath.Rel(sw.Root, path)
+			found = append(found, filepath.ToSlash(rel))
+		}
+		sort.Strings(found)
+		return found
+	}
+	assert.Equal([]string{"dir/mid.txt", "new.txt"}, walk(now.Add(-24*time.Hour), time.Time{}))
+	assert.Equal([]string{"dir/mid.txt", "old.txt"}, walk(time.Time{}, now.Add(-time.Hour)))
+	assert.Equal([]string{"dir/mid.txt"}, walk(now.Add(-24*time.Hour), now.Add(-time.Hour)))
+
+	sw := skywalker.New(tmp, NewTW())
+	sw.ModifiedAfter, sw.ModifiedBefore = now, now.Add(-time.Hour)
+	var confErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &confErr))
+}
+
+func TestFilterSyntaxError(t *testing.T) {
+	for _, filter := range []string{
+		"ext(.go",
+		"ext(.go) &&",
+		"size>1XB",
+		"color(red)",
+		"type(x)",
+		"(ext(.go)",
+		"ext(.go) ext(.txt)",
+	} {
+		t.Run(filter, func(t *testing.T) {
+			sw := skywalker.New(root, NewTW())
+			sw.Filter = filter
+			err := sw.Walk()
+			var syntaxErr *skywalker.FilterSyntaxError
+			assert.True(t, errors.As(err, &syntaxErr), "Expected a FilterSyntaxError but got %v", err)
+		})
+	}
+}
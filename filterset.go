@@ -0,0 +1,255 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gobwas/glob"
+)
+
+//FilterSet is the compiled form of the List, RegexList, ExtList, DirList, SkipHidden, Filter and limits of a Skywalker.
+//Compiling thousands of globs on every walk is wasteful in services running many short walks,
+//so compile them once with CompileFilters, or share them through a FilterCache, and hand the FilterSet
+//to every Skywalker in Filters. A FilterSet never changes once compiled so it is safe to share between
+//Skywalkers, also while they are walking. To reconfigure, compile a new one and swap it in between walks.
+type FilterSet struct {
+	listType ListType
+	list     []glob.Glob
+
+	regexListType ListType
+	regexList     []*regexp.Regexp
+
+	extListType ListType
+	extMap      map[string]struct{}
+
+	dirListType ListType
+	dirList     []string
+
+	skipHidden bool
+
+	filter filterNode
+
+	maxPath   int
+	maxName   int
+	forbidden []glob.Glob
+
+	minDepth int
+	maxDepth int
+
+	minSize int64
+	maxSize int64
+
+	modifiedAfter  time.Time
+	modifiedBefore time.Time
+}
+
+//CompileFilters compiles the List, RegexList, ExtList, DirList, SkipHidden, Filter, Types, limits, depths, sizes and modification times of the
+//Skywalker into a FilterSet. It returns a *GlobCompileError, *RegexCompileError or *FilterSyntaxError if any of them is invalid.
+func (sw *Skywalker) CompileFilters() (*FilterSet, error) {
+	fs := &FilterSet{
+		listType:      sw.ListType,
+		regexListType: sw.RegexListType,
+		extListType:   sw.ExtListType,
+		dirListType:   sw.DirListType,
+		dirList:       append([]string(nil), sw.DirList...),
+		skipHidden:    sw.SkipHidden,
+		maxPath:       sw.MaxPathLength,
+		maxName:       sw.MaxNameLength,
+		minDepth:      sw.MinDepth,
+		maxDepth:      sw.MaxDepth,
+		minSize:       sw.MinSize,
+		maxSize:       sw.MaxSize,
+
+		modifiedAfter:  sw.ModifiedAfter,
+		modifiedBefore: sw.ModifiedBefore,
+	}
+	fs.extMap = make(map[string]struct{}, len(sw.ExtList))
+	for _, ext := range sw.ExtList {
+		fs.extMap[ext] = struct{}{}
+	}
+	fs.list = make([]glob.Glob, len(sw.List))
+	for i, g := range sw.List {
+		gl, err := glob.Compile(cleanGlob(g), filepath.Separator)
+		if err != nil {
+			return nil, &GlobCompileError{Pattern: g, Err: err}
+		}
+		fs.list[i] = gl
+	}
+	fs.regexList = make([]*regexp.Regexp, len(sw.RegexList))
+	for i, pattern := range sw.RegexList {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, &RegexCompileError{Pattern: pattern, Err: err}
+		}
+		fs.regexList[i] = re
+	}
+	fs.forbidden = make([]glob.Glob, len(sw.ForbiddenNames))
+	for i, g := range sw.ForbiddenNames {
+		gl, err := glob.Compile(g)
+		if err != nil {
+			return nil, &GlobCompileError{Pattern: g, Err: err}
+		}
+		fs.forbidden[i] = gl
+	}
+	if sw.Filter != "" {
+		filter, err := compileFilter(sw.Filter, sw.types())
+		if err != nil {
+			return nil, err
+		}
+		fs.filter = filter
+	}
+	return fs, nil
+}
+
+//matcherAt creates a Matcher using the FilterSet relative to root.
+func (fs *FilterSet) matcherAt(root string, filesOnly bool) (*Matcher, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	dirMap := make(map[string]bool, len(fs.dirList))
+	for _, dir := range fs.dirList {
+		if fs.dirListType == LTWhitelist {
+			dirs := splitPath(dir)
+			for i := len(dirs); i > 0; i-- {
+				dirMap[filepath.Join(root, filepath.Join(dirs[:i]...))] = i == len(dirs)
+			}
+		} else {
+			dirMap[filepath.Join(root, cleanDir(dir))] = true
+		}
+	}
+	return &Matcher{
+		FilterSet: fs,
+		root:      root,
+		dirMap:    dirMap,
+		filesOnly: filesOnly,
+	}, nil
+}
+
+//matcherIn creates a Matcher for the slash separated root inside of an fs.FS.
+func (fs *FilterSet) matcherIn(root string, filesOnly bool) (*Matcher, error) {
+	root = path.Clean(root)
+	if !iofs.ValidPath(root) {
+		return nil, &ConfigError{Field: "Root", Msg: "must be a valid path inside of FS"}
+	}
+	dirMap := make(map[string]bool, len(fs.dirList))
+	for _, dir := range fs.dirList {
+		dir = path.Clean(filepath.ToSlash(dir))
+		if fs.dirListType == LTWhitelist {
+			dirs := strings.Split(strings.Trim(dir, "/"), "/")
+			for i := len(dirs); i > 0; i-- {
+				dirMap[path.Join(root, path.Join(dirs[:i]...))] = i == len(dirs)
+			}
+		} else {
+			dirMap[path.Join(root, dir)] = true
+		}
+	}
+	return &Matcher{
+		FilterSet: fs,
+		root:      root,
+		dirMap:    dirMap,
+		filesOnly: filesOnly,
+		slash:     true,
+	}, nil
+}
+
+//FilterCache shares FilterSets between Skywalkers configured with the same filters.
+//It is safe to use concurrently. Invalid filters are cached as well so they are not compiled again.
+type FilterCache struct {
+	mutex sync.Mutex
+	sets  map[string]*filterEntry
+}
+
+type filterEntry struct {
+	once sync.Once
+	fs   *FilterSet
+	err  error
+}
+
+//NewFilterCache creates an empty FilterCache.
+func NewFilterCache() *FilterCache {
+	return &FilterCache{sets: make(map[string]*filterEntry)}
+}
+
+//Get returns the FilterSet for the filters of sw, compiling them the first time they are seen.
+//Concurrent calls for the same filters wait for a single compile.
+func (fc *FilterCache) Get(sw *Skywalker) (*FilterSet, error) {
+	key := filterKey(sw)
+	fc.mutex.Lock()
+	entry, ok := fc.sets[key]
+	if !ok {
+		entry = new(filterEntry)
+		fc.sets[key] = entry
+	}
+	fc.mutex.Unlock()
+	entry.once.Do(func() {
+		entry.fs, entry.err = sw.CompileFilters()
+	})
+	return entry.fs, entry.err
+}
+
+//Len returns how many different filters are cached.
+func (fc *FilterCache) Len() int {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return len(fc.sets)
+}
+
+//Reset forgets every cached FilterSet. FilterSets already handed out keep working.
+func (fc *FilterCache) Reset() {
+	fc.mutex.Lock()
+	fc.sets = make(map[string]*filterEntry)
+	fc.mutex.Unlock()
+}
+
+//filterKey identifies everything CompileFilters uses. Types are compared by identity as the
+//kind predicate looks them up while matching.
+func filterKey(sw *Skywalker) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d\x00%d\x00%d\x00%d\x00%p\x00%q\x00%d\x00%d\x00%d\x00%d\x00%d\x00%d\x00", sw.ListType, sw.RegexListType, sw.ExtListType, sw.DirListType, sw.types(), sw.Filter,
+		sw.MaxPathLength, sw.MaxNameLength, sw.MinDepth, sw.MaxDepth, sw.MinSize, sw.MaxSize)
+	fmt.Fprintf(&b, "%s\x00%s\x00%t\x00", sw.ModifiedAfter.Format(time.RFC3339Nano), sw.ModifiedBefore.Format(time.RFC3339Nano), sw.SkipHidden)
+	for _, list := range [][]string{sw.List, sw.RegexList, sw.ExtList, sw.DirList, sw.ForbiddenNames} {
+		fmt.Fprintf(&b, "%d\x00", len(list))
+		for _, s := range list {
+			fmt.Fprintf(&b, "%q\x00", s)
+		}
+	}
+	return b.String()
+}
+
+//limits checks rel, a path relative to the root separated by sep, against MaxPathLength, MaxNameLength
+//and ForbiddenNames. Lengths are in characters, with a single character between names like most targets count.
+func (fs *FilterSet) limits(rel string, sep string) Reason {
+	if fs.maxPath <= 0 && fs.maxName <= 0 && len(fs.forbidden) == 0 {
+		return RMatched
+	}
+	if rel == "" {
+		return RMatched
+	}
+	for _, name := range strings.Split(rel, sep) {
+		if fs.maxName > 0 && utf8.RuneCountInString(name) > fs.maxName {
+			return RNameLength
+		}
+		for _, gl := range fs.forbidden {
+			if gl.Match(name) {
+				return RForbiddenName
+			}
+		}
+	}
+	if fs.maxPath > 0 && utf8.RuneCountInString(rel) > fs.maxPath {
+		return RPathLength
+	}
+	return RMatched
+}
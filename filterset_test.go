@@ -0,0 +1,94 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterSet(t *testing.T) {
+	assert := assert.New(t)
+	config := skywalker.New(root, nil)
+	config.DirList = []string{"sub"}
+	config.ExtListType = skywalker.LTWhitelist
+	config.ExtList = []string{".txt", ".log"}
+	config.Filter = "!name(a.*)"
+	fs, err := config.CompileFilters()
+	assert.NoError(err)
+
+	//Changing the config afterwards does not change the compiled set.
+	config.ExtList = []string{".pdf"}
+
+	var wg sync.WaitGroup
+	workers := make([]*TestWorker, 4)
+	for i := range workers {
+		workers[i] = NewTW()
+		sw := skywalker.New(root, workers[i])
+		sw.Filters = fs
+		if i%2 == 1 {
+			sw.Root = filepath.Join(root, "sub")
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(sw.Walk())
+		}()
+	}
+	wg.Wait()
+	for i, tw := range workers {
+		if i%2 == 0 {
+			assert.Len(tw.found, 2, "Only just.txt in the and subfolder")
+			_, ok := tw.found[mustAbs(filepath.Join(root, "the", "just.txt"))]
+			assert.True(ok)
+		} else {
+			//Relative to the sub root DirList skips sub/sub, which does not exist.
+			assert.Len(tw.found, 2)
+		}
+	}
+}
+
+func TestFilterCache(t *testing.T) {
+	assert := assert.New(t)
+	fc := skywalker.NewFilterCache()
+	a := skywalker.New(root, nil)
+	a.List = []string{"**/*.txt"}
+	b := skywalker.New("elsewhere", nil)
+	b.List = []string{"**/*.txt"}
+	fsA, err := fc.Get(a)
+	assert.NoError(err)
+	fsB, err := fc.Get(b)
+	assert.NoError(err)
+	assert.True(fsA == fsB, "Same filters should share a FilterSet")
+
+	b.ListType = skywalker.LTWhitelist
+	fsB, err = fc.Get(b)
+	assert.NoError(err)
+	assert.False(fsA == fsB)
+
+	bad := skywalker.New(root, nil)
+	bad.Filter = "ext(.go"
+	for i := 0; i < 2; i++ {
+		_, err = fc.Get(bad)
+		var syntaxErr *skywalker.FilterSyntaxError
+		assert.True(errors.As(err, &syntaxErr))
+	}
+	assert.Equal(3, fc.Len())
+	fc.Reset()
+	assert.Equal(0, fc.Len())
+}
+
+func mustAbs(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		panic(err)
+	}
+	return abs
+}
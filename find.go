@@ -0,0 +1,125 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gobwas/glob"
+)
+
+//ErrNotFound is returned by FindFirst when nothing matched.
+var ErrNotFound = errors.New("no path matched")
+
+//errFound stops a walk once a match is found.
+var errFound = errors.New("found")
+
+//FindFirst looks for a path matching relGlob, relative to each root, in Root and every overlay at the same time.
+//The match from the highest root wins, like it would with Overlays, and inside a root the first match in
+//lexical order does. Only paths the Skywalker's filters let through can match.
+//Roots are walked starting from the longest part of relGlob without any glob characters and stop as soon
+//as they find a match or a higher root already has one.
+func (sw *Skywalker) FindFirst(relGlob string) (string, error) {
+	if err := sw.osOnly("FindFirst"); err != nil {
+		return "", err
+	}
+	gl, err := glob.Compile(cleanGlob(relGlob), filepath.Separator)
+	if err != nil {
+		return "", &GlobCompileError{Pattern: relGlob, Err: err}
+	}
+	roots := append([]string{sw.Root}, sw.Overlays...)
+	layers := make([]*Matcher, len(roots))
+	for i, root := range roots {
+		if layers[i], err = sw.matcherAt(root); err != nil {
+			return "", err
+		}
+	}
+	prefix := literalPrefix(relGlob)
+	found := make([]string, len(layers))
+	errs := make([]error, len(layers))
+	best := int32(-1)
+	wg := new(sync.WaitGroup)
+	wg.Add(len(layers))
+	for i, m := range layers {
+		go func(i int, m *Matcher) {
+			defer wg.Done()
+			found[i], errs[i] = findIn(m, gl, prefix, func() bool {
+				return atomic.LoadInt32(&best) > int32(i)
+			})
+			if found[i] == "" {
+				return
+			}
+			for {
+				cur := atomic.LoadInt32(&best)
+				if cur >= int32(i) || atomic.CompareAndSwapInt32(&best, cur, int32(i)) {
+					return
+				}
+			}
+		}(i, m)
+	}
+	wg.Wait()
+	if best >= 0 {
+		return found[best], nil
+	}
+	for i := len(errs) - 1; i >= 0; i-- {
+		if errs[i] != nil {
+			return "", errs[i]
+		}
+	}
+	return "", ErrNotFound
+}
+
+//findIn walks the root of m from prefix and returns the first path matching gl.
+//It gives up without an error as soon as beaten returns true.
+func findIn(m *Matcher, gl glob.Glob, prefix string, beaten func() bool) (string, error) {
+	var match string
+	err := walkDir(filepath.Join(m.root, prefix), func(path string, info os.FileInfo, err error) error {
+		if beaten() {
+			return errFound
+		}
+		if err != nil {
+			if path == m.root {
+				return rootError(path, err)
+			}
+			return nil
+		}
+		ok, reason := m.Match(path, info)
+		if !ok {
+			if info.IsDir() && reason.prunes() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if gl.Match(strings.TrimPrefix(path, m.root+string(filepath.Separator))) {
+			match = path
+			return errFound
+		}
+		return nil
+	})
+	if err == errFound {
+		return match, nil
+	}
+	if os.IsNotExist(err) && prefix != "" {
+		return "", nil
+	}
+	return "", err
+}
+
+//literalPrefix returns the leading directories of relGlob that do not contain any glob characters.
+func literalPrefix(relGlob string) string {
+	parts := strings.Split(relGlob, "/")
+	i := 0
+	for ; i < len(parts)-1; i++ {
+		if strings.ContainsAny(parts[i], `*?[]{}\`) {
+			break
+		}
+	}
+	return filepath.Join(parts[:i]...)
+}
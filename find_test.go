@@ -0,0 +1,47 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindFirst(t *testing.T) {
+	assert := assert.New(t)
+	base, top := t.TempDir(), t.TempDir()
+	writeFiles(t, base, map[string]string{
+		"conf/app.yaml":      "base",
+		"conf/b.yaml":        "base",
+		"assets/logo.png":    "base",
+		"assets/icon.png":    "base",
+		"vendor/x/deep.json": "base",
+	})
+	writeFiles(t, top, map[string]string{
+		"conf/app.yaml": "top",
+	})
+	sw := skywalker.New(base, NewTW())
+	sw.Overlays = []string{top}
+	sw.DirList = []string{"vendor"}
+
+	path, err := sw.FindFirst("conf/app.yaml")
+	assert.NoError(err)
+	assert.Equal(filepath.Join(top, "conf", "app.yaml"), path)
+
+	path, err = sw.FindFirst("assets/*.png")
+	assert.NoError(err)
+	assert.Equal(filepath.Join(base, "assets", "icon.png"), path)
+
+	_, err = sw.FindFirst("**/deep.json")
+	assert.Equal(skywalker.ErrNotFound, err)
+
+	_, err = sw.FindFirst("[")
+	var globErr *skywalker.GlobCompileError
+	assert.True(errors.As(err, &globErr), "Expected a GlobCompileError but got %v", err)
+}
@@ -0,0 +1,132 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+)
+
+//FingerprintStore keeps the fingerprint of every directory between walks.
+//It is only used from the walking goroutine so it does not need to be thread safe.
+type FingerprintStore interface {
+	Get(dir string) (string, bool)
+	Put(dir, fingerprint string)
+}
+
+//FingerprintMap is a FingerprintStore kept in memory.
+//Use LoadFingerprints and Save to keep it between runs.
+type FingerprintMap map[string]string
+
+//Get returns the stored fingerprint of dir.
+func (fm FingerprintMap) Get(dir string) (string, bool) {
+	fp, ok := fm[dir]
+	return fp, ok
+}
+
+//Put stores the fingerprint of dir.
+func (fm FingerprintMap) Put(dir, fingerprint string) {
+	fm[dir] = fingerprint
+}
+
+//LoadFingerprints reads a FingerprintMap written by Save.
+//An empty FingerprintMap is returned if the file does not exist.
+func LoadFingerprints(path string) (FingerprintMap, error) {
+	fm := make(FingerprintMap)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fm, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fm, json.Unmarshal(data, &fm)
+}
+
+//Save atomically writes the FingerprintMap to path.
+func (fm FingerprintMap) Save(path string) error {
+	data, err := json.Marshal(fm)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0666, atomicfile.SPFile)
+}
+
+//unchanged reports whether dir has the same fingerprint as the store has for it and should be skipped.
+func (sw *Skywalker) unchanged(dir string) bool {
+	if sw.Fingerprints == nil {
+		return false
+	}
+	old, ok := sw.Fingerprints.Get(dir)
+	if !ok || old != sw.fingerprints[dir] || !sw.skipDir(dir, RUnchanged) {
+		return false
+	}
+	if sw.OnUnchanged != nil {
+		sw.OnUnchanged(dir)
+	}
+	return true
+}
+
+//fingerprintTree fingerprints every directory under the matcher's root from the paths the matcher lets through.
+//skip decides whether a directory the matcher filtered out is pruned.
+func fingerprintTree(m *Matcher, skip func(path string, reason Reason) bool) (map[string]string, error) {
+	lines := make(map[string][]string)
+	err := walkDir(m.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == m.root {
+				return rootError(path, err)
+			}
+			return nil
+		}
+		match, reason := m.Match(path, info)
+		if info.IsDir() {
+			if !match && reason.prunes() && skip(path, reason) {
+				return filepath.SkipDir
+			}
+			if _, ok := lines[path]; !ok {
+				lines[path] = []string{}
+			}
+			return nil
+		}
+		if match {
+			dir := filepath.Dir(path)
+			lines[dir] = append(lines[dir], fingerprintLine(info))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(lines))
+	for dir := range lines {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	fps := make(map[string]string, len(dirs))
+	for _, dir := range dirs {
+		entries := lines[dir]
+		sort.Strings(entries)
+		sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+		fps[dir] = hex.EncodeToString(sum[:])
+		if dir != m.root {
+			parent := filepath.Dir(dir)
+			lines[parent] = append(lines[parent], filepath.Base(dir)+"\x00d\x00"+fps[dir])
+		}
+	}
+	return fps, nil
+}
+
+func fingerprintLine(info os.FileInfo) string {
+	return info.Name() + "\x00f\x00" + strconv.FormatInt(info.Size(), 10) + "\x00" +
+		strconv.FormatInt(info.ModTime().UnixNano(), 10) + "\x00" + strconv.FormatUint(uint64(info.Mode()), 8)
+}
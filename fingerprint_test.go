@@ -0,0 +1,57 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprints(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src")
+	writeFiles(t, src, map[string]string{
+		"a/one.txt":   "one",
+		"a/x/two.txt": "two",
+		"b/three.txt": "three",
+	})
+	store := filepath.Join(tmp, "fingerprints.json")
+
+	run := func() (*TestWorker, []string) {
+		fps, err := skywalker.LoadFingerprints(store)
+		assert.NoError(err)
+		tw := NewTW()
+		var unchanged []string
+		sw := skywalker.New(src, tw)
+		sw.Fingerprints = fps
+		sw.OnUnchanged = func(dir string) {
+			rel, _ := filepath.Rel(sw.Root, dir)
+			unchanged = append(unchanged, filepath.ToSlash(rel))
+		}
+		assert.NoError(sw.Walk())
+		assert.NoError(fps.Save(store))
+		return tw, unchanged
+	}
+
+	tw, unchanged := run()
+	assert.Len(tw.found, 3)
+	assert.Empty(unchanged)
+
+	tw, unchanged = run()
+	assert.Empty(tw.found)
+	assert.Equal([]string{"."}, unchanged)
+
+	later := time.Now().Add(time.Hour)
+	assert.NoError(os.Chtimes(filepath.Join(src, "a/x/two.txt"), later, later))
+	tw, unchanged = run()
+	assert.Len(tw.found, 2, "Only the changed directories should be walked")
+	assert.Equal([]string{"b"}, unchanged)
+}
@@ -0,0 +1,142 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+//SymlinkMode is what a walk does with the symlinks it finds that FollowLinks does not follow.
+type SymlinkMode int
+
+const (
+	//SMHandOn hands links to the Worker like any other file without following them,
+	//which is what filepath.WalkDir does.
+	SMHandOn SymlinkMode = iota
+	//SMSkip leaves links out of the walk. Each is sent as an EKSkipped event with RSymlink.
+	SMSkip
+	//SMFollow follows every link, as if FollowLinks matched everything.
+	SMFollow
+	//SMReport hands links to the Worker, which has to be a SymlinkWorker, along with what they point to.
+	SMReport
+)
+
+var symlinkModeNames = [...]string{"hand on", "skip", "follow", "report"}
+
+func (sm SymlinkMode) String() string {
+	if sm < 0 || int(sm) >= len(symlinkModeNames) {
+		return "unknown"
+	}
+	return symlinkModeNames[sm]
+}
+
+//SymlinkWorker is a Worker that wants to know where the links it is handed point to, e.g. to back them up
+//as links. With SMReport WorkSymlink is called instead of Work for every link, with the error of reading it if
+//that failed. Everything else is still handed to the Worker as usual.
+type SymlinkWorker interface {
+	Worker
+	WorkSymlink(path, target string, err error)
+}
+
+//workSymlink hands the link at path to the SymlinkWorker.
+func (sw *Skywalker) workSymlink(path string) {
+	target, err := os.Readlink(path)
+	sw.Worker.(SymlinkWorker).WorkSymlink(path, target, err)
+}
+
+//compileFollowLinks compiles the FollowLinks globs.
+func (sw *Skywalker) compileFollowLinks() error {
+	sw.follow = make([]glob.Glob, len(sw.FollowLinks))
+	for i, g := range sw.FollowLinks {
+		gl, err := glob.Compile(cleanGlob(g), filepath.Separator)
+		if err != nil {
+			return &GlobCompileError{Pattern: g, Err: err}
+		}
+		sw.follow[i] = gl
+	}
+	return nil
+}
+
+//follows reports whether the link at path in root is followed.
+func (sw *Skywalker) follows(root, path string) bool {
+	if sw.SymlinkMode == SMFollow {
+		return true
+	}
+	rel := strings.Replace(path, root, "", 1)
+	for _, gl := range sw.follow {
+		if gl.Match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+//fileKey is the device and inode of a file. It is zero where fileID does not know them.
+type fileKey struct {
+	dev, ino uint64
+}
+
+func keyOf(info os.FileInfo) fileKey {
+	dev, ino := fileID(info)
+	return fileKey{dev: dev, ino: ino}
+}
+
+//loops reports whether the directory with key is one the walk is already in at path, checking every parent
+//of path up to root by device and inode like find -L does. An unknown key never loops.
+func loops(root, path string, key fileKey) bool {
+	if key == (fileKey{}) {
+		return false
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(dir); err == nil && keyOf(info) == key {
+			return true
+		}
+		if len(dir) <= len(root) || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+//followLinks wraps walkFn so links matching FollowLinks, or every link with SMFollow, are handed to it as what they point to.
+//The contents of a linked directory are walked with their paths below the link.
+//A link to a directory the link is in, or the walk is in through other links, is not followed so links can not loop.
+func (sw *Skywalker) followLinks(root string, walkFn filepath.WalkFunc) filepath.WalkFunc {
+	if len(sw.follow) == 0 && sw.SymlinkMode != SMFollow {
+		return walkFn
+	}
+	var visit filepath.WalkFunc
+	visit = func(path string, info os.FileInfo, err error) error {
+		if err != nil || fileType(info)&os.ModeSymlink == 0 || !sw.follows(root, path) {
+			return walkFn(path, info, err)
+		}
+		target, terr := filepath.EvalSymlinks(path)
+		if terr != nil {
+			//Broken links are handed on as links.
+			return walkFn(path, info, err)
+		}
+		tinfo, terr := os.Stat(target)
+		if terr != nil {
+			return walkFn(path, info, err)
+		}
+		if !tinfo.IsDir() {
+			return walkFn(path, tinfo, nil)
+		}
+		parent, perr := filepath.EvalSymlinks(filepath.Dir(path))
+		if perr != nil || parent == target || strings.HasPrefix(parent, strings.TrimSuffix(target, string(filepath.Separator))+string(filepath.Separator)) {
+			return walkFn(path, info, err)
+		}
+		if loops(root, path, keyOf(tinfo)) {
+			return walkFn(path, info, err)
+		}
+		return walkDir(target, func(p string, i os.FileInfo, e error) error {
+			return visit(path+strings.TrimPrefix(p, target), i, e)
+		})
+	}
+	return visit
+}
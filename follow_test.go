@@ -0,0 +1,146 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowLinks(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"releases/v1/app.txt":    "v1",
+		"releases/v2/app.txt":    "v2",
+		"releases/v2/config.txt": "config",
+		"other/data.txt":         "data",
+	})
+	for link, target := range map[string]string{
+		"current":               "releases/v2",
+		"previous":              "releases/v1",
+		"latest.txt":            "releases/v2/app.txt",
+		"releases/v2/loop":      "..",
+		"releases/v2/elsewhere": "../../other",
+	} {
+		if err := os.Symlink(target, filepath.Join(tmp, link)); err != nil {
+			t.Skip("Can not create symlinks:", err)
+		}
+	}
+
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.FilesOnly = false
+	sw.FollowLinks = []string{"/current", "/current/**", "/latest.txt"}
+	assert.NoError(sw.Walk())
+	rels := make(map[string]struct{})
+	for path := range tw.found {
+		rel, _ := filepath.Rel(sw.Root, path)
+		rels[filepath.ToSlash(rel)] = struct{}{}
+	}
+	for _, rel := range []string{
+		"current", "current/app.txt", "current/config.txt",
+		"current/elsewhere", "current/elsewhere/data.txt",
+		"current/loop", "previous", "latest.txt",
+	} {
+		_, ok := rels[rel]
+		assert.True(ok, "Expected %s", rel)
+	}
+	for _, rel := range []string{"previous/app.txt", "current/loop/v1", "releases/v2/elsewhere/data.txt"} {
+		_, ok := rels[rel]
+		assert.False(ok, "Did not expect %s", rel)
+	}
+
+	sw.FollowLinks = []string{"[unclosed"}
+	var globErr *skywalker.GlobCompileError
+	assert.True(errors.As(sw.Walk(), &globErr))
+}
+
+type linkWorker struct {
+	*TestWorker
+	targets map[string]string
+}
+
+func (lw *linkWorker) WorkSymlink(path, target string, err error) {
+	lw.Lock()
+	defer lw.Unlock()
+	lw.targets[path] = target
+}
+
+func TestSymlinkMode(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"c/one.txt": "one",
+		"d/two.txt": "two",
+	})
+	for link, target := range map[string]string{
+		"link.txt": "c/one.txt",
+		"c/tod":    "../d",
+		"d/toc":    "../c",
+	} {
+		if err := os.Symlink(target, filepath.Join(tmp, link)); err != nil {
+			t.Skip("Can not create symlinks:", err)
+		}
+	}
+	rels := func(sw *skywalker.Skywalker, found map[string]struct{}) map[string]struct{} {
+		rels := make(map[string]struct{})
+		for path := range found {
+			rel, _ := filepath.Rel(sw.Root, path)
+			rels[filepath.ToSlash(rel)] = struct{}{}
+		}
+		return rels
+	}
+
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.SymlinkMode = skywalker.SMSkip
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RSymlink {
+			tw.Lock()
+			skipped = append(skipped, ev.Path)
+			tw.Unlock()
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Equal(map[string]struct{}{"c/one.txt": {}, "d/two.txt": {}}, rels(sw, tw.found))
+	assert.Len(skipped, 3)
+
+	tw = NewTW()
+	sw = skywalker.New(tmp, tw)
+	sw.SymlinkMode = skywalker.SMFollow
+	assert.NoError(sw.Walk())
+	found := rels(sw, tw.found)
+	for _, rel := range []string{"link.txt", "c/tod/two.txt", "c/tod/toc", "d/toc/one.txt", "d/toc/tod"} {
+		_, ok := found[rel]
+		assert.True(ok, "Expected %s", rel)
+	}
+	for _, rel := range []string{"c/tod/toc/one.txt", "d/toc/tod/two.txt"} {
+		_, ok := found[rel]
+		assert.False(ok, "Did not expect %s", rel)
+	}
+
+	lw := &linkWorker{TestWorker: NewTW(), targets: make(map[string]string)}
+	sw = skywalker.New(tmp, lw)
+	sw.SymlinkMode = skywalker.SMReport
+	assert.NoError(sw.Walk())
+	assert.Equal(map[string]struct{}{"c/one.txt": {}, "d/two.txt": {}}, rels(sw, lw.found))
+	assert.Equal(map[string]string{
+		filepath.Join(sw.Root, "link.txt"): "c/one.txt",
+		filepath.Join(sw.Root, "c/tod"):    "../d",
+		filepath.Join(sw.Root, "d/toc"):    "../c",
+	}, lw.targets)
+
+	sw = skywalker.New(tmp, NewTW())
+	sw.SymlinkMode = skywalker.SMReport
+	var confErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &confErr))
+}
@@ -0,0 +1,186 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package skywalker
+
+/*
+#cgo LDFLAGS: -framework CoreServices
+#include <stdint.h>
+#include <stdlib.h>
+#include <CoreServices/CoreServices.h>
+#include <dispatch/dispatch.h>
+
+extern void fseventsCallback(uintptr_t id, size_t n, char **paths, uint32_t *flags);
+
+static void fseventsStreamCallback(ConstFSEventStreamRef stream, void *info, size_t n, void *paths,
+		const FSEventStreamEventFlags flags[], const FSEventStreamEventId ids[]) {
+	fseventsCallback((uintptr_t)info, n, (char **)paths, (uint32_t *)flags);
+}
+
+//fseventsStart starts a stream of the events in the tree at root on queue for the watcher registered as id.
+static FSEventStreamRef fseventsStart(uintptr_t id, const char *root, double latency, dispatch_queue_t queue) {
+	FSEventStreamContext ctx = {0, (void *)id, NULL, NULL, NULL};
+	CFStringRef path = CFStringCreateWithCString(NULL, root, kCFStringEncodingUTF8);
+	if (path == NULL) {
+		return NULL;
+	}
+	CFArrayRef paths = CFArrayCreate(NULL, (const void **)&path, 1, &kCFTypeArrayCallBacks);
+	CFRelease(path);
+	FSEventStreamRef stream = FSEventStreamCreate(NULL, fseventsStreamCallback, &ctx, paths,
+		kFSEventStreamEventIdSinceNow, latency, kFSEventStreamCreateFlagFileEvents | kFSEventStreamCreateFlagNoDefer);
+	CFRelease(paths);
+	if (stream == NULL) {
+		return NULL;
+	}
+	FSEventStreamSetDispatchQueue(stream, queue);
+	if (!FSEventStreamStart(stream)) {
+		FSEventStreamInvalidate(stream);
+		FSEventStreamRelease(stream);
+		return NULL;
+	}
+	return stream;
+}
+
+static void fseventsStop(FSEventStreamRef stream) {
+	FSEventStreamStop(stream);
+	FSEventStreamInvalidate(stream);
+	FSEventStreamRelease(stream);
+}
+
+static dispatch_queue_t fseventsQueue(void) {
+	return dispatch_queue_create("skywalker.fsevents", DISPATCH_QUEUE_SERIAL);
+}
+
+static void fseventsRelease(dispatch_queue_t queue) {
+	dispatch_release(queue);
+}
+*/
+import "C"
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+)
+
+//fseventsLatency is how many seconds FSEvents waits for more events before handing them on. WatchDelay
+//waits for more changes on top of it.
+const fseventsLatency = 0.01
+
+//fseventsDropped are the flags of an event telling events were lost.
+const fseventsDropped = C.kFSEventStreamEventFlagMustScanSubDirs | C.kFSEventStreamEventFlagUserDropped |
+	C.kFSEventStreamEventFlagKernelDropped
+
+//fseventsChanged are the flags of an event that make its path changed.
+const fseventsChanged = C.kFSEventStreamEventFlagItemCreated | C.kFSEventStreamEventFlagItemRenamed |
+	C.kFSEventStreamEventFlagItemModified
+
+//fseventsRegistry finds the fseventsWatcher a callback is for. C is only handed its ID, as it may not keep Go pointers.
+type fseventsRegistry struct {
+	mutex    sync.Mutex
+	next     uintptr
+	watchers map[uintptr]*fseventsWatcher
+}
+
+var fseventsWatchers = fseventsRegistry{watchers: make(map[uintptr]*fseventsWatcher)}
+
+func (fr *fseventsRegistry) add(fw *fseventsWatcher) uintptr {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+	fr.next++
+	fr.watchers[fr.next] = fw
+	return fr.next
+}
+
+func (fr *fseventsRegistry) get(id uintptr) *fseventsWatcher {
+	fr.mutex.Lock()
+	defer fr.mutex.Unlock()
+	return fr.watchers[id]
+}
+
+func (fr *fseventsRegistry) remove(id uintptr) {
+	fr.mutex.Lock()
+	delete(fr.watchers, id)
+	fr.mutex.Unlock()
+}
+
+//fseventsWatcher is told about changes to the whole tree at its root by FSEvents. Events name the real path
+//that changed, with symbolic links like /var resolved, so the real path of every watched directory is kept to
+//find the path it was walked as.
+type fseventsWatcher struct {
+	cs     *changeSet
+	id     uintptr
+	stream C.FSEventStreamRef
+	queue  C.dispatch_queue_t
+
+	mutex sync.Mutex
+	//dirs are the watched directories by their real path.
+	dirs map[string]string
+}
+
+func newFSEventsWatcher(cs *changeSet, root string) (watcher, error) {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return nil, err
+	}
+	fw := &fseventsWatcher{cs: cs, dirs: make(map[string]string)}
+	fw.id = fseventsWatchers.add(fw)
+	croot := C.CString(resolved)
+	defer C.free(unsafe.Pointer(croot))
+	fw.queue = C.fseventsQueue()
+	fw.stream = C.fseventsStart(C.uintptr_t(fw.id), croot, fseventsLatency, fw.queue)
+	if fw.stream == nil {
+		fseventsWatchers.remove(fw.id)
+		C.fseventsRelease(fw.queue)
+		return nil, &os.PathError{Op: "FSEventStreamStart", Path: root, Err: os.ErrInvalid}
+	}
+	return fw, nil
+}
+
+func (fw *fseventsWatcher) add(path string) error {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return err
+	}
+	fw.mutex.Lock()
+	fw.dirs[resolved] = path
+	fw.mutex.Unlock()
+	return nil
+}
+
+//event handles an event for realPath with flags.
+func (fw *fseventsWatcher) event(realPath string, flags uint32) {
+	if flags&fseventsDropped != 0 {
+		fw.cs.fail(ErrWatchOverflow)
+		return
+	}
+	if flags&fseventsChanged == 0 {
+		return
+	}
+	fw.mutex.Lock()
+	dir, ok := fw.dirs[filepath.Dir(realPath)]
+	fw.mutex.Unlock()
+	if !ok {
+		return
+	}
+	fw.cs.add(filepath.Join(dir, filepath.Base(realPath)))
+}
+
+func (fw *fseventsWatcher) close() error {
+	C.fseventsStop(fw.stream)
+	//Events already queued find no watcher once it is removed.
+	fseventsWatchers.remove(fw.id)
+	C.fseventsRelease(fw.queue)
+	return nil
+}
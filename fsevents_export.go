@@ -0,0 +1,32 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package skywalker
+
+//The preamble of a file with an export may only declare, so the callback lives apart from the C it is called by.
+
+/*
+#include <stddef.h>
+#include <stdint.h>
+*/
+import "C"
+
+import "unsafe"
+
+//fseventsCallback hands the n paths and flags of events to the fseventsWatcher registered as id.
+//export fseventsCallback
+func fseventsCallback(id C.uintptr_t, n C.size_t, paths **C.char, flags *C.uint32_t) {
+	fw := fseventsWatchers.get(uintptr(id))
+	if fw == nil {
+		return
+	}
+	ps := (*[1 << 28]*C.char)(unsafe.Pointer(paths))[:n:n]
+	fs := (*[1 << 28]C.uint32_t)(unsafe.Pointer(flags))[:n:n]
+	for i := range ps {
+		fw.event(C.GoString(ps[i]), uint32(fs[i]))
+	}
+}
@@ -0,0 +1,15 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build darwin && !cgo
+// +build darwin,!cgo
+
+package skywalker
+
+import "syscall"
+
+//newFSEventsWatcher fails as FSEvents can only be used with cgo.
+func newFSEventsWatcher(*changeSet, string) (watcher, error) {
+	return nil, syscall.ENOSYS
+}
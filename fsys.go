@@ -0,0 +1,71 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+//NewFS creates a Skywalker for the slash separated root inside of fsys, e.g. an embed.FS, a zip.Reader or
+//a testing/fstest.MapFS, with the same defaults as New. Use "." for all of fsys.
+//Paths handed to the worker are inside of fsys as well, so it should read them with Skywalker.Open.
+func NewFS(fsys fs.FS, root string, worker Worker) *Skywalker {
+	sw := New(root, worker)
+	sw.FS = fsys
+	return sw
+}
+
+//Open opens path, as handed to the Worker, from FS if it is set and with Open from the filesystem otherwise.
+//Reads of the file are held to MaxReadBytes and ReadTimeout.
+func (sw *Skywalker) Open(path string) (fs.File, error) {
+	if sw.FS != nil {
+		file, err := sw.FS.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return sw.guard(file), nil
+	}
+	if sw.ReadTimeout > 0 {
+		file, err := openGuarded(path)
+		if err != nil {
+			return nil, err
+		}
+		return sw.guard(file), nil
+	}
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return sw.guard(file), nil
+}
+
+//lstat is os.Lstat, or fs.Stat with FS as an fs.FS has no links to not follow.
+func (sw *Skywalker) lstat(path string) (os.FileInfo, error) {
+	if sw.FS != nil {
+		return fs.Stat(sw.FS, path)
+	}
+	return os.Lstat(path)
+}
+
+//walkFS walks root inside of fsys like walkDir.
+func walkFS(fsys fs.FS, root string, walkFn filepath.WalkFunc) error {
+	return fs.WalkDir(fsys, root, func(path string, entry fs.DirEntry, err error) error {
+		var info os.FileInfo
+		if entry != nil {
+			info = &entryInfo{entry: entry}
+		}
+		return walkFn(path, info, err)
+	})
+}
+
+//osOnly returns an error if FS is set, for what only works on the filesystem.
+func (sw *Skywalker) osOnly(what string) error {
+	if sw.FS != nil {
+		return &ConfigError{Field: "FS", Msg: "can not be used with " + what}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//FSWorker reads every file it is handed through the Skywalker.
+type FSWorker struct {
+	sw       *skywalker.Skywalker
+	mutex    sync.Mutex
+	contents map[string]string
+}
+
+func (fw *FSWorker) Work(path string) {
+	file, err := fw.sw.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	b, _ := io.ReadAll(file)
+	fw.mutex.Lock()
+	defer fw.mutex.Unlock()
+	fw.contents[path] = string(b)
+}
+
+func TestNewFS(t *testing.T) {
+	assert := assert.New(t)
+	fsys := fstest.MapFS{
+		"a.txt":             {Data: []byte("a")},
+		".hidden.txt":       {Data: []byte("h")},
+		"b.log":             {Data: []byte("b")},
+		"sub/c.txt":         {Data: []byte("c")},
+		"sub/skip/d.txt":    {Data: []byte("d")},
+		"sub/deeper/e.txt":  {Data: []byte("e")},
+		"other/f.txt":       {Data: []byte("f")},
+		"other/skip/g.txt":  {Data: []byte("g")},
+		"sub/deeper/h.conf": {Data: []byte("h")},
+	}
+	walk := func(root string, configure func(sw *skywalker.Skywalker)) map[string]string {
+		fw := &FSWorker{contents: make(map[string]string)}
+		fw.sw = skywalker.NewFS(fsys, root, fw)
+		fw.sw.ExtList = []string{".txt"}
+		fw.sw.ExtListType = skywalker.LTWhitelist
+		if configure != nil {
+			configure(fw.sw)
+		}
+		assert.NoError(fw.sw.Walk())
+		return fw.contents
+	}
+
+	assert.Equal(map[string]string{
+		"a.txt": "a", ".hidden.txt": "h", "sub/c.txt": "c", "sub/deeper/e.txt": "e", "other/f.txt": "f",
+	}, walk(".", func(sw *skywalker.Skywalker) {
+		sw.DirList = []string{"sub/skip", "other/skip"}
+	}))
+	assert.Equal(map[string]string{"sub/c.txt": "c", "sub/skip/d.txt": "d"}, walk("sub", func(sw *skywalker.Skywalker) {
+		sw.List = []string{"/deeper/**"}
+	}))
+	assert.Equal(map[string]string{"sub/deeper/e.txt": "e"}, walk(".", func(sw *skywalker.Skywalker) {
+		sw.DirList = []string{"sub/deeper"}
+		sw.DirListType = skywalker.LTWhitelist
+	}))
+
+	sw := skywalker.NewFS(fsys, "../up", NewTW())
+	var configErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &configErr))
+	sw = skywalker.NewFS(fsys, "missing", NewTW())
+	var rootErr *skywalker.RootNotExistError
+	assert.True(errors.As(sw.Walk(), &rootErr))
+	sw = skywalker.NewFS(fsys, ".", NewTW())
+	_, err := sw.ListDir(".")
+	assert.True(errors.As(err, &configErr))
+}
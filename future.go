@@ -0,0 +1,103 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"errors"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+//ErrNotWalking is returned by Enqueue when no walk is running.
+var ErrNotWalking = errors.New("skywalker is not walking")
+
+//Future is what became of a path handed to Enqueue. It is ready once a worker is done with the path.
+type Future struct {
+	//Path is the path that was enqueued.
+	Path string
+
+	done chan struct{}
+	res  Result
+}
+
+func newFuture(path string) *Future {
+	return &Future{Path: path, done: make(chan struct{})}
+}
+
+func (f *Future) resolve(res Result) {
+	f.res = res
+	close(f.done)
+}
+
+//Done is closed once a worker is done with the path.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+//Wait waits for a worker to be done with the path or ctx to be done. The Result holds what a ResultWorker
+//returned, the error of an ErrorWorker, or the values and error of a ChunkWorker's chunks. Err is the error
+//of the walk's context if the path was dropped.
+func (f *Future) Wait(ctx context.Context) (Result, error) {
+	select {
+	case <-f.done:
+		return f.res, nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+//Enqueue hands a path to the workers of the running Walk or Redispatch along with whatever the walk finds,
+//so an interactive caller can wait for the files it needs right now while the rest of the walk continues.
+//Like Redispatch the path is not filtered, relative paths are relative to Root and it has to be inside of Root or
+//one of the Overlays. It returns ErrNotWalking if nothing is running or the walk already found everything,
+//as the workers may be gone by the time it would be handed to them. It blocks while the queue is full.
+func (sw *Skywalker) Enqueue(path string) (*Future, error) {
+	root := sw.Root
+	if sw.FS != nil {
+		path = pathpkg.Clean(path)
+		if root != "." && path != root && !strings.HasPrefix(path, root+"/") {
+			return nil, ErrNotInRoot
+		}
+	} else {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		path = filepath.Clean(path)
+	}
+	sw.enqueueMutex.RLock()
+	defer sw.enqueueMutex.RUnlock()
+	if sw.enqueue == nil {
+		return nil, ErrNotWalking
+	}
+	if sw.FS == nil {
+		var err error
+		if root, err = sw.rootOf(path); err != nil {
+			return nil, err
+		}
+	}
+	info, err := sw.lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	f := newFuture(path)
+	sw.enqueue(item{path: path, info: info, root: root, future: f})
+	return f, nil
+}
+
+//openEnqueue lets Enqueue hand items to dispatch, which has to be safe to call from any goroutine.
+func (sw *Skywalker) openEnqueue(dispatch func(item)) {
+	sw.enqueueMutex.Lock()
+	sw.enqueue = dispatch
+	sw.enqueueMutex.Unlock()
+}
+
+//closeEnqueue waits for every Enqueue handing over an item and stops any more.
+func (sw *Skywalker) closeEnqueue() {
+	sw.enqueueMutex.Lock()
+	sw.enqueue = nil
+	sw.enqueueMutex.Unlock()
+}
@@ -0,0 +1,69 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnqueue(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+		"extra.dat": "extra",
+		"empty.dat": "",
+	})
+
+	sw := skywalker.New(tmp, &SizeWorker{NewTW()})
+	sw.ExtListType = skywalker.LTWhitelist
+	sw.ExtList = []string{".txt"}
+	_, err := sw.Enqueue("extra.dat")
+	assert.Equal(skywalker.ErrNotWalking, err)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	sw.EnterDir = func(dir string) error {
+		if filepath.Base(dir) == "sub" {
+			close(entered)
+			<-release
+		}
+		return nil
+	}
+	walked := make(chan error)
+	go func() {
+		walked <- sw.Walk()
+	}()
+	<-entered
+
+	ctx := context.Background()
+	f, err := sw.Enqueue("extra.dat")
+	assert.NoError(err)
+	res, err := f.Wait(ctx)
+	assert.NoError(err)
+	assert.Equal(int64(5), res.Value)
+	assert.Equal(filepath.Join(sw.Root, "extra.dat"), f.Path)
+
+	f, err = sw.Enqueue(filepath.Join(sw.Root, "empty.dat"))
+	assert.NoError(err)
+	<-f.Done()
+	res, _ = f.Wait(ctx)
+	assert.Equal(errEmpty, res.Err)
+
+	_, err = sw.Enqueue("missing.dat")
+	assert.Error(err)
+	_, err = sw.Enqueue(filepath.Dir(sw.Root))
+	assert.Equal(skywalker.ErrNotInRoot, err)
+
+	close(release)
+	assert.NoError(<-walked)
+	_, err = sw.Enqueue("extra.dat")
+	assert.Equal(skywalker.ErrNotWalking, err)
+}
@@ -0,0 +1,76 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	hashMutex sync.RWMutex
+	hashes    = map[string]func() hash.Hash{
+		"md5":    md5.New,
+		"sha1":   sha1.New,
+		"sha224": sha256.New224,
+		"sha256": sha256.New,
+		"sha384": sha512.New384,
+		"sha512": sha512.New,
+		"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+		"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+		"crc64":  func() hash.Hash { return crc64.New(crc64.MakeTable(crc64.ECMA)) },
+		"fnv64a": func() hash.Hash { return fnv.New64a() },
+	}
+)
+
+//HashWorker is a Worker hashing files with a hash that can be picked for the whole walk with Skywalker.HashName.
+type HashWorker interface {
+	Worker
+	//UseHash makes the worker hash with newHash from now on.
+	UseHash(newHash func() hash.Hash)
+}
+
+//RegisterHash makes newHash available to LookupHash as name, replacing anything registered as name before.
+//The standard library's md5, sha1, sha224, sha256, sha384, sha512, crc32, crc32c, crc64 and fnv64a are
+//registered already. Import the fasthash package for xxhash64 and blake3.
+//Names are case insensitive. It is safe to call concurrently.
+func RegisterHash(name string, newHash func() hash.Hash) {
+	hashMutex.Lock()
+	defer hashMutex.Unlock()
+	hashes[strings.ToLower(name)] = newHash
+}
+
+//LookupHash returns the hash registered as name, so the hash a worker uses can be picked by name,
+//e.g. from a flag. It returns an UnknownHashError if nothing is registered as name.
+func LookupHash(name string) (func() hash.Hash, error) {
+	hashMutex.RLock()
+	defer hashMutex.RUnlock()
+	newHash, ok := hashes[strings.ToLower(name)]
+	if !ok {
+		return nil, &UnknownHashError{Name: name}
+	}
+	return newHash, nil
+}
+
+//HashNames returns the name of every registered hash, sorted.
+func HashNames() []string {
+	hashMutex.RLock()
+	defer hashMutex.RUnlock()
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRegistry(t *testing.T) {
+	assert := assert.New(t)
+	newHash, err := skywalker.LookupHash("SHA256")
+	if assert.NoError(err) {
+		assert.Equal(sha256.Size, newHash().Size())
+	}
+
+	t.Cleanup(skywalker.SaveHashes())
+	_, err = skywalker.LookupHash("adler32")
+	var unknown *skywalker.UnknownHashError
+	if assert.True(errors.As(err, &unknown)) {
+		assert.Equal("adler32", unknown.Name)
+	}
+	skywalker.RegisterHash("adler32", func() hash.Hash { return adler32.New() })
+	newHash, err = skywalker.LookupHash("ADLER32")
+	if assert.NoError(err) {
+		assert.Equal(4, newHash().Size())
+	}
+	assert.Contains(skywalker.HashNames(), "adler32")
+	assert.Contains(skywalker.HashNames(), "crc32c")
+}
+
+func TestHashName(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "src")
+	writeFiles(t, src, map[string]string{"a.txt": "a"})
+	cw := skywalker.NewCopyWorker(src, filepath.Join(tmp, "dest"))
+	cw.Verify = true
+	sw := skywalker.New(src, cw)
+	sw.HashName = "crc32"
+	assert.NoError(sw.Walk())
+	if results := cw.Results(); assert.Len(results, 1) {
+		h := crc32.NewIEEE()
+		h.Write([]byte("a"))
+		assert.Equal(hex.EncodeToString(h.Sum(nil)), results[0].Checksum)
+	}
+
+	sw.HashName = "nope"
+	var unknown *skywalker.UnknownHashError
+	assert.ErrorAs(sw.Walk(), &unknown)
+
+	sw = skywalker.New(src, NewTW())
+	sw.HashName = "md5"
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.Walk(), &ce) {
+		assert.Equal("Worker", ce.Field)
+	}
+}
@@ -0,0 +1,15 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package skywalker
+
+import "os"
+
+//hiddenAttr returns false as only Windows hides files by an attribute.
+func hiddenAttr(os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,227 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"bytes"
+	iofs "io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+//ignoreRule is a single pattern of an ignore file.
+type ignoreRule struct {
+	segs     []string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+//parseIgnore parses the patterns of an ignore file, which follow the rules of .gitignore.
+//Invalid patterns are left out like git does.
+func parseIgnore(data []byte) []ignoreRule {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		//Trailing spaces are ignored unless they are escaped.
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		var rule ignoreRule
+		if line[0] == '!' {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		//A slash anywhere but at the end ties the pattern to the directory of the ignore file.
+		rule.anchored = strings.Contains(line, "/")
+		rule.segs = strings.Split(strings.TrimPrefix(line, "/"), "/")
+		valid := true
+		for i, seg := range rule.segs {
+			seg = strings.Replace(seg, "[!", "[^", -1)
+			if _, err := pathpkg.Match(seg, ""); err != nil {
+				valid = false
+				break
+			}
+			rule.segs[i] = seg
+		}
+		if valid {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+//match reports whether the rule matches rel, the slash separated path relative to the directory of its ignore file.
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := pathpkg.Match(r.segs[0], pathpkg.Base(rel))
+		return ok
+	}
+	return matchSegs(r.segs, strings.Split(rel, "/"))
+}
+
+//matchSegs matches the names of a path against the segments of a pattern, where ** matches any number of names.
+func matchSegs(segs, names []string) bool {
+	if len(segs) == 0 {
+		return len(names) == 0
+	}
+	if segs[0] == "**" {
+		if len(segs) == 1 {
+			//A trailing /** matches everything inside, but not the directory itself.
+			return len(names) > 0
+		}
+		for i := 0; i <= len(names); i++ {
+			if matchSegs(segs[1:], names[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	if ok, _ := pathpkg.Match(segs[0], names[0]); !ok {
+		return false
+	}
+	return matchSegs(segs[1:], names[1:])
+}
+
+//ignoreDir holds the rules of the ignore files in a directory.
+type ignoreDir struct {
+	dir   string
+	rules []ignoreRule
+}
+
+//ignores keeps the rules of the ignore files of every directory the walk is in.
+//Only the walking goroutine uses it.
+type ignores struct {
+	sw    *Skywalker
+	root  string
+	sep   string
+	stack []ignoreDir
+}
+
+func (sw *Skywalker) newIgnores(root string) *ignores {
+	if len(sw.IgnoreFiles) == 0 {
+		return nil
+	}
+	ig := &ignores{sw: sw, root: root, sep: string(filepath.Separator)}
+	if sw.FS != nil {
+		ig.sep = "/"
+	}
+	return ig
+}
+
+//track wraps walkFn so the ignore files of every directory the walk goes into are read before anything in it is.
+func (ig *ignores) track(walkFn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		ig.leaveUntil(path)
+		ret := walkFn(path, info, err)
+		if ret == nil && err == nil && info.IsDir() {
+			ig.enter(path)
+		}
+		return ret
+	}
+}
+
+func (ig *ignores) inside(dir, path string) bool {
+	if ig.sep == "/" && dir == "." {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, ig.sep)+ig.sep)
+}
+
+func (ig *ignores) leaveUntil(path string) {
+	for len(ig.stack) > 0 && !ig.inside(ig.stack[len(ig.stack)-1].dir, path) {
+		ig.stack = ig.stack[:len(ig.stack)-1]
+	}
+}
+
+//enter reads the ignore files in dir.
+func (ig *ignores) enter(dir string) {
+	var rules []ignoreRule
+	for _, name := range ig.sw.IgnoreFiles {
+		var file string
+		var data []byte
+		var err error
+		if ig.sw.FS != nil {
+			file = pathpkg.Join(dir, name)
+			data, err = iofs.ReadFile(ig.sw.FS, file)
+		} else {
+			file = filepath.Join(dir, name)
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			if !os.IsNotExist(err) {
+				ig.sw.emit(Event{Kind: EKError, Path: file, Root: ig.root, Err: err})
+			}
+			continue
+		}
+		rules = append(rules, parseIgnore(data)...)
+	}
+	if len(rules) > 0 {
+		ig.stack = append(ig.stack, ignoreDir{dir: dir, rules: rules})
+	}
+}
+
+//enterAbove reads the ignore files of every directory from the root down to the one path is in, as if the walk
+//just got to path. It returns false if one of the directories is ignored.
+func (ig *ignores) enterAbove(path string) bool {
+	var dirs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == ig.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] != ig.root && ig.ignored(dirs[i], true) {
+			return false
+		}
+		ig.enter(dirs[i])
+	}
+	return true
+}
+
+//ignored reports whether path is ignored by the ignore files of the directories it is in.
+//The deepest directory with a matching pattern decides and within it the last matching pattern does.
+func (ig *ignores) ignored(path string, isDir bool) bool {
+	for i := len(ig.stack) - 1; i >= 0; i-- {
+		d := ig.stack[i]
+		if !ig.inside(d.dir, path) {
+			continue
+		}
+		rel := path
+		if !(ig.sep == "/" && d.dir == ".") {
+			rel = strings.TrimPrefix(path, strings.TrimSuffix(d.dir, ig.sep)+ig.sep)
+		}
+		rel = filepath.ToSlash(rel)
+		for j := len(d.rules) - 1; j >= 0; j-- {
+			if d.rules[j].match(rel, isDir) {
+				return !d.rules[j].negate
+			}
+		}
+	}
+	return false
+}
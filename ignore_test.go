@@ -0,0 +1,61 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreFiles(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		".gitignore":      "# build output\n*.log\n!keep.log\nbuild/\n/top.txt\ndocs/**/*.tmp\n\\#hash.txt\ntrailing.txt   \n",
+		"top.txt":         "",
+		"trailing.txt":    "",
+		"#hash.txt":       "",
+		"a.log":           "",
+		"keep.log":        "",
+		"build/out.bin":   "",
+		"docs/readme.md":  "",
+		"docs/x.tmp":      "",
+		"docs/a/b/y.tmp":  "",
+		"sub/.gitignore":  "!a.log\n*.md\n",
+		"sub/top.txt":     "",
+		"sub/build":       "",
+		"sub/a.log":       "",
+		"sub/b.log":       "",
+		"sub/notes.md":    "",
+		"sub/deep/c.md":   "",
+		"sub/deep/ok.txt": "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.IgnoreFiles = []string{".gitignore"}
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RIgnored {
+			rel, _ := filepath.Rel(ev.Root, ev.Path)
+			skipped = append(skipped, filepath.ToSlash(rel))
+		}
+	}
+	assert.NoError(sw.Walk())
+	var found []string
+	for path := range tw.found {
+		rel, _ := filepath.Rel(sw.Root, path)
+		found = append(found, filepath.ToSlash(rel))
+	}
+	sort.Strings(found)
+	assert.Equal([]string{
+		".gitignore", "docs/readme.md", "keep.log",
+		"sub/.gitignore", "sub/a.log", "sub/build", "sub/deep/ok.txt", "sub/top.txt",
+	}, found)
+	assert.Equal([]string{"build"}, skipped)
+}
@@ -0,0 +1,49 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type InfoWorker struct {
+	*sync.Mutex
+	sizes map[string]int64
+	work  int
+}
+
+func (iw *InfoWorker) Work(path string) {
+	iw.Lock()
+	defer iw.Unlock()
+	iw.work++
+}
+
+func (iw *InfoWorker) WorkInfo(path string, info os.FileInfo) {
+	iw.Lock()
+	defer iw.Unlock()
+	iw.sizes[filepath.Base(path)] = info.Size()
+}
+
+func TestInfoWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+		"empty":     "",
+	})
+	iw := &InfoWorker{Mutex: new(sync.Mutex), sizes: make(map[string]int64)}
+	var _ skywalker.InfoWorker = iw
+	sw := skywalker.New(tmp, iw)
+	assert.NoError(sw.Walk())
+	assert.Equal(map[string]int64{"a.txt": 3, "b.txt": 2, "empty": 0}, iw.sizes)
+	assert.Equal(0, iw.work)
+}
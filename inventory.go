@@ -0,0 +1,157 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+//nopWorker stands in for the Worker of an Inventory, which does not need one.
+type nopWorker struct{}
+
+func (nopWorker) Work(string) {}
+
+//Inventory is InventoryContext with context.Background().
+func (sw *Skywalker) Inventory(fn func(WorkItem) error) error {
+	return sw.InventoryContext(context.Background(), fn)
+}
+
+//InventoryContext walks like WalkContext but without a Worker, handing fn a WorkItem for every path a walk
+//would queue. Stating the paths is all the work there is, so NumWorkers goroutines do it while the walk goes
+//on and the Info of every WorkItem is already stated, making it the fastest way to list a tree with its metadata.
+//fn is called concurrently so make sure it is thread safe. If it returns an error the walk stops and
+//InventoryContext returns it. Worker is not used and can be nil. ProgressFile, Known, Fingerprints and
+//everything else the workers would do are not used either. Events and Stats work like they do for a walk.
+func (sw *Skywalker) InventoryContext(ctx context.Context, fn func(WorkItem) error) error {
+	if sw.Worker == nil {
+		sw.Worker = nopWorker{}
+		defer func() { sw.Worker = nil }()
+	}
+	if err := sw.init(); err != nil {
+		return err
+	}
+	sw.progress = nil
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sw.ctx = ctx
+	defer func() { sw.ctx = nil }()
+	sw.resetCanceled()
+	sw.recorder = nil
+	if sw.Record != nil {
+		sw.recorder = &recorder{enc: json.NewEncoder(sw.Record)}
+	}
+	started := time.Now()
+	sw.emit(Event{Kind: EKStart, Root: sw.Root})
+	err := sw.inventory(fn, cancel)
+	if err == nil && sw.recorder != nil {
+		err = sw.recorder.err
+	}
+	sw.emit(Event{Kind: EKFinish, Root: sw.Root, Err: err, Duration: time.Since(started)})
+	return err
+}
+
+//InventoryAll returns a WorkItem for every path a walk would queue, sorted by path. See InventoryContext.
+func (sw *Skywalker) InventoryAll() ([]WorkItem, error) {
+	var mutex sync.Mutex
+	var items []WorkItem
+	err := sw.Inventory(func(wi WorkItem) error {
+		mutex.Lock()
+		items = append(items, wi)
+		mutex.Unlock()
+		return nil
+	})
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	return items, err
+}
+
+//Collect is CollectContext with context.Background().
+func (sw *Skywalker) Collect() ([]string, error) {
+	return sw.CollectContext(context.Background())
+}
+
+//CollectContext is a dry run of WalkContext. It returns every path the walk would hand the Worker, sorted,
+//without calling it, e.g. to preview what a Worker deleting or re-encoding files would touch. Every filter
+//is applied, but Known is not as it is checked by the workers. See InventoryContext.
+func (sw *Skywalker) CollectContext(ctx context.Context) ([]string, error) {
+	var mutex sync.Mutex
+	var paths []string
+	err := sw.InventoryContext(ctx, func(wi WorkItem) error {
+		mutex.Lock()
+		paths = append(paths, wi.Path)
+		mutex.Unlock()
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
+
+//inventory does the walk InventoryContext describes once everything is initialized.
+func (sw *Skywalker) inventory(fn func(WorkItem) error, cancel context.CancelFunc) error {
+	for _, layer := range sw.layers {
+		if _, err := sw.lstat(layer.root); err != nil {
+			return rootError(layer.root, err)
+		}
+	}
+	sw.startStats()
+	var fnErr error
+	var fnOnce sync.Once
+	items := make(chan item, sw.QueueSize)
+	wg := new(sync.WaitGroup)
+	wg.Add(sw.NumWorkers)
+	for i := 0; i < sw.NumWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for w := range items {
+				if sw.ctxErr() == nil {
+					if ei, ok := w.info.(*entryInfo); ok {
+						if info := ei.stat(); info != nil {
+							w.info = info
+						}
+					}
+					if err := fn(WorkItem{Path: w.path, Info: w.info, Root: w.root, Labels: sw.Labels}); err != nil {
+						fnOnce.Do(func() {
+							fnErr = err
+							cancel()
+						})
+					}
+				}
+				sw.countDone(w)
+			}
+		}()
+	}
+	dispatch := sw.countQueued(func(w item) {
+		items <- w
+	})
+	if sw.EnterDir != nil || sw.LeaveDir != nil {
+		runtime.LockOSThread()
+	}
+	err := sw.walkLayers(dispatch)
+	if sw.EnterDir != nil || sw.LeaveDir != nil {
+		runtime.UnlockOSThread()
+	}
+	if sw.segments != nil {
+		sw.segments.done()
+	}
+	if sw.top != nil {
+		sw.top.flush()
+	}
+	close(items)
+	wg.Wait()
+	sw.stopStats()
+	if fnErr != nil {
+		return fnErr
+	}
+	if err == nil {
+		err = sw.ctxErr()
+	}
+	return err
+}
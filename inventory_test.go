@@ -0,0 +1,76 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventory(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+		"sub/c.log": "c",
+	})
+	sw := skywalker.New(tmp, nil)
+	sw.ExtList = []string{".log"}
+	items, err := sw.InventoryAll()
+	assert.NoError(err)
+	assert.Nil(sw.Worker)
+	if assert.Len(items, 2) {
+		assert.Equal(filepath.Join(sw.Root, "a.txt"), items[0].Path)
+		assert.Equal(int64(3), items[0].Info.Size())
+		assert.Equal(filepath.Join(sw.Root, "sub", "b.txt"), items[1].Path)
+		assert.Equal(int64(2), items[1].Info.Size())
+		assert.Equal(sw.Root, items[1].Root)
+	}
+	stats := sw.Stats()
+	assert.Equal(int64(2), stats.Files)
+	assert.Equal(int64(2), stats.Done)
+
+	errStop := errors.New("stop")
+	sw.NumWorkers = 1
+	calls := 0
+	err = sw.Inventory(func(skywalker.WorkItem) error {
+		calls++
+		return errStop
+	})
+	assert.Equal(errStop, err)
+	assert.Equal(1, calls)
+}
+
+func TestCollect(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"b.txt":      "",
+		"a.txt":      "",
+		"sub/c.txt":  "",
+		"skip/d.txt": "",
+		"sub/e.jpeg": "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.DirList = []string{"skip"}
+	sw.ExtList = []string{".txt"}
+	sw.ExtListType = skywalker.LTWhitelist
+	sw.FilesOnly = true
+	paths, err := sw.Collect()
+	assert.NoError(err)
+	assert.Equal([]string{
+		filepath.Join(sw.Root, "a.txt"),
+		filepath.Join(sw.Root, "b.txt"),
+		filepath.Join(sw.Root, "sub", "c.txt"),
+	}, paths)
+	assert.Empty(tw.found)
+	assert.Equal(tw, sw.Worker)
+}
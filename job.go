@@ -0,0 +1,315 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Job is a walk described as data, so platform teams can keep file-maintenance jobs in configuration
+//rather than code and run them with RunJob. Loaded from JSON it looks like:
+//
+//	{
+//		"name": "archive-logs",
+//		"roots": ["/var/log/app", "/var/log/web"],
+//		"filter": "ext(.log) && age>30d",
+//		"exclude_dirs": ["current"],
+//		"action": "move",
+//		"args": {"dest": "/archive/logs"},
+//		"workers": 4,
+//		"schedule": "0 3 * * *",
+//		"overlap": "skip",
+//		"outputs": [{"kind": "summary", "path": "/var/log/archive-logs.txt"}]
+//	}
+type Job struct {
+	//Name identifies the job. It is handed to the walks as the "job" label.
+	Name string `json:"name"`
+	//Roots are walked one after the other with the same filters and action.
+	Roots []string `json:"roots"`
+
+	//Filter, ExcludeDirs, SkipHidden and MaxDepth are the Filter, DirList blacklist, SkipHidden and MaxDepth
+	//of the walks. Only files are handed to the action.
+	Filter      string   `json:"filter,omitempty"`
+	ExcludeDirs []string `json:"exclude_dirs,omitempty"`
+	SkipHidden  bool     `json:"skip_hidden,omitempty"`
+	MaxDepth    int      `json:"max_depth,omitempty"`
+
+	//Action is the name of the registered action creating the Worker, see RegisterAction, and Args what it
+	//is created with.
+	Action string            `json:"action"`
+	Args   map[string]string `json:"args,omitempty"`
+
+	//Workers and RateLimit are the NumWorkers and RateLimit of the walks. Workers defaults to what New uses.
+	Workers   int     `json:"workers,omitempty"`
+	RateLimit float64 `json:"rate_limit,omitempty"`
+
+	//Schedule is when a Scheduler runs the job, as a cron expression like "0 3 * * *" for 3am every day,
+	//see ParseCron. RunJob ignores it.
+	Schedule string `json:"schedule,omitempty"`
+	//Overlap is what a Scheduler does when the job is due while it still runs: "skip" the run, the default,
+	//or "queue" it to start once the running one is done. Runs due while one is queued are merged with it.
+	Overlap string `json:"overlap,omitempty"`
+
+	//Outputs are where the runs of the job are reported.
+	Outputs []JobOutput `json:"outputs,omitempty"`
+}
+
+//JobOutput is a file a run of a Job is reported to. It is created, or truncated, every run.
+type JobOutput struct {
+	//Kind is what is written: "events" for an EventStream, "record" for the Record of every path
+	//or "summary" for the Summary report of every root.
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
+
+//JobReport is how a run of a Job went.
+type JobReport struct {
+	Name     string
+	Started  time.Time
+	Duration time.Duration
+	//Roots are the roots walked, in the order of Job.Roots. Roots left after one failed are still walked,
+	//but not once the run's context is done.
+	Roots []RootReport
+	//Err is the first error of the run: the Job being invalid, a root failing or an output that could not be written.
+	Err error
+}
+
+//RootReport is how the walk of one root of a Job went.
+type RootReport struct {
+	Root  string
+	Stats Stats
+	Err   error
+}
+
+//ActionFunc creates the Worker of a Job for root from the job's Args.
+type ActionFunc func(root string, args map[string]string) (Worker, error)
+
+var (
+	actionMutex sync.RWMutex
+	actions     = map[string]ActionFunc{
+		"move":    destAction(func(root, dest string) Worker { return NewMoveWorker(root, dest) }),
+		"copy":    destAction(func(root, dest string) Worker { return NewCopyWorker(root, dest) }),
+		"cas":     destAction(func(root, dest string) Worker { return NewCASWorker(root, dest) }),
+		"extract": destAction(func(root, dest string) Worker { return NewExtractWorker(root, dest) }),
+		"replace": replaceAction,
+	}
+)
+
+//destAction is an action for a worker taking the directory in the dest arg.
+func destAction(newWorker func(root, dest string) Worker) ActionFunc {
+	return func(root string, args map[string]string) (Worker, error) {
+		if args["dest"] == "" {
+			return nil, &ConfigError{Field: "Args", Msg: "must hold dest"}
+		}
+		return newWorker(root, args["dest"]), nil
+	}
+}
+
+//replaceAction is a ReplaceWorker replacing the regular expression in the pattern arg with the replacement arg.
+func replaceAction(_ string, args map[string]string) (Worker, error) {
+	if args["pattern"] == "" {
+		return nil, &ConfigError{Field: "Args", Msg: "must hold pattern"}
+	}
+	re, err := regexp.Compile(args["pattern"])
+	if err != nil {
+		return nil, &RegexCompileError{Pattern: args["pattern"], Err: err}
+	}
+	return NewReplaceWorker(re, args["replacement"]), nil
+}
+
+//RegisterAction makes newWorker available to Jobs as name, replacing anything registered as name before.
+//move, copy, cas and extract, taking the directory in the dest arg, and replace, taking the pattern and
+//replacement args, are registered already. Names are case insensitive. It is safe to call concurrently.
+func RegisterAction(name string, newWorker ActionFunc) {
+	actionMutex.Lock()
+	defer actionMutex.Unlock()
+	actions[strings.ToLower(name)] = newWorker
+}
+
+//LookupAction returns the action registered as name. It returns an UnknownActionError if nothing is registered as name.
+func LookupAction(name string) (ActionFunc, error) {
+	actionMutex.RLock()
+	defer actionMutex.RUnlock()
+	newWorker, ok := actions[strings.ToLower(name)]
+	if !ok {
+		return nil, &UnknownActionError{Name: name}
+	}
+	return newWorker, nil
+}
+
+//ActionNames returns the name of every registered action, sorted.
+func ActionNames() []string {
+	actionMutex.RLock()
+	defer actionMutex.RUnlock()
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//LoadJobs reads the JSON array of Jobs in the file at path. It returns a JobError for the first Job that is not valid.
+func LoadJobs(path string) ([]Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	if err = json.Unmarshal(data, &jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if err = job.Validate(); err != nil {
+			return nil, &JobError{Job: job.Name, Err: err}
+		}
+	}
+	return jobs, nil
+}
+
+//Validate returns a ConfigError naming the field of the Job that is not valid, a CronSyntaxError or an UnknownActionError.
+func (job Job) Validate() error {
+	switch {
+	case job.Name == "":
+		return &ConfigError{Field: "Name", Msg: "must be set"}
+	case len(job.Roots) == 0:
+		return &ConfigError{Field: "Roots", Msg: "must hold at least one root"}
+	case job.Workers < 0:
+		return &ConfigError{Field: "Workers", Msg: "must not be negative"}
+	case job.MaxDepth < 0:
+		return &ConfigError{Field: "MaxDepth", Msg: "must not be negative"}
+	case job.RateLimit < 0:
+		return &ConfigError{Field: "RateLimit", Msg: "must not be negative"}
+	case job.Overlap != "" && job.Overlap != "skip" && job.Overlap != "queue":
+		return &ConfigError{Field: "Overlap", Msg: "must be skip or queue"}
+	}
+	if job.Schedule != "" {
+		if _, err := ParseCron(job.Schedule); err != nil {
+			return err
+		}
+	}
+	for _, out := range job.Outputs {
+		switch {
+		case out.Kind != "events" && out.Kind != "record" && out.Kind != "summary":
+			return &ConfigError{Field: "Outputs", Msg: "kind must be events, record or summary"}
+		case out.Path == "":
+			return &ConfigError{Field: "Outputs", Msg: "path must be set"}
+		}
+	}
+	_, err := LookupAction(job.Action)
+	return err
+}
+
+//RunJob validates job and walks its roots, handing every file that passes its filters to the Worker its action
+//creates, one per root. The walks stop once ctx is done.
+func RunJob(ctx context.Context, job Job) (report JobReport) {
+	report = JobReport{Name: job.Name, Started: time.Now()}
+	defer func() {
+		report.Duration = time.Since(report.Started)
+	}()
+	if report.Err = job.Validate(); report.Err != nil {
+		return report
+	}
+	newWorker, _ := LookupAction(job.Action)
+	outs := make([]*os.File, len(job.Outputs))
+	for i, out := range job.Outputs {
+		file, err := os.Create(out.Path)
+		if err != nil {
+			report.Err = err
+			break
+		}
+		outs[i] = file
+	}
+	defer func() {
+		for _, file := range outs {
+			if file == nil {
+				continue
+			}
+			if err := file.Close(); err != nil && report.Err == nil {
+				report.Err = err
+			}
+		}
+	}()
+	if report.Err != nil {
+		return report
+	}
+	for _, root := range job.Roots {
+		if ctx.Err() != nil {
+			break
+		}
+		rr := job.runRoot(ctx, root, newWorker, outs)
+		report.Roots = append(report.Roots, rr)
+		if rr.Err != nil && report.Err == nil {
+			report.Err = rr.Err
+		}
+	}
+	return report
+}
+
+//runRoot walks root for the job, reporting to the files opened for its Outputs.
+func (job Job) runRoot(ctx context.Context, root string, newWorker ActionFunc, outs []*os.File) RootReport {
+	rr := RootReport{Root: root}
+	worker, err := newWorker(root, job.Args)
+	if err != nil {
+		rr.Err = err
+		return rr
+	}
+	sw := New(root, worker)
+	sw.Filter = job.Filter
+	sw.DirList = job.ExcludeDirs
+	sw.SkipHidden = job.SkipHidden
+	sw.MaxDepth = job.MaxDepth
+	sw.RateLimit = job.RateLimit
+	if job.Workers > 0 {
+		sw.NumWorkers = job.Workers
+	}
+	sw.Labels = map[string]string{"job": job.Name}
+	var handlers []func(Event)
+	var streams []*EventStream
+	summaries := make(map[io.Writer]*Summary)
+	for i, out := range job.Outputs {
+		switch out.Kind {
+		case "events":
+			es := NewEventStream(outs[i])
+			streams = append(streams, es)
+			handlers = append(handlers, es.Handle)
+		case "record":
+			sw.Record = outs[i]
+		case "summary":
+			s := NewSummary()
+			summaries[outs[i]] = s
+			handlers = append(handlers, s.Handle)
+		}
+	}
+	if len(handlers) > 0 {
+		sw.OnEvent = func(ev Event) {
+			for _, handle := range handlers {
+				handle(ev)
+			}
+		}
+	}
+	rr.Err = sw.WalkContext(ctx)
+	rr.Stats = sw.Stats()
+	for _, es := range streams {
+		es.Close()
+		if err := es.Err(); err != nil && rr.Err == nil {
+			rr.Err = err
+		}
+	}
+	for w, s := range summaries {
+		if _, err := io.WriteString(w, s.Report(rr.Stats)); err != nil && rr.Err == nil {
+			rr.Err = err
+		}
+	}
+	return rr
+}
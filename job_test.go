@@ -0,0 +1,98 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunJob(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"app/a.log":         "a",
+		"app/a.txt":         "a",
+		"app/current/b.log": "b",
+		"web/c.log":         "c",
+	})
+	dest := filepath.Join(tmp, "archive")
+	out := t.TempDir()
+	spec := `[{
+		"name": "archive-logs",
+		"roots": [` + quote(filepath.Join(tmp, "app")) + `, ` + quote(filepath.Join(tmp, "web")) + `],
+		"filter": "ext(.log)",
+		"exclude_dirs": ["current"],
+		"action": "MOVE",
+		"args": {"dest": ` + quote(dest) + `},
+		"workers": 2,
+		"outputs": [
+			{"kind": "summary", "path": ` + quote(filepath.Join(out, "summary.txt")) + `},
+			{"kind": "events", "path": ` + quote(filepath.Join(out, "events.jsonl")) + `}
+		]
+	}]`
+	assert.NoError(os.WriteFile(filepath.Join(out, "jobs.json"), []byte(spec), 0666))
+	jobs, err := skywalker.LoadJobs(filepath.Join(out, "jobs.json"))
+	if !assert.NoError(err) || !assert.Len(jobs, 1) {
+		return
+	}
+
+	report := skywalker.RunJob(context.Background(), jobs[0])
+	assert.NoError(report.Err)
+	assert.Equal("archive-logs", report.Name)
+	if assert.Len(report.Roots, 2) {
+		assert.Equal(int64(1), report.Roots[0].Stats.Files)
+		assert.Equal(int64(1), report.Roots[1].Stats.Files)
+	}
+	assert.FileExists(filepath.Join(dest, "a.log"))
+	assert.FileExists(filepath.Join(dest, "c.log"))
+	assert.FileExists(filepath.Join(tmp, "app", "a.txt"))
+	assert.FileExists(filepath.Join(tmp, "app", "current", "b.log"))
+
+	summary, err := os.ReadFile(filepath.Join(out, "summary.txt"))
+	assert.NoError(err)
+	assert.Equal(2, strings.Count(string(summary), "Walked"))
+	events, err := os.ReadFile(filepath.Join(out, "events.jsonl"))
+	assert.NoError(err)
+	assert.Contains(string(events), `"job":"archive-logs"`)
+}
+
+func TestJobValidate(t *testing.T) {
+	assert := assert.New(t)
+	job := skywalker.Job{Name: "j", Roots: []string{t.TempDir()}, Action: "shred"}
+	var ue *skywalker.UnknownActionError
+	assert.ErrorAs(job.Validate(), &ue)
+
+	skywalker.RegisterAction("count", func(string, map[string]string) (skywalker.Worker, error) {
+		return NewTW(), nil
+	})
+	assert.Contains(skywalker.ActionNames(), "count")
+	job.Action = "count"
+	assert.NoError(job.Validate())
+
+	job.Outputs = []skywalker.JobOutput{{Kind: "tweet", Path: "x"}}
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(skywalker.RunJob(context.Background(), job).Err, &ce) {
+		assert.Equal("Outputs", ce.Field)
+	}
+
+	job.Outputs = nil
+	job.Action = "copy"
+	report := skywalker.RunJob(context.Background(), job)
+	if assert.ErrorAs(report.Err, &ce) {
+		assert.Equal("Args", ce.Field)
+	}
+}
+
+//quote returns s as a JSON string.
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `\`, `\\`) + `"`
+}
@@ -0,0 +1,144 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//ErrNoSnapshot is returned by a SnapshotStore when there is no snapshot for the time asked for.
+var ErrNoSnapshot = errors.New("no snapshot at or before that time")
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json"
+	snapshotLayout = "20060102T150405.000000000Z"
+)
+
+//SnapshotStore keeps TreeSnapshots of the same tree in Dir, one file per snapshot named after when it was taken.
+//Together with a TreeRecorder that has a Hash it answers questions like "what changed since last Tuesday".
+type SnapshotStore struct {
+	Dir string
+}
+
+//NewSnapshotStore creates a SnapshotStore in dir, creating dir if needed.
+func NewSnapshotStore(dir string) (*SnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	return &SnapshotStore{Dir: dir}, nil
+}
+
+func (ss *SnapshotStore) path(at time.Time) string {
+	return filepath.Join(ss.Dir, snapshotPrefix+at.UTC().Format(snapshotLayout)+snapshotSuffix)
+}
+
+//Save stores ts as taken at at. A snapshot already stored for the exact same time is replaced.
+func (ss *SnapshotStore) Save(ts TreeSnapshot, at time.Time) error {
+	return ts.Save(ss.path(at))
+}
+
+//Times returns when every stored snapshot was taken, oldest first.
+func (ss *SnapshotStore) Times() ([]time.Time, error) {
+	entries, err := os.ReadDir(ss.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var times []time.Time
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+		at, err := time.Parse(snapshotLayout, strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix))
+		if err != nil {
+			continue
+		}
+		times = append(times, at)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times, nil
+}
+
+//At returns the latest snapshot taken at or before at and when it was taken.
+//It returns ErrNoSnapshot if every snapshot is newer.
+func (ss *SnapshotStore) At(at time.Time) (TreeSnapshot, time.Time, error) {
+	times, err := ss.Times()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	i := sort.Search(len(times), func(i int) bool { return times[i].After(at) })
+	if i == 0 {
+		return nil, time.Time{}, ErrNoSnapshot
+	}
+	ts, err := LoadTreeSnapshot(ss.path(times[i-1]))
+	return ts, times[i-1], err
+}
+
+//Latest returns the newest snapshot and when it was taken.
+//It returns ErrNoSnapshot if there are none.
+func (ss *SnapshotStore) Latest() (TreeSnapshot, time.Time, error) {
+	times, err := ss.Times()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(times) == 0 {
+		return nil, time.Time{}, ErrNoSnapshot
+	}
+	at := times[len(times)-1]
+	ts, err := LoadTreeSnapshot(ss.path(at))
+	return ts, at, err
+}
+
+//Journal returns what changed between the snapshots in effect at from and at to, sorted by path.
+//Each is the latest snapshot taken at or before the time given. If no snapshot is that old for from,
+//everything in the snapshot for to is reported as added.
+func (ss *SnapshotStore) Journal(from, to time.Time) ([]Change, error) {
+	older, _, err := ss.At(from)
+	if err == ErrNoSnapshot {
+		older, err = TreeSnapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	newer, _, err := ss.At(to)
+	if err != nil {
+		return nil, err
+	}
+	return newer.Diff(older), nil
+}
+
+//Since returns what changed between the snapshot in effect at since and the latest one.
+func (ss *SnapshotStore) Since(since time.Time) ([]Change, error) {
+	_, latest, err := ss.Latest()
+	if err != nil {
+		return nil, err
+	}
+	return ss.Journal(since, latest)
+}
+
+//Prune removes every snapshot taken before before except the newest of them,
+//so the journal can still go back as far as before.
+func (ss *SnapshotStore) Prune(before time.Time) error {
+	times, err := ss.Times()
+	if err != nil {
+		return err
+	}
+	i := sort.Search(len(times), func(i int) bool { return !times[i].Before(before) })
+	if i == 0 {
+		return nil
+	}
+	for _, at := range times[:i-1] {
+		if err = os.Remove(ss.path(at)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
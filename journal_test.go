@@ -0,0 +1,78 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotJournal(t *testing.T) {
+	assert := assert.New(t)
+	tmp, dir := t.TempDir(), t.TempDir()
+	store, err := skywalker.NewSnapshotStore(dir)
+	assert.NoError(err)
+	record := func(at time.Time) {
+		tr := skywalker.NewTreeRecorder(tmp)
+		tr.Hash = sha256.New
+		assert.NoError(skywalker.New(tmp, tr).Walk())
+		assert.NoError(store.Save(tr.Snapshot(), at))
+	}
+	monday := time.Date(2017, 3, 6, 12, 0, 0, 0, time.UTC)
+	tuesday, wednesday := monday.AddDate(0, 0, 1), monday.AddDate(0, 0, 2)
+
+	writeFiles(t, tmp, map[string]string{"a.txt": "a", "b.txt": "b", "touched.txt": "same"})
+	record(monday)
+	writeFiles(t, tmp, map[string]string{"a.txt": "changed", "c.txt": "c"})
+	later := time.Now().Add(time.Hour)
+	assert.NoError(os.Chtimes(filepath.Join(tmp, "touched.txt"), later, later))
+	record(tuesday)
+	assert.NoError(os.Remove(filepath.Join(tmp, "b.txt")))
+	//Copied and removed instead of renamed so only the hash can tell.
+	writeFiles(t, tmp, map[string]string{"moved/c.txt": "c"})
+	assert.NoError(os.Remove(filepath.Join(tmp, "c.txt")))
+	record(wednesday)
+
+	times, err := store.Times()
+	assert.NoError(err)
+	assert.Equal([]time.Time{monday, tuesday, wednesday}, times)
+
+	kinds := func(changes []skywalker.Change) map[string]skywalker.ChangeKind {
+		found := make(map[string]skywalker.ChangeKind)
+		for _, c := range changes {
+			found[c.Path] = c.Kind
+		}
+		return found
+	}
+	changes, err := store.Journal(monday, tuesday.Add(time.Hour))
+	assert.NoError(err)
+	assert.Equal(map[string]skywalker.ChangeKind{"a.txt": skywalker.CKModified, "c.txt": skywalker.CKAdded}, kinds(changes))
+	for _, c := range changes {
+		if c.Kind == skywalker.CKModified {
+			assert.NotEqual(c.Old.Hash, c.New.Hash)
+		}
+	}
+
+	changes, err = store.Since(tuesday)
+	assert.NoError(err)
+	assert.Equal(map[string]skywalker.ChangeKind{"b.txt": skywalker.CKRemoved, "moved/c.txt": skywalker.CKRenamed}, kinds(changes))
+
+	_, err = store.Journal(monday, monday.Add(-time.Hour))
+	assert.Equal(skywalker.ErrNoSnapshot, err)
+	changes, err = store.Journal(monday.Add(-time.Hour), monday)
+	assert.NoError(err)
+	assert.Len(changes, 3)
+
+	assert.NoError(store.Prune(wednesday))
+	times, err = store.Times()
+	assert.NoError(err)
+	assert.Equal([]time.Time{tuesday, wednesday}, times)
+}
@@ -0,0 +1,162 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//QuickHashBlock is how many bytes QuickHash reads from the start and from the end of a file.
+const QuickHashBlock = 64 * 1024
+
+//QuickHash returns a cheap fingerprint of the file at path, the hex encoded SHA-256 of its size and its first and
+//last QuickHashBlock bytes. Files of the same size that only differ in the middle share it, so it is meant for
+//skipping files that are known to be harmless, not for proving two files are the same.
+func QuickHash(path string) (string, error) {
+	file, err := Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return quickHash(file)
+}
+
+func quickHash(file fs.File) (string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+	h := sha256.New()
+	var sizeBuf [8]byte
+	binary.BigEndian.PutUint64(sizeBuf[:], uint64(size))
+	h.Write(sizeBuf[:])
+	if _, err = io.CopyN(h, file, QuickHashBlock); err != nil && err != io.EOF {
+		return "", err
+	}
+	if size > QuickHashBlock {
+		//The last block never overlaps the first.
+		tail := size - QuickHashBlock
+		if tail > QuickHashBlock {
+			tail = QuickHashBlock
+		}
+		if err = skipTo(file, size-tail, QuickHashBlock); err != nil {
+			return "", err
+		}
+		if _, err = io.CopyN(h, file, tail); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//skipTo moves file from offset at to offset to, reading through it if it can not seek.
+func skipTo(file fs.File, to, at int64) error {
+	if seeker, ok := file.(io.Seeker); ok {
+		_, err := seeker.Seek(to, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, file, to-at)
+	return err
+}
+
+//KnownSet is a set of QuickHashes of known files, e.g. the files of a clean operating system install or a
+//reference set like the NSRL. Files in it are not handed to the Worker of a Skywalker with Known.
+//It is safe to use concurrently.
+type KnownSet struct {
+	mutex sync.RWMutex
+	sums  map[string]struct{}
+}
+
+//NewKnownSet creates an empty KnownSet.
+func NewKnownSet() *KnownSet {
+	return &KnownSet{sums: make(map[string]struct{})}
+}
+
+//ReadKnownSet reads a KnownSet with a QuickHash on every line. Empty lines and lines starting with # are left out.
+func ReadKnownSet(r io.Reader) (*KnownSet, error) {
+	ks := NewKnownSet()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		ks.Add(line)
+	}
+	return ks, scanner.Err()
+}
+
+//Add adds a QuickHash to the set.
+func (ks *KnownSet) Add(sum string) {
+	ks.mutex.Lock()
+	ks.sums[strings.ToLower(sum)] = struct{}{}
+	ks.mutex.Unlock()
+}
+
+//AddFile adds the QuickHash of the file at path to the set.
+func (ks *KnownSet) AddFile(path string) error {
+	sum, err := QuickHash(path)
+	if err != nil {
+		return err
+	}
+	ks.Add(sum)
+	return nil
+}
+
+//Contains reports whether the QuickHash is in the set.
+func (ks *KnownSet) Contains(sum string) bool {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+	_, ok := ks.sums[strings.ToLower(sum)]
+	return ok
+}
+
+//Len returns how many QuickHashes are in the set.
+func (ks *KnownSet) Len() int {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+	return len(ks.sums)
+}
+
+//WriteTo writes the set in the format ReadKnownSet reads, sorted.
+func (ks *KnownSet) WriteTo(w io.Writer) (int64, error) {
+	ks.mutex.RLock()
+	sums := make([]string, 0, len(ks.sums))
+	for sum := range ks.sums {
+		sums = append(sums, sum)
+	}
+	ks.mutex.RUnlock()
+	sort.Strings(sums)
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, sum := range sums {
+		m, err := bw.WriteString(sum + "\n")
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+//known reports whether the file at path is in the Known set. Files that can not be read are not known.
+func (sw *Skywalker) known(path string) bool {
+	file, err := sw.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	sum, err := quickHash(file)
+	return err == nil && sw.Known.Contains(sum)
+}
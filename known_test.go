@@ -0,0 +1,62 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnownSet(t *testing.T) {
+	assert := assert.New(t)
+	big := strings.Repeat("a", 3*skywalker.QuickHashBlock)
+	middle := []byte(big)
+	middle[len(middle)/2] = 'b'
+	ref, tmp := t.TempDir(), t.TempDir()
+	writeFiles(t, ref, map[string]string{
+		"kernel.sys": "known",
+		"big.bin":    big,
+	})
+	writeFiles(t, tmp, map[string]string{
+		"windows/kernel.sys": "known",
+		"windows/big.bin":    string(middle),
+		"users/evil.exe":     "unknown",
+		"users/big.bin":      big[1:],
+	})
+
+	ks := skywalker.NewKnownSet()
+	assert.NoError(ks.AddFile(filepath.Join(ref, "kernel.sys")))
+	assert.NoError(ks.AddFile(filepath.Join(ref, "big.bin")))
+	var buf bytes.Buffer
+	_, err := ks.WriteTo(&buf)
+	assert.NoError(err)
+	ks, err = skywalker.ReadKnownSet(strings.NewReader("# reference set\n" + strings.ToUpper(buf.String())))
+	assert.NoError(err)
+	assert.Equal(2, ks.Len())
+
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.Known = ks
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RKnown {
+			tw.Lock()
+			skipped = append(skipped, ev.Path)
+			tw.Unlock()
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Len(tw.found, 2)
+	for _, rel := range []string{"users/evil.exe", "users/big.bin"} {
+		_, ok := tw.found[filepath.Join(sw.Root, rel)]
+		assert.True(ok, "Expected %s", rel)
+	}
+	assert.Len(skipped, 2)
+}
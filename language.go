@@ -0,0 +1,127 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//languageExts maps lower case extensions to the language of files that have them.
+var languageExts = map[string]string{
+	".go": "Go", ".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".cxx": "C++", ".hpp": "C++", ".hh": "C++",
+	".cs": "C#", ".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin", ".scala": "Scala", ".swift": "Swift",
+	".m": "Objective-C", ".mm": "Objective-C++", ".rs": "Rust", ".zig": "Zig", ".d": "D",
+	".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript", ".jsx": "JavaScript", ".ts": "TypeScript", ".tsx": "TypeScript",
+	".py": "Python", ".pyw": "Python", ".rb": "Ruby", ".php": "PHP", ".pl": "Perl", ".pm": "Perl", ".lua": "Lua",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".fish": "Fish", ".ps1": "PowerShell", ".bat": "Batchfile", ".cmd": "Batchfile",
+	".r": "R", ".jl": "Julia", ".hs": "Haskell", ".ml": "OCaml", ".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang",
+	".clj": "Clojure", ".lisp": "Common Lisp", ".el": "Emacs Lisp", ".vim": "Vim Script", ".dart": "Dart", ".groovy": "Groovy",
+	".sql": "SQL", ".html": "HTML", ".htm": "HTML", ".css": "CSS", ".scss": "SCSS", ".less": "Less",
+	".json": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML", ".md": "Markdown",
+	".proto": "Protocol Buffer", ".tf": "HCL", ".hcl": "HCL", ".mk": "Makefile", ".cmake": "CMake",
+}
+
+//languageNames maps lower case file names that say what language they are.
+var languageNames = map[string]string{
+	"makefile": "Makefile", "gnumakefile": "Makefile", "dockerfile": "Dockerfile", "containerfile": "Dockerfile",
+	"cmakelists.txt": "CMake", "rakefile": "Ruby", "gemfile": "Ruby", "jenkinsfile": "Groovy", "vagrantfile": "Ruby",
+	".bashrc": "Shell", ".bash_profile": "Shell", ".zshrc": "Shell", ".profile": "Shell", ".vimrc": "Vim Script",
+}
+
+//languageAliases maps lower case interpreter, vim filetype and emacs mode names to languages.
+var languageAliases = map[string]string{
+	"sh": "Shell", "bash": "Shell", "zsh": "Shell", "dash": "Shell", "ksh": "Shell", "ash": "Shell", "shell-script": "Shell",
+	"python": "Python", "python2": "Python", "python3": "Python", "pypy": "Python", "pypy3": "Python",
+	"ruby": "Ruby", "perl": "Perl", "perl5": "Perl", "php": "PHP", "lua": "Lua", "luajit": "Lua",
+	"node": "JavaScript", "nodejs": "JavaScript", "javascript": "JavaScript", "js": "JavaScript", "deno": "TypeScript",
+	"typescript": "TypeScript", "ts-node": "TypeScript", "rscript": "R", "r": "R", "julia": "Julia", "fish": "Fish",
+	"pwsh": "PowerShell", "powershell": "PowerShell", "tclsh": "Tcl", "wish": "Tcl", "awk": "Awk", "gawk": "Awk",
+	"make": "Makefile", "c": "C", "cpp": "C++", "c++": "C++", "objc": "Objective-C", "go": "Go", "rust": "Rust",
+	"java": "Java", "groovy": "Groovy", "scala": "Scala", "elixir": "Elixir", "erlang": "Erlang", "escript": "Erlang",
+	"haskell": "Haskell", "runghc": "Haskell", "ocaml": "OCaml", "yaml": "YAML", "json": "JSON", "html": "HTML",
+	"markdown": "Markdown", "sql": "SQL", "emacs-lisp": "Emacs Lisp", "lisp": "Common Lisp", "dockerfile": "Dockerfile",
+}
+
+var (
+	vimModeline   = regexp.MustCompile(`(?:^|\s)(?:vim?|ex):.*?\b(?:ft|filetype|syntax)=([\w+-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([\w+-]+).*?|([\w+-]+))\s*-\*-`)
+)
+
+//modelineLines is how many lines at the start and end of the prefix are searched for a modeline.
+const modelineLines = 5
+
+//DetectLanguage guesses the programming language of the file at path from the start of its contents.
+//Like linguist, a vim or emacs modeline wins, then well known file names, then a shebang and finally
+//the extension. It returns "" if none of them say. prefix may be nil to only go by the name.
+func DetectLanguage(path string, prefix []byte) string {
+	if lang := modelineLanguage(prefix); lang != "" {
+		return lang
+	}
+	base := filepath.Base(path)
+	if lang, ok := languageNames[strings.ToLower(base)]; ok {
+		return lang
+	}
+	if lang := shebangLanguage(prefix); lang != "" {
+		return lang
+	}
+	return languageExts[strings.ToLower(filepath.Ext(base))]
+}
+
+func modelineLanguage(prefix []byte) string {
+	lines := bytes.Split(prefix, []byte("\n"))
+	if len(lines) > 2*modelineLines {
+		lines = append(lines[:modelineLines], lines[len(lines)-modelineLines:]...)
+	}
+	for _, line := range lines {
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			if lang := languageAliases[strings.ToLower(string(m[1]))]; lang != "" {
+				return lang
+			}
+		}
+		if m := emacsModeline.FindSubmatch(line); m != nil {
+			name := m[1]
+			if len(name) == 0 {
+				name = m[2]
+			}
+			if lang := languageAliases[strings.ToLower(string(name))]; lang != "" {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+func shebangLanguage(prefix []byte) string {
+	if !bytes.HasPrefix(prefix, []byte("#!")) {
+		return ""
+	}
+	line := prefix[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interpreter = f
+				break
+			}
+		}
+	}
+	interpreter = strings.ToLower(interpreter)
+	if lang, ok := languageAliases[interpreter]; ok {
+		return lang
+	}
+	//python3.11, ruby2.7 and the like.
+	return languageAliases[strings.TrimRight(interpreter, "0123456789.")]
+}
@@ -0,0 +1,57 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		path, prefix, expected string
+	}{
+		{"main.go", "package main", "Go"},
+		{"lib/Thing.PY", "", "Python"},
+		{"bin/tool", "#!/usr/bin/env python3\nprint(1)", "Python"},
+		{"bin/run", "#!/bin/bash -e\n", "Shell"},
+		{"bin/srv", "#!/usr/bin/env -S node --harmony\n", "JavaScript"},
+		{"bin/old", "#!/usr/local/bin/ruby2.7\n", "Ruby"},
+		{"Makefile", "#!/bin/sh\nall:", "Makefile"},
+		{"Dockerfile", "FROM scratch", "Dockerfile"},
+		{"defs.h", "// vim: set ft=cpp:\nclass A;", "C++"},
+		{"script", ";; -*- mode: emacs-lisp; lexical-binding: t -*-", "Emacs Lisp"},
+		{"conf.txt", "# -*- yaml -*-\na: 1", "YAML"},
+		{"README", "just text", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			assert.Equal(t, c.expected, skywalker.DetectLanguage(c.path, []byte(c.prefix)))
+		})
+	}
+}
+
+func TestDetectLanguageWalk(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"main.go":  "package main",
+		"bin/tool": "#!/usr/bin/env python3\n",
+	})
+	tw := &SnapshotTestWorker{TestWorker: NewTW(), snaps: make(map[string]skywalker.Snapshot)}
+	sw := skywalker.New(tmp, tw)
+	sw.DetectLanguage = true
+	assert.NoError(sw.Walk())
+
+	languages := make(map[string]string)
+	for name, snap := range tw.snaps {
+		languages[filepath.Base(name)] = snap.Language
+		assert.Equal(skywalker.EUnknown, snap.Encoding)
+	}
+	assert.Equal(map[string]string{"main.go": "Go", "tool": "Python"}, languages)
+}
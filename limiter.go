@@ -0,0 +1,138 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+//ByteLimiter is a token bucket that caps how many bytes per second can be read through it.
+//One ByteLimiter can be shared by every worker so the cap applies to all of them together.
+//A nil *ByteLimiter does not limit anything.
+type ByteLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+//NewByteLimiter creates a ByteLimiter that allows bytesPerSec bytes to be read every second.
+//Up to a second worth of bytes can be read at once after the limiter has been idle.
+//It panics if bytesPerSec is not more than 0, use a nil *ByteLimiter to not limit anything.
+func NewByteLimiter(bytesPerSec int64) *ByteLimiter {
+	if bytesPerSec <= 0 {
+		panic("skywalker: NewByteLimiter bytesPerSec must be more than 0")
+	}
+	return &ByteLimiter{
+		rate:   float64(bytesPerSec),
+		burst:  float64(bytesPerSec),
+		tokens: float64(bytesPerSec),
+		last:   time.Now(),
+	}
+}
+
+//WaitN blocks until n bytes are allowed through the limiter.
+func (bl *ByteLimiter) WaitN(n int) {
+	if bl == nil || n <= 0 {
+		return
+	}
+	time.Sleep(bl.reserve(float64(n)))
+}
+
+//reserve takes n tokens from the bucket and returns how long to wait for them to be paid back.
+func (bl *ByteLimiter) reserve(n float64) time.Duration {
+	bl.mutex.Lock()
+	defer bl.mutex.Unlock()
+	now := time.Now()
+	bl.tokens += now.Sub(bl.last).Seconds() * bl.rate
+	if bl.tokens > bl.burst {
+		bl.tokens = bl.burst
+	}
+	bl.last = now
+	bl.tokens -= n
+	if bl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bl.tokens / bl.rate * float64(time.Second))
+}
+
+//Reader wraps r so every read from it counts against the limiter.
+func (bl *ByteLimiter) Reader(r io.Reader) io.Reader {
+	if bl == nil {
+		return r
+	}
+	return &limitedReader{r: r, bl: bl}
+}
+
+type limitedReader struct {
+	r  io.Reader
+	bl *ByteLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if max := int(lr.bl.burst); max > 0 && len(p) > max {
+		p = p[:max]
+	}
+	n, err := lr.r.Read(p)
+	lr.bl.WaitN(n)
+	return n, err
+}
+
+//RateLimiter throttles how fast paths are handed to the Worker, see Skywalker.RateLimiter.
+//A *rate.Limiter from golang.org/x/time/rate is one.
+type RateLimiter interface {
+	//Wait blocks until the next path is allowed or ctx is done, returning ctx.Err() if it is.
+	Wait(ctx context.Context) error
+}
+
+//NewRateLimiter creates a RateLimiter that lets perSec paths through every second.
+//Up to a second worth of paths, and at least one, go through at once after it has been idle.
+//It panics if perSec is not more than 0.
+func NewRateLimiter(perSec float64) RateLimiter {
+	if !(perSec > 0) {
+		panic("skywalker: NewRateLimiter perSec must be more than 0")
+	}
+	burst := perSec
+	if burst < 1 {
+		burst = 1
+	}
+	return &pathLimiter{bucket: ByteLimiter{rate: perSec, burst: burst, tokens: burst, last: time.Now()}}
+}
+
+//pathLimiter is a token bucket of paths instead of bytes.
+type pathLimiter struct {
+	bucket ByteLimiter
+}
+
+func (pl *pathLimiter) Wait(ctx context.Context) error {
+	wait := pl.bucket.reserve(1)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//rateWait waits for the RateLimiter of the walk if there is one.
+func (sw *Skywalker) rateWait() error {
+	if sw.rate == nil {
+		return nil
+	}
+	ctx := sw.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return sw.rate.Wait(ctx)
+}
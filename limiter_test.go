@@ -0,0 +1,89 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByteLimiter(t *testing.T) {
+	assert := assert.New(t)
+	bl := skywalker.NewByteLimiter(10000)
+	start := time.Now()
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := io.Copy(io.Discard, bl.Reader(bytes.NewReader(make([]byte, 5000))))
+			assert.NoError(err)
+			assert.Equal(int64(5000), n)
+		}()
+	}
+	wg.Wait()
+	assert.True(time.Since(start) >= 450*time.Millisecond, "Readers should share the limit")
+}
+
+func TestByteLimiterInvalid(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() { skywalker.NewByteLimiter(0) })
+	assert.Panics(func() { skywalker.NewByteLimiter(-1) })
+}
+
+func TestRateLimiterInvalid(t *testing.T) {
+	assert := assert.New(t)
+	assert.Panics(func() { skywalker.NewRateLimiter(0) })
+	assert.Panics(func() { skywalker.NewRateLimiter(-1) })
+	assert.Panics(func() { skywalker.NewRateLimiter(math.NaN()) })
+}
+
+func TestByteLimiterNil(t *testing.T) {
+	var bl *skywalker.ByteLimiter
+	r := bytes.NewReader(nil)
+	assert.Equal(t, io.Reader(r), bl.Reader(r))
+}
+
+func TestRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a": "", "b": "", "c": "", "d": "", "e": "", "f": ""})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.RateLimit = 20
+	start := time.Now()
+	assert.NoError(sw.Walk())
+	//A second worth is let through at once, so there is no waiting for fewer paths.
+	assert.Len(tw.found, 6)
+	assert.True(time.Since(start) < time.Second)
+
+	tw = NewTW()
+	sw = skywalker.New(tmp, tw)
+	sw.RateLimiter = skywalker.NewRateLimiter(10)
+	//Use up the burst so every path waits.
+	for i := 0; i < 10; i++ {
+		sw.RateLimiter.Wait(context.Background())
+	}
+	start = time.Now()
+	assert.NoError(sw.Walk())
+	assert.Len(tw.found, 6)
+	assert.True(time.Since(start) >= 500*time.Millisecond, "Expected the workers to wait for the limiter, took %s", time.Since(start))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	sw.RateLimiter = skywalker.NewRateLimiter(0.1)
+	assert.ErrorIs(sw.WalkContext(ctx), context.DeadlineExceeded)
+
+	sw.RateLimit = -1
+	assert.Error(sw.Walk())
+}
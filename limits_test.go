@@ -0,0 +1,62 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"ok.txt":                 "a",
+		"a-very-long-name.txt":   "a",
+		"deep/er/than/allowed.x": "a",
+		"CON/inside.txt":         "a",
+		"dir/aux":                "a",
+	})
+	var mutex sync.Mutex
+	skipped := make(map[string]skywalker.Reason)
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.MaxPathLength = 16
+	sw.MaxNameLength = 12
+	sw.ForbiddenNames = []string{"CON", "aux"}
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind != skywalker.EKSkipped {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		rel, _ := filepath.Rel(ev.Root, ev.Path)
+		skipped[filepath.ToSlash(rel)] = ev.Reason
+	}
+	assert.NoError(sw.Walk())
+
+	var found []string
+	for path := range tw.found {
+		rel, _ := filepath.Rel(sw.Root, path)
+		found = append(found, rel)
+	}
+	sort.Strings(found)
+	assert.Equal([]string{"ok.txt"}, found)
+	assert.Equal(map[string]skywalker.Reason{
+		"a-very-long-name.txt":   skywalker.RNameLength,
+		"deep/er/than/allowed.x": skywalker.RPathLength,
+		"CON":                    skywalker.RForbiddenName,
+		"dir/aux":                skywalker.RForbiddenName,
+	}, skipped)
+	assert.Equal("forbidden name", skywalker.RForbiddenName.String())
+
+	sw.ForbiddenNames = []string{"["}
+	assert.Error(sw.Walk())
+}
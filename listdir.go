@@ -0,0 +1,65 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+)
+
+//ListEntry is a single entry of a directory returned by ListDir.
+type ListEntry struct {
+	//Path is the absolute path of the entry.
+	Path string
+	Info os.FileInfo
+	//Matched is true if a walk would hand the path to the Worker.
+	//Directories a walk only goes through, like with FilesOnly, are listed with Matched false.
+	Matched bool
+}
+
+//ListDir returns the entries of the directory dir, relative to Root, without going any deeper.
+//Files are only listed if they pass the filters and directories unless a walk would skip them entirely,
+//so a tree can be expanded one level at a time with the same filters as Walk. Entries are sorted by name.
+func (sw *Skywalker) ListDir(dir string) ([]ListEntry, error) {
+	if err := sw.osOnly("ListDir"); err != nil {
+		return nil, err
+	}
+	m, err := sw.Matcher()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(m.root, cleanDir(dir))
+	if _, err = relPath(m.root, path); err != nil {
+		return nil, err
+	}
+	return sw.listDir(m, path)
+}
+
+//listDir lists the absolute directory path using m.
+func (sw *Skywalker) listDir(m *Matcher, path string) ([]ListEntry, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []ListEntry
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		entryPath := filepath.Join(path, entry.Name())
+		match, reason := m.Match(entryPath, info)
+		if !match {
+			if !info.IsDir() {
+				continue
+			}
+			if reason.prunes() && sw.skipDir(entryPath, reason) {
+				continue
+			}
+		}
+		list = append(list, ListEntry{Path: entryPath, Info: info, Matched: match})
+	}
+	return list, nil
+}
@@ -0,0 +1,51 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListDir(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.go":           "",
+		"b.log":          "",
+		"src/c.go":       "",
+		"src/deep/d.go":  "",
+		"vendor/e.go":    "",
+		"docs/readme.md": "",
+	})
+	sw := skywalker.New(tmp, NewTW())
+	sw.DirList = []string{"vendor"}
+	sw.ExtList = []string{".log"}
+
+	names := func(entries []skywalker.ListEntry) map[string]bool {
+		found := make(map[string]bool)
+		for _, e := range entries {
+			found[e.Info.Name()] = e.Matched
+		}
+		return found
+	}
+	entries, err := sw.ListDir("")
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"a.go": true, "docs": false, "src": false}, names(entries))
+
+	entries, err = sw.ListDir("src")
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"c.go": true, "deep": false}, names(entries))
+
+	sw.FilesOnly = false
+	entries, err = sw.ListDir("src")
+	assert.NoError(err)
+	assert.Equal(map[string]bool{"c.go": true, "deep": true}, names(entries))
+
+	_, err = sw.ListDir("../")
+	assert.Equal(skywalker.ErrNotInRoot, err)
+}
@@ -0,0 +1,355 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+//Reason is why a Matcher did or did not match a path.
+type Reason int
+
+const (
+	//RMatched is used when the path passed every filter.
+	RMatched Reason = iota
+	//RDirList is used when the path was filtered out by DirList.
+	//Nothing below a directory filtered out for this reason can match either.
+	RDirList
+	//RDirListParent is used when a directory is only a parent of a directory in a DirList whitelist.
+	//It is not matched itself but paths below it can be.
+	RDirListParent
+	//RExtList is used when the file was filtered out by ExtList.
+	RExtList
+	//RList is used when the path was filtered out by the globs in List.
+	RList
+	//RFilesOnly is used when the path is a directory and only files are wanted.
+	RFilesOnly
+	//RFilter is used when the path did not satisfy the Filter expression.
+	RFilter
+	//RUnchanged is used when a directory is skipped because its fingerprint did not change.
+	//A Matcher never returns it, it is only handed to Skywalker.OnSkipDir.
+	RUnchanged
+	//RPathLength is used when the path relative to Root is longer than MaxPathLength.
+	RPathLength
+	//RNameLength is used when a name in the path is longer than MaxNameLength.
+	RNameLength
+	//RForbiddenName is used when a name in the path matches ForbiddenNames.
+	RForbiddenName
+	//RSymlink is used when a link is left out of the walk by SMSkip.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	RSymlink
+	//RMaxDepth is used when the path is deeper below the root than MaxDepth.
+	//Nothing below a directory filtered out for this reason can match either.
+	RMaxDepth
+	//RMinDepth is used when the path is not as deep below the root as MinDepth. Paths below it can still match.
+	RMinDepth
+	//RCompleted is used when a directory is skipped because it was completed by an earlier walk with the same ProgressFile.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	RCompleted
+	//RSize is used when the file is smaller than MinSize or larger than MaxSize.
+	RSize
+	//RModTime is used when the file was not modified after ModifiedAfter and before ModifiedBefore.
+	RModTime
+	//RIgnored is used when the path matches a pattern in one of the IgnoreFiles.
+	//A Matcher never returns it, it is handed to Skywalker.OnSkipDir and sent with EKSkipped for directories.
+	RIgnored
+	//RKnown is used when a file is skipped because its QuickHash is in the Known set.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	RKnown
+	//RRegexList is used when the path was filtered out by the regular expressions in RegexList.
+	RRegexList
+	//RVirtualFS is used when a directory is skipped for being on a virtual filesystem, see SkipVirtualFS.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	RVirtualFS
+	//ROpenForWrite is used when a file is skipped for being open for writing, see SkipOpenForWrite.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	ROpenForWrite
+	//RContentType is used when a file is skipped for its sniffed content type, see ContentTypeList.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	RContentType
+	//RHidden is used when the path is hidden and SkipHidden is set.
+	//Nothing below a directory filtered out for this reason can match either.
+	RHidden
+	//RJournaled is used when a file is skipped because its key is in the Journal.
+	//A Matcher never returns it, it is only sent with EKSkipped.
+	RJournaled
+)
+
+var reasonNames = [...]string{"matched", "dir list", "dir list parent", "ext list", "list", "files only", "filter", "unchanged",
+	"path length", "name length", "forbidden name", "symlink",
+	"max depth", "min depth", "completed", "size", "mod time", "ignored", "known", "regex list", "virtual fs",
+	"open for write", "content type", "hidden", "journaled"}
+
+//prunes reports whether nothing below a directory filtered out for the reason can match either.
+func (r Reason) prunes() bool {
+	return r == RDirList || r == RMaxDepth || r == RHidden || r.limit()
+}
+
+//limit reports whether the reason is one of the limits for stricter filesystems.
+func (r Reason) limit() bool {
+	return r == RPathLength || r == RNameLength || r == RForbiddenName
+}
+
+func (r Reason) String() string {
+	if r < 0 || int(r) >= len(reasonNames) {
+		return "unknown"
+	}
+	return reasonNames[r]
+}
+
+//Matcher decides which paths a Skywalker hands to its Worker.
+//It can be used on its own to apply the exact same selection outside of a walk.
+//A Matcher is safe to use concurrently.
+type Matcher struct {
+	*FilterSet
+
+	root      string
+	dirMap    map[string]bool
+	filesOnly bool
+	//slash is set for a Matcher of paths inside an fs.FS, which are slash separated and relative.
+	slash bool
+}
+
+//Matcher compiles the filters of the Skywalker into a Matcher.
+//Root is made absolute and paths given to the Matcher should be absolute as well.
+func (sw *Skywalker) Matcher() (*Matcher, error) {
+	return sw.matcherAt(sw.Root)
+}
+
+//matcherAt compiles the filters of the Skywalker relative to root.
+//Filters is used if it is set.
+func (sw *Skywalker) matcherAt(root string) (*Matcher, error) {
+	fs := sw.Filters
+	if fs == nil {
+		var err error
+		if fs, err = sw.CompileFilters(); err != nil {
+			return nil, err
+		}
+	}
+	if sw.FS != nil {
+		return fs.matcherIn(root, sw.FilesOnly)
+	}
+	return fs.matcherAt(root, sw.FilesOnly)
+}
+
+//Root is the absolute path the Matcher's filters are relative to.
+//With FS it is the slash separated path inside of it.
+func (m *Matcher) Root() string {
+	return m.root
+}
+
+//Match reports whether path would be handed to the Worker and, if not, which filter stopped it.
+func (m *Matcher) Match(path string, info os.FileInfo) (bool, Reason) {
+	depth := m.depth(path)
+	if m.maxDepth > 0 && depth > m.maxDepth {
+		return false, RMaxDepth
+	}
+	if m.skipHidden && path != m.root && hidden(info) {
+		return false, RHidden
+	}
+	if info.IsDir() {
+		if reason := m.skipDir(path); reason != RMatched {
+			return false, reason
+		}
+		if reason := m.limits(path); reason != RMatched {
+			return false, reason
+		}
+		if depth < m.minDepth {
+			return false, RMinDepth
+		}
+		if m.filesOnly {
+			return false, RFilesOnly
+		}
+	} else {
+		if reason := m.skipFile(path); reason != RMatched {
+			return false, reason
+		}
+		if reason := m.limits(path); reason != RMatched {
+			return false, reason
+		}
+		if depth < m.minDepth {
+			return false, RMinDepth
+		}
+	}
+	if m.matchPath(path) == (m.listType == LTBlacklist) {
+		return false, RList
+	}
+	if m.matchRegex(path) == (m.regexListType == LTBlacklist) {
+		return false, RRegexList
+	}
+	if !info.IsDir() && !m.sizeOK(info) {
+		return false, RSize
+	}
+	if !info.IsDir() && !m.modTimeOK(info) {
+		return false, RModTime
+	}
+	if m.filter != nil && !m.filter.eval(m.rel(path), info) {
+		return false, RFilter
+	}
+	return true, RMatched
+}
+
+func (m *Matcher) skipDir(path string) Reason {
+	switch m.dirListType {
+	case LTBlacklist:
+		_, inList := m.dirMap[path]
+		if inList {
+			return RDirList
+		}
+	case LTWhitelist:
+		if path == m.root {
+			return RMatched
+		}
+		return m.whiteListDir(path)
+	}
+	return RMatched
+}
+
+func (m *Matcher) whiteListDir(path string) Reason {
+	dirs := m.split(m.rel(path))
+	for i := 1; i < len(dirs)+1; i++ {
+		try := m.join(m.root, m.join(dirs[:i]...))
+		root, found := m.dirMap[try]
+		if found && root {
+			return RMatched // if it is the root no need to continue. Just use it
+		}
+		if !found {
+			return RDirList // if it was not found at all ignore. the order of the search is important
+		}
+	}
+	return RDirListParent // if it was found but not the root and was the last iteration
+}
+
+func (m *Matcher) skipFile(path string) Reason {
+	dir, name := filepath.Split(path)
+	if m.slash {
+		dir, name = pathpkg.Split(path)
+	}
+	if m.dirListType == LTWhitelist {
+		if m.whiteListDir(dir) != RMatched {
+			return RDirList
+		}
+	}
+	_, inList := m.extMap[filepath.Ext(name)]
+	switch m.extListType {
+	case LTBlacklist:
+		if inList {
+			return RExtList
+		}
+	case LTWhitelist:
+		if !inList {
+			return RExtList
+		}
+	}
+	return RMatched
+}
+
+func (m *Matcher) matchPath(path string) bool {
+	path = m.rel(path)
+	for _, gl := range m.list {
+		if match := gl.Match(path); match {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Matcher) matchRegex(path string) bool {
+	if len(m.regexList) == 0 {
+		return false
+	}
+	path = filepath.ToSlash(m.rel(path))
+	for _, re := range m.regexList {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+//rel returns path relative to the root with a leading separator, which is what List and Filter match against.
+func (m *Matcher) rel(path string) string {
+	if !m.slash {
+		return strings.Replace(path, m.root, "", 1)
+	}
+	if m.root == "." {
+		if path == "." {
+			return ""
+		}
+		return "/" + path
+	}
+	return strings.TrimPrefix(path, m.root)
+}
+
+func (m *Matcher) join(elem ...string) string {
+	if m.slash {
+		return pathpkg.Join(elem...)
+	}
+	return filepath.Join(elem...)
+}
+
+func (m *Matcher) split(path string) []string {
+	if m.slash {
+		return strings.Split(strings.Trim(pathpkg.Clean(path), "/"), "/")
+	}
+	return splitPath(path)
+}
+
+//hidden reports whether the file described by info is hidden: its name starts with a dot or, on Windows,
+//it has the hidden attribute.
+func hidden(info os.FileInfo) bool {
+	return strings.HasPrefix(info.Name(), ".") || hiddenAttr(info)
+}
+
+//sizeOK reports whether the file described by info is within MinSize and MaxSize.
+func (m *Matcher) sizeOK(info os.FileInfo) bool {
+	if m.minSize <= 0 && m.maxSize <= 0 {
+		return true
+	}
+	size := info.Size()
+	return size >= m.minSize && (m.maxSize <= 0 || size <= m.maxSize)
+}
+
+//modTimeOK reports whether the file described by info was modified after ModifiedAfter and before ModifiedBefore.
+func (m *Matcher) modTimeOK(info os.FileInfo) bool {
+	if m.modifiedAfter.IsZero() && m.modifiedBefore.IsZero() {
+		return true
+	}
+	mod := info.ModTime()
+	return (m.modifiedAfter.IsZero() || mod.After(m.modifiedAfter)) && (m.modifiedBefore.IsZero() || mod.Before(m.modifiedBefore))
+}
+
+//depth returns how many names path is below the root, which is 0 for the root itself.
+func (m *Matcher) depth(path string) int {
+	if m.slash {
+		return strings.Count(m.rel(path), "/")
+	}
+	return strings.Count(m.rel(path), string(filepath.Separator))
+}
+
+//lastDepth reports whether nothing below the directory at path can match because of MaxDepth,
+//so the walk does not have to read it.
+func (m *Matcher) lastDepth(path string) bool {
+	return m.maxDepth > 0 && m.depth(path) >= m.maxDepth
+}
+
+//limits checks path against MaxPathLength, MaxNameLength and ForbiddenNames.
+func (m *Matcher) limits(path string) Reason {
+	if m.slash {
+		return m.FilterSet.limits(strings.TrimPrefix(m.rel(path), "/"), "/")
+	}
+	return m.FilterSet.limits(strings.TrimPrefix(m.rel(path), string(filepath.Separator)), string(filepath.Separator))
+}
+
+func cleanGlob(gl string) string {
+	if runtime.GOOS == "windows" {
+		return strings.Replace(gl, `/`, `\\`, -1) //must escape the backslash for windows comparison
+	}
+	return gl
+}
@@ -0,0 +1,171 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	sw := skywalker.New(root, NewTW())
+	sw.DirListType = skywalker.LTWhitelist
+	sw.DirList = []string{"sub/folder"}
+	sw.ExtListType = skywalker.LTBlacklist
+	sw.ExtList = []string{".log"}
+	sw.List = []string{"**/files"}
+	m, err := sw.Matcher()
+	assert.NoError(t, err)
+
+	cases := []struct {
+		path   string
+		match  bool
+		reason skywalker.Reason
+	}{
+		{"sub/folder/subfolder/just.txt", true, skywalker.RMatched},
+		{"sub/folder/subfolder/a.log", false, skywalker.RExtList},
+		{"sub/folder/subfolder/files", false, skywalker.RList},
+		{"sub/folder/subfolder", false, skywalker.RFilesOnly},
+		{"sub", false, skywalker.RDirListParent},
+		{"sub/few.pdf", false, skywalker.RDirList},
+		{"the", false, skywalker.RDirList},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			path := filepath.Join(m.Root(), c.path)
+			info, err := os.Stat(path)
+			assert.NoError(t, err)
+			match, reason := m.Match(path, info)
+			assert.Equal(t, c.match, match)
+			assert.Equal(t, c.reason, reason, "Expected %s but got %s", c.reason, reason)
+		})
+	}
+}
+
+func TestOnSkipDir(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"main.go":                  "",
+		"node_modules/dep/a.js":    "",
+		"node_modules/ours/b.js":   "",
+		"node_modules/ours/BUILD":  "",
+		"web/node_modules/x/c.js":  "",
+		"web/node_modules/x/BUILD": "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.DirList = []string{"node_modules", "node_modules/dep", "web/node_modules"}
+	var skipped []string
+	sw.OnSkipDir = func(path string, reason skywalker.Reason) bool {
+		assert.Equal(skywalker.RDirList, reason)
+		rel, _ := filepath.Rel(sw.Root, path)
+		skipped = append(skipped, filepath.ToSlash(rel))
+		_, err := os.Stat(filepath.Join(path, "ours", "BUILD"))
+		return err != nil
+	}
+	assert.NoError(sw.Walk())
+
+	assert.Equal([]string{"node_modules", "node_modules/dep", "web/node_modules"}, skipped)
+	var found []string
+	for path := range tw.found {
+		rel, _ := filepath.Rel(sw.Root, path)
+		found = append(found, filepath.ToSlash(rel))
+	}
+	sort.Strings(found)
+	assert.Equal([]string{"main.go", "node_modules/ours/BUILD", "node_modules/ours/b.js"}, found)
+}
+
+func TestRegexList(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"build/3f9a0c1e/app.js": "",
+		"build/latest/app.js":   "",
+		"src/app.js":            "",
+		"src/app.test.js":       "",
+	})
+	sw := skywalker.New(tmp, NewTW())
+	sw.FilesOnly = true
+	sw.RegexList = []string{`^/build/[0-9a-f]{8}/`}
+	sw.List = []string{"**.test.js"}
+	m, err := sw.Matcher()
+	assert.NoError(err)
+	cases := []struct {
+		path   string
+		match  bool
+		reason skywalker.Reason
+	}{
+		{"build/3f9a0c1e/app.js", false, skywalker.RRegexList},
+		{"build/latest/app.js", true, skywalker.RMatched},
+		{"src/app.js", true, skywalker.RMatched},
+		{"src/app.test.js", false, skywalker.RList},
+	}
+	for _, c := range cases {
+		path := filepath.Join(m.Root(), c.path)
+		info, err := os.Stat(path)
+		assert.NoError(err)
+		match, reason := m.Match(path, info)
+		assert.Equal(c.match, match, c.path)
+		assert.Equal(c.reason, reason, "Expected %s but got %s for %s", c.reason, reason, c.path)
+	}
+
+	sw.List = nil
+	sw.RegexListType = skywalker.LTWhitelist
+	sw.RegexList = []string{`\.js$`, `^/build/[0-9a-f]{8}$`}
+	m, err = sw.Matcher()
+	assert.NoError(err)
+	path := filepath.Join(m.Root(), "build", "latest", "app.js")
+	info, err := os.Stat(path)
+	assert.NoError(err)
+	match, _ := m.Match(path, info)
+	assert.True(match)
+
+	sw.RegexList = []string{`(`}
+	_, err = sw.Matcher()
+	var rerr *skywalker.RegexCompileError
+	if assert.ErrorAs(err, &rerr) {
+		assert.Equal(`(`, rerr.Pattern)
+	}
+}
+
+func TestSkipHidden(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, filepath.Join(tmp, ".root"), map[string]string{
+		"main.go":        "",
+		".env":           "",
+		".git/config":    "",
+		".cache/a/b.bin": "",
+		"src/.hidden.go": "",
+		"src/visible.go": "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(filepath.Join(tmp, ".root"), tw)
+	sw.FilesOnly = true
+	sw.SkipHidden = true
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RHidden {
+			rel, _ := filepath.Rel(sw.Root, ev.Path)
+			skipped = append(skipped, filepath.ToSlash(rel))
+		}
+	}
+	assert.NoError(sw.Walk())
+	var found []string
+	for path := range tw.found {
+		rel, _ := filepath.Rel(sw.Root, path)
+		found = append(found, filepath.ToSlash(rel))
+	}
+	sort.Strings(found)
+	assert.Equal([]string{"main.go", "src/visible.go"}, found)
+	sort.Strings(skipped)
+	assert.Equal([]string{".cache", ".git"}, skipped)
+}
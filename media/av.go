@@ -0,0 +1,154 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+)
+
+//mp4Epoch is when MP4 and QuickTime times start counting.
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//isBox reports whether typ is a top level box that starts MP4 and QuickTime files.
+func isBox(typ string) bool {
+	switch typ {
+	case "ftyp", "moov", "mdat", "wide", "free", "skip":
+		return true
+	}
+	return false
+}
+
+type box struct {
+	typ        string
+	start, end int64 //where the body of the box is
+}
+
+//boxes lists the boxes between start and end.
+func boxes(r io.ReaderAt, start, end int64) []box {
+	var list []box
+	for start+8 <= end {
+		var hdr [16]byte
+		if _, err := r.ReadAt(hdr[:8], start); err != nil {
+			break
+		}
+		size, body := int64(binary.BigEndian.Uint32(hdr[:4])), start+8
+		switch size {
+		case 0:
+			size = end - start
+		case 1:
+			if _, err := r.ReadAt(hdr[8:], start+8); err != nil {
+				return list
+			}
+			size, body = int64(binary.BigEndian.Uint64(hdr[8:])), start+16
+		}
+		if size < body-start || start+size > end {
+			break
+		}
+		list = append(list, box{typ: string(hdr[4:8]), start: body, end: start + size})
+		start += size
+	}
+	return list
+}
+
+func find(list []box, typ string) (box, bool) {
+	for _, b := range list {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+func readMP4(file *os.File) (Metadata, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return Metadata{}, err
+	}
+	md := Metadata{Format: "mp4"}
+	moov, ok := find(boxes(file, 0, info.Size()), "moov")
+	if !ok {
+		return md, nil
+	}
+	inner := boxes(file, moov.start, moov.end)
+	if mvhd, ok := find(inner, "mvhd"); ok {
+		buf := make([]byte, mvhd.end-mvhd.start)
+		if _, err = file.ReadAt(buf, mvhd.start); err != nil {
+			return md, err
+		}
+		var created, scale, duration uint64
+		switch {
+		case len(buf) >= 32 && buf[0] == 1:
+			created, scale, duration = binary.BigEndian.Uint64(buf[4:]), uint64(binary.BigEndian.Uint32(buf[20:])), binary.BigEndian.Uint64(buf[24:])
+		case len(buf) >= 20:
+			created, scale, duration = uint64(binary.BigEndian.Uint32(buf[4:])), uint64(binary.BigEndian.Uint32(buf[12:])), uint64(binary.BigEndian.Uint32(buf[16:]))
+		}
+		if created != 0 {
+			md.Taken = mp4Epoch.Add(time.Duration(created) * time.Second)
+		}
+		if scale != 0 {
+			md.Duration = time.Duration(float64(duration) / float64(scale) * float64(time.Second))
+		}
+	}
+	for _, trak := range inner {
+		if trak.typ != "trak" {
+			continue
+		}
+		tkhd, ok := find(boxes(file, trak.start, trak.end), "tkhd")
+		if !ok {
+			continue
+		}
+		buf := make([]byte, tkhd.end-tkhd.start)
+		if _, err = file.ReadAt(buf, tkhd.start); err != nil {
+			return md, err
+		}
+		at := 76 //width and height come after the 4 byte version and flags and 72 bytes of v0 fields
+		if len(buf) > 0 && buf[0] == 1 {
+			at = 88
+		}
+		if len(buf) < at+8 {
+			continue
+		}
+		if w, h := binary.BigEndian.Uint32(buf[at:])>>16, binary.BigEndian.Uint32(buf[at+4:])>>16; w != 0 && h != 0 {
+			md.Width, md.Height = int(w), int(h)
+			break
+		}
+	}
+	return md, nil
+}
+
+func readWAV(file *os.File) (Metadata, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return Metadata{}, err
+	}
+	md := Metadata{Format: "wav"}
+	var byteRate uint32
+	var dataSize int64 = -1
+	for start := int64(12); start+8 <= info.Size(); {
+		var hdr [8]byte
+		if _, err = file.ReadAt(hdr[:], start); err != nil {
+			return md, err
+		}
+		size := int64(binary.LittleEndian.Uint32(hdr[4:]))
+		switch string(hdr[:4]) {
+		case "fmt ":
+			var fmtChunk [12]byte
+			if _, err = file.ReadAt(fmtChunk[:], start+8); err != nil {
+				return md, err
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:])
+		case "data":
+			dataSize = size
+		}
+		start += 8 + size + size%2
+	}
+	if byteRate != 0 && dataSize >= 0 {
+		md.Duration = time.Duration(float64(dataSize) / float64(byteRate) * float64(time.Second))
+	}
+	return md, nil
+}
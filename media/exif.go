@@ -0,0 +1,109 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	tagExifIFD          = 0x8769
+	tagDateTime         = 0x0132
+	tagDateTimeOriginal = 0x9003
+	exifTimeLayout      = "2006:01:02 15:04:05"
+)
+
+//exifTime finds when a JPEG was taken in its EXIF segment.
+//It returns the zero time without an error if there is no EXIF or no date in it.
+func exifTime(r io.Reader) (time.Time, error) {
+	br := bufio.NewReader(r)
+	if _, err := br.Discard(2); err != nil {
+		return time.Time{}, err
+	}
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(br, marker[:]); err != nil {
+			return time.Time{}, nil
+		}
+		if marker[0] != 0xff || marker[1] == 0xda || marker[1] == 0xd9 {
+			return time.Time{}, nil //image data starts, no EXIF before it
+		}
+		size := int(binary.BigEndian.Uint16(marker[2:])) - 2
+		if size < 0 {
+			return time.Time{}, nil
+		}
+		if marker[1] != 0xe1 {
+			if _, err := br.Discard(size); err != nil {
+				return time.Time{}, nil
+			}
+			continue
+		}
+		seg := make([]byte, size)
+		if _, err := io.ReadFull(br, seg); err != nil {
+			return time.Time{}, nil
+		}
+		if len(seg) > 6 && string(seg[:6]) == "Exif\x00\x00" {
+			return tiffTime(seg[6:]), nil
+		}
+	}
+}
+
+//tiffTime reads DateTimeOriginal, or DateTime if there is none, out of the TIFF structure EXIF uses.
+func tiffTime(tiff []byte) time.Time {
+	if len(tiff) < 8 {
+		return time.Time{}
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return time.Time{}
+	}
+	ifd0 := readIFD(tiff, order, order.Uint32(tiff[4:]))
+	if off, ok := ifd0[tagExifIFD]; ok {
+		exif := readIFD(tiff, order, order.Uint32(off))
+		if t, ok := exifDate(tiff, order, exif[tagDateTimeOriginal]); ok {
+			return t
+		}
+	}
+	t, _ := exifDate(tiff, order, ifd0[tagDateTime])
+	return t
+}
+
+//readIFD returns the raw 12 byte entries of the IFD at offset, keyed by tag and starting at the count.
+func readIFD(tiff []byte, order binary.ByteOrder, offset uint32) map[uint16][]byte {
+	entries := make(map[uint16][]byte)
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return entries
+	}
+	n := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < n; i++ {
+		start := int(offset) + 2 + i*12
+		if start+12 > len(tiff) {
+			break
+		}
+		entries[order.Uint16(tiff[start:])] = tiff[start+8 : start+12]
+	}
+	return entries
+}
+
+//exifDate parses an ASCII date entry whose value is stored at the offset in entry.
+func exifDate(tiff []byte, order binary.ByteOrder, entry []byte) (time.Time, bool) {
+	if entry == nil {
+		return time.Time{}, false
+	}
+	off := int(order.Uint32(entry))
+	if off < 0 || off+len(exifTimeLayout) > len(tiff) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(exifTimeLayout, string(tiff[off:off+len(exifTimeLayout)]))
+	return t, err == nil
+}
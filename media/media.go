@@ -0,0 +1,122 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Package media reads basic metadata from image, video and audio files.
+//It only uses the standard library and is kept out of skywalker so programs that do not need it
+//do not pay for the image decoders.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"os"
+	"time"
+
+	//Registered so image.DecodeConfig knows these formats.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	"github.com/dixonwille/skywalker"
+)
+
+//ErrUnsupported is returned by Read when the file is not in a format it knows.
+var ErrUnsupported = errors.New("unsupported media format")
+
+//Metadata is what could be read from a media file. Fields that could not be found are left at their zero value.
+type Metadata struct {
+	//Format is "jpeg", "png", "gif", "mp4" (also used for mov and m4a) or "wav".
+	Format string
+	//Width and Height are in pixels. For video they come from the first track that has them.
+	Width  int
+	Height int
+	//Taken is when a photo was taken, from EXIF, or when a video was created.
+	//EXIF does not store a time zone so photo times are the camera's wall clock read as UTC.
+	Taken time.Time
+	//Duration is how long a video or audio file plays.
+	Duration time.Duration
+}
+
+//Read reads the metadata of the file at path. The format is detected from the contents, not the extension.
+func Read(path string) (Metadata, error) {
+	file, err := skywalker.Open(path)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer file.Close()
+	head := make([]byte, 12)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return Metadata{}, err
+	}
+	head = head[:n]
+	switch {
+	case bytes.HasPrefix(head, []byte("\xff\xd8")):
+		return readImage(file, true)
+	case bytes.HasPrefix(head, []byte("\x89PNG")), bytes.HasPrefix(head, []byte("GIF8")):
+		return readImage(file, false)
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:]) == "WAVE":
+		return readWAV(file)
+	case len(head) >= 8 && isBox(string(head[4:8])):
+		return readMP4(file)
+	}
+	return Metadata{}, ErrUnsupported
+}
+
+func readImage(file *os.File, jpeg bool) (Metadata, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return Metadata{}, err
+	}
+	cfg, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return Metadata{}, err
+	}
+	md := Metadata{Format: format, Width: cfg.Width, Height: cfg.Height}
+	if jpeg {
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return md, err
+		}
+		md.Taken, err = exifTime(file)
+	}
+	return md, err
+}
+
+//Worker is a skywalker.ResultWorker that reads the Metadata of every file it is given.
+//Set the Skywalker's Results to keep them. Files in unsupported formats give a nil value and no error,
+//so filtering with something like kind(image, video, audio) keeps the store small.
+type Worker struct {
+	//OnMetadata is called with every file that had metadata if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnMetadata func(path string, md Metadata)
+}
+
+//NewWorker creates a Worker.
+func NewWorker() *Worker {
+	return &Worker{}
+}
+
+//Work reads the metadata of the file at path and hands it to OnMetadata.
+func (w *Worker) Work(path string) {
+	w.WorkResult(skywalker.WorkItem{Path: path})
+}
+
+//WorkResult reads the metadata of the file in item.
+func (w *Worker) WorkResult(item skywalker.WorkItem) (interface{}, error) {
+	if item.Info != nil && item.Info.IsDir() {
+		return nil, nil
+	}
+	md, err := Read(item.Path)
+	if err == ErrUnsupported {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if w.OnMetadata != nil {
+		w.OnMetadata(item.Path, md)
+	}
+	return md, nil
+}
@@ -0,0 +1,131 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package media_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/dixonwille/skywalker/media"
+	"github.com/stretchr/testify/assert"
+)
+
+//exifJPEG encodes a w by h JPEG with an EXIF segment holding DateTimeOriginal.
+func exifJPEG(t *testing.T, w, h int, taken string) []byte {
+	img := new(bytes.Buffer)
+	assert.NoError(t, jpeg.Encode(img, image.NewGray(image.Rect(0, 0, w, h)), nil))
+	//TIFF header, IFD0 with the Exif IFD pointer, the Exif IFD with DateTimeOriginal, then the date.
+	tiff := new(bytes.Buffer)
+	le := binary.LittleEndian
+	tiff.WriteString("II")
+	binary.Write(tiff, le, uint16(42))
+	binary.Write(tiff, le, uint32(8))
+	binary.Write(tiff, le, uint16(1))
+	binary.Write(tiff, le, []uint16{0x8769, 4})
+	binary.Write(tiff, le, []uint32{1, 26})
+	binary.Write(tiff, le, uint32(0))
+	binary.Write(tiff, le, uint16(1))
+	binary.Write(tiff, le, []uint16{0x9003, 2})
+	binary.Write(tiff, le, []uint32{20, 44})
+	binary.Write(tiff, le, uint32(0))
+	tiff.WriteString(taken + "\x00")
+	seg := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	out := new(bytes.Buffer)
+	out.Write(img.Bytes()[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(out, binary.BigEndian, uint16(len(seg)+2))
+	out.Write(seg)
+	out.Write(img.Bytes()[2:])
+	return out.Bytes()
+}
+
+func mp4Box(typ string, body ...[]byte) []byte {
+	b := new(bytes.Buffer)
+	size := 8
+	for _, part := range body {
+		size += len(part)
+	}
+	binary.Write(b, binary.BigEndian, uint32(size))
+	b.WriteString(typ)
+	for _, part := range body {
+		b.Write(part)
+	}
+	return b.Bytes()
+}
+
+func testMP4(created time.Time, scale, duration uint32, w, h uint16) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[4:], uint32(created.Sub(time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC))/time.Second))
+	binary.BigEndian.PutUint32(mvhd[12:], scale)
+	binary.BigEndian.PutUint32(mvhd[16:], duration)
+	audio := make([]byte, 84)
+	video := make([]byte, 84)
+	binary.BigEndian.PutUint32(video[76:], uint32(w)<<16)
+	binary.BigEndian.PutUint32(video[80:], uint32(h)<<16)
+	return append(mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")), mp4Box("moov",
+		mp4Box("mvhd", mvhd),
+		mp4Box("trak", mp4Box("tkhd", audio)),
+		mp4Box("trak", mp4Box("tkhd", video)),
+	)...)
+}
+
+func testWAV(byteRate uint32, dataSize int) []byte {
+	b := new(bytes.Buffer)
+	b.WriteString("RIFF")
+	binary.Write(b, binary.LittleEndian, uint32(36+dataSize))
+	b.WriteString("WAVEfmt ")
+	binary.Write(b, binary.LittleEndian, []uint32{16, 1 | 2<<16, 44100, byteRate, 4 | 16<<16})
+	b.WriteString("data")
+	binary.Write(b, binary.LittleEndian, uint32(dataSize))
+	b.Write(make([]byte, dataSize))
+	return b.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	pngData := new(bytes.Buffer)
+	assert.NoError(png.Encode(pngData, image.NewGray(image.Rect(0, 0, 3, 2))))
+	created := time.Date(2016, 5, 4, 3, 2, 1, 0, time.UTC)
+	files := map[string][]byte{
+		"photo.jpg": exifJPEG(t, 8, 4, "2015:06:07 08:09:10"),
+		"image.png": pngData.Bytes(),
+		"clip.mov":  testMP4(created, 600, 1500, 1920, 1080),
+		"sound.wav": testWAV(1000, 2500),
+		"notes.txt": []byte("hello there"),
+	}
+	for name, data := range files {
+		assert.NoError(os.WriteFile(filepath.Join(tmp, name), data, 0666))
+	}
+
+	store := skywalker.NewMemoryStore()
+	sw := skywalker.New(tmp, media.NewWorker())
+	sw.Results = store
+	assert.NoError(sw.Walk())
+
+	results := make(map[string]interface{})
+	for path, res := range store.Results() {
+		assert.NoError(res.Err)
+		results[filepath.Base(path)] = res.Value
+	}
+	assert.Equal(map[string]interface{}{
+		"photo.jpg": media.Metadata{Format: "jpeg", Width: 8, Height: 4, Taken: time.Date(2015, 6, 7, 8, 9, 10, 0, time.UTC)},
+		"image.png": media.Metadata{Format: "png", Width: 3, Height: 2},
+		"clip.mov":  media.Metadata{Format: "mp4", Width: 1920, Height: 1080, Taken: created, Duration: 2500 * time.Millisecond},
+		"sound.wav": media.Metadata{Format: "wav", Duration: 2500 * time.Millisecond},
+		"notes.txt": nil,
+	}, results)
+
+	_, err := media.Read(filepath.Join(tmp, "notes.txt"))
+	assert.Equal(media.ErrUnsupported, err)
+}
@@ -0,0 +1,282 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//ErrNotInTree is returned by MerkleTree.Proof for a path that is not a file in the tree.
+var ErrNotInTree = errors.New("path is not a file in the tree")
+
+//MerkleWorker is a Worker that hashes every file it is given so a Merkle tree of the walked tree can be
+//built once the walk is done, with file digests rolled up into directory digests. Two trees only share a
+//root digest if they hold the same names and contents, and a MerkleProof shows a single file is part of a
+//tree with only the digests of the directories on its way to the root.
+//Only what the Skywalker hands the worker counts, so filters decide what is in the tree.
+//Empty directories are only seen if FilesOnly is false.
+type MerkleWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//Hash is used for files and directories alike. Defaults to sha256.New, see LookupHash for others.
+	Hash func() hash.Hash
+
+	root rootRel
+
+	mutex sync.Mutex
+	files map[string][]byte
+	dirs  map[string]struct{}
+	errs  map[string]error
+}
+
+//NewMerkleWorker creates a MerkleWorker for the tree at root.
+func NewMerkleWorker(root string) *MerkleWorker {
+	return &MerkleWorker{
+		Root:  root,
+		files: make(map[string][]byte),
+		dirs:  make(map[string]struct{}),
+		errs:  make(map[string]error),
+	}
+}
+
+//UseHash sets Hash, see Skywalker.HashName.
+func (mw *MerkleWorker) UseHash(newHash func() hash.Hash) {
+	mw.Hash = newHash
+}
+
+//Work hashes the file at path or remembers the directory at path.
+func (mw *MerkleWorker) Work(path string) {
+	mw.WorkErr(path)
+}
+
+//WorkErr is Work returning why the file at path could not be hashed, so the walk returns it as well.
+func (mw *MerkleWorker) WorkErr(path string) error {
+	rel, err := mw.root.rel(mw.Root, path)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	info, err := os.Lstat(path)
+	if err == nil && info.IsDir() {
+		mw.mutex.Lock()
+		mw.dirs[rel] = struct{}{}
+		mw.mutex.Unlock()
+		return nil
+	}
+	var sum []byte
+	if err == nil {
+		sum, err = mw.leaf(path)
+	}
+	mw.mutex.Lock()
+	defer mw.mutex.Unlock()
+	if err != nil {
+		mw.errs[rel] = err
+		return err
+	}
+	mw.files[rel] = sum
+	return nil
+}
+
+func (mw *MerkleWorker) newHash() hash.Hash {
+	if mw.Hash == nil {
+		return sha256.New()
+	}
+	return mw.Hash()
+}
+
+//leaf hashes the contents of the file at path, prefixed so it can not be mistaken for a directory.
+func (mw *MerkleWorker) leaf(path string) ([]byte, error) {
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	h := mw.newHash()
+	h.Write([]byte{merkleFile})
+	if _, err = io.Copy(h, file); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+//Tree rolls the file digests up into the Merkle tree. It should be called once the walk is done.
+//If any file could not be hashed the tree would not match the files, so the first of them is returned
+//as a WorkerError instead.
+func (mw *MerkleWorker) Tree() (*MerkleTree, error) {
+	mw.mutex.Lock()
+	defer mw.mutex.Unlock()
+	if len(mw.errs) > 0 {
+		paths := make([]string, 0, len(mw.errs))
+		for rel := range mw.errs {
+			paths = append(paths, rel)
+		}
+		sort.Strings(paths)
+		return nil, &WorkerError{Path: paths[0], Err: mw.errs[paths[0]]}
+	}
+	t := &MerkleTree{newHash: mw.newHash, nodes: map[string]*merkleNode{".": {dir: true}}}
+	var addDir func(dir string) *merkleNode
+	addDir = func(dir string) *merkleNode {
+		if n, ok := t.nodes[dir]; ok {
+			return n
+		}
+		n := &merkleNode{dir: true}
+		t.nodes[dir] = n
+		parent := addDir(path.Dir(dir))
+		parent.children = append(parent.children, path.Base(dir))
+		return n
+	}
+	for dir := range mw.dirs {
+		addDir(dir)
+	}
+	for rel, sum := range mw.files {
+		t.nodes[rel] = &merkleNode{sum: sum}
+		parent := addDir(path.Dir(rel))
+		parent.children = append(parent.children, path.Base(rel))
+	}
+	t.digest(".")
+	return t, nil
+}
+
+//Node kinds hashed in front of their contents.
+const (
+	merkleFile byte = iota
+	merkleDir
+)
+
+//MerkleTree is the Merkle tree built by a MerkleWorker. Paths are relative to its Root using "/",
+//with "." being the root itself.
+type MerkleTree struct {
+	newHash func() hash.Hash
+	nodes   map[string]*merkleNode
+}
+
+type merkleNode struct {
+	sum      []byte
+	dir      bool
+	children []string
+}
+
+//digest computes the digest of the directory at dir and everything below it, after its children.
+func (t *MerkleTree) digest(dir string) []byte {
+	n := t.nodes[dir]
+	if n.sum != nil {
+		return n.sum
+	}
+	sort.Strings(n.children)
+	entries := make([]MerkleEntry, len(n.children))
+	for i, name := range n.children {
+		child := path.Join(dir, name)
+		entries[i] = MerkleEntry{Name: name, Dir: t.nodes[child].dir}
+		if entries[i].Dir {
+			entries[i].Digest = t.digest(child)
+		} else {
+			entries[i].Digest = t.nodes[child].sum
+		}
+	}
+	n.sum = dirDigestOf(t.newHash, entries)
+	return n.sum
+}
+
+//dirDigestOf hashes the entries of a directory, sorted by name.
+func dirDigestOf(newHash func() hash.Hash, entries []MerkleEntry) []byte {
+	h := newHash()
+	h.Write([]byte{merkleDir})
+	for _, e := range entries {
+		kind := merkleFile
+		if e.Dir {
+			kind = merkleDir
+		}
+		h.Write([]byte{kind})
+		io.WriteString(h, e.Name+"\x00")
+		h.Write(e.Digest)
+	}
+	return h.Sum(nil)
+}
+
+//Root returns the digest of the whole tree.
+func (t *MerkleTree) Root() []byte {
+	return t.nodes["."].sum
+}
+
+//Digest returns the digest of the file or directory at rel.
+func (t *MerkleTree) Digest(rel string) ([]byte, bool) {
+	n, ok := t.nodes[path.Clean(rel)]
+	if !ok {
+		return nil, false
+	}
+	return n.sum, true
+}
+
+//MerkleEntry is a file or directory in a directory of a MerkleProof.
+type MerkleEntry struct {
+	Name   string
+	Dir    bool
+	Digest []byte
+}
+
+//MerkleProof shows the file at Path with the digest Leaf is part of a tree.
+//Steps hold the entries of every directory from the one holding the file up to the root, sorted by name.
+type MerkleProof struct {
+	Path  string
+	Leaf  []byte
+	Steps [][]MerkleEntry
+}
+
+//Proof returns the proof that the file at rel is part of the tree.
+func (t *MerkleTree) Proof(rel string) (*MerkleProof, error) {
+	rel = path.Clean(rel)
+	n, ok := t.nodes[rel]
+	if !ok || n.dir {
+		return nil, ErrNotInTree
+	}
+	p := &MerkleProof{Path: rel, Leaf: n.sum}
+	for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+		d := t.nodes[dir]
+		entries := make([]MerkleEntry, len(d.children))
+		for i, name := range d.children {
+			child := t.nodes[path.Join(dir, name)]
+			entries[i] = MerkleEntry{Name: name, Dir: child.dir, Digest: child.sum}
+		}
+		p.Steps = append(p.Steps, entries)
+		if dir == "." {
+			return p, nil
+		}
+	}
+}
+
+//Verify reports whether the proof leads from Leaf to root using newHash, the Hash of the MerkleWorker.
+func (p *MerkleProof) Verify(root []byte, newHash func() hash.Hash) bool {
+	names := strings.Split(p.Path, "/")
+	if len(names) != len(p.Steps) {
+		return false
+	}
+	sum := p.Leaf
+	for i, entries := range p.Steps {
+		name := names[len(names)-1-i]
+		found := false
+		for _, e := range entries {
+			if e.Name == name {
+				found = e.Dir == (i > 0) && bytes.Equal(e.Digest, sum)
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+		sum = dirDigestOf(newHash, entries)
+	}
+	return bytes.Equal(sum, root)
+}
@@ -0,0 +1,75 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func merkleTree(t *testing.T, root string) *skywalker.MerkleTree {
+	mw := skywalker.NewMerkleWorker(root)
+	sw := skywalker.New(root, mw)
+	sw.FilesOnly = false
+	assert.NoError(t, sw.Walk())
+	tree, err := mw.Tree()
+	assert.NoError(t, err)
+	return tree
+}
+
+func TestMerkleWorker(t *testing.T) {
+	assert := assert.New(t)
+	files := map[string]string{
+		"a/x/one.txt": "one",
+		"a/two.txt":   "two",
+		"b/one.txt":   "one",
+		"three.txt":   "three",
+	}
+	first, second := t.TempDir(), t.TempDir()
+	writeFiles(t, first, files)
+	writeFiles(t, second, files)
+
+	tree := merkleTree(t, first)
+	root := tree.Root()
+	assert.Len(root, sha256.Size)
+	assert.Equal(root, merkleTree(t, second).Root())
+
+	one, ok := tree.Digest("a/x/one.txt")
+	assert.True(ok)
+	other, _ := tree.Digest("b/one.txt")
+	assert.Equal(one, other)
+	_, ok = tree.Digest("missing")
+	assert.False(ok)
+
+	proof, err := tree.Proof("a/x/one.txt")
+	assert.NoError(err)
+	assert.Len(proof.Steps, 3)
+	assert.True(proof.Verify(root, sha256.New))
+	proof.Leaf = other[:1]
+	assert.False(proof.Verify(root, sha256.New))
+
+	proof, err = tree.Proof("three.txt")
+	assert.NoError(err)
+	assert.True(proof.Verify(root, sha256.New))
+	proof.Path = "four.txt"
+	assert.False(proof.Verify(root, sha256.New))
+
+	_, err = tree.Proof("a")
+	assert.Equal(skywalker.ErrNotInTree, err)
+
+	assert.NoError(os.WriteFile(filepath.Join(second, "a", "two.txt"), []byte("changed"), 0644))
+	changed := merkleTree(t, second)
+	assert.NotEqual(root, changed.Root())
+	proof, _ = tree.Proof("b/one.txt")
+	assert.False(proof.Verify(changed.Root(), sha256.New))
+
+	assert.NoError(os.MkdirAll(filepath.Join(second, "empty"), 0755))
+	assert.NotEqual(changed.Root(), merkleTree(t, second).Root())
+}
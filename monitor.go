@@ -0,0 +1,185 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//Violation is a change to a monitored tree.
+type Violation struct {
+	Change
+	//Silent is true if the contents changed without the size or modification time changing,
+	//which only the random re-verification can find.
+	Silent bool
+	//Found is when the change was noticed.
+	Found time.Time
+}
+
+//Monitor watches a tree for changes to files, the way a file integrity monitor does.
+//It hashes every file once for a baseline, then every Interval walks the tree again only looking at
+//metadata and re-hashes Sample random files, so silent corruption is found eventually without re-reading
+//everything each time. Every change is reported once, compared to what the previous check saw.
+//Changes are found by polling, the Skywalker's filters decide what is monitored and its Worker is replaced.
+type Monitor struct {
+	//Hash is used for the baseline and re-verification. Defaults to sha256.
+	Hash func() hash.Hash
+
+	//Interval is how long to wait between checks in Run. Defaults to a minute.
+	Interval time.Duration
+
+	//Sample is how many random unchanged files are re-hashed on each check. Defaults to 100.
+	Sample int
+
+	//OnViolation is called with every change found if it is set. It is only called from the checking goroutine.
+	OnViolation func(Violation)
+
+	//Store, if set, gets the baseline and the state after every check that found a change.
+	Store *SnapshotStore
+
+	sw    *Skywalker
+	rand  *rand.Rand
+	known TreeSnapshot
+}
+
+//NewMonitor creates a Monitor for the tree sw walks.
+func NewMonitor(sw *Skywalker) *Monitor {
+	return &Monitor{
+		Hash:     sha256.New,
+		Interval: time.Minute,
+		Sample:   100,
+		sw:       sw,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+//scan walks the tree recording every file, hashing them if hashed is true.
+func (m *Monitor) scan(hashed bool) (TreeSnapshot, error) {
+	tr := NewTreeRecorder(m.sw.Root)
+	if hashed {
+		tr.Hash = m.Hash
+	}
+	m.sw.Worker = tr
+	if err := m.sw.Walk(); err != nil {
+		return nil, err
+	}
+	return tr.Snapshot(), nil
+}
+
+//Baseline hashes every file and makes that the state checks compare with.
+func (m *Monitor) Baseline() error {
+	ts, err := m.scan(true)
+	if err != nil {
+		return err
+	}
+	m.known = ts
+	if m.Store != nil {
+		return m.Store.Save(ts, time.Now())
+	}
+	return nil
+}
+
+//Check compares the tree with what was seen last and re-verifies a random sample of the unchanged files.
+//It takes a Baseline first if there is none. Violations are returned sorted by path.
+func (m *Monitor) Check() ([]Violation, error) {
+	if m.known == nil {
+		if err := m.Baseline(); err != nil {
+			return nil, err
+		}
+	}
+	cur, err := m.scan(false)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var violations []Violation
+	touched := make(map[string]struct{})
+	for _, c := range cur.Diff(m.known) {
+		if c.New != nil {
+			c.New.Hash = m.hash(c.Path)
+			cur[c.Path] = *c.New
+			touched[c.Path] = struct{}{}
+		}
+		violations = append(violations, Violation{Change: c, Found: now})
+	}
+	var unchanged []string
+	for _, path := range cur.paths() {
+		if _, ok := touched[path]; ok {
+			continue
+		}
+		state := cur[path]
+		state.Hash = m.known[path].Hash
+		cur[path] = state
+		if state.Hash != "" {
+			unchanged = append(unchanged, path)
+		}
+	}
+	m.rand.Shuffle(len(unchanged), func(i, j int) { unchanged[i], unchanged[j] = unchanged[j], unchanged[i] })
+	if len(unchanged) > m.Sample {
+		unchanged = unchanged[:m.Sample]
+	}
+	for _, path := range unchanged {
+		old, state := m.known[path], cur[path]
+		state.Hash = m.hash(path)
+		if state.Hash == "" || state.Hash == old.Hash {
+			continue
+		}
+		cur[path] = state
+		violations = append(violations, Violation{Change: Change{Kind: CKModified, Path: path, Old: &old, New: &state}, Silent: true, Found: now})
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	m.known = cur
+	if m.OnViolation != nil {
+		for _, v := range violations {
+			m.OnViolation(v)
+		}
+	}
+	if m.Store != nil && len(violations) > 0 {
+		return violations, m.Store.Save(cur, now)
+	}
+	return violations, nil
+}
+
+//hash returns the hex encoded checksum of the file at the relative path or "" if it can not be read.
+func (m *Monitor) hash(rel string) string {
+	info, err := os.Lstat(filepath.Join(m.sw.Root, filepath.FromSlash(rel)))
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	sum, err := checksum(filepath.Join(m.sw.Root, filepath.FromSlash(rel)), m.Hash(), nil)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(sum)
+}
+
+//Run checks the tree every Interval until ctx is done. It only returns early if a check fails.
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.known == nil {
+		if err := m.Baseline(); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := m.Check(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitor(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "aaaa", "b.txt": "bbbb", "c.txt": "cccc"})
+	m := skywalker.NewMonitor(skywalker.New(tmp, nil))
+	assert.NoError(m.Baseline())
+
+	violations, err := m.Check()
+	assert.NoError(err)
+	assert.Empty(violations)
+
+	//Same size and modification time, different contents.
+	info, err := os.Stat(filepath.Join(tmp, "b.txt"))
+	assert.NoError(err)
+	writeFiles(t, tmp, map[string]string{"b.txt": "BBBB", "d.txt": "new"})
+	assert.NoError(os.Chtimes(filepath.Join(tmp, "b.txt"), info.ModTime(), info.ModTime()))
+	assert.NoError(os.Remove(filepath.Join(tmp, "c.txt")))
+
+	var reported []skywalker.Violation
+	m.OnViolation = func(v skywalker.Violation) { reported = append(reported, v) }
+	violations, err = m.Check()
+	assert.NoError(err)
+	assert.Equal(violations, reported)
+	assert.Len(violations, 3)
+	for _, v := range violations {
+		switch v.Path {
+		case "b.txt":
+			assert.Equal(skywalker.CKModified, v.Kind)
+			assert.True(v.Silent)
+		case "c.txt":
+			assert.Equal(skywalker.CKRemoved, v.Kind)
+		case "d.txt":
+			assert.Equal(skywalker.CKAdded, v.Kind)
+			assert.NotEmpty(v.New.Hash)
+		}
+	}
+
+	violations, err = m.Check()
+	assert.NoError(err)
+	assert.Empty(violations, "Changes are only reported once")
+
+	m.Interval = 5 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	m.OnViolation = func(v skywalker.Violation) {
+		assert.Equal("e.txt", v.Path)
+		cancel()
+	}
+	writeFiles(t, tmp, map[string]string{"e.txt": "e"})
+	assert.NoError(m.Run(ctx))
+}
@@ -0,0 +1,186 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//CollisionPolicy is used to specify what happens when a destination file already exists.
+type CollisionPolicy int
+
+const (
+	//CPRename is used to keep both files by adding a number to the name of the incoming file.
+	CPRename CollisionPolicy = iota
+	//CPSkip is used to leave the incoming file where it is.
+	CPSkip
+	//CPOverwrite is used to replace the existing file with the incoming one.
+	CPOverwrite
+)
+
+//MoveResult is what a MoveWorker did with a single path.
+type MoveResult struct {
+	//Path is where the file was found.
+	Path string
+	//Dest is where the file was moved to. Empty if the file was not moved.
+	Dest string
+	//Skipped is true if the file was left in place because the destination already existed.
+	Skipped bool
+	//Err is set if the file could not be moved.
+	Err error
+}
+
+//MoveWorker is a Worker that moves every file it is given into Dest keeping the path relative to Root.
+//If a file can not be renamed into place (different devices) it is copied and then deleted.
+//Directories are ignored so it is best used with FilesOnly.
+type MoveWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//Dest is the directory files are moved into.
+	Dest string
+
+	//Collision is what to do when a file already exists at the destination.
+	Collision CollisionPolicy
+
+	//OnMove is called after each file is handled if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnMove func(MoveResult)
+
+	root rootRel
+
+	mutex   sync.Mutex
+	results []MoveResult
+}
+
+//NewMoveWorker creates a MoveWorker that moves files found in root into dest.
+//Defaults to renaming files that collide with existing ones.
+func NewMoveWorker(root, dest string) *MoveWorker {
+	return &MoveWorker{
+		Root:      root,
+		Dest:      dest,
+		Collision: CPRename,
+	}
+}
+
+//Work moves the file at path into Dest.
+func (mw *MoveWorker) Work(path string) {
+	res, ok := mw.move(path)
+	if !ok {
+		return
+	}
+	mw.mutex.Lock()
+	mw.results = append(mw.results, res)
+	mw.mutex.Unlock()
+	if mw.OnMove != nil {
+		mw.OnMove(res)
+	}
+}
+
+//Results returns what happened to every file handled so far.
+func (mw *MoveWorker) Results() []MoveResult {
+	mw.mutex.Lock()
+	defer mw.mutex.Unlock()
+	results := make([]MoveResult, len(mw.results))
+	copy(results, mw.results)
+	return results
+}
+
+func (mw *MoveWorker) move(path string) (MoveResult, bool) {
+	res := MoveResult{Path: path}
+	info, err := os.Lstat(path)
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	if info.IsDir() {
+		return res, false
+	}
+	rel, err := mw.root.rel(mw.Root, path)
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	dest := filepath.Join(mw.Dest, rel)
+	if err = os.MkdirAll(filepath.Dir(dest), 0777); err != nil {
+		res.Err = err
+		return res, true
+	}
+	dest, claimed, err := claimDest(dest, mw.Collision)
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	if dest == "" {
+		res.Skipped = true
+		return res, true
+	}
+	if err = moveFile(path, dest); err != nil {
+		if claimed {
+			os.Remove(dest)
+		}
+		res.Err = err
+		return res, true
+	}
+	res.Dest = dest
+	return res, true
+}
+
+//claimDest decides where a file should be written according to policy.
+//Returns an empty destination if the file should be skipped.
+//claimed is true if an empty placeholder was created to reserve the name, so concurrent workers do not race for it.
+func claimDest(dest string, policy CollisionPolicy) (string, bool, error) {
+	switch policy {
+	case CPOverwrite:
+		return dest, false, nil
+	case CPSkip:
+		ok, err := reserve(dest)
+		if err != nil || !ok {
+			return "", false, err
+		}
+		return dest, true, nil
+	}
+	dir, name := filepath.Split(dest)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	try := dest
+	for i := 1; ; i++ {
+		ok, err := reserve(try)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return try, true, nil
+		}
+		try = filepath.Join(dir, base+" ("+strconv.Itoa(i)+")"+ext)
+	}
+}
+
+//reserve creates an empty file at path only if nothing exists there yet.
+func reserve(path string) (bool, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, file.Close()
+}
+
+//moveFile renames src to dst falling back to copying and deleting when a rename is not possible.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,81 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveWorker(t *testing.T) {
+	cases := []struct {
+		name      string
+		collision skywalker.CollisionPolicy
+		expected  map[string]string
+		skipped   int
+	}{
+		{"Rename", skywalker.CPRename, map[string]string{
+			"a/one.txt":     "new",
+			"a/one (1).txt": "old",
+			"b/two.log":     "new",
+		}, 0},
+		{"Skip", skywalker.CPSkip, map[string]string{
+			"a/one.txt": "new",
+			"b/two.log": "new",
+		}, 1},
+		{"Overwrite", skywalker.CPOverwrite, map[string]string{
+			"a/one.txt": "old",
+			"b/two.log": "new",
+		}, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert := assert.New(t)
+			tmp := t.TempDir()
+			src := filepath.Join(tmp, "src")
+			dest := filepath.Join(tmp, "dest")
+			writeFiles(t, src, map[string]string{"a/one.txt": "old", "b/two.log": "new"})
+			writeFiles(t, dest, map[string]string{"a/one.txt": "new"})
+
+			mw := skywalker.NewMoveWorker(src, dest)
+			mw.Collision = c.collision
+			sw := skywalker.New(src, mw)
+			assert.NoError(sw.Walk())
+
+			skipped := 0
+			for _, res := range mw.Results() {
+				assert.NoError(res.Err)
+				if res.Skipped {
+					skipped++
+				}
+			}
+			assert.Equal(c.skipped, skipped, "Not the expected number of skipped files")
+			for rel, content := range c.expected {
+				data, err := os.ReadFile(filepath.Join(dest, rel))
+				assert.NoError(err)
+				assert.Equal(content, string(data), "Unexpected content in %s", rel)
+			}
+			_, err := os.Stat(filepath.Join(src, "b/two.log"))
+			assert.True(os.IsNotExist(err), "Source file should have been moved")
+		})
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"time"
+)
+
+//writersMaxAge is how long SkipOpenForWrite trusts a scan of the files open for writing before scanning again.
+const writersMaxAge = time.Second
+
+//writers are the files processes had open for writing when they were last scanned.
+//Only the walking goroutine uses it.
+type writers struct {
+	scanned time.Time
+	ids     map[[2]uint64]struct{}
+}
+
+//openForWrite reports whether the regular file described by info is open for writing by any process.
+func (sw *Skywalker) openForWrite(info os.FileInfo) bool {
+	if sw.writers == nil || !fileType(info).IsRegular() {
+		return false
+	}
+	if time.Since(sw.writers.scanned) > writersMaxAge {
+		sw.writers.ids = openWriters()
+		sw.writers.scanned = time.Now()
+	}
+	if len(sw.writers.ids) == 0 {
+		return false
+	}
+	dev, ino := fileID(info)
+	_, ok := sw.writers.ids[[2]uint64{dev, ino}]
+	return ok
+}
@@ -0,0 +1,62 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+//openWriters returns the device and inode of every regular file a process has open for writing, as far as the
+//fd directories in /proc show. The files of other users' processes are only seen with the privileges to read them.
+func openWriters() map[[2]uint64]struct{} {
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	ids := make(map[[2]uint64]struct{})
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil {
+			continue
+		}
+		dir := "/proc/" + proc.Name()
+		fds, err := os.ReadDir(dir + "/fd")
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			var st syscall.Stat_t
+			if err := syscall.Stat(dir+"/fd/"+fd.Name(), &st); err != nil || st.Mode&syscall.S_IFMT != syscall.S_IFREG {
+				continue
+			}
+			if fdWrites(dir + "/fdinfo/" + fd.Name()) {
+				ids[[2]uint64{uint64(st.Dev), uint64(st.Ino)}] = struct{}{}
+			}
+		}
+	}
+	return ids
+}
+
+//fdWrites reports whether the flags in the fdinfo file at path allow writing.
+func fdWrites(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value := strings.TrimPrefix(scanner.Text(), "flags:")
+		if value == scanner.Text() {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimSpace(value), 8, 64)
+		return err == nil && flags&syscall.O_ACCMODE != syscall.O_RDONLY
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipOpenForWrite(t *testing.T) {
+	assert := assert.New(t)
+	if _, err := os.Stat("/proc/self/fdinfo"); err != nil {
+		t.Skip("/proc is not mounted")
+	}
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"done.log":    "",
+		"writing.log": "",
+		"reading.log": "",
+	})
+	writing, err := os.OpenFile(filepath.Join(tmp, "writing.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if !assert.NoError(err) {
+		return
+	}
+	defer writing.Close()
+	reading, err := os.Open(filepath.Join(tmp, "reading.log"))
+	if !assert.NoError(err) {
+		return
+	}
+	defer reading.Close()
+
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.FilesOnly = true
+	sw.SkipOpenForWrite = true
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.ROpenForWrite {
+			skipped = append(skipped, ev.Path)
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Equal([]string{filepath.Join(sw.Root, "writing.log")}, skipped)
+	assert.Len(tw.found, 2)
+
+	writing.Close()
+	tw = NewTW()
+	sw.Worker = tw
+	skipped = nil
+	assert.NoError(sw.Walk())
+	assert.Empty(skipped)
+	assert.Len(tw.found, 3)
+}
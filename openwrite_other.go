@@ -0,0 +1,13 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package skywalker
+
+//openWriters returns nothing as only Linux shows which files are open for writing.
+func openWriters() map[[2]uint64]struct{} {
+	return nil
+}
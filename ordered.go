@@ -0,0 +1,65 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "sync"
+
+//orderer delivers what the workers did with every path in the order the paths were queued.
+type orderer struct {
+	mutex sync.Mutex
+	//next is the sequence number of the path that is delivered next.
+	next    uint64
+	pending map[uint64][]func()
+}
+
+func newOrderer() *orderer {
+	return &orderer{next: 1, pending: make(map[uint64][]func())}
+}
+
+//sequence wraps dispatch so every path is numbered in the order it is queued.
+//Only a single goroutine may call the returned func.
+func (o *orderer) sequence(dispatch func(item)) func(item) {
+	var seq uint64
+	return func(w item) {
+		seq++
+		w.seq = seq
+		dispatch(w)
+	}
+}
+
+//finish runs deliver, once every path queued before the one numbered seq was delivered,
+//followed by every later path that is already waiting.
+func (o *orderer) finish(seq uint64, deliver []func()) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.pending[seq] = deliver
+	for {
+		funcs, ok := o.pending[o.next]
+		if !ok {
+			return
+		}
+		delete(o.pending, o.next)
+		o.next++
+		for _, f := range funcs {
+			f()
+		}
+	}
+}
+
+//ordered reports whether what is done with w has to wait for the paths queued before it.
+func (sw *Skywalker) ordered(w item) bool {
+	return sw.order != nil && w.seq != 0
+}
+
+//deliver runs funcs now, or in the order the paths were queued if the walk is Ordered.
+func (sw *Skywalker) deliver(w item, funcs ...func()) {
+	if sw.ordered(w) {
+		sw.order.finish(w.seq, funcs)
+		return
+	}
+	for _, f := range funcs {
+		f()
+	}
+}
@@ -0,0 +1,73 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type slowWorker struct{}
+
+func (slowWorker) Work(path string) {}
+
+func (slowWorker) WorkResult(item skywalker.WorkItem) (interface{}, error) {
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	return item.Path, nil
+}
+
+type orderStore struct {
+	mutex sync.Mutex
+	paths []string
+}
+
+func (s *orderStore) Put(item skywalker.WorkItem, res skywalker.Result) error {
+	s.mutex.Lock()
+	s.paths = append(s.paths, res.Value.(string))
+	s.mutex.Unlock()
+	return nil
+}
+
+func (s *orderStore) Flush() error { return nil }
+
+func TestOrdered(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 40; i++ {
+		files[fmt.Sprintf("d%d/s%d/f%02d", i%3, i%2, i)] = ""
+	}
+	writeFiles(t, tmp, files)
+	sw := skywalker.New(tmp, slowWorker{})
+	sw.NumWorkers = 8
+	sw.Ordered = true
+	store := &orderStore{}
+	sw.Results = store
+	var mutex sync.Mutex
+	var queued, done []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch ev.Kind {
+		case skywalker.EKQueued:
+			queued = append(queued, ev.Path)
+		case skywalker.EKDone:
+			done = append(done, ev.Path)
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Len(queued, 40)
+	assert.Equal(queued, done)
+	assert.Equal(queued, store.paths)
+
+	sw.ShuffleWindow = 4
+	assert.Error(sw.Walk())
+}
@@ -0,0 +1,13 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package skywalker
+
+//osPath returns path as it is, names are never trimmed here.
+func osPath(path string) string {
+	return path
+}
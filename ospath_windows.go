@@ -0,0 +1,40 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build windows
+// +build windows
+
+package skywalker
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+//osPath returns the \\?\ form of path if a name in it ends in a space or a dot, which Win32 would trim.
+func osPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) || !trimmedByWin32(path) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
+
+func trimmedByWin32(path string) bool {
+	for _, name := range strings.FieldsFunc(path, func(r rune) bool { return r == '\\' || r == '/' }) {
+		if name == "." || name == ".." {
+			continue
+		}
+		if strings.HasSuffix(name, " ") || strings.HasSuffix(name, ".") {
+			return true
+		}
+	}
+	return false
+}
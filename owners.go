@@ -0,0 +1,96 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+//OwnerStat is how many queued files a user or group owns and how many bytes they hold.
+type OwnerStat struct {
+	ID uint32
+	//Name is only set if it was asked for and the ID could be looked up.
+	Name  string
+	Count int
+	Bytes int64
+}
+
+//OwnerStats returns the usage per user and per group of the last Walk with Owners, the most bytes first.
+//With names every ID is looked up in the user and group databases.
+//Both are empty where files do not have an owner, like on Windows. It should not be called while walking.
+func (sw *Skywalker) OwnerStats(names bool) (users []OwnerStat, groups []OwnerStat) {
+	if sw.owners == nil {
+		return nil, nil
+	}
+	sw.owners.mutex.Lock()
+	defer sw.owners.mutex.Unlock()
+	users = ownerList(sw.owners.users, names, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+	groups = ownerList(sw.owners.groups, names, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+	return users, groups
+}
+
+func ownerList(stats map[uint32]OwnerStat, names bool, lookup func(id string) (string, error)) []OwnerStat {
+	list := make([]OwnerStat, 0, len(stats))
+	for _, stat := range stats {
+		if names {
+			stat.Name, _ = lookup(strconv.FormatUint(uint64(stat.ID), 10))
+		}
+		list = append(list, stat)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bytes != list[j].Bytes {
+			return list[i].Bytes > list[j].Bytes
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+//owners adds up the usage per owner. The workers fill it in so the stats run in parallel.
+type owners struct {
+	mutex  sync.Mutex
+	users  map[uint32]OwnerStat
+	groups map[uint32]OwnerStat
+}
+
+func newOwners() *owners {
+	return &owners{users: make(map[uint32]OwnerStat), groups: make(map[uint32]OwnerStat)}
+}
+
+func (o *owners) add(info os.FileInfo) {
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return
+	}
+	size := info.Size()
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	for _, owner := range []struct {
+		stats map[uint32]OwnerStat
+		id    uint32
+	}{{o.users, uid}, {o.groups, gid}} {
+		stat := owner.stats[owner.id]
+		stat.ID = owner.id
+		stat.Count++
+		stat.Bytes += size
+		owner.stats[owner.id] = stat
+	}
+}
@@ -0,0 +1,46 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerStats(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("Files do not have a numeric owner here")
+	}
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+	})
+	sw := skywalker.New(tmp, NewTW())
+	sw.Owners = true
+	assert.NoError(sw.Walk())
+
+	users, groups := sw.OwnerStats(false)
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	assert.Equal([]skywalker.OwnerStat{{ID: uid, Count: 2, Bytes: 5}}, users)
+	assert.Equal([]skywalker.OwnerStat{{ID: gid, Count: 2, Bytes: 5}}, groups)
+
+	users, _ = sw.OwnerStats(true)
+	if u, err := user.LookupId(strconv.Itoa(os.Getuid())); err == nil && assert.Len(users, 1) {
+		assert.Equal(u.Username, users[0].Name)
+	}
+
+	//Redispatching does not count the files again.
+	assert.NoError(sw.Redispatch([]string{"a.txt"}))
+	users, _ = sw.OwnerStats(false)
+	assert.Equal(2, users[0].Count)
+}
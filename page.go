@@ -0,0 +1,129 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//ErrBadCursor is returned by WalkPage for a cursor it did not hand out.
+var ErrBadCursor = errors.New("not a cursor returned by WalkPage")
+
+//ErrPageLimit is returned by WalkPage when the limit is less than 1.
+var ErrPageLimit = errors.New("page limit must be at least 1")
+
+//errPageFull stops the walk of a page once there is a path for the next one.
+var errPageFull = errors.New("page full")
+
+//Page is what WalkPage returns.
+type Page struct {
+	//Items are the paths of the page in the order a walk finds them, with their Info stated.
+	Items []WorkItem
+	//Next is the cursor of the page after this one, or "" if this is the last one.
+	Next string
+}
+
+//WalkPage returns up to limit of the paths a walk would hand the Worker, starting after cursor, which is ""
+//for the first page and the Next of the page before for the others. Paths come in the lexical order of the walk
+//and a page only reads the directories holding its paths and the last path of the page before, so a web service
+//can page through a large tree with the same filters as Walk without keeping a walk running for every client.
+//The cursor is opaque and can be handed to clients. Paths created or removed between pages show up or not like
+//they would for a walk started then. IgnoreFiles, Overlays and everything the workers would do are not used.
+//It can not be used with FS.
+func (sw *Skywalker) WalkPage(cursor string, limit int) (Page, error) {
+	if err := sw.osOnly("WalkPage"); err != nil {
+		return Page{}, err
+	}
+	if limit < 1 {
+		return Page{}, ErrPageLimit
+	}
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+	m, err := sw.Matcher()
+	if err != nil {
+		return Page{}, err
+	}
+	var page Page
+	var last string
+	err = walkDir(m.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == m.root {
+				return rootError(path, err)
+			}
+			return nil
+		}
+		key := "/" + strings.TrimPrefix(filepath.ToSlash(m.rel(path)), "/")
+		if after != "" && walkOrder(key, after) <= 0 {
+			//Only the directories holding the path the last page ended with have anything left for this one.
+			if info.IsDir() && !holdsPath(key, after) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		match, reason := m.Match(path, info)
+		if !match {
+			if info.IsDir() && reason.prunes() && sw.skipDir(path, reason) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(page.Items) == limit {
+			page.Next = encodeCursor(last)
+			return errPageFull
+		}
+		if ei, ok := info.(*entryInfo); ok {
+			if stated := ei.stat(); stated != nil {
+				info = stated
+			}
+		}
+		page.Items = append(page.Items, WorkItem{Path: path, Info: info, Root: m.root, Labels: sw.Labels})
+		last = key
+		return nil
+	})
+	if err != nil && err != errPageFull {
+		return Page{}, err
+	}
+	return page, nil
+}
+
+//A cursor is the path of the last item of a page relative to Root, with a leading "/" and "/" between names.
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	if cursor == "" {
+		return "", nil
+	}
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(key) == 0 || key[0] != '/' {
+		return "", ErrBadCursor
+	}
+	return string(key), nil
+}
+
+//walkOrder compares the paths a and b, relative to Root with a leading "/", in the order a walk finds them:
+//name by name, so a directory comes right before everything in it.
+func walkOrder(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "/"), "/")
+	bs := strings.Split(strings.TrimPrefix(b, "/"), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+//holdsPath reports whether the directory dir is path or holds it, both relative to Root with a leading "/".
+func holdsPath(dir, path string) bool {
+	return dir == "/" || dir == path || strings.HasPrefix(path, dir+"/")
+}
@@ -0,0 +1,87 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkPage(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":           "",
+		"b/c.txt":         "",
+		"b/d/e.txt":       "",
+		"b/d/f.log":       "",
+		"b/g.txt":         "",
+		"h/i.txt":         "",
+		"j.txt":           "",
+		"skip/k.txt":      "",
+		"skip/deep/l.txt": "",
+	})
+	sw := skywalker.New(tmp, NewTW())
+	sw.FilesOnly = true
+	sw.ExtListType = skywalker.LTWhitelist
+	sw.ExtList = []string{".txt"}
+	sw.DirListType = skywalker.LTBlacklist
+	sw.DirList = []string{"skip"}
+	want, err := sw.Collect()
+	assert.NoError(err)
+	assert.Len(want, 6)
+
+	var got []string
+	cursor := ""
+	pages := 0
+	for {
+		page, err := sw.WalkPage(cursor, 4)
+		if !assert.NoError(err) {
+			return
+		}
+		pages++
+		for _, wi := range page.Items {
+			assert.Equal(sw.Root, wi.Root)
+			assert.Equal(int64(0), wi.Info.Size())
+			got = append(got, wi.Path)
+		}
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+	assert.Equal(2, pages)
+	assert.Equal(want, got)
+
+	//A page goes on after the last path of the page before even if it is gone.
+	page, err := sw.WalkPage("", 2)
+	assert.NoError(err)
+	assert.Equal(want[:2], pathsOf(page.Items))
+	assert.NoError(os.Remove(filepath.Join(tmp, "b", "c.txt")))
+	page, err = sw.WalkPage(page.Next, 2)
+	assert.NoError(err)
+	assert.Equal(want[2:4], pathsOf(page.Items))
+}
+
+func TestWalkPageErrors(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(t.TempDir(), NewTW())
+	_, err := sw.WalkPage("", 0)
+	assert.Equal(skywalker.ErrPageLimit, err)
+	_, err = sw.WalkPage("not a cursor", 1)
+	assert.Equal(skywalker.ErrBadCursor, err)
+}
+
+func pathsOf(items []skywalker.WorkItem) []string {
+	var paths []string
+	for _, wi := range items {
+		paths = append(paths, wi.Path)
+	}
+	return paths
+}
@@ -0,0 +1,260 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//readsPerWalker is how many directories each reader may have read ahead of the walk before it waits.
+const readsPerWalker = 16
+
+//parallelWalkers is how many goroutines read directories. The walking goroutine is one of them.
+func (sw *Skywalker) parallelWalkers() int {
+	if sw.ParallelWalkers <= 1 || sw.EnterDir != nil || sw.LeaveDir != nil || sw.FS != nil {
+		return 1
+	}
+	return sw.ParallelWalkers
+}
+
+//dirWalker returns the function walking a tree for a walk.
+func (sw *Skywalker) dirWalker() func(root string, walkFn filepath.WalkFunc) error {
+	if sw.FS != nil {
+		return func(root string, walkFn filepath.WalkFunc) error {
+			return walkFS(sw.FS, root, walkFn)
+		}
+	}
+	n := sw.parallelWalkers()
+	if n == 1 {
+		return walkDir
+	}
+	return func(root string, walkFn filepath.WalkFunc) error {
+		return parallelWalkDir(root, n, walkFn)
+	}
+}
+
+//parallelWalkDir is walkDir reading directories ahead of the walk on n-1 extra goroutines.
+//walkFn is called from the calling goroutine in the same order and with the same arguments as walkDir,
+//only the reading is done in parallel. Every directory found is queued to be read, the most recently found
+//first so the readers stay close to where the walk is going next. Reads of directories the walk skips are
+//thrown away. The walk reads a directory itself if no reader got to it yet, so it never waits on a reader
+//that is not already reading what it needs.
+func parallelWalkDir(root string, n int, walkFn filepath.WalkFunc) error {
+	dr := newDirReader(n-1, (n-1)*readsPerWalker)
+	defer dr.close()
+	fn := func(path string, entry fs.DirEntry, err error) error {
+		var info os.FileInfo
+		if entry != nil {
+			info = &entryInfo{entry: entry}
+		}
+		return walkFn(path, info, err)
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = dr.walk(root, fs.FileInfoToDirEntry(info), nil, fn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+//dirRead states.
+const (
+	drQueued = iota
+	drReading
+	drDone
+	drTaken
+	drCanceled
+)
+
+//dirRead is a directory read, or waiting to be read, ahead of the walk.
+type dirRead struct {
+	path     string
+	state    int
+	canceled bool
+	entries  []fs.DirEntry
+	err      error
+	done     chan struct{}
+	children map[string]*dirRead
+}
+
+//dirReader hands directories to the readers and keeps what they read until the walk takes or cancels it.
+type dirReader struct {
+	mutex sync.Mutex
+	cond  *sync.Cond
+	stack []*dirRead
+	//ready is how many reads are done that the walk has neither taken nor canceled.
+	ready  int
+	max    int
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newDirReader(readers, max int) *dirReader {
+	dr := &dirReader{max: max}
+	dr.cond = sync.NewCond(&dr.mutex)
+	dr.wg.Add(readers)
+	for i := 0; i < readers; i++ {
+		go dr.reader()
+	}
+	return dr
+}
+
+func (dr *dirReader) reader() {
+	defer dr.wg.Done()
+	for {
+		dr.mutex.Lock()
+		for !dr.closed && (len(dr.stack) == 0 || dr.ready >= dr.max) {
+			dr.cond.Wait()
+		}
+		if dr.closed {
+			dr.mutex.Unlock()
+			return
+		}
+		r := dr.stack[len(dr.stack)-1]
+		dr.stack = dr.stack[:len(dr.stack)-1]
+		if r.state != drQueued {
+			dr.mutex.Unlock()
+			continue
+		}
+		r.state = drReading
+		dr.mutex.Unlock()
+
+		entries, err := os.ReadDir(r.path)
+
+		dr.mutex.Lock()
+		r.entries, r.err = entries, err
+		if r.canceled {
+			r.state, r.entries = drCanceled, nil
+		} else {
+			r.state = drDone
+			dr.ready++
+			dr.queueChildren(r)
+		}
+		close(r.done)
+		dr.mutex.Unlock()
+	}
+}
+
+//queueChildren queues every directory in r to be read, the first one on top. The mutex has to be held.
+func (dr *dirReader) queueChildren(r *dirRead) {
+	r.children = make(map[string]*dirRead)
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if !r.entries[i].IsDir() {
+			continue
+		}
+		child := &dirRead{path: filepath.Join(r.path, r.entries[i].Name()), done: make(chan struct{})}
+		r.children[r.entries[i].Name()] = child
+		dr.stack = append(dr.stack, child)
+	}
+	if len(r.children) > 0 {
+		dr.cond.Broadcast()
+	}
+}
+
+//take returns what is in r, reading it if no reader started to yet.
+func (dr *dirReader) take(r *dirRead) ([]fs.DirEntry, map[string]*dirRead, error) {
+	dr.mutex.Lock()
+	switch r.state {
+	case drQueued:
+		r.state = drTaken
+		dr.mutex.Unlock()
+		entries, err := os.ReadDir(r.path)
+		dr.mutex.Lock()
+		r.entries, r.err = entries, err
+		dr.queueChildren(r)
+		close(r.done)
+	case drReading:
+		dr.mutex.Unlock()
+		<-r.done
+		dr.mutex.Lock()
+		fallthrough
+	case drDone:
+		r.state = drTaken
+		dr.ready--
+		dr.cond.Broadcast()
+	}
+	entries, children, err := r.entries, r.children, r.err
+	r.entries = nil
+	dr.mutex.Unlock()
+	return entries, children, err
+}
+
+//cancel throws away r and everything read below it unless the walk took it.
+func (dr *dirReader) cancel(r *dirRead) {
+	dr.mutex.Lock()
+	defer dr.mutex.Unlock()
+	dr.cancelLocked(r)
+	dr.cond.Broadcast()
+}
+
+func (dr *dirReader) cancelLocked(r *dirRead) {
+	switch r.state {
+	case drQueued:
+		r.state = drCanceled
+	case drReading:
+		r.canceled = true
+	case drDone:
+		r.state, r.entries = drCanceled, nil
+		dr.ready--
+		for _, child := range r.children {
+			dr.cancelLocked(child)
+		}
+	}
+}
+
+//walk walks path like filepath.WalkDir does. r is the read of path if it was queued.
+func (dr *dirReader) walk(path string, entry fs.DirEntry, r *dirRead, fn fs.WalkDirFunc) error {
+	if err := fn(path, entry, nil); err != nil || !entry.IsDir() {
+		if err == filepath.SkipDir && entry.IsDir() {
+			err = nil
+		}
+		if r != nil {
+			dr.cancel(r)
+		}
+		return err
+	}
+	if r == nil {
+		r = &dirRead{path: path, done: make(chan struct{})}
+	}
+	entries, children, err := dr.take(r)
+	defer func() {
+		for _, child := range children {
+			dr.cancel(child)
+		}
+	}()
+	if err != nil {
+		if err = fn(path, entry, err); err != nil {
+			if err == filepath.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := dr.walk(filepath.Join(path, e.Name()), e, children[e.Name()], fn); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+//close stops the readers and waits for them.
+func (dr *dirReader) close() {
+	dr.mutex.Lock()
+	dr.closed = true
+	dr.cond.Broadcast()
+	dr.mutex.Unlock()
+	dr.wg.Wait()
+}
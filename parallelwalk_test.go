@@ -0,0 +1,76 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelWalkers(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := make(map[string]string)
+	var skip []string
+	for i := 0; i < 20; i++ {
+		skip = append(skip, fmt.Sprintf("skip%02d", i))
+		for j := 0; j < 5; j++ {
+			files[fmt.Sprintf("d%02d/e%d/f.txt", i, j)] = "x"
+			files[fmt.Sprintf("skip%02d/e%d/f.txt", i, j)] = "x"
+		}
+	}
+	writeFiles(t, tmp, files)
+
+	order := func(parallel int) ([]string, map[string]struct{}) {
+		var visited []string
+		var mutex sync.Mutex
+		tw := NewTW()
+		sw := skywalker.New(tmp, tw)
+		sw.FilesOnly = false
+		sw.ParallelWalkers = parallel
+		sw.DirList = skip
+		sw.OnEvent = func(ev skywalker.Event) {
+			if ev.Kind == skywalker.EKQueued || ev.Kind == skywalker.EKSkipped {
+				mutex.Lock()
+				visited = append(visited, ev.Path)
+				mutex.Unlock()
+			}
+		}
+		assert.NoError(sw.Walk())
+		return visited, tw.found
+	}
+	serial, serialFound := order(0)
+	parallel, parallelFound := order(8)
+	assert.Len(serialFound, 1+20+100+100)
+	assert.Equal(serial, parallel)
+	assert.Equal(serialFound, parallelFound)
+}
+
+func TestParallelWalkersStop(t *testing.T) {
+	assert := assert.New(t)
+	before := runtime.NumGoroutine()
+	sw := skywalker.New(root, NewTW())
+	sw.ParallelWalkers = 4
+	assert.Equal(sw.NumWorkers+3, sw.Goroutines())
+	sw.Filter = "size>"
+	assert.Error(sw.Walk())
+	sw.Filter = ""
+	sw.Root = filepath.Join(root, "not/here")
+	assert.Error(sw.Walk())
+	sw.Root = root
+	sw.ParallelWalkers = -1
+	assert.Error(sw.Walk())
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(before, runtime.NumGoroutine())
+}
@@ -0,0 +1,70 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+//Open opens a path found by a walk for reading. Names can hold anything the filesystem allows, including
+//newlines, control characters and invalid UTF-8. On Windows Win32 trims trailing spaces and dots from names,
+//so a file named "a." would open "a" instead. Open uses the \\?\ form of such paths to open the right file.
+func Open(path string) (*os.File, error) {
+	return os.Open(osPath(path))
+}
+
+//rawPath returns the bytes of path if JSON can not carry it as a string, which replaces invalid UTF-8.
+//They are base64 encoded next to the string so the exact path survives.
+func rawPath(path string) []byte {
+	if utf8.ValidString(path) {
+		return nil
+	}
+	return []byte(path)
+}
+
+//fromRaw returns raw as the path if it is set and path otherwise.
+func fromRaw(path string, raw []byte) string {
+	if raw != nil {
+		return string(raw)
+	}
+	return path
+}
+
+//escapeManifestPath escapes path the way GNU coreutils does in checksum files.
+//It reports whether anything was escaped, in which case the line has to start with a backslash.
+func escapeManifestPath(path string) (string, bool) {
+	if !strings.ContainsAny(path, "\\\n\r") {
+		return path, false
+	}
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path), true
+}
+
+//unescapeManifestPath undoes escapeManifestPath. It returns false for unknown escapes.
+func unescapeManifestPath(path string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '\\' {
+			b.WriteByte(path[i])
+			continue
+		}
+		i++
+		if i == len(path) {
+			return "", false
+		}
+		switch path[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
@@ -0,0 +1,169 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+//IssueKind is why a path would not survive being copied to a stricter filesystem.
+type IssueKind int
+
+const (
+	//IKCaseCollision is used when a name only differs in case from another name in the same directory.
+	IKCaseCollision IssueKind = iota
+	//IKReservedName is used for names Windows reserves for devices, like CON, NUL or COM1, with any extension.
+	IKReservedName
+	//IKIllegalChar is used for names holding a character Windows does not allow, like ':' or a control character.
+	IKIllegalChar
+	//IKTrailingChar is used for names ending in a space or dot, which Windows drops.
+	IKTrailingChar
+	//IKPathLength is used when the path is longer than MaxPathLength.
+	IKPathLength
+	//IKNameLength is used when the name is longer than MaxNameLength.
+	IKNameLength
+)
+
+var issueKindNames = [...]string{"case collision", "reserved name", "illegal character", "trailing character", "path length", "name length"}
+
+func (ik IssueKind) String() string {
+	if ik < 0 || int(ik) >= len(issueKindNames) {
+		return "unknown"
+	}
+	return issueKindNames[ik]
+}
+
+//PortabilityIssue is a single problem found with a path.
+type PortabilityIssue struct {
+	//Path is relative to Root using "/".
+	Path string
+	Kind IssueKind
+	//Other is the path Path collides with for IKCaseCollision.
+	Other string
+	//Detail says what exactly is wrong, like the illegal character or the length.
+	Detail string
+}
+
+//DefaultMaxPathLength and DefaultMaxNameLength are the limits of Windows without long path support.
+const (
+	DefaultMaxPathLength = 259
+	DefaultMaxNameLength = 255
+)
+
+//PortabilityWorker is a Worker that reports every path that would not survive being copied to Windows,
+//a case insensitive filesystem or one with shorter limits.
+//Names are only checked when their own path is worked, so it should be used with FilesOnly false.
+type PortabilityWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//MaxPathLength and MaxNameLength are in characters of the path relative to Root and of its name.
+	//Zero turns the check off.
+	MaxPathLength int
+	MaxNameLength int
+
+	//OnIssue is called for every issue as it is found. It is called concurrently.
+	OnIssue func(PortabilityIssue)
+
+	root rootRel
+
+	mutex  sync.Mutex
+	folded map[string]string
+	issues []PortabilityIssue
+}
+
+//NewPortabilityWorker creates a PortabilityWorker for the tree at root with the default limits.
+func NewPortabilityWorker(root string) *PortabilityWorker {
+	return &PortabilityWorker{
+		Root:          root,
+		MaxPathLength: DefaultMaxPathLength,
+		MaxNameLength: DefaultMaxNameLength,
+		folded:        make(map[string]string),
+	}
+}
+
+//Work checks the path and its name.
+func (pw *PortabilityWorker) Work(p string) {
+	rel, err := pw.root.rel(pw.Root, p)
+	if err != nil || rel == "." {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	issues := checkName(rel, path.Base(rel), pw.MaxNameLength)
+	if n := utf8.RuneCountInString(rel); pw.MaxPathLength > 0 && n > pw.MaxPathLength {
+		issues = append(issues, PortabilityIssue{Path: rel, Kind: IKPathLength, Detail: fmt.Sprintf("%d > %d", n, pw.MaxPathLength)})
+	}
+	key := path.Join(path.Dir(rel), strings.ToLower(path.Base(rel)))
+	pw.mutex.Lock()
+	if other, ok := pw.folded[key]; ok {
+		//Report the same pair no matter which of them was worked first.
+		first, second := other, rel
+		if second < first {
+			first, second = second, first
+		}
+		issues = append(issues, PortabilityIssue{Path: second, Kind: IKCaseCollision, Other: first})
+	} else {
+		pw.folded[key] = rel
+	}
+	pw.issues = append(pw.issues, issues...)
+	pw.mutex.Unlock()
+	if pw.OnIssue != nil {
+		for _, issue := range issues {
+			pw.OnIssue(issue)
+		}
+	}
+}
+
+//Issues returns every issue found so far sorted by path.
+func (pw *PortabilityWorker) Issues() []PortabilityIssue {
+	pw.mutex.Lock()
+	defer pw.mutex.Unlock()
+	issues := make([]PortabilityIssue, len(pw.issues))
+	copy(issues, pw.issues)
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		return issues[i].Kind < issues[j].Kind
+	})
+	return issues
+}
+
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+//checkName returns the issues with name, the last part of rel.
+func checkName(rel, name string, maxName int) []PortabilityIssue {
+	var issues []PortabilityIssue
+	base := name
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if reservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		issues = append(issues, PortabilityIssue{Path: rel, Kind: IKReservedName, Detail: base})
+	}
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(`<>:"|?*\`, r) {
+			issues = append(issues, PortabilityIssue{Path: rel, Kind: IKIllegalChar, Detail: fmt.Sprintf("%q", r)})
+			break
+		}
+	}
+	if strings.HasSuffix(name, " ") || strings.HasSuffix(name, ".") {
+		issues = append(issues, PortabilityIssue{Path: rel, Kind: IKTrailingChar, Detail: fmt.Sprintf("%q", name[len(name)-1:])})
+	}
+	if n := utf8.RuneCountInString(name); maxName > 0 && n > maxName {
+		issues = append(issues, PortabilityIssue{Path: rel, Kind: IKNameLength, Detail: fmt.Sprintf("%d > %d", n, maxName)})
+	}
+	return issues
+}
@@ -0,0 +1,50 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortability(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows does not allow these names")
+	}
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"ok.txt":             "a",
+		"a/B.txt":            "a",
+		"a/b.txt":            "a",
+		"con.txt":            "a",
+		"what?":              "a",
+		"dot./inside":        "a",
+		"long-enough-name/x": "a",
+	})
+	if entries, _ := os.ReadDir(tmp + "/a"); len(entries) != 2 {
+		t.Skip("The filesystem is case insensitive")
+	}
+	pw := skywalker.NewPortabilityWorker(tmp)
+	pw.MaxNameLength = 12
+	pw.MaxPathLength = 17
+	sw := skywalker.New(tmp, pw)
+	sw.FilesOnly = false
+	assert.NoError(sw.Walk())
+
+	assert.Equal([]skywalker.PortabilityIssue{
+		{Path: "a/b.txt", Kind: skywalker.IKCaseCollision, Other: "a/B.txt"},
+		{Path: "con.txt", Kind: skywalker.IKReservedName, Detail: "con"},
+		{Path: "dot.", Kind: skywalker.IKTrailingChar, Detail: `"."`},
+		{Path: "long-enough-name", Kind: skywalker.IKNameLength, Detail: "16 > 12"},
+		{Path: "long-enough-name/x", Kind: skywalker.IKPathLength, Detail: "18 > 17"},
+		{Path: "what?", Kind: skywalker.IKIllegalChar, Detail: "'?'"},
+	}, pw.Issues())
+	assert.Equal("case collision", skywalker.IKCaseCollision.String())
+}
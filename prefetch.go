@@ -0,0 +1,50 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "sync"
+
+//prefetcher hints the kernel about files that were just queued from a goroutine of its own
+//so the walk does not wait on opening them.
+type prefetcher struct {
+	files  chan string
+	length int64
+	wg     sync.WaitGroup
+}
+
+func newPrefetcher(size int, length int64) *prefetcher {
+	p := &prefetcher{files: make(chan string, size), length: length}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for path := range p.files {
+			willNeed(path, p.length)
+		}
+	}()
+	return p
+}
+
+//push queues path to be prefetched. It is dropped if the prefetcher has fallen too far behind,
+//as by then the workers are likely reading it already.
+func (p *prefetcher) push(path string) {
+	select {
+	case p.files <- path:
+	default:
+	}
+}
+
+//stop waits for everything queued to be hinted.
+func (p *prefetcher) stop() {
+	close(p.files)
+	p.wg.Wait()
+}
+
+//prefetchers is how many goroutines prefetching starts.
+func (sw *Skywalker) prefetchers() int {
+	if sw.Prefetch && sw.FS == nil {
+		return 1
+	}
+	return 0
+}
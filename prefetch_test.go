@@ -0,0 +1,32 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefetch(t *testing.T) {
+	assert := assert.New(t)
+	tw := NewTW()
+	sw := skywalker.New(root, tw)
+	sw.Prefetch = true
+	sw.PrefetchBytes = 4096
+	assert.Equal(sw.NumWorkers+1, sw.Goroutines())
+	assert.NoError(sw.Walk())
+
+	expected := NewTW()
+	assert.NoError(skywalker.New(root, expected).Walk())
+	assert.Equal(expected.found, tw.found)
+
+	sw.PrefetchBytes = -1
+	var cfgErr *skywalker.ConfigError
+	err := sw.Walk()
+	assert.True(errors.As(err, &cfgErr), "Expected a ConfigError but got %v", err)
+}
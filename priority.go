@@ -0,0 +1,145 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"container/heap"
+	"os"
+	"sync"
+)
+
+//QueueItem is a path waiting for a worker, as Less sees it.
+type QueueItem struct {
+	Path string
+	//Info is never nil. Redispatch and Replay lines recorded without it look the path up before it is queued.
+	Info os.FileInfo
+	//Root is the root Path was found in. It is only different from Skywalker.Root when using Overlays.
+	Root string
+}
+
+//LargestFirst is a Less handing the largest files to the workers first.
+func LargestFirst(a, b QueueItem) bool {
+	return sizeOf(a.Info) > sizeOf(b.Info)
+}
+
+//NewestFirst is a Less handing the most recently modified files to the workers first.
+func NewestFirst(a, b QueueItem) bool {
+	if a.Info == nil || b.Info == nil {
+		return b.Info == nil && a.Info != nil
+	}
+	return a.Info.ModTime().After(b.Info.ModTime())
+}
+
+func sizeOf(info os.FileInfo) int64 {
+	if info == nil {
+		return 0
+	}
+	return info.Size()
+}
+
+//prioritizer holds back up to size items and sends the first of them by Less on every time send returns.
+type prioritizer struct {
+	mutex    sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	heap     prioHeap
+	size     int
+	closed   bool
+	done     chan struct{}
+}
+
+//prioHeap is a heap of items by Less, or the order they were pushed for items Less does not tell apart.
+type prioHeap struct {
+	less  func(a, b QueueItem) bool
+	items []prioItem
+	seq   uint64
+}
+
+type prioItem struct {
+	item
+	qi  QueueItem
+	seq uint64
+}
+
+func (h *prioHeap) Len() int { return len(h.items) }
+
+func (h *prioHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	switch {
+	case h.less(a.qi, b.qi):
+		return true
+	case h.less(b.qi, a.qi):
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *prioHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *prioHeap) Push(x interface{}) { h.items = append(h.items, x.(prioItem)) }
+
+func (h *prioHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items[len(h.items)-1] = prioItem{}
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+//newPrioritizer starts sending items to send, which blocks until a worker takes them.
+func newPrioritizer(size int, less func(a, b QueueItem) bool, send func(item)) *prioritizer {
+	if size < 1 {
+		size = 1
+	}
+	p := &prioritizer{heap: prioHeap{less: less}, size: size, done: make(chan struct{})}
+	p.notEmpty = sync.NewCond(&p.mutex)
+	p.notFull = sync.NewCond(&p.mutex)
+	go func() {
+		defer close(p.done)
+		for {
+			p.mutex.Lock()
+			for p.heap.Len() == 0 && !p.closed {
+				p.notEmpty.Wait()
+			}
+			if p.heap.Len() == 0 {
+				p.mutex.Unlock()
+				return
+			}
+			pi := heap.Pop(&p.heap).(prioItem)
+			p.mutex.Unlock()
+			p.notFull.Signal()
+			send(pi.item)
+		}
+	}()
+	return p
+}
+
+//push adds w, waiting while size items are held back already.
+func (p *prioritizer) push(w item) {
+	//Less reading the info of a path found in a directory would stat it while every push and pop waits.
+	statNow(w.info)
+	p.mutex.Lock()
+	for p.heap.Len() >= p.size {
+		p.notFull.Wait()
+	}
+	p.heap.seq++
+	heap.Push(&p.heap, prioItem{item: w, qi: QueueItem{Path: w.path, Info: w.info, Root: w.root}, seq: p.heap.seq})
+	p.mutex.Unlock()
+	p.notEmpty.Signal()
+}
+
+func (p *prioritizer) len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.heap.Len()
+}
+
+//flush sends everything held back and stops.
+func (p *prioritizer) flush() {
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+	p.notEmpty.Broadcast()
+	<-p.done
+}
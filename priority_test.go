@@ -0,0 +1,83 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//sizeWorker keeps the sizes of the files in the order it got them, holding on to the first one for a while
+//so the walk finds everything before the rest are handed on.
+type sizeWorker struct {
+	mutex sync.Mutex
+	sizes []int64
+}
+
+func (w *sizeWorker) Work(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	w.mutex.Lock()
+	w.sizes = append(w.sizes, info.Size())
+	first := len(w.sizes) == 1
+	w.mutex.Unlock()
+	if first {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestLess(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("d%d/f%02d", i%5, i)] = strings.Repeat("x", (i*37)%50)
+	}
+	writeFiles(t, tmp, files)
+	w := new(sizeWorker)
+	sw := skywalker.New(tmp, w)
+	sw.NumWorkers = 1
+	sw.QueueSize = 1000
+	sw.Less = skywalker.LargestFirst
+	assert.NoError(sw.Walk())
+	if assert.Len(w.sizes, 50) {
+		//The first path is handed on right away and the second one picked while it is worked on.
+		for i := 3; i < len(w.sizes); i++ {
+			assert.True(w.sizes[i-1] >= w.sizes[i], "%v is not largest first", w.sizes)
+		}
+	}
+
+	sw.ShuffleWindow = 10
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.Walk(), &ce) {
+		assert.Equal("Less", ce.Field)
+	}
+}
+
+func TestNewestFirst(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"old": "", "new": ""})
+	now := time.Now()
+	assert.NoError(os.Chtimes(filepath.Join(tmp, "old"), now.Add(-time.Hour), now.Add(-time.Hour)))
+	old, err := os.Stat(filepath.Join(tmp, "old"))
+	assert.NoError(err)
+	young, err := os.Stat(filepath.Join(tmp, "new"))
+	assert.NoError(err)
+	assert.True(skywalker.NewestFirst(skywalker.QueueItem{Info: young}, skywalker.QueueItem{Info: old}))
+	assert.False(skywalker.NewestFirst(skywalker.QueueItem{Info: old}, skywalker.QueueItem{Info: young}))
+	assert.True(skywalker.NewestFirst(skywalker.QueueItem{Info: old}, skywalker.QueueItem{}))
+	assert.False(skywalker.LargestFirst(skywalker.QueueItem{Info: old}, skywalker.QueueItem{}))
+}
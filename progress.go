@@ -0,0 +1,301 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"encoding/json"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+)
+
+//DefaultProgressInterval is how often the progress of a walk is saved if ProgressInterval is not set.
+const DefaultProgressInterval = time.Minute
+
+//ProgressState is what is saved in a ProgressFile.
+type ProgressState struct {
+	//Dirs are the completed directories. Nothing below them is in the list.
+	Dirs []string
+	//Files and Bytes are how many files were worked on in total, including in earlier runs.
+	Files int64
+	Bytes int64
+	Saved time.Time
+}
+
+//LoadProgress reads the ProgressFile at path.
+func LoadProgress(path string) (*ProgressState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state ProgressState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+//progress keeps track of which directories a walk completed. A directory is complete once the walk left it,
+//every path queued in it was worked on and every directory in it is complete.
+type progress struct {
+	file string
+	dir  func(string) string
+	sep  string
+
+	mutex sync.Mutex
+	state ProgressState
+	done  map[string]bool
+	nodes map[string]*progressNode
+	//stack are the directories the walk is in. Only the walking goroutine uses it.
+	stack []string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+//progressNode is a directory that is not complete yet.
+type progressNode struct {
+	//pending counts the paths queued in the directory, the directories in it and the walk itself until it leaves.
+	pending int
+	//failed is set if anything in or below the directory could not be read so it can never be complete.
+	failed bool
+	//completed are the complete directories in it, which are dropped from the list once it is complete.
+	completed []string
+}
+
+//loadProgress reads the progress of an earlier walk from the ProgressFile, if there is one.
+func (sw *Skywalker) loadProgress() error {
+	sw.progress = nil
+	if sw.ProgressFile == "" {
+		return nil
+	}
+	p := &progress{
+		file:  sw.ProgressFile,
+		dir:   filepath.Dir,
+		sep:   string(filepath.Separator),
+		done:  make(map[string]bool),
+		nodes: make(map[string]*progressNode),
+	}
+	if sw.FS != nil {
+		p.dir, p.sep = pathpkg.Dir, "/"
+	}
+	state, err := LoadProgress(sw.ProgressFile)
+	if err == nil {
+		p.state = *state
+		for _, dir := range state.Dirs {
+			p.done[dir] = true
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	sw.progress = p
+	return nil
+}
+
+//start saves the progress every interval until stop.
+func (p *progress) start(interval time.Duration) {
+	if interval == 0 {
+		interval = DefaultProgressInterval
+	}
+	p.stop = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				//A failed save is tried again at the next interval.
+				p.save()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+//finish stops saving. The ProgressFile is removed if the walk finished, otherwise the progress is saved one last time.
+func (p *progress) finish(err error) error {
+	close(p.stop)
+	p.wg.Wait()
+	if err != nil {
+		p.save()
+		return nil
+	}
+	if rerr := os.Remove(p.file); rerr != nil && !os.IsNotExist(rerr) {
+		return rerr
+	}
+	return nil
+}
+
+func (p *progress) save() error {
+	p.mutex.Lock()
+	state := p.state
+	state.Dirs = make([]string, 0, len(p.done))
+	for dir := range p.done {
+		state.Dirs = append(state.Dirs, dir)
+	}
+	p.mutex.Unlock()
+	sort.Strings(state.Dirs)
+	state.Saved = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(p.file, data, 0666, atomicfile.SPDir)
+}
+
+//completed reports whether an earlier walk completed the directory at path. It is then counted as complete in its parent.
+func (p *progress) completed(path string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.done[path] {
+		return false
+	}
+	if parent, ok := p.nodes[p.dir(path)]; ok {
+		parent.completed = append(parent.completed, path)
+	}
+	return true
+}
+
+//track wraps walkFn so every directory the walk goes into is tracked until it is complete.
+func (p *progress) track(walkFn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		p.leaveUntil(path)
+		ret := walkFn(path, info, err)
+		if err != nil {
+			p.fail(path)
+			return ret
+		}
+		if ret == nil && info.IsDir() {
+			p.enter(path)
+		}
+		return ret
+	}
+}
+
+//queue wraps dispatch so every path queued counts toward its directory.
+func (p *progress) queue(dispatch func(item)) func(item) {
+	return func(w item) {
+		p.mutex.Lock()
+		if node, ok := p.nodes[p.dir(w.path)]; ok {
+			node.pending++
+		}
+		p.mutex.Unlock()
+		dispatch(w)
+	}
+}
+
+//worked is called once a queued path was worked on. Paths dropped because the walk was canceled are not.
+func (sw *Skywalker) worked(w item) {
+	if sw.ctxErr() != nil || sw.isCanceled(w.path) {
+		return
+	}
+	sw.progress.worked(w.path, w.info)
+}
+
+func (p *progress) worked(path string, info os.FileInfo) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !info.IsDir() {
+		p.state.Files++
+		p.state.Bytes += info.Size()
+	}
+	dir := p.dir(path)
+	if node, ok := p.nodes[dir]; ok {
+		node.pending--
+		p.check(dir, node)
+	}
+}
+
+func (p *progress) enter(dir string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if parent, ok := p.nodes[p.dir(dir)]; ok && p.dir(dir) != dir {
+		parent.pending++
+	}
+	p.nodes[dir] = &progressNode{pending: 1}
+	p.stack = append(p.stack, dir)
+}
+
+//leaveUntil leaves every directory the walk is in that path is not inside of.
+func (p *progress) leaveUntil(path string) {
+	for len(p.stack) > 0 {
+		top := p.stack[len(p.stack)-1]
+		if path == top || top == "." && p.sep == "/" || strings.HasPrefix(path, strings.TrimSuffix(top, p.sep)+p.sep) {
+			return
+		}
+		p.leave()
+	}
+}
+
+//leaveAll leaves every directory once a root was walked.
+func (p *progress) leaveAll() {
+	for len(p.stack) > 0 {
+		p.leave()
+	}
+}
+
+func (p *progress) leave() {
+	dir := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if node, ok := p.nodes[dir]; ok {
+		node.pending--
+		p.check(dir, node)
+	}
+}
+
+//fail marks the directory at path, or the one it is in, and every directory above it as never complete.
+func (p *progress) fail(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, ok := p.nodes[path]; !ok {
+		path = p.dir(path)
+	}
+	for {
+		node, ok := p.nodes[path]
+		if !ok {
+			return
+		}
+		node.failed = true
+		parent := p.dir(path)
+		if parent == path {
+			return
+		}
+		path = parent
+	}
+}
+
+//check completes dir once nothing is pending in it. The mutex has to be held.
+func (p *progress) check(dir string, node *progressNode) {
+	for node.pending == 0 {
+		delete(p.nodes, dir)
+		if !node.failed {
+			for _, child := range node.completed {
+				delete(p.done, child)
+			}
+			p.done[dir] = true
+		}
+		parent := p.dir(dir)
+		next, ok := p.nodes[parent]
+		if !ok || parent == dir {
+			return
+		}
+		if !node.failed {
+			next.completed = append(next.completed, dir)
+		}
+		next.pending--
+		dir, node = parent, next
+	}
+}
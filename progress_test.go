@@ -0,0 +1,74 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type cancelWorker struct {
+	*TestWorker
+	at     string
+	cancel context.CancelFunc
+}
+
+func (cw *cancelWorker) Work(path string) {
+	cw.TestWorker.Work(path)
+	if filepath.Base(path) == cw.at {
+		cw.cancel()
+	}
+}
+
+func TestProgressFile(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a/1.txt": "1",
+		"a/2.txt": "22",
+		"a/3.txt": "333",
+		"b/4.txt": "4",
+		"b/5.txt": "5",
+		"b/6.txt": "6",
+	})
+	file := filepath.Join(t.TempDir(), "progress.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cw := &cancelWorker{TestWorker: NewTW(), at: "4.txt", cancel: cancel}
+	sw := skywalker.New(tmp, cw)
+	sw.NumWorkers = 1
+	sw.ProgressFile = file
+	assert.Equal(context.Canceled, sw.WalkContext(ctx))
+
+	state, err := skywalker.LoadProgress(file)
+	assert.NoError(err)
+	assert.Equal([]string{filepath.Join(sw.Root, "a")}, state.Dirs)
+	assert.Equal(int64(3), state.Files)
+	assert.Equal(int64(6), state.Bytes)
+
+	tw := NewTW()
+	sw = skywalker.New(tmp, tw)
+	sw.ProgressFile = file
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RCompleted {
+			skipped = append(skipped, ev.Path)
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Equal([]string{filepath.Join(sw.Root, "a")}, skipped)
+	assert.Len(tw.found, 3)
+	for path := range tw.found {
+		assert.Equal(filepath.Join(sw.Root, "b"), filepath.Dir(path))
+	}
+	_, err = os.Stat(file)
+	assert.True(os.IsNotExist(err))
+}
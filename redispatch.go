@@ -0,0 +1,74 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+)
+
+//Redispatch hands paths to the Worker again without walking, e.g. to retry the files that failed.
+//It uses the same NumWorkers, QueueSize, ShuffleWindow, Snapshot and Results as Walk but does not
+//filter paths again, as they were picked by the caller. Relative paths are relative to Root.
+//Every path has to be inside Root or one of the Overlays or ErrNotInRoot is returned before anything is
+//dispatched. Paths that no longer exist are skipped. With FS paths are inside of it, like the ones handed
+//to the Worker. It returns the first error from the Results store. It should not be called while walking.
+func (sw *Skywalker) Redispatch(paths []string) error {
+	if sw.matcher == nil {
+		if err := sw.init(); err != nil {
+			return err
+		}
+	} else if err := sw.validate(); err != nil {
+		return err
+	}
+	items := make([]item, 0, len(paths))
+	for _, path := range paths {
+		if sw.FS != nil {
+			path = pathpkg.Clean(path)
+			if sw.Root != "." && path != sw.Root && !strings.HasPrefix(path, sw.Root+"/") {
+				return ErrNotInRoot
+			}
+			items = append(items, item{path: path, root: sw.Root})
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sw.Root, path)
+		}
+		root, err := sw.rootOf(path)
+		if err != nil {
+			return err
+		}
+		items = append(items, item{path: filepath.Clean(path), root: root})
+	}
+	unlock, err := sw.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	dispatch, wait, err := sw.pool()
+	if err != nil {
+		return err
+	}
+	for _, w := range items {
+		info, err := sw.lstat(w.path)
+		if err != nil {
+			continue
+		}
+		w.info = info
+		dispatch(w)
+	}
+	return wait()
+}
+
+//rootOf returns the highest root path is in.
+func (sw *Skywalker) rootOf(path string) (string, error) {
+	for i := len(sw.layers) - 1; i >= 0; i-- {
+		if _, err := relPath(sw.layers[i].root, path); err == nil {
+			return sw.layers[i].root, nil
+		}
+	}
+	return "", ErrNotInRoot
+}
@@ -0,0 +1,45 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedispatch(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "a",
+		"sub/b.txt": "bb",
+		"empty":     "",
+	})
+	store := skywalker.NewMemoryStore()
+	sw := skywalker.New(tmp, &SizeWorker{TestWorker: NewTW()})
+	sw.Results = store
+	assert.NoError(sw.Walk())
+
+	var failed []string
+	for path, res := range store.Results() {
+		if res.Err != nil {
+			failed = append(failed, path)
+		}
+	}
+	assert.Equal([]string{filepath.Join(sw.Root, "empty")}, failed)
+
+	writeFiles(t, tmp, map[string]string{"empty": "not anymore"})
+	sw.Results = skywalker.NewMemoryStore()
+	assert.NoError(sw.Redispatch(append(failed, "missing")))
+	assert.Equal(map[string]skywalker.Result{
+		filepath.Join(sw.Root, "empty"): {Value: int64(11)},
+	}, sw.Results.(*skywalker.MemoryStore).Results())
+
+	assert.Equal(skywalker.ErrNotInRoot, sw.Redispatch([]string{os.TempDir()}))
+}
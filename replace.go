@@ -0,0 +1,99 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+)
+
+//ReplaceResult is what a ReplaceWorker did with a single file.
+type ReplaceResult struct {
+	TransformResult
+	//Count is how many matches of the pattern were replaced.
+	Count int
+}
+
+//ReplaceWorker is a Worker that replaces every match of Pattern with Replacement in each file it is given.
+//Replacement may reference submatches the same way regexp.Regexp.Expand does ($1, ${name}).
+//Files are replaced atomically and only if something matched.
+//Directories are ignored so it is best used with FilesOnly.
+type ReplaceWorker struct {
+	//Pattern is what to search for.
+	Pattern *regexp.Regexp
+
+	//Replacement is what each match is replaced with.
+	Replacement string
+
+	//DryRun leaves every file alone and fills in the Diff of each result instead.
+	DryRun bool
+
+	//BackupSuffix is added to a copy of the original file made before it is replaced.
+	//No backup is made if it is empty.
+	BackupSuffix string
+
+	//Sync is how hard to try making each replaced file survive a crash.
+	Sync atomicfile.SyncPolicy
+
+	//OnReplace is called after each file is handled if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnReplace func(ReplaceResult)
+
+	mutex   sync.Mutex
+	results []ReplaceResult
+}
+
+//NewReplaceWorker creates a ReplaceWorker that replaces pattern with replacement.
+func NewReplaceWorker(pattern *regexp.Regexp, replacement string) *ReplaceWorker {
+	return &ReplaceWorker{
+		Pattern:     pattern,
+		Replacement: replacement,
+	}
+}
+
+//Work replaces every match in the file at path.
+func (rw *ReplaceWorker) Work(path string) {
+	tw := &TransformWorker{
+		DryRun:       rw.DryRun,
+		BackupSuffix: rw.BackupSuffix,
+		Sync:         rw.Sync,
+	}
+	var count int
+	tr, ok := tw.transform(path, func(r io.Reader, w io.Writer) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		count = len(rw.Pattern.FindAllIndex(data, -1))
+		if count == 0 {
+			_, err = w.Write(data)
+			return err
+		}
+		_, err = w.Write(rw.Pattern.ReplaceAll(data, []byte(rw.Replacement)))
+		return err
+	})
+	if !ok {
+		return
+	}
+	res := ReplaceResult{TransformResult: tr, Count: count}
+	rw.mutex.Lock()
+	rw.results = append(rw.results, res)
+	rw.mutex.Unlock()
+	if rw.OnReplace != nil {
+		rw.OnReplace(res)
+	}
+}
+
+//Results returns what happened to every file handled so far.
+func (rw *ReplaceWorker) Results() []ReplaceResult {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	results := make([]ReplaceResult, len(rw.results))
+	copy(results, rw.results)
+	return results
+}
@@ -0,0 +1,57 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplaceWorker(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		name := "Replace"
+		if dryRun {
+			name = "DryRun"
+		}
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			tmp := t.TempDir()
+			writeFiles(t, tmp, map[string]string{
+				"a.go": "foo1()\nfoo2()\nbar()\n",
+				"b.go": "bar()\n",
+			})
+
+			rw := skywalker.NewReplaceWorker(regexp.MustCompile(`foo(\d)`), "baz$1")
+			rw.DryRun = dryRun
+			sw := skywalker.New(tmp, rw)
+			sw.ExtListType = skywalker.LTWhitelist
+			sw.ExtList = []string{".go"}
+			assert.NoError(sw.Walk())
+
+			counts := make(map[string]skywalker.ReplaceResult)
+			for _, res := range rw.Results() {
+				assert.NoError(res.Err)
+				counts[filepath.Base(res.Path)] = res
+			}
+			assert.Equal(2, counts["a.go"].Count)
+			assert.True(counts["a.go"].Changed)
+			assert.Equal(0, counts["b.go"].Count)
+			assert.False(counts["b.go"].Changed)
+
+			data, _ := os.ReadFile(filepath.Join(tmp, "a.go"))
+			if dryRun {
+				assert.Equal("foo1()\nfoo2()\nbar()\n", string(data))
+				assert.Contains(counts["a.go"].Diff, "-foo1()\n-foo2()\n+baz1()\n+baz2()\n bar()\n")
+			} else {
+				assert.Equal("baz1()\nbaz2()\nbar()\n", string(data))
+			}
+		})
+	}
+}
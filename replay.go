@@ -0,0 +1,156 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//Decisions recorded for every path a walk visits.
+const (
+	decisionQueued   = "queued"
+	decisionFiltered = "filtered"
+	decisionSkipped  = "skipped"
+	decisionShadowed = "shadowed"
+	decisionError    = "error"
+)
+
+//recordLine is a single path in a recording.
+type recordLine struct {
+	Path     string      `json:"path"`
+	PathRaw  []byte      `json:"path_raw,omitempty"`
+	Root     string      `json:"root"`
+	RootRaw  []byte      `json:"root_raw,omitempty"`
+	Size     int64       `json:"size,omitempty"`
+	Mode     os.FileMode `json:"mode,omitempty"`
+	ModTime  time.Time   `json:"mod_time,omitempty"`
+	Info     bool        `json:"info,omitempty"`
+	Decision string      `json:"decision"`
+	Reason   Reason      `json:"reason,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+//recorder writes a recording from the walking goroutine, keeping the first error.
+type recorder struct {
+	enc *json.Encoder
+	err error
+}
+
+//record writes what was decided about path if the walk is being recorded.
+func (sw *Skywalker) record(path, root string, info os.FileInfo, decision string, reason Reason, err error) {
+	rec := sw.recorder
+	if rec == nil || rec.err != nil {
+		return
+	}
+	line := recordLine{Path: path, PathRaw: rawPath(path), Root: root, RootRaw: rawPath(root), Decision: decision, Reason: reason}
+	if info != nil {
+		line.Info = true
+		line.Size = info.Size()
+		line.Mode = info.Mode()
+		line.ModTime = info.ModTime()
+	}
+	if err != nil {
+		line.Error = err.Error()
+	}
+	rec.err = rec.enc.Encode(line)
+}
+
+//recordedInfo is the os.FileInfo of a recorded path.
+type recordedInfo struct {
+	line recordLine
+}
+
+func (ri recordedInfo) Name() string       { return filepath.Base(ri.line.Path) }
+func (ri recordedInfo) Size() int64        { return ri.line.Size }
+func (ri recordedInfo) Mode() os.FileMode  { return ri.line.Mode }
+func (ri recordedInfo) ModTime() time.Time { return ri.line.ModTime }
+func (ri recordedInfo) IsDir() bool        { return ri.line.Mode.IsDir() }
+func (ri recordedInfo) Sys() interface{}   { return nil }
+
+//Replay hands the Worker every path queued in a recording made with Record, in the order they were queued,
+//without touching the filesystem. The FileInfo handed to SnapshotWorkers and ResultWorkers is the one recorded.
+//A queued path recorded without one is looked up like Redispatch does and sent as an EKError event if it is gone.
+//OnEvent gets the same queued, skipped and error events the recorded walk had, so a bug further down
+//can be reproduced deterministically, e.g. in CI. Use a single worker for the exact same order of work.
+//Filters are not applied again. Anything reading files, like DetectEncoding, Snapshot, Prefetch or the
+//Worker itself, still does.
+func (sw *Skywalker) Replay(r io.Reader) error {
+	if err := sw.validate(); err != nil {
+		return err
+	}
+	if sw.TreeLock != TLNone {
+		return &ConfigError{Field: "TreeLock", Msg: "can not be used with Replay"}
+	}
+	sw.resetCanceled()
+	sw.segments = nil
+	sw.extStats = make(map[string]ExtStat)
+	started := time.Now()
+	sw.emit(Event{Kind: EKStart, Root: sw.Root})
+	err := sw.replay(r)
+	sw.emit(Event{Kind: EKFinish, Root: sw.Root, Err: err, Duration: time.Since(started)})
+	return err
+}
+
+func (sw *Skywalker) replay(r io.Reader) error {
+	dispatch, wait, err := sw.pool()
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for n := 1; scanner.Scan(); n++ {
+		var line recordLine
+		if err = json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			err = fmt.Errorf("recording line %d: %v", n, err)
+			break
+		}
+		line.Path, line.Root = fromRaw(line.Path, line.PathRaw), fromRaw(line.Root, line.RootRaw)
+		var info os.FileInfo
+		if line.Info {
+			info = recordedInfo{line: line}
+		}
+		switch line.Decision {
+		case decisionQueued:
+			if info == nil {
+				//The workers need the info of a path, so it is looked up like Redispatch does.
+				fi, lerr := sw.lstat(line.Path)
+				if lerr != nil {
+					sw.emit(Event{Kind: EKError, Path: line.Path, Root: line.Root, Err: lerr})
+					continue
+				}
+				info = fi
+			}
+			if !info.IsDir() {
+				sw.countExt(line.Path, info)
+			}
+			sw.emit(Event{Kind: EKQueued, Path: line.Path, Root: line.Root, Info: info})
+			dispatch(item{path: line.Path, info: info, root: line.Root})
+		case decisionSkipped:
+			sw.emit(Event{Kind: EKSkipped, Path: line.Path, Root: line.Root, Info: info, Reason: line.Reason})
+		case decisionError:
+			sw.emit(Event{Kind: EKError, Path: line.Path, Root: line.Root, Info: info, Err: replayedError(line.Error)})
+		}
+	}
+	if err == nil {
+		err = scanner.Err()
+	}
+	if werr := wait(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+//replayedError is an error recorded while walking. Only its message is kept.
+type replayedError string
+
+func (re replayedError) Error() string {
+	return string(re)
+}
@@ -0,0 +1,100 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a/one.txt":       "1",
+		"a/two.txt":       "22",
+		"a/skip.log":      "log",
+		"vendor/lib.txt":  "lib",
+		"b/three.txt":     "333",
+		"b/deeper/4.txt":  "4444",
+		"b/deeper/5.json": "{}",
+	})
+
+	var events []skywalker.Event
+	var mutex sync.Mutex
+	onEvent := func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKDone || ev.Kind == skywalker.EKFinish {
+			return
+		}
+		mutex.Lock()
+		ev.Info = nil
+		ev.Duration = 0
+		events = append(events, ev)
+		mutex.Unlock()
+	}
+
+	recording := new(bytes.Buffer)
+	sw := skywalker.New(tmp, &SizeWorker{NewTW()})
+	sw.NumWorkers = 1
+	sw.DirList = []string{"vendor"}
+	sw.ExtListType = skywalker.LTWhitelist
+	sw.ExtList = []string{".txt"}
+	sw.Record = recording
+	sw.Results = skywalker.NewMemoryStore()
+	sw.OnEvent = onEvent
+	assert.NoError(sw.Walk())
+	walked := events
+	results := sw.Results.(*skywalker.MemoryStore).Results()
+
+	assert.NoError(os.RemoveAll(tmp))
+	events = nil
+	replayed := skywalker.New(tmp, &SizeWorker{NewTW()})
+	replayed.NumWorkers = 1
+	replayed.Results = skywalker.NewMemoryStore()
+	replayed.OnEvent = onEvent
+	assert.NoError(replayed.Replay(bytes.NewReader(recording.Bytes())))
+	assert.Equal(walked, events)
+	assert.Equal(results, replayed.Results.(*skywalker.MemoryStore).Results())
+	assert.Len(results, 4)
+	assert.Equal(int64(4), results[filepath.Join(sw.Root, "b", "deeper", "4.txt")].Value)
+
+	err := replayed.Replay(bytes.NewReader([]byte("{not json\n")))
+	assert.NotNil(err)
+}
+
+func TestReplayWithoutInfo(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "aaa"})
+	recording := new(bytes.Buffer)
+	enc := json.NewEncoder(recording)
+	for _, name := range []string{"a.txt", "gone.txt"} {
+		assert.NoError(enc.Encode(map[string]string{"path": filepath.Join(tmp, name), "root": tmp, "decision": "queued"}))
+	}
+
+	sw := skywalker.New(tmp, &SizeWorker{NewTW()})
+	sw.DetectEncoding = true
+	sw.Results = skywalker.NewMemoryStore()
+	var gone int32
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKError && errors.Is(ev.Err, os.ErrNotExist) {
+			atomic.AddInt32(&gone, 1)
+		}
+	}
+	assert.NoError(sw.Replay(recording))
+	results := sw.Results.(*skywalker.MemoryStore).Results()
+	assert.Len(results, 1)
+	assert.Equal(int64(3), results[filepath.Join(tmp, "a.txt")].Value)
+	assert.Equal(int32(1), atomic.LoadInt32(&gone))
+}
@@ -0,0 +1,143 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+//KeyFunc returns the idempotency key of the file at path, see Skywalker.Journal.
+type KeyFunc func(path string, info os.FileInfo) (string, error)
+
+//MetaKey is the KeyFunc keying a file by its path, size and modification time, so it is worked on again once it
+//changes. It does not read the file.
+func MetaKey(path string, info os.FileInfo) (string, error) {
+	return path + "\x00" + strconv.FormatInt(info.Size(), 10) + "\x00" + strconv.FormatInt(info.ModTime().UnixNano(), 10), nil
+}
+
+//ContentKey is the KeyFunc keying a file by the SHA-256 of its contents, so a file that is moved or copied is not
+//worked on again either. It reads every file through the operating system, so it can not be used with FS.
+func ContentKey(path string, _ os.FileInfo) (string, error) {
+	file, err := Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//ResultJournal is an append only file of the idempotency keys of the files a Worker is done with, so a walk that is
+//run again after a crash or a restart skips them. Every key is written as soon as it is added.
+//It is safe to use concurrently.
+type ResultJournal struct {
+	mutex sync.Mutex
+	file  *os.File
+	keys  map[string]struct{}
+}
+
+//OpenResultJournal opens the ResultJournal at path, reading the keys already in it, or creates it.
+//A line that was only partly written when the process stopped is dropped.
+func OpenResultJournal(path string) (*ResultJournal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	rj := &ResultJournal{file: file, keys: make(map[string]struct{})}
+	r := bufio.NewReader(file)
+	//end is the offset after the last complete line.
+	var end int64
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		end += int64(len(line))
+		if key, err := strconv.Unquote(line[:len(line)-1]); err == nil {
+			rj.keys[key] = struct{}{}
+		}
+	}
+	if err = file.Truncate(end); err == nil {
+		_, err = file.Seek(end, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return rj, nil
+}
+
+//Contains reports whether key was added.
+func (rj *ResultJournal) Contains(key string) bool {
+	rj.mutex.Lock()
+	defer rj.mutex.Unlock()
+	_, ok := rj.keys[key]
+	return ok
+}
+
+//Add writes key to the journal unless it is in it already.
+func (rj *ResultJournal) Add(key string) error {
+	rj.mutex.Lock()
+	defer rj.mutex.Unlock()
+	if _, ok := rj.keys[key]; ok {
+		return nil
+	}
+	if _, err := rj.file.WriteString(strconv.Quote(key) + "\n"); err != nil {
+		return err
+	}
+	rj.keys[key] = struct{}{}
+	return nil
+}
+
+//Len returns how many keys are in the journal.
+func (rj *ResultJournal) Len() int {
+	rj.mutex.Lock()
+	defer rj.mutex.Unlock()
+	return len(rj.keys)
+}
+
+//Close syncs the journal to disk and closes it.
+func (rj *ResultJournal) Close() error {
+	rj.mutex.Lock()
+	defer rj.mutex.Unlock()
+	err := rj.file.Sync()
+	if cerr := rj.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+//journalKey returns the key of w for the Journal, or "" if it has none.
+func (sw *Skywalker) journalKey(w item) string {
+	keyFn := sw.JournalKey
+	if keyFn == nil {
+		keyFn = MetaKey
+	}
+	key, err := keyFn(w.path, w.info)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+//journal adds key to the Journal, keeping the first error for Walk to return.
+func (sw *Skywalker) journal(key string) {
+	if err := sw.Journal.Add(key); err != nil {
+		sw.storeErr(err)
+	}
+}
@@ -0,0 +1,110 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultJournal(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "a", "b.txt": "b", "c.pdf": "c", "d/e.txt": "e"})
+	path := filepath.Join(t.TempDir(), "journal")
+	walk := func() (*PDFWorker, int32, error) {
+		rj, err := skywalker.OpenResultJournal(path)
+		if !assert.NoError(err) {
+			t.FailNow()
+		}
+		defer func() { assert.NoError(rj.Close()) }()
+		pw := &PDFWorker{TestWorker: NewTW()}
+		sw := skywalker.New(tmp, pw)
+		sw.FilesOnly = true
+		sw.Journal = rj
+		var skipped int32
+		sw.OnEvent = func(ev skywalker.Event) {
+			if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RJournaled {
+				atomic.AddInt32(&skipped, 1)
+			}
+		}
+		return pw, skipped, sw.Walk()
+	}
+
+	pw, skipped, err := walk()
+	assert.ErrorIs(err, errPDF)
+	assert.Len(pw.found, 4)
+	assert.Equal(int32(0), skipped)
+
+	//The failed file is worked on again, the others only once they change.
+	pw, skipped, err = walk()
+	assert.ErrorIs(err, errPDF)
+	assert.Len(pw.found, 1)
+	assert.Equal(int32(3), skipped)
+
+	writeFiles(t, tmp, map[string]string{"b.txt": "bb"})
+	pw, _, _ = walk()
+	assert.Len(pw.found, 2)
+	assert.Contains(pw.found, filepath.Join(tmp, "b.txt"))
+
+	//A line cut short by a crash is dropped.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0666)
+	assert.NoError(err)
+	_, err = file.WriteString(`"half`)
+	assert.NoError(err)
+	assert.NoError(file.Close())
+	rj, err := skywalker.OpenResultJournal(path)
+	assert.NoError(err)
+	assert.Equal(4, rj.Len())
+	assert.NoError(rj.Add("key"))
+	assert.True(rj.Contains("key"))
+	assert.NoError(rj.Close())
+	rj, err = skywalker.OpenResultJournal(path)
+	assert.NoError(err)
+	assert.Equal(5, rj.Len())
+	assert.True(rj.Contains("key"))
+	assert.NoError(rj.Close())
+}
+
+func TestResultJournalEncodings(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "a", "b.bin": "\x00\x01\x02"})
+	rj, err := skywalker.OpenResultJournal(filepath.Join(t.TempDir(), "journal"))
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	defer rj.Close()
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.FilesOnly = true
+	sw.Journal = rj
+	sw.Encodings = []skywalker.Encoding{skywalker.EASCII}
+	assert.NoError(sw.Walk())
+	assert.Len(tw.found, 1)
+	//The file left out by Encodings was never handed to the Worker, so it is not done.
+	assert.Equal(1, rj.Len())
+}
+
+func TestContentKey(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "same", "b/c.txt": "same", "d.txt": "other"})
+	key := func(name string) string {
+		path := filepath.Join(tmp, name)
+		info, err := os.Stat(path)
+		assert.NoError(err)
+		k, err := skywalker.ContentKey(path, info)
+		assert.NoError(err)
+		return k
+	}
+	assert.Equal(key("a.txt"), key("b/c.txt"))
+	assert.NotEqual(key("a.txt"), key("d.txt"))
+}
@@ -0,0 +1,183 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"database/sql"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+)
+
+//WorkItem is a path handed to a ResultWorker along with what it looked like when it was found.
+type WorkItem struct {
+	Path string
+	Info os.FileInfo
+	//Root is the root Path was found in. It is only different from Skywalker.Root when using Overlays.
+	Root string
+	//Annotations are only filled in if the Skywalker was asked for them.
+	Annotations
+	//Labels are the Labels of the Skywalker.
+	Labels map[string]string
+}
+
+//Result is what a ResultWorker returned for a WorkItem.
+type Result struct {
+	Value interface{}
+	Err   error
+}
+
+//ResultWorker is a Worker that returns a value for every path.
+//If the Skywalker's Worker is a ResultWorker WorkResult is called instead of Work or WorkSnapshot
+//and what it returns is handed to the Skywalker's Results store.
+type ResultWorker interface {
+	Worker
+	WorkResult(item WorkItem) (interface{}, error)
+}
+
+//ResultStore keeps what a ResultWorker returned.
+//Put is called concurrently from every worker so it must be thread safe.
+//Flush is called once after the last Put of a walk.
+type ResultStore interface {
+	Put(item WorkItem, res Result) error
+	Flush() error
+}
+
+//MemoryStore is a ResultStore that keeps every Result in memory keyed by path.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	results map[string]Result
+}
+
+//NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{results: make(map[string]Result)}
+}
+
+//Put stores res for the path of item.
+func (ms *MemoryStore) Put(item WorkItem, res Result) error {
+	ms.mutex.Lock()
+	ms.results[item.Path] = res
+	ms.mutex.Unlock()
+	return nil
+}
+
+//Flush does nothing as everything is already in memory.
+func (ms *MemoryStore) Flush() error {
+	return nil
+}
+
+//Results returns a copy of everything stored so far.
+func (ms *MemoryStore) Results() map[string]Result {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	results := make(map[string]Result, len(ms.results))
+	for path, res := range ms.results {
+		results[path] = res
+	}
+	return results
+}
+
+//SQLiteStore is a ResultStore that writes every Result into a SQLite table.
+//Values are stored JSON encoded and errors by their message. The table is created if it does not exist:
+//
+//	CREATE TABLE <table> (path TEXT PRIMARY KEY, size INTEGER, mod_time INTEGER, value TEXT, error TEXT)
+//
+//skywalker does not import a SQLite driver. Open db with whichever driver the program already uses.
+type SQLiteStore struct {
+	//BatchSize is how many results are buffered before they are written in a single transaction.
+	BatchSize int
+
+	db    *sql.DB
+	table string
+
+	mutex   sync.Mutex
+	pending []sqliteRow
+}
+
+//quoteIdent quotes name as an SQL identifier, doubling the quotes in it.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+type sqliteRow struct {
+	path    string
+	size    int64
+	modTime int64
+	value   []byte
+	err     sql.NullString
+}
+
+//NewSQLiteStore creates a SQLiteStore that writes into table, creating it if needed.
+//BatchSize defaults to 500.
+func NewSQLiteStore(db *sql.DB, table string) (*SQLiteStore, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + quoteIdent(table) + ` (path TEXT PRIMARY KEY, size INTEGER, mod_time INTEGER, value TEXT, error TEXT)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{
+		BatchSize: 500,
+		db:        db,
+		table:     table,
+	}, nil
+}
+
+//Put buffers res and writes the buffer once it holds BatchSize results.
+func (ss *SQLiteStore) Put(item WorkItem, res Result) error {
+	value, err := json.Marshal(res.Value)
+	if err != nil {
+		return err
+	}
+	row := sqliteRow{path: item.Path, value: value}
+	if item.Info != nil {
+		row.size = item.Info.Size()
+		row.modTime = item.Info.ModTime().UnixNano()
+	}
+	if res.Err != nil {
+		row.err = sql.NullString{String: res.Err.Error(), Valid: true}
+	}
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	ss.pending = append(ss.pending, row)
+	if len(ss.pending) < ss.BatchSize {
+		return nil
+	}
+	return ss.flush()
+}
+
+//Flush writes every buffered result.
+func (ss *SQLiteStore) Flush() error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	return ss.flush()
+}
+
+func (ss *SQLiteStore) flush() error {
+	if len(ss.pending) == 0 {
+		return nil
+	}
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO ` + quoteIdent(ss.table) + ` (path, size, mod_time, value, error) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range ss.pending {
+		if _, err = stmt.Exec(row.path, row.size, row.modTime, string(row.value), row.err); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	ss.pending = ss.pending[:0]
+	return nil
+}
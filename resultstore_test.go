@@ -0,0 +1,237 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type SizeWorker struct {
+	*TestWorker
+}
+
+var errEmpty = errors.New("empty file")
+
+func (sw *SizeWorker) WorkResult(item skywalker.WorkItem) (interface{}, error) {
+	if item.Info.Size() == 0 {
+		return nil, errEmpty
+	}
+	return item.Info.Size(), nil
+}
+
+type failingStore struct{ err error }
+
+func (fs failingStore) Put(skywalker.WorkItem, skywalker.Result) error { return fs.err }
+func (fs failingStore) Flush() error                                   { return nil }
+
+func TestResultStore(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+		"empty":     "",
+	})
+	store := skywalker.NewMemoryStore()
+	sw := skywalker.New(tmp, &SizeWorker{TestWorker: NewTW()})
+	sw.Results = store
+	assert.NoError(sw.Walk())
+
+	results := store.Results()
+	assert.Len(results, 3)
+	assert.Equal(int64(3), results[filepath.Join(sw.Root, "a.txt")].Value)
+	assert.Equal(int64(2), results[filepath.Join(sw.Root, "sub", "b.txt")].Value)
+	assert.Equal(errEmpty, results[filepath.Join(sw.Root, "empty")].Err)
+
+	storeErr := errors.New("store is full")
+	sw.Results = failingStore{err: storeErr}
+	assert.Equal(storeErr, sw.Walk())
+}
+
+//memSQL is a database/sql driver for the statements SQLiteStore runs, keeping a database in memory for every
+//name it is opened with. Table names are read the way SQLite reads quoted identifiers.
+type memSQL struct {
+	mutex sync.Mutex
+	dbs   map[string]map[string]map[string][]driver.Value
+}
+
+var memDriver = &memSQL{dbs: make(map[string]map[string]map[string][]driver.Value)}
+
+func init() {
+	sql.Register("memsql", memDriver)
+}
+
+func (d *memSQL) Open(name string) (driver.Conn, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.dbs[name] == nil {
+		d.dbs[name] = make(map[string]map[string][]driver.Value)
+	}
+	return &memConn{d: d, tables: d.dbs[name]}, nil
+}
+
+type memConn struct {
+	d      *memSQL
+	tables map[string]map[string][]driver.Value
+}
+
+func (c *memConn) Prepare(query string) (driver.Stmt, error) {
+	return &memStmt{c: c, query: query}, nil
+}
+func (c *memConn) Close() error              { return nil }
+func (c *memConn) Begin() (driver.Tx, error) { return memTx{}, nil }
+
+type memTx struct{}
+
+func (memTx) Commit() error   { return nil }
+func (memTx) Rollback() error { return nil }
+
+type memStmt struct {
+	c     *memConn
+	query string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+//table returns the table named in the query after prefix, which has to be followed by suffix.
+func (s *memStmt) table(prefix, suffix string) (string, error) {
+	if !strings.HasPrefix(s.query, prefix+`"`) {
+		return "", errors.New("unsupported query: " + s.query)
+	}
+	rest := s.query[len(prefix)+1:]
+	var name strings.Builder
+	for i := 0; i < len(rest); i++ {
+		if rest[i] != '"' {
+			name.WriteByte(rest[i])
+			continue
+		}
+		if i+1 < len(rest) && rest[i+1] == '"' {
+			name.WriteByte('"')
+			i++
+			continue
+		}
+		if !strings.HasPrefix(rest[i+1:], suffix) {
+			return "", errors.New("syntax error: " + s.query)
+		}
+		return name.String(), nil
+	}
+	return "", errors.New("unterminated identifier: " + s.query)
+}
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mutex.Lock()
+	defer s.c.d.mutex.Unlock()
+	if table, err := s.table("CREATE TABLE IF NOT EXISTS ", " (path TEXT PRIMARY KEY,"); err == nil {
+		if s.c.tables[table] == nil {
+			s.c.tables[table] = make(map[string][]driver.Value)
+		}
+		return driver.RowsAffected(0), nil
+	}
+	table, err := s.table("INSERT OR REPLACE INTO ", " (path, size, mod_time, value, error) VALUES")
+	if err != nil {
+		return nil, err
+	}
+	rows, ok := s.c.tables[table]
+	if !ok {
+		return nil, errors.New("no such table: " + table)
+	}
+	rows[args[0].(string)] = append([]driver.Value(nil), args...)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.c.d.mutex.Lock()
+	defer s.c.d.mutex.Unlock()
+	table, err := s.table("SELECT path, size, mod_time, value, error FROM ", " ORDER BY path")
+	if err != nil {
+		return nil, err
+	}
+	rows, ok := s.c.tables[table]
+	if !ok {
+		return nil, errors.New("no such table: " + table)
+	}
+	mr := &memRows{}
+	for _, row := range rows {
+		mr.rows = append(mr.rows, row)
+	}
+	sort.Slice(mr.rows, func(i, j int) bool { return mr.rows[i][0].(string) < mr.rows[j][0].(string) })
+	return mr, nil
+}
+
+type memRows struct{ rows [][]driver.Value }
+
+func (r *memRows) Columns() []string { return []string{"path", "size", "mod_time", "value", "error"} }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLiteStore(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "aaa",
+		"sub/b.txt": "bb",
+		"empty":     "",
+	})
+	db, err := sql.Open("memsql", tmp)
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	defer db.Close()
+	//A quote in the name has to be doubled, not escaped like in Go.
+	const table = `walk "results"`
+	store, err := skywalker.NewSQLiteStore(db, table)
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	store.BatchSize = 2
+	sw := skywalker.New(tmp, &SizeWorker{TestWorker: NewTW()})
+	sw.Results = store
+	assert.NoError(sw.Walk())
+
+	rows, err := db.Query(`SELECT path, size, mod_time, value, error FROM "walk ""results""" ORDER BY path`)
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	defer rows.Close()
+	type row struct {
+		path, value string
+		size        int64
+		err         sql.NullString
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		var modTime int64
+		assert.NoError(rows.Scan(&r.path, &r.size, &modTime, &r.value, &r.err))
+		assert.NotEqual(int64(0), modTime)
+		got = append(got, r)
+	}
+	assert.NoError(rows.Err())
+	assert.Equal([]row{
+		{path: filepath.Join(tmp, "a.txt"), value: "3", size: 3},
+		{path: filepath.Join(tmp, "empty"), value: "null", err: sql.NullString{String: errEmpty.Error(), Valid: true}},
+		{path: filepath.Join(tmp, "sub", "b.txt"), value: "2", size: 2},
+	}, got)
+}
@@ -0,0 +1,147 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//DefaultScaleInterval is how often the workers are scaled if ScaleInterval is not set.
+const DefaultScaleInterval = 100 * time.Millisecond
+
+//scaler grows the workers of a walk toward NumWorkers while queuing has to wait for them and shrinks them
+//toward MinWorkers while some of them have nothing to do.
+type scaler struct {
+	sw    *Skywalker
+	wg    *sync.WaitGroup
+	items chan item
+	//quit is taken by a worker waiting for an item, so only idle workers stop.
+	quit chan struct{}
+
+	//pressure is set when queuing an item had to wait for a worker.
+	pressure int32
+	busy     int32
+	//running is only changed by the scaling goroutine. Workers stopping once the items are closed are not
+	//taken from it, unlike Skywalker.running.
+	running int
+
+	//mutex guards closed, after which no more workers are started so the WaitGroup can be waited on.
+	mutex  sync.Mutex
+	closed bool
+
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+//newScaler starts MinWorkers workers taking items and scales them every ScaleInterval until stop is called.
+func (sw *Skywalker) newScaler(wg *sync.WaitGroup, items chan item) *scaler {
+	s := &scaler{sw: sw, wg: wg, items: items, quit: make(chan struct{}), done: make(chan struct{})}
+	s.add(sw.MinWorkers)
+	interval := sw.ScaleInterval
+	if interval == 0 {
+		interval = DefaultScaleInterval
+	}
+	s.stopped.Add(1)
+	go func() {
+		defer s.stopped.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.scale()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+//push queues w, noting when it has to wait for a worker.
+func (s *scaler) push(w item) {
+	select {
+	case s.items <- w:
+	default:
+		atomic.StoreInt32(&s.pressure, 1)
+		s.items <- w
+	}
+}
+
+//scale doubles the workers if queuing had to wait since the last time, or else stops one that is idle.
+func (s *scaler) scale() {
+	if atomic.SwapInt32(&s.pressure, 0) == 1 {
+		n := s.running
+		if s.running+n > s.sw.NumWorkers {
+			n = s.sw.NumWorkers - s.running
+		}
+		s.add(n)
+		return
+	}
+	if s.running <= s.sw.MinWorkers || len(s.items) > 0 || int(atomic.LoadInt32(&s.busy)) >= s.running {
+		return
+	}
+	select {
+	case s.quit <- struct{}{}:
+		s.running--
+	default:
+	}
+}
+
+//add starts n more workers unless the items are closed.
+func (s *scaler) add(n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if n <= 0 || s.closed {
+		return
+	}
+	s.running += n
+	atomic.AddInt32(&s.sw.running, int32(n))
+	s.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go s.worker()
+	}
+}
+
+func (s *scaler) worker() {
+	defer s.wg.Done()
+	defer atomic.AddInt32(&s.sw.running, -1)
+	for {
+		select {
+		case w, ok := <-s.items:
+			if !ok {
+				return
+			}
+			atomic.AddInt32(&s.busy, 1)
+			s.sw.work(w)
+			atomic.AddInt32(&s.busy, -1)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+//close closes the items. The workers still running finish them, stopping the idle ones as they go.
+func (s *scaler) close() {
+	s.mutex.Lock()
+	s.closed = true
+	close(s.items)
+	s.mutex.Unlock()
+}
+
+//stop stops the scaling once the workers are done.
+func (s *scaler) stop() {
+	close(s.done)
+	s.stopped.Wait()
+}
+
+//Workers returns how many workers take paths from the queue of the walk, which changes with MinWorkers,
+//or 0 if nothing is running. LargeWorkers and the workers of a SharedPool are not counted.
+//It is safe to call from any goroutine.
+func (sw *Skywalker) Workers() int {
+	return int(atomic.LoadInt32(&sw.running))
+}
@@ -0,0 +1,77 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//scaleWorker is slow on every file and waits on the last one for the other workers to be stopped.
+type scaleWorker struct {
+	sw         *skywalker.Skywalker
+	busy, most int32
+	shrunk     bool
+}
+
+func (w *scaleWorker) Work(path string) {
+	busy := atomic.AddInt32(&w.busy, 1)
+	defer atomic.AddInt32(&w.busy, -1)
+	for {
+		most := atomic.LoadInt32(&w.most)
+		if busy <= most || atomic.CompareAndSwapInt32(&w.most, most, busy) {
+			break
+		}
+	}
+	if filepath.Base(path) != "z" {
+		time.Sleep(5 * time.Millisecond)
+		return
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if w.sw.Workers() == w.sw.MinWorkers {
+			w.shrunk = true
+			return
+		}
+	}
+}
+
+func TestMinWorkers(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := map[string]string{"z": ""}
+	for i := 0; i < 100; i++ {
+		files[fmt.Sprintf("a%03d", i)] = ""
+	}
+	writeFiles(t, tmp, files)
+	w := new(scaleWorker)
+	sw := skywalker.New(tmp, w)
+	w.sw = sw
+	sw.NumWorkers = 8
+	sw.MinWorkers = 1
+	sw.ScaleInterval = 5 * time.Millisecond
+	sw.QueueSize = 1
+	assert.NoError(sw.Walk())
+	assert.True(atomic.LoadInt32(&w.most) > 1, "workers were not scaled up")
+	assert.True(atomic.LoadInt32(&w.most) <= 8)
+	assert.True(w.shrunk, "workers were not scaled down")
+	assert.Equal(0, sw.Workers())
+}
+
+func TestMinWorkersConfig(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(t.TempDir(), NewTW())
+	sw.NumWorkers = 2
+	sw.MinWorkers = 3
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.Walk(), &ce) {
+		assert.Equal("MinWorkers", ce.Field)
+	}
+}
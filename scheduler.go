@@ -0,0 +1,244 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+//DefaultHistorySize is how many runs of every job a Scheduler keeps if HistorySize is not set.
+const DefaultHistorySize = 10
+
+//ErrJobRunning is the Err of the report of a run that was skipped because the job was still running.
+var ErrJobRunning = errors.New("job is still running")
+
+//ErrUnknownJob is returned by Trigger when the Scheduler has no job with the name.
+var ErrUnknownJob = errors.New("unknown job")
+
+//Scheduler runs Jobs on their Schedule with RunJob, for running file-maintenance jobs as a daemon.
+//A job is never run twice at once: if it is due while it still runs, its Overlap decides whether the
+//run is skipped or queued. Jobs without a Schedule only run when triggered.
+type Scheduler struct {
+	//HistorySize is how many reports of every job are kept for History, DefaultHistorySize if it is 0.
+	HistorySize int
+
+	//Location is the time zone the schedules are in. Defaults to time.Local.
+	Location *time.Location
+
+	//OnReport is called with the report of every run once it is done, including the skipped ones.
+	//It is called from the goroutine of the run, or of Run or Trigger for skipped ones, so it has to be safe
+	//for concurrent use.
+	OnReport func(JobReport)
+
+	jobs  map[string]*scheduledJob
+	names []string
+	mutex sync.Mutex
+	ctx   context.Context
+	runs  sync.WaitGroup
+}
+
+//scheduledJob is a job of a Scheduler with the state of its runs, guarded by the Scheduler's mutex.
+type scheduledJob struct {
+	job      Job
+	schedule *CronSchedule
+	next     time.Time
+	running  bool
+	queued   bool
+	history  []JobReport
+}
+
+//NewScheduler creates a Scheduler for jobs. It returns a JobError for the first one that is not valid
+//or that has the name of one before it.
+func NewScheduler(jobs []Job) (*Scheduler, error) {
+	s := &Scheduler{jobs: make(map[string]*scheduledJob)}
+	for _, job := range jobs {
+		if err := job.Validate(); err != nil {
+			return nil, &JobError{Job: job.Name, Err: err}
+		}
+		if _, ok := s.jobs[job.Name]; ok {
+			return nil, &JobError{Job: job.Name, Err: &ConfigError{Field: "Name", Msg: "must be unique"}}
+		}
+		sj := &scheduledJob{job: job}
+		if job.Schedule != "" {
+			sj.schedule, _ = ParseCron(job.Schedule)
+		}
+		s.jobs[job.Name] = sj
+		s.names = append(s.names, job.Name)
+	}
+	return s, nil
+}
+
+//Run runs the jobs when they are due until ctx is done, then waits for the runs to stop. The runs are
+//canceled along with ctx. Only runs started while Run is running are given ctx, Trigger uses
+//context.Background otherwise.
+func (s *Scheduler) Run(ctx context.Context) error {
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	s.mutex.Lock()
+	s.ctx = ctx
+	now := time.Now().In(loc)
+	for _, sj := range s.jobs {
+		if sj.schedule != nil {
+			sj.next = sj.schedule.Next(now)
+		}
+	}
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		s.ctx = nil
+		for _, sj := range s.jobs {
+			sj.next = time.Time{}
+		}
+		s.mutex.Unlock()
+		s.runs.Wait()
+	}()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		s.mutex.Lock()
+		now = time.Now().In(loc)
+		var wake time.Time
+		var skipped []JobReport
+		for _, name := range s.names {
+			sj := s.jobs[name]
+			if sj.next.IsZero() {
+				continue
+			}
+			if !sj.next.After(now) {
+				if report, ok := s.start(sj, now); !ok {
+					skipped = append(skipped, report)
+				}
+				sj.next = sj.schedule.Next(now)
+				if sj.next.IsZero() {
+					continue
+				}
+			}
+			if wake.IsZero() || sj.next.Before(wake) {
+				wake = sj.next
+			}
+		}
+		s.mutex.Unlock()
+		s.report(skipped...)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if wake.IsZero() {
+			wake = now.Add(24 * time.Hour)
+		}
+		timer.Reset(wake.Sub(now))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+//Trigger runs the job called name now, whether it has a Schedule or not. It returns ErrJobRunning if the job is
+//still running and skipped by its Overlap, or ErrUnknownJob.
+func (s *Scheduler) Trigger(name string) error {
+	s.mutex.Lock()
+	sj, ok := s.jobs[name]
+	if !ok {
+		s.mutex.Unlock()
+		return ErrUnknownJob
+	}
+	report, ok := s.start(sj, time.Now())
+	s.mutex.Unlock()
+	if !ok {
+		s.report(report)
+		return ErrJobRunning
+	}
+	return nil
+}
+
+//start runs sj unless it is running already, in which case the run is queued or skipped.
+//If it was skipped it returns false with the report recorded for it. The mutex has to be held.
+func (s *Scheduler) start(sj *scheduledJob, now time.Time) (JobReport, bool) {
+	if sj.running {
+		if sj.job.Overlap == "queue" {
+			sj.queued = true
+			return JobReport{}, true
+		}
+		report := JobReport{Name: sj.job.Name, Started: now, Err: ErrJobRunning}
+		s.record(sj, report)
+		return report, false
+	}
+	sj.running = true
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		for {
+			report := RunJob(ctx, sj.job)
+			s.mutex.Lock()
+			s.record(sj, report)
+			queued := sj.queued && ctx.Err() == nil
+			sj.queued = false
+			sj.running = queued
+			s.mutex.Unlock()
+			s.report(report)
+			if !queued {
+				return
+			}
+		}
+	}()
+	return JobReport{}, true
+}
+
+//report hands reports to OnReport if it is set.
+func (s *Scheduler) report(reports ...JobReport) {
+	if s.OnReport == nil {
+		return
+	}
+	for _, report := range reports {
+		s.OnReport(report)
+	}
+}
+
+//record adds report to the history of sj, dropping the oldest one past HistorySize. The mutex has to be held.
+func (s *Scheduler) record(sj *scheduledJob, report JobReport) {
+	history := s.HistorySize
+	if history == 0 {
+		history = DefaultHistorySize
+	}
+	sj.history = append(sj.history, report)
+	if len(sj.history) > history {
+		sj.history = append(sj.history[:0], sj.history[len(sj.history)-history:]...)
+	}
+}
+
+//History returns the reports of the last runs of the job called name, oldest first.
+func (s *Scheduler) History(name string) []JobReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sj, ok := s.jobs[name]
+	if !ok {
+		return nil
+	}
+	return append([]JobReport(nil), sj.history...)
+}
+
+//Next returns when the job called name is due next, or the zero time if it has no Schedule or Run is not running.
+func (s *Scheduler) Next(name string) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	sj, ok := s.jobs[name]
+	if !ok {
+		return time.Time{}
+	}
+	return sj.next
+}
@@ -0,0 +1,122 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//blockWorker holds the walk on its first path until release is closed.
+type blockWorker struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (w *blockWorker) Work(string) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.txt": "a"})
+	//The workers created for every job, in order.
+	created := map[string]chan *blockWorker{"skip": make(chan *blockWorker, 2), "queue": make(chan *blockWorker, 2)}
+	skywalker.RegisterAction("block", func(root string, args map[string]string) (skywalker.Worker, error) {
+		w := &blockWorker{started: make(chan struct{}), release: make(chan struct{})}
+		created[args["id"]] <- w
+		return w, nil
+	})
+
+	s, err := skywalker.NewScheduler([]skywalker.Job{
+		{Name: "skip", Roots: []string{tmp}, Action: "block", Args: map[string]string{"id": "skip"}},
+		{Name: "queue", Roots: []string{tmp}, Action: "block", Args: map[string]string{"id": "queue"}, Overlap: "queue"},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	s.HistorySize = 2
+	reports := make(chan skywalker.JobReport, 10)
+	s.OnReport = func(r skywalker.JobReport) { reports <- r }
+	assert.Equal(skywalker.ErrUnknownJob, s.Trigger("nope"))
+
+	assert.NoError(s.Trigger("skip"))
+	skip := <-created["skip"]
+	<-skip.started
+	assert.Equal(skywalker.ErrJobRunning, s.Trigger("skip"))
+	assert.Equal(skywalker.ErrJobRunning, s.Trigger("skip"))
+	assert.Equal(skywalker.ErrJobRunning, (<-reports).Err)
+	assert.Equal(skywalker.ErrJobRunning, (<-reports).Err)
+	close(skip.release)
+	r := <-reports
+	assert.NoError(r.Err)
+	assert.Equal(int64(1), r.Roots[0].Stats.Files)
+	history := s.History("skip")
+	if assert.Len(history, 2) {
+		assert.Equal(skywalker.ErrJobRunning, history[0].Err)
+		assert.NoError(history[1].Err)
+	}
+
+	assert.NoError(s.Trigger("queue"))
+	first := <-created["queue"]
+	<-first.started
+	assert.NoError(s.Trigger("queue"))
+	assert.NoError(s.Trigger("queue"))
+	close(first.release)
+	assert.NoError((<-reports).Err)
+	second := <-created["queue"]
+	<-second.started
+	close(second.release)
+	assert.NoError((<-reports).Err)
+	select {
+	case <-created["queue"]:
+		t.Error("Runs queued while one is queued are merged")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Len(s.History("queue"), 2)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	assert := assert.New(t)
+	s, err := skywalker.NewScheduler([]skywalker.Job{
+		{Name: "every", Roots: []string{t.TempDir()}, Action: "copy", Args: map[string]string{"dest": t.TempDir()}, Schedule: "* * * * *"},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+	var next time.Time
+	for deadline := time.Now().Add(5 * time.Second); next.IsZero() && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		next = s.Next("every")
+	}
+	assert.True(next.After(time.Now()))
+	assert.Equal(0, next.Second())
+	cancel()
+	assert.NoError(<-done)
+	assert.True(s.Next("every").IsZero())
+
+	_, err = skywalker.NewScheduler([]skywalker.Job{
+		{Name: "bad", Roots: []string{"."}, Action: "copy", Schedule: "0 25 * * *"},
+	})
+	var cse *skywalker.CronSyntaxError
+	assert.ErrorAs(err, &cse)
+	_, err = skywalker.NewScheduler([]skywalker.Job{
+		{Name: "bad", Roots: []string{"."}, Action: "copy", Overlap: "wait"},
+	})
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(err, &ce) {
+		assert.Equal("Overlap", ce.Field)
+	}
+}
@@ -0,0 +1,213 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+//ErrScratchBudget is returned by Scratch.Reserve when more is asked for than ScratchBudget allows.
+var ErrScratchBudget = errors.New("scratch budget exceeded")
+
+//ScratchWorker is a Worker that needs temporary space on disk, e.g. for transcoding or extracting files.
+//WorkScratch is called instead of Work with a Scratch only it uses while working on path. Every error it
+//returns is handled like one returned by an ErrorWorker.
+type ScratchWorker interface {
+	Worker
+	WorkScratch(path string, scratch *Scratch) error
+}
+
+//Scratch is the temporary directory of a worker. It is emptied after every path and removed with
+//everything in it once the walk is done, so nothing is left behind even if the Worker forgets.
+type Scratch struct {
+	//Dir is the directory the Worker can use as it likes.
+	Dir string
+
+	space    *scratchSpace
+	reserved int64
+}
+
+//Reserve asks for n more bytes of ScratchBudget before they are written to Dir. Everything reserved is given
+//back once the Worker is done with the path. If the budget is used up by other workers the first Reserve for
+//a path waits for them while later ones fail, so workers never wait on each other holding space.
+//It returns ErrScratchBudget if it can not be had and the error of the walk's context if it ends while waiting.
+//Without a ScratchBudget it always succeeds.
+func (s *Scratch) Reserve(n int64) error {
+	if err := s.space.reserve(n, s.reserved == 0); err != nil {
+		return err
+	}
+	s.reserved += n
+	return nil
+}
+
+//Reserved returns how many bytes were reserved for the current path.
+func (s *Scratch) Reserved() int64 {
+	return s.reserved
+}
+
+//scratchSpace hands every worker a Scratch of its own and keeps track of the ScratchBudget.
+type scratchSpace struct {
+	dir    string
+	budget int64
+	ctx    context.Context
+
+	mutex sync.Mutex
+	used  int64
+	//freed is closed and replaced every time space is given back.
+	freed chan struct{}
+	idle  []*Scratch
+	n     int
+}
+
+//newScratchSpace creates the directory of the walk in parent and the directories of n workers in it.
+func newScratchSpace(ctx context.Context, parent string, budget int64, n int) (*scratchSpace, error) {
+	if parent == "" {
+		parent = os.TempDir()
+	}
+	if err := os.MkdirAll(parent, 0777); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(parent, "skywalker-")
+	if err != nil {
+		return nil, err
+	}
+	space := &scratchSpace{dir: dir, budget: budget, ctx: ctx, freed: make(chan struct{})}
+	for i := 0; i < n; i++ {
+		s, err := space.create()
+		if err != nil {
+			space.remove()
+			return nil, err
+		}
+		space.idle = append(space.idle, s)
+	}
+	return space, nil
+}
+
+//create makes the directory of another worker. With a SharedPool there can be more workers than expected.
+func (space *scratchSpace) create() (*Scratch, error) {
+	space.mutex.Lock()
+	space.n++
+	dir := filepath.Join(space.dir, "worker-"+strconv.Itoa(space.n))
+	space.mutex.Unlock()
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Scratch{Dir: dir, space: space}, nil
+}
+
+func (space *scratchSpace) take() (*Scratch, error) {
+	space.mutex.Lock()
+	if n := len(space.idle); n > 0 {
+		s := space.idle[n-1]
+		space.idle = space.idle[:n-1]
+		space.mutex.Unlock()
+		return s, nil
+	}
+	space.mutex.Unlock()
+	return space.create()
+}
+
+//put empties the directory of s and gives back what it reserved.
+func (space *scratchSpace) put(s *Scratch) error {
+	space.release(s.reserved)
+	s.reserved = 0
+	entries, err := os.ReadDir(s.Dir)
+	for _, entry := range entries {
+		if rerr := os.RemoveAll(filepath.Join(s.Dir, entry.Name())); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	if err != nil {
+		//A directory that could not be emptied is not handed out again.
+		return err
+	}
+	space.mutex.Lock()
+	space.idle = append(space.idle, s)
+	space.mutex.Unlock()
+	return nil
+}
+
+func (space *scratchSpace) reserve(n int64, wait bool) error {
+	if space.budget == 0 || n <= 0 {
+		return nil
+	}
+	if n > space.budget {
+		return ErrScratchBudget
+	}
+	for {
+		space.mutex.Lock()
+		if space.used+n <= space.budget {
+			space.used += n
+			space.mutex.Unlock()
+			return nil
+		}
+		freed := space.freed
+		space.mutex.Unlock()
+		if !wait {
+			return ErrScratchBudget
+		}
+		select {
+		case <-freed:
+		case <-space.ctx.Done():
+			return space.ctx.Err()
+		}
+	}
+}
+
+func (space *scratchSpace) release(n int64) {
+	if space.budget == 0 || n <= 0 {
+		return
+	}
+	space.mutex.Lock()
+	space.used -= n
+	close(space.freed)
+	space.freed = make(chan struct{})
+	space.mutex.Unlock()
+}
+
+//remove deletes the directory of the walk with every worker's directory in it.
+func (space *scratchSpace) remove() error {
+	return os.RemoveAll(space.dir)
+}
+
+//openScratch creates the Scratch of every worker if the Worker is a ScratchWorker.
+func (sw *Skywalker) openScratch() error {
+	sw.scratch = nil
+	if _, ok := sw.Worker.(ScratchWorker); !ok {
+		return nil
+	}
+	ctx := sw.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	n := sw.NumWorkers + sw.largeWorkers()
+	if sw.Pool != nil {
+		n = 0
+	}
+	space, err := newScratchSpace(ctx, sw.ScratchDir, sw.ScratchBudget, n)
+	if err != nil {
+		return err
+	}
+	sw.scratch = space
+	return nil
+}
+
+//workScratch hands path to a ScratchWorker with a Scratch of its own.
+func (sw *Skywalker) workScratch(scw ScratchWorker, path string) error {
+	s, err := sw.scratch.take()
+	if err != nil {
+		return err
+	}
+	err = scw.WorkScratch(path, s)
+	if perr := sw.scratch.put(s); err == nil {
+		err = perr
+	}
+	return err
+}
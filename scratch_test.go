@@ -0,0 +1,74 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type scratchWorker struct {
+	sync.Mutex
+	dirs  map[string]bool
+	dirty int
+	size  int64
+}
+
+func (sw *scratchWorker) Work(path string) {}
+
+func (sw *scratchWorker) WorkScratch(path string, scratch *skywalker.Scratch) error {
+	if entries, _ := os.ReadDir(scratch.Dir); len(entries) > 0 {
+		sw.Lock()
+		sw.dirty++
+		sw.Unlock()
+	}
+	if err := scratch.Reserve(sw.size); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(scratch.Dir, "transcoded"), []byte(path), 0600); err != nil {
+		return err
+	}
+	sw.Lock()
+	sw.dirs[scratch.Dir] = true
+	sw.Unlock()
+	return nil
+}
+
+func TestScratch(t *testing.T) {
+	assert := assert.New(t)
+	tmp, scratch := t.TempDir(), t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.mov": "", "b.mov": "", "c.mov": "", "d.mov": "", "e.mov": "",
+	})
+	worker := &scratchWorker{dirs: make(map[string]bool), size: 10}
+	sw := skywalker.New(tmp, worker)
+	sw.NumWorkers = 2
+	sw.ScratchDir = scratch
+	sw.ScratchBudget = 15
+	assert.NoError(sw.Walk())
+	assert.Equal(0, worker.dirty)
+	assert.NotEmpty(worker.dirs)
+	assert.LessOrEqual(len(worker.dirs), 2)
+	for dir := range worker.dirs {
+		assert.Equal(scratch, filepath.Dir(filepath.Dir(dir)))
+	}
+	entries, err := os.ReadDir(scratch)
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	worker.size = 20
+	err = sw.Walk()
+	var workErrs skywalker.WorkErrors
+	if assert.True(errors.As(err, &workErrs)) {
+		assert.Len(workErrs, 5)
+		assert.ErrorIs(workErrs[0], skywalker.ErrScratchBudget)
+	}
+}
@@ -0,0 +1,210 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Package secrets finds things that look like credentials or personal information in files.
+//It is a preset on top of skywalker for the common job of auditing a tree before it is shared.
+package secrets
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dixonwille/skywalker"
+)
+
+//Rule is a single kind of thing to look for.
+type Rule struct {
+	//Name identifies the rule in every Finding.
+	Name string
+	//Pattern is matched against every line.
+	Pattern *regexp.Regexp
+	//Group is the submatch that holds the secret. 0 is the whole match.
+	Group int
+	//MinEntropy, if set, drops matches whose secret has fewer bits of Shannon entropy per character.
+	//It keeps rules for generic assignments like password=... from reporting placeholders.
+	MinEntropy float64
+}
+
+//DefaultRules look for well known credential formats and high entropy values assigned to suspicious names.
+var DefaultRules = []Rule{
+	{Name: "aws-access-key-id", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`), Group: 1},
+	{Name: "aws-secret-access-key", Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?['"=:\s]([0-9a-zA-Z/+]{40})\b`), Group: 1, MinEntropy: 4},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[0-9A-Za-z]{36})\b`), Group: 1},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\b(xox[abprs]-[0-9A-Za-z-]{10,72})\b`), Group: 1},
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY( BLOCK)?-----`)},
+	{Name: "generic-secret", Pattern: regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api_?key)["']?\s*[:=]\s*["']?([^\s"']{8,})`), Group: 1, MinEntropy: 3.5},
+}
+
+//PIIRules look for personal information. They are not part of DefaultRules as they report far more often.
+var PIIRules = []Rule{
+	{Name: "email", Pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{Name: "us-ssn", Pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+}
+
+//Finding is a single match of a Rule.
+type Finding struct {
+	Rule string
+	Path string
+	//Line and Column are where the secret starts, both counting from 1. Column is in bytes.
+	Line   int
+	Column int
+	//Match is the secret with everything but its first 4 characters masked, unless the Scanner reveals them.
+	Match string
+	//Entropy is the bits of Shannon entropy per character of the secret.
+	Entropy float64
+}
+
+//Scanner is a skywalker.ResultWorker that reports every Finding in the files it is given.
+//WorkResult returns the []Finding of each file, or nil if there were none, so findings can go to a
+//skywalker.ResultStore. Binary files and directories are skipped.
+type Scanner struct {
+	//Rules are what to look for. Defaults to DefaultRules.
+	Rules []Rule
+
+	//MaxSize skips files larger than it. Defaults to 10MB. 0 scans everything.
+	MaxSize int64
+
+	//Reveal keeps secrets unmasked in Finding.Match.
+	Reveal bool
+
+	//OnFinding is called with every Finding if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnFinding func(Finding)
+
+	mutex    sync.Mutex
+	findings []Finding
+}
+
+//NewScanner creates a Scanner using DefaultRules.
+func NewScanner() *Scanner {
+	return &Scanner{
+		Rules:   DefaultRules,
+		MaxSize: 10 << 20,
+	}
+}
+
+//Work scans the file at path.
+func (s *Scanner) Work(path string) {
+	s.WorkResult(skywalker.WorkItem{Path: path})
+}
+
+//WorkResult scans the file in item.
+func (s *Scanner) WorkResult(item skywalker.WorkItem) (interface{}, error) {
+	info := item.Info
+	if info == nil {
+		var err error
+		if info, err = os.Stat(item.Path); err != nil {
+			return nil, err
+		}
+	}
+	if !info.Mode().IsRegular() || (s.MaxSize > 0 && info.Size() > s.MaxSize) {
+		return nil, nil
+	}
+	findings, err := s.scan(item.Path)
+	if err != nil || len(findings) == 0 {
+		return nil, err
+	}
+	s.mutex.Lock()
+	s.findings = append(s.findings, findings...)
+	s.mutex.Unlock()
+	if s.OnFinding != nil {
+		for _, f := range findings {
+			s.OnFinding(f)
+		}
+	}
+	return findings, nil
+}
+
+func (s *Scanner) scan(path string) ([]Finding, error) {
+	file, err := skywalker.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	prefix, err := r.Peek(skywalker.EncodingPrefix)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if !skywalker.SniffEncoding(prefix).IsText() {
+		return nil, nil
+	}
+	var findings []Finding
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; lines.Scan(); n++ {
+		findings = append(findings, s.scanLine(path, n, lines.Bytes())...)
+	}
+	return findings, lines.Err()
+}
+
+func (s *Scanner) scanLine(path string, n int, line []byte) []Finding {
+	var findings []Finding
+	for _, rule := range s.Rules {
+		for _, loc := range rule.Pattern.FindAllSubmatchIndex(line, -1) {
+			start, end := loc[2*rule.Group], loc[2*rule.Group+1]
+			if start < 0 {
+				continue
+			}
+			secret := string(line[start:end])
+			entropy := Entropy(secret)
+			if entropy < rule.MinEntropy {
+				continue
+			}
+			if !s.Reveal {
+				secret = mask(secret)
+			}
+			findings = append(findings, Finding{
+				Rule:    rule.Name,
+				Path:    path,
+				Line:    n,
+				Column:  start + 1,
+				Match:   secret,
+				Entropy: entropy,
+			})
+		}
+	}
+	return findings
+}
+
+//Findings returns every Finding so far.
+func (s *Scanner) Findings() []Finding {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	findings := make([]Finding, len(s.findings))
+	copy(findings, s.findings)
+	return findings
+}
+
+//Entropy returns the bits of Shannon entropy per character of s.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func mask(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", len(runes)-4)
+}
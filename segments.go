@@ -0,0 +1,168 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//SegmentStat is what happened below one immediate child of Root during a walk.
+type SegmentStat struct {
+	//Segment is the name of the child of Root. Files directly in Root are under "".
+	Segment string
+	//Count is how many paths were queued and Bytes how many bytes the queued files hold.
+	Count int
+	Bytes int64
+	//Errors is how many paths could not be read while walking.
+	Errors int
+	//WalkTime is how long was spent finding paths in the segment.
+	WalkTime time.Duration
+	//WorkTime is how long workers spent on the segment's paths added together.
+	WorkTime time.Duration
+	//Duration is from when the segment was first entered until its last path was worked on.
+	Duration time.Duration
+}
+
+//segments keeps a SegmentStat for every child of Root.
+//With Overlays children of every root with the same name share a segment.
+//The walking goroutine owns current and entered, everything else is shared with the workers.
+type segments struct {
+	current string
+	entered time.Time
+
+	mutex sync.Mutex
+	stats map[string]*SegmentStat
+	start map[string]time.Time
+}
+
+func newSegments() *segments {
+	return &segments{
+		stats: make(map[string]*SegmentStat),
+		start: make(map[string]time.Time),
+	}
+}
+
+//segmentOf returns the segment path in root belongs to.
+func segmentOf(root, path string, dir bool) string {
+	rel := path
+	if root != "." {
+		//Only the root of an FS is relative.
+		rel = strings.TrimPrefix(path, root)
+	}
+	rel = strings.TrimPrefix(strings.TrimPrefix(rel, string(filepath.Separator)), "/")
+	if i := strings.IndexAny(rel, "/"+string(filepath.Separator)); i >= 0 {
+		return rel[:i]
+	}
+	if dir {
+		return rel
+	}
+	return ""
+}
+
+//stat returns the stat for seg. The mutex must be held.
+func (s *segments) stat(seg string) *SegmentStat {
+	stat, ok := s.stats[seg]
+	if !ok {
+		stat = &SegmentStat{Segment: seg}
+		s.stats[seg] = stat
+	}
+	return stat
+}
+
+//visit is called by the walker for every path below root and keeps track of the walk time.
+//It returns the segment of path.
+func (s *segments) visit(root, path string, dir bool) string {
+	seg := segmentOf(root, path, dir)
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if seg != s.current || s.entered.IsZero() {
+		s.leave(now)
+		s.current, s.entered = seg, now
+	}
+	if _, ok := s.start[seg]; !ok {
+		s.start[seg] = now
+	}
+	s.stat(seg)
+	return seg
+}
+
+//leave adds the time since the current segment was entered to its walk time. The mutex must be held.
+func (s *segments) leave(now time.Time) {
+	if s.entered.IsZero() {
+		return
+	}
+	stat := s.stat(s.current)
+	stat.WalkTime += now.Sub(s.entered)
+	if d := now.Sub(s.start[s.current]); d > stat.Duration {
+		stat.Duration = d
+	}
+}
+
+func (s *segments) failed(seg string) {
+	s.mutex.Lock()
+	s.stat(seg).Errors++
+	s.mutex.Unlock()
+}
+
+func (s *segments) queued(seg string, info os.FileInfo) {
+	s.mutex.Lock()
+	stat := s.stat(seg)
+	stat.Count++
+	if !info.IsDir() {
+		stat.Bytes += info.Size()
+	}
+	s.mutex.Unlock()
+}
+
+//done closes the walk time of the last segment walked.
+func (s *segments) done() {
+	s.mutex.Lock()
+	s.leave(time.Now())
+	s.entered = time.Time{}
+	s.mutex.Unlock()
+}
+
+//worked is called by a worker after it is done with path.
+func (s *segments) worked(root, path string, dir bool, started time.Time) {
+	if path == root {
+		return
+	}
+	seg := segmentOf(root, path, dir)
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stat := s.stat(seg)
+	stat.WorkTime += now.Sub(started)
+	if d := now.Sub(s.start[seg]); d > stat.Duration {
+		stat.Duration = d
+	}
+}
+
+func (s *segments) list() []SegmentStat {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list := make([]SegmentStat, 0, len(s.stats))
+	for _, stat := range s.stats {
+		list = append(list, *stat)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Segment < list[j].Segment })
+	return list
+}
+
+//SegmentStats returns the stats of every immediate child of Root from the last Walk, sorted by name.
+//It is empty unless Segments was set.
+//It should not be called while walking.
+func (sw *Skywalker) SegmentStats() []SegmentStat {
+	if sw.segments == nil {
+		return nil
+	}
+	return sw.segments.list()
+}
@@ -0,0 +1,48 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentStats(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"top.txt":         "1",
+		"a/one.txt":       "12",
+		"a/deep/two.txt":  "123",
+		"b/three.txt":     "1234",
+		"b/skip/four.txt": "12345",
+	})
+	sw := skywalker.New(tmp, NewTW())
+	assert.Nil(sw.SegmentStats())
+	sw.Segments = true
+	sw.DirList = []string{"b/skip"}
+	assert.NoError(sw.Walk())
+
+	stats := sw.SegmentStats()
+	assert.Len(stats, 3)
+	expected := []struct {
+		segment string
+		count   int
+		bytes   int64
+	}{
+		{"", 1, 1},
+		{"a", 2, 5},
+		{"b", 1, 4},
+	}
+	for i, e := range expected {
+		assert.Equal(e.segment, stats[i].Segment)
+		assert.Equal(e.count, stats[i].Count)
+		assert.Equal(e.bytes, stats[i].Bytes)
+		assert.Equal(0, stats[i].Errors)
+		assert.True(stats[i].Duration >= stats[i].WalkTime)
+	}
+}
@@ -0,0 +1,137 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "sync"
+
+//SharedPool is a pool of workers shared by every Skywalker that has it as its Pool.
+//Walks running at the same time get their paths worked on in turns, one path from each walk that has
+//something queued, so a walk of a huge tree can not starve the walks of small ones.
+//This is meant for services walking trees for many tenants in one process.
+type SharedPool struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	queues []*poolQueue
+	next   int
+	closed bool
+	wg     sync.WaitGroup
+}
+
+//poolQueue holds the paths of a single walk waiting for the SharedPool.
+type poolQueue struct {
+	sw       *Skywalker
+	items    []item
+	size     int
+	inFlight int
+}
+
+//NewSharedPool starts numWorkers workers. Close stops them.
+func NewSharedPool(numWorkers int) *SharedPool {
+	sp := new(SharedPool)
+	sp.cond = sync.NewCond(&sp.mutex)
+	sp.wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go sp.worker()
+	}
+	return sp
+}
+
+func (sp *SharedPool) worker() {
+	defer sp.wg.Done()
+	for {
+		q, w, ok := sp.take()
+		if !ok {
+			return
+		}
+		q.sw.work(w)
+		sp.mutex.Lock()
+		q.inFlight--
+		sp.mutex.Unlock()
+		sp.cond.Broadcast()
+	}
+}
+
+//take waits for the next path, going around the walks in turn. It returns false once the pool is closed.
+func (sp *SharedPool) take() (*poolQueue, item, bool) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+	for {
+		for i := range sp.queues {
+			idx := (sp.next + i) % len(sp.queues)
+			q := sp.queues[idx]
+			if len(q.items) == 0 {
+				continue
+			}
+			w := q.items[0]
+			q.items[0] = item{}
+			q.items = q.items[1:]
+			q.inFlight++
+			sp.next = idx + 1
+			sp.cond.Broadcast()
+			return q, w, true
+		}
+		if sp.closed {
+			return nil, item{}, false
+		}
+		sp.cond.Wait()
+	}
+}
+
+//join adds a queue for sw holding at most size paths.
+func (sp *SharedPool) join(sw *Skywalker, size int) *poolQueue {
+	if size < 1 {
+		size = 1
+	}
+	q := &poolQueue{sw: sw, size: size}
+	sp.mutex.Lock()
+	sp.queues = append(sp.queues, q)
+	sp.mutex.Unlock()
+	return q
+}
+
+//push queues w, waiting while the queue is full.
+func (sp *SharedPool) push(q *poolQueue, w item) {
+	sp.mutex.Lock()
+	for len(q.items) >= q.size {
+		sp.cond.Wait()
+	}
+	q.items = append(q.items, w)
+	sp.mutex.Unlock()
+	sp.cond.Broadcast()
+}
+
+//queued returns how many paths are waiting in q.
+func (sp *SharedPool) queued(q *poolQueue) int {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+	return len(q.items)
+}
+
+//leave waits for everything in q to be worked on and removes it.
+func (sp *SharedPool) leave(q *poolQueue) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+	for len(q.items) > 0 || q.inFlight > 0 {
+		sp.cond.Wait()
+	}
+	for i, other := range sp.queues {
+		if other == q {
+			sp.queues = append(sp.queues[:i], sp.queues[i+1:]...)
+			if sp.next > i {
+				sp.next--
+			}
+			break
+		}
+	}
+}
+
+//Close stops the workers once every walk using the pool is done. Walks must not be started after it.
+func (sp *SharedPool) Close() {
+	sp.mutex.Lock()
+	sp.closed = true
+	sp.mutex.Unlock()
+	sp.cond.Broadcast()
+	sp.wg.Wait()
+}
@@ -0,0 +1,58 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//SlowWorker counts the paths it is given taking a while for each.
+type SlowWorker struct {
+	count int32
+}
+
+func (sw *SlowWorker) Work(path string) {
+	time.Sleep(time.Millisecond)
+	atomic.AddInt32(&sw.count, 1)
+}
+
+func TestSharedPool(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		files[fmt.Sprintf("huge/%03d.txt", i)] = "x"
+	}
+	for i := 0; i < 5; i++ {
+		files[fmt.Sprintf("small/%d.txt", i)] = "x"
+	}
+	writeFiles(t, tmp, files)
+
+	pool := skywalker.NewSharedPool(2)
+	defer pool.Close()
+	huge, small := new(SlowWorker), new(SlowWorker)
+	hugeSW := skywalker.New(filepath.Join(tmp, "huge"), huge)
+	hugeSW.Pool = pool
+	hugeSW.QueueSize = 200
+	assert.Equal(0, hugeSW.Goroutines())
+	smallSW := skywalker.New(filepath.Join(tmp, "small"), small)
+	smallSW.Pool = pool
+
+	hugeDone := make(chan error)
+	go func() { hugeDone <- hugeSW.Walk() }()
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(smallSW.Walk())
+	assert.Equal(int32(5), atomic.LoadInt32(&small.count))
+	assert.True(atomic.LoadInt32(&huge.count) < 150, "The small walk should not wait behind the huge one")
+	assert.NoError(<-hugeDone)
+	assert.Equal(int32(300), atomic.LoadInt32(&huge.count))
+}
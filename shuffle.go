@@ -0,0 +1,49 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"math/rand"
+	"time"
+)
+
+//shuffler holds back a window of items and sends them on in a random order.
+type shuffler struct {
+	window []item
+	size   int
+	rand   *rand.Rand
+	send   func(item)
+}
+
+func newShuffler(size int, send func(item)) *shuffler {
+	return &shuffler{
+		window: make([]item, 0, size),
+		size:   size,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		send:   send,
+	}
+}
+
+//push adds w to the window sending a random item on if the window is full.
+func (s *shuffler) push(w item) {
+	if len(s.window) < s.size {
+		s.window = append(s.window, w)
+		return
+	}
+	i := s.rand.Intn(len(s.window))
+	s.send(s.window[i])
+	s.window[i] = w
+}
+
+//flush sends everything left in the window in a random order.
+func (s *shuffler) flush() {
+	s.rand.Shuffle(len(s.window), func(i, j int) {
+		s.window[i], s.window[j] = s.window[j], s.window[i]
+	})
+	for _, w := range s.window {
+		s.send(w)
+	}
+	s.window = s.window[:0]
+}
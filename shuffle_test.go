@@ -0,0 +1,39 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type OrderWorker struct {
+	*sync.Mutex
+	order []string
+}
+
+func (ow *OrderWorker) Work(path string) {
+	ow.Lock()
+	defer ow.Unlock()
+	ow.order = append(ow.order, path)
+}
+
+func TestShuffleWindow(t *testing.T) {
+	assert := assert.New(t)
+	ow := &OrderWorker{Mutex: new(sync.Mutex)}
+	sw := skywalker.New(root, ow)
+	sw.NumWorkers = 1
+	sw.ShuffleWindow = 100
+	assert.NoError(sw.Walk())
+
+	assert.Len(ow.order, len(subFolders)*len(subFiles))
+	sorted := append([]string(nil), ow.order...)
+	sort.Strings(sorted)
+	assert.NotEqual(sorted, ow.order, "Paths should not come in walk order")
+}
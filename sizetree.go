@@ -0,0 +1,142 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//SizeNode is a directory in a SizeTree.
+type SizeNode struct {
+	Name string `json:"name"`
+	//Size and Count are the bytes and number of queued files in the directory and everything below it.
+	Size  int64 `json:"size"`
+	Count int   `json:"count"`
+	//Children are sorted by name.
+	Children []*SizeNode `json:"children,omitempty"`
+
+	children map[string]*SizeNode
+}
+
+//SizeTree builds the directory tree of the files queued by a walk, with their total size and count on every
+//directory, so the shape of a large tree can be rendered from a single walk.
+//Hand its Handle to Skywalker.OnEvent, on its own or through an EventBus.
+//With Overlays the roots are merged into one tree.
+type SizeTree struct {
+	mutex sync.Mutex
+	root  *SizeNode
+}
+
+//NewSizeTree creates an empty SizeTree.
+func NewSizeTree() *SizeTree {
+	return &SizeTree{root: newSizeNode(".")}
+}
+
+func newSizeNode(name string) *SizeNode {
+	return &SizeNode{Name: name, children: make(map[string]*SizeNode)}
+}
+
+//Handle adds every queued file to the tree. A new walk starts a new tree.
+func (st *SizeTree) Handle(ev Event) {
+	switch ev.Kind {
+	case EKStart:
+		st.mutex.Lock()
+		st.root = newSizeNode(".")
+		st.mutex.Unlock()
+	case EKQueued:
+		if ev.Info == nil || ev.Info.IsDir() {
+			return
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(ev.Path, ev.Root), string(filepath.Separator))
+		var dirs []string
+		if dir := filepath.Dir(rel); dir != "." {
+			dirs = strings.Split(dir, string(filepath.Separator))
+		}
+		size := ev.Info.Size()
+		st.mutex.Lock()
+		defer st.mutex.Unlock()
+		node := st.root
+		node.Size += size
+		node.Count++
+		for _, dir := range dirs {
+			child, ok := node.children[dir]
+			if !ok {
+				child = newSizeNode(dir)
+				node.children[dir] = child
+			}
+			child.Size += size
+			child.Count++
+			node = child
+		}
+	}
+}
+
+//Root returns a copy of the tree so far.
+func (st *SizeTree) Root() *SizeNode {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	return st.root.copy()
+}
+
+func (n *SizeNode) copy() *SizeNode {
+	c := &SizeNode{Name: n.Name, Size: n.Size, Count: n.Count}
+	for _, child := range n.children {
+		c.Children = append(c.Children, child.copy())
+	}
+	sort.Slice(c.Children, func(i, j int) bool { return c.Children[i].Name < c.Children[j].Name })
+	return c
+}
+
+//WriteJSON writes the tree as a single JSON object of nested SizeNodes.
+func (st *SizeTree) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(st.Root())
+}
+
+//WriteDOT writes the tree as a graphviz digraph with the size and count on every directory.
+//Directories deeper than maxDepth are left out, a maxDepth below 1 writes the whole tree.
+func (st *SizeTree) WriteDOT(w io.Writer, maxDepth int) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph tree {\n\tnode [shape=box];\n")
+	id := 0
+	var write func(n *SizeNode, depth int) int
+	write = func(n *SizeNode, depth int) int {
+		self := id
+		id++
+		fmt.Fprintf(bw, "\tn%d [label=\"%s\\n%s in %d files\"];\n", self, dotEscaper.Replace(n.Name), humanBytes(n.Size), n.Count)
+		if maxDepth > 0 && depth >= maxDepth {
+			return self
+		}
+		for _, child := range n.Children {
+			fmt.Fprintf(bw, "\tn%d -> n%d;\n", self, write(child, depth+1))
+		}
+		return self
+	}
+	write(st.Root(), 0)
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+//dotEscaper escapes a name for a quoted DOT string, which unlike Go's %q keeps non-ASCII characters as they are.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
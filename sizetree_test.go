@@ -0,0 +1,56 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeTree(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":       "aaa",
+		"sub/b.txt":   "bb",
+		"sub/c/d.txt": "dddd",
+		"skip/e.txt":  "e",
+	})
+	st := skywalker.NewSizeTree()
+	sw := skywalker.New(tmp, NewTW())
+	sw.DirList = []string{"skip"}
+	sw.OnEvent = st.Handle
+	assert.NoError(sw.Walk())
+
+	root := st.Root()
+	assert.Equal(int64(9), root.Size)
+	assert.Equal(3, root.Count)
+	if assert.Len(root.Children, 1) {
+		sub := root.Children[0]
+		assert.Equal("sub", sub.Name)
+		assert.Equal(int64(6), sub.Size)
+		assert.Equal(2, sub.Count)
+		if assert.Len(sub.Children, 1) {
+			assert.Equal(&skywalker.SizeNode{Name: "c", Size: 4, Count: 1}, sub.Children[0])
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(st.WriteJSON(&buf))
+	var decoded skywalker.SizeNode
+	assert.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(root, &decoded)
+
+	buf.Reset()
+	assert.NoError(st.WriteDOT(&buf, 1))
+	assert.Equal("digraph tree {\n\tnode [shape=box];\n"+
+		"\tn0 [label=\".\\n9B in 3 files\"];\n"+
+		"\tn1 [label=\"sub\\n6B in 2 files\"];\n"+
+		"\tn0 -> n1;\n}\n", buf.String())
+}
@@ -6,20 +6,71 @@
 package skywalker
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gobwas/glob"
 )
 
+//MaxWorkers is the most NumWorkers can be.
+const MaxWorkers = 10000
+
+//ErrNotInRoot is returned when a path handed to a worker does not live under the worker's Root.
+var ErrNotInRoot = errors.New("path is not inside of root")
+
 //Worker is anything that knows what to do with a path.
 type Worker interface {
 	Work(path string)
 }
 
+//InfoWorker is a Worker that also wants the os.FileInfo the walk already has for the path,
+//so it can read the size, mode and modification time without another stat.
+//Paths are read with filepath.WalkDir, so the info only stats the path the first time something needs more
+//than its name and type, and at most once. WorkInfo is called instead of Work.
+type InfoWorker interface {
+	Worker
+	WorkInfo(path string, info os.FileInfo)
+}
+
+//ErrorWorker is a Worker that reports when it fails on a path.
+//WorkErr is called instead of Work and every error it returns is handed to OnWorkError and returned by Walk in WorkErrors.
+type ErrorWorker interface {
+	Worker
+	WorkErr(path string) error
+}
+
+//item is a path waiting in the queue along with what it looked like when it was found.
+type item struct {
+	path string
+	info os.FileInfo
+	root string
+	//chunk is set if the item is only a range of the file.
+	chunk *chunkPart
+	//walked is set for items queued by a walk, whose ExtStats bytes and Owners the worker adds up.
+	walked bool
+	//future is set for items handed to Enqueue.
+	future *Future
+	//batch is set for the paths of a directory handed to a BatchWorker together. path is the directory.
+	batch []item
+	//seq numbers the items of an Ordered walk in the order they were queued, starting at 1.
+	seq uint64
+	//meta is set for items queued by a walk with ExtendedInfo.
+	meta *FileMeta
+}
+
 //ListType is used to specify how to handle the contents of a list
 type ListType int
 
@@ -40,32 +91,420 @@ type Skywalker struct {
 	//It uses https://github.com/gobwas/glob for glob checking on each patch check.
 	ListType ListType
 	List     []string
-	list     []glob.Glob
+
+	//RegexList and RegexListType filter paths like List does with regular expressions (https://golang.org/s/re2syntax),
+	//for structured patterns like build hashes that globs can not express. They are matched against the path
+	//relative to Root with a leading "/" and "/" between names on every platform, e.g. ^/build/[0-9a-f]{8}/.
+	//A path has to pass both List and RegexList.
+	RegexListType ListType
+	RegexList     []string
 
 	//ExtList and ExtListType are used to narrow down the files by their extensions.
 	//Make sure to include the preceding ".".
 	ExtListType ListType
 	ExtList     []string
-	extMap      map[string]struct{}
 
 	//DirList and DirListType are used to narrow down by directories.
 	//Will skip the appropriate directories and their files/subfolders.
 	DirListType ListType
 	DirList     []string
-	dirMap      map[string]bool
+
+	//SkipHidden leaves out hidden files and directories, with everything below them, like .git and .cache.
+	//A name starting with a dot is hidden everywhere and on Windows so is anything with the hidden attribute,
+	//like the junk in AppData. Root is never left out.
+	SkipHidden bool
+
+	//Overlays are more roots walked together with Root as a union, like overlayfs.
+	//Later roots shadow earlier ones (Root being the lowest) by their path relative to their root
+	//and every relative path is only handed to the Worker once, from the highest root that has it.
+	//A file shadows a directory of the same name and the other way around.
+	//Each root is converted to an absolute path before start. Fingerprints are not used with Overlays.
+	Overlays []string
+	layers   []*Matcher
+
+	//FS, if set, is walked instead of the filesystem, see NewFS. Root and the paths handed to the Worker
+	//are slash separated paths inside of it, like fs.WalkDir uses. Overlays, FollowLinks and Fingerprints
+	//can not be used with it and ParallelWalkers and Prefetch are ignored. Estimate, ListDir and FindFirst
+	//only work on the filesystem.
+	FS fs.FS
+
+	//ParallelWalkers is how many goroutines read directories, the walking goroutine being one of them.
+	//More than one reads directories ahead of the walk, which helps wide trees on fast or high latency storage
+	//like NVMe or NFS. Everything else about the walk, like the order paths are found in and which goroutine
+	//calls OnSkipDir or OnExtStat, stays the same. Directories the walk ends up skipping may still be read.
+	//It is ignored with EnterDir or LeaveDir, as the readers would not run with what EnterDir set up.
+	//Directories below followed links are only read by the walking goroutine.
+	//Only walks use it, Estimate, ListDir, FindFirst and the Fingerprints pre-pass read on their own.
+	ParallelWalkers int
+
+	//FollowLinks are globs, like List, of the symlinks that are followed. Everything else is not, which is
+	//what filepath.WalkDir does. A followed link is handed to the Worker as what it points to and the contents of a
+	//linked directory are found below the link's path, e.g. /current/** for a deployment whose current release
+	//is a link.
+	//Only walks follow links, Estimate, ListDir, FindFirst and the Fingerprints pre-pass do not.
+	FollowLinks []string
+	follow      []glob.Glob
+
+	//SymlinkMode is what is done with every other link, see SymlinkMode. Followed links that point to a directory
+	//the walk is already in are handed on as links, which is checked by device and inode where the OS has them,
+	//so two directories linking to each other do not loop. Only SMSkip can be used with FS.
+	SymlinkMode SymlinkMode
+
+	//MaxPathLength, MaxNameLength and ForbiddenNames filter out paths a stricter filesystem or object store
+	//would not take, so a tree can be checked before it is moved. Lengths are in characters of the path relative
+	//to Root and of every name in it. ForbiddenNames are globs matched against every name, e.g. "CON" or "*:*".
+	//Directories over a limit are skipped with everything below them. Every path filtered out by them is
+	//reported as an EKSkipped event with the Reason why.
+	MaxPathLength  int
+	MaxNameLength  int
+	ForbiddenNames []string
+
+	//IgnoreFiles are the names of ignore files, e.g. .gitignore or .skywalkerignore, read from every directory the
+	//walk goes into and applied to everything below it with the rules of .gitignore: negation with !, directory only
+	//patterns ending in /, patterns with a / being relative to the directory of the file and ** matching any number of
+	//directories. Ignore files deeper in the tree win over the ones above them. Like git, a path in an ignored
+	//directory can not be brought back as the directory is not walked into. Only walks read them, Estimate, ListDir,
+	//FindFirst and the Fingerprints pre-pass do not.
+	IgnoreFiles []string
+
+	//MaxDepth and MinDepth bound how many names below Root paths are handed to the Worker, like find's
+	//-maxdepth and -mindepth, with the files directly in Root at depth 1. Directories at MaxDepth are not read.
+	//Directories above MinDepth are still walked into. 0 means no limit.
+	MaxDepth int
+	MinDepth int
+
+	//MinSize and MaxSize, in bytes, are the smallest and largest files handed to the Worker. Files outside of them are
+	//never queued so the Worker does not pay for files it would throw away. Checking them stats every file the other
+	//filters let through. 0 means no limit.
+	MinSize int64
+	MaxSize int64
+
+	//ModifiedAfter and ModifiedBefore only hand files modified after and before them to the Worker, e.g.
+	//time.Now().Add(-24*time.Hour) for whatever changed in the last day. Directories are walked no matter when
+	//they were modified. The zero time means no limit.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	//SkipVirtualFS skips directories on virtual filesystems like /proc, /sys, /dev/pts and cgroups, whose files
+	//claim to be empty while holding more than a worker wants to read or block until something happens, so
+	//a system inventory can walk /. They are sent as EKSkipped events with RVirtualFS. Checking it costs a statfs
+	//for every directory. It only does something on Linux and is ignored with FS.
+	SkipVirtualFS bool
+
+	//SkipOpenForWrite skips files a process has open for writing, so log shippers and backup tools leave
+	//half-written files alone. They are sent as EKSkipped events with ROpenForWrite. The open files are found
+	//by scanning /proc at most once a second, which only sees the files of other users' processes with the
+	//privileges to, and every queued file is stated. It only does something on Linux and is ignored with FS.
+	SkipOpenForWrite bool
+	writers          *writers
+
+	//MaxReadBytes and ReadTimeout guard reading the files opened with Skywalker.Open, which Known, DetectEncoding
+	//and DetectLanguage use and workers should too. Reads past MaxReadBytes fail with ErrReadLimit and a read taking
+	//longer than ReadTimeout fails with os.ErrDeadlineExceeded, after which the file can not be read anymore.
+	//With ReadTimeout, files are opened without waiting for the writer of a FIFO on Linux. 0 means no limit.
+	//Files without read deadlines, like most of /proc, are read from a goroutine of their own. If the read times
+	//out that goroutine is left behind, blocked in the kernel, until the read returns, even after the walk is done.
+	MaxReadBytes int64
+	ReadTimeout  time.Duration
+
+	//Filters, if set, is used instead of compiling List, ExtList, DirList, Filter and Types on every walk.
+	//See FilterSet. A walk keeps using the Filters it started with.
+	Filters *FilterSet
+
+	//Filter is an expression paths must also satisfy, e.g. `ext(.go) && size>1KB && !path(**/vendor/**)`.
+	//Predicates are ext, name, path, type, kind, size and age combined with !, && and ||.
+	//See the README for the full syntax.
+	Filter string
+
+	//DetectEncoding guesses the text encoding of every file from its first EncodingPrefix bytes and
+	//hands it to SnapshotWorkers and ResultWorkers in Annotations.
+	//It is done by the workers so reading the files does not slow down the walk.
+	DetectEncoding bool
+
+	//Encodings, if set, only hands files with one of these encodings to the Worker. It implies DetectEncoding.
+	//Files are still queued so they are counted in ExtStats and SegmentStats.
+	Encodings []Encoding
+
+	//Known, if set, skips files whose QuickHash is in it, e.g. known operating system files a scanner does not need
+	//to look at. It is checked by the workers so reading the files does not slow down the walk. Skipped files are
+	//still queued, so they are counted in ExtStats and SegmentStats, and are sent as EKSkipped events with RKnown.
+	//Files split into chunks for a ChunkWorker are not checked.
+	Known *KnownSet
+
+	//ContentTypeList and ContentTypeListType narrow down the files by the MIME type http.DetectContentType sniffs
+	//from their first ContentTypePrefix bytes, like image/jpeg, or image/* for every image type, so a file is
+	//judged by what it holds rather than by its extension. Only regular files are checked, by the workers so reading
+	//them does not slow down the walk. Files that can not be read are only handed on by a blacklist. Files left out
+	//are still queued, so they are counted in ExtStats and SegmentStats, and are sent as EKSkipped events with
+	//RContentType. Files split into chunks for a ChunkWorker are not checked.
+	ContentTypeListType ListType
+	ContentTypeList     []string
+
+	//Journal, if set, makes the walk idempotent across restarts: the key JournalKey, MetaKey if it is nil, returns
+	//for every regular file is added to it once the Worker is done with the file without an error or panic, and
+	//files whose key is in it already are skipped and sent as EKSkipped events with RJournaled. Files that can not
+	//be keyed are worked on but not journaled. It is checked by the workers, so skipped files are still queued.
+	//Files split into chunks for a ChunkWorker are not checked. The first error writing it is returned by Walk.
+	Journal    *ResultJournal
+	JournalKey KeyFunc
+
+	//HashName, if set, is the name of the registered hash the Worker hashes with, see LookupHash, so the hash of a
+	//walk can be picked like its filters, e.g. a fast one for deduplication. It replaces the Hash of the Worker,
+	//which has to be a HashWorker, before anything is dispatched.
+	HashName string
+
+	//TreeLock, if set, locks Root and the Overlays for the walk so walks changing the same tree do not run at once,
+	//see TreeLock. Walk, WalkAndWatch and Redispatch take the locks. It can not be used with FS or Replay.
+	TreeLock TreeLock
+
+	//DetectLanguage guesses the programming language of every file, like linguist, and hands it to
+	//SnapshotWorkers and ResultWorkers in Annotations. It shares the prefix read for DetectEncoding.
+	DetectLanguage bool
+
+	//Types maps extensions to the logical types used by the kind predicate of Filter.
+	//DefaultTypes is used if it is nil.
+	Types *TypeRegistry
 
 	//NumWorkers are how many workers are listening to the queue to do the work.
+	//It has to be at least 1 and at most MaxWorkers.
 	NumWorkers int
 
+	//MinWorkers, if more than 0, makes the workers scale with the work instead of always running NumWorkers,
+	//for workloads whose cost per path varies a lot, like decoding images while skipping everything else.
+	//The walk starts MinWorkers of them, doubles them up to NumWorkers every ScaleInterval, DefaultScaleInterval
+	//if it is 0, in which queuing a path had to wait for a worker and stops one that has nothing to do every
+	//ScaleInterval in which it did not. It has to be at most NumWorkers and is ignored with Pool.
+	MinWorkers    int
+	ScaleInterval time.Duration
+	running       int32
+
+	//WorkTimeout, if more than 0, is how long a ContextWorker gets for a single path before its context is
+	//canceled. The path then fails with a WorkTimeoutError, even if the worker returned nil. Canceling the context
+	//is all it does: a worker blocked in a read that does not watch the context, like one on a hung network mount,
+	//keeps its worker until the read returns. It has to be set with a ContextWorker only.
+	WorkTimeout time.Duration
+
+	//RateLimit, if more than 0, caps how many paths per second are handed to the Worker, e.g. when it calls a remote
+	//API for every file. RateLimiter, if set, is used instead, e.g. a *rate.Limiter from golang.org/x/time/rate shared
+	//by several walks. The workers wait for it, so the queue fills up and the walk slows down along with them.
+	//Every chunk of a ChunkWorker counts as a path.
+	RateLimit   float64
+	RateLimiter RateLimiter
+	rate        RateLimiter
+
+	//ScratchDir is where a ScratchWorker gets a directory for every worker, in a directory of the walk that is
+	//created when the workers start and removed with everything in it once they are done. Defaults to os.TempDir().
+	//ScratchBudget, if set, is how many bytes all workers may reserve with Scratch.Reserve at the same time.
+	ScratchDir    string
+	ScratchBudget int64
+	scratch       *scratchSpace
+
+	//Ordered delivers what the workers did with the paths in the order the walk found them, which is lexical
+	//order in every directory: Results are put, EKDone events sent, WorkErrors kept and FinishChunks called in
+	//that order, so manifests and checksum lists come out the same on every run. Paths that are done early wait
+	//for the ones before them, which holds on to their results. Paths handed to Enqueue are not held back.
+	//It can not be used with ShuffleWindow.
+	Ordered bool
+	order   *orderer
+
+	//BatchSize is how many paths of a directory a BatchWorker gets at most in one call. Defaults to DefaultBatchSize.
+	BatchSize int
+
+	//Pool, if set, works on the paths with the SharedPool's workers instead of starting NumWorkers of its own.
+	//QueueSize still limits how many paths of this walk wait in it.
+	Pool *SharedPool
+
 	//QueueSize is how many paths to queue up at a time.
 	//Useful for fine control over memory usage if needed.
 	QueueSize int
 
+	//ShuffleWindow randomizes the order paths are handed to workers when it is more than 1.
+	//Up to ShuffleWindow paths are held back and a random one of them is sent each time a new one is found.
+	//Useful to spread load across storage that does not like hot prefixes, like object stores.
+	ShuffleWindow int
+
+	//Less, if set, orders the paths waiting for a worker, handing a before b if it returns true, e.g. LargestFirst
+	//or NewestFirst so a deduplication or cache warming job handles the big or new files before the long tail.
+	//Only the paths found but not yet handed to a worker are ordered, up to QueueSize of them, so the larger
+	//QueueSize the closer it gets to ordering the whole tree. They are held in a heap instead of the queue of the
+	//workers, and finding paths waits once it is full. The next path is picked as soon as the one before it was
+	//taken, so one path can get to a worker ahead of better ones found while it waited.
+	//Paths handed to Enqueue are not held back.
+	//It can not be used with Ordered, ShuffleWindow or a BatchWorker.
+	Less func(a, b QueueItem) bool
+
+	//LargeFileSize, if more than 0, sends files of at least this many bytes to their own pool of
+	//LargeWorkers workers so a few huge files do not hold the main workers hostage while many small
+	//files wait behind them. Large files wait in a queue of their own that does not count toward QueueSize.
+	LargeFileSize int64
+
+	//LargeWorkers is how many workers only handle large files. Defaults to 1 if LargeFileSize is set.
+	//They are started on top of NumWorkers.
+	LargeWorkers int
+
+	//ChunkSize, if more than 0, splits files larger than it into chunks of ChunkSize bytes that are handed
+	//to different workers when the Worker is a ChunkWorker. Chunks are never sent to the large workers.
+	ChunkSize int64
+
+	//Prefetch asks the kernel to start reading every file into the page cache as it is queued,
+	//so it is likely there by the time a worker opens it. It helps workers that read whole files
+	//from spinning disks. Only the first PrefetchBytes of each file are read ahead if it is set.
+	//It only does something on 64 bit Linux.
+	Prefetch      bool
+	PrefetchBytes int64
+
+	//Fingerprints skips directories that have not changed since the last walk that used the same store.
+	//Before walking, every directory gets a fingerprint built from the names, sizes, modes and
+	//modification times of everything below it that passes the filters, which costs an extra pass over the tree.
+	//Fingerprints are only stored once a walk finishes without an error.
+	Fingerprints FingerprintStore
+
+	//ProgressFile, if set, is where the progress of the walk is saved every ProgressInterval, which defaults to
+	//DefaultProgressInterval, so a walk that crashed or was stopped picks up where it left off when it is started
+	//again with the same ProgressFile. Only completed directories are skipped, where every path was worked on,
+	//so the paths in the others are handed to the Worker again. Directories that could not be fully read are
+	//never complete. It is saved atomically, once more if Walk fails and removed once a walk finishes.
+	//See LoadProgress for what is in it. It can not be used with Overlays.
+	ProgressFile     string
+	ProgressInterval time.Duration
+	progress         *progress
+
+	//OnUnchanged is called with every directory skipped because its fingerprint matched the store.
+	OnUnchanged  func(dir string)
+	fingerprints map[string]string
+
+	//OnSkipDir is called before a directory and everything below it is skipped, with the reason why.
+	//Returning false walks into the directory anyway. Paths below it still have to pass the filters,
+	//so this is most useful with a DirList blacklist, e.g. only skipping node_modules if it does not hold a package you build.
+	//It is only ever called from the walking goroutine. With Fingerprints it can be called twice for a directory.
+	OnSkipDir func(path string, reason Reason) bool
+
+	//EnterDir is called right before a directory is read and LeaveDir once everything below it was found.
+	//They are meant for switching credentials or capabilities for a subtree, e.g. only reading /root as root.
+	//Both are called from the walking goroutine, which is locked to its OS thread for the whole walk, so
+	//per thread state like Linux capabilities set in EnterDir applies to reading the directory. Workers run
+	//on other goroutines and threads and are not affected unless the change is process wide.
+	//LeaveDir should undo whatever EnterDir did. If EnterDir returns an error the directory is skipped
+	//and LeaveDir is not called for it. The Fingerprints pre-pass does not call either.
+	EnterDir func(dir string) error
+	LeaveDir func(dir string)
+
 	//Worker is the function that is called on each file/directory.
 	Worker Worker
 
 	//FilesOnly should be set to true if you only want to queue up files.
 	FilesOnly bool
+
+	//Snapshot checks each path again right before it is worked on and sets Snapshot.Changed
+	//if it no longer matches what was found while walking. Only used with a SnapshotWorker.
+	Snapshot bool
+
+	//ExtendedInfo gathers the FileMeta of every queued path while walking, rather than in the workers, and
+	//hands it to an ExtendedWorker along with the path. Reading the extended attributes of a path takes a
+	//syscall for their names and one for each value. Only used with an ExtendedWorker, which gathers it
+	//itself otherwise.
+	ExtendedInfo bool
+
+	//OnExtStat is called every time a file is queued with the running totals for its extension.
+	//It is only ever called from the walking goroutine so it does not need to be thread safe.
+	OnExtStat func(ext string, count int, bytes int64)
+	extStats  map[string]ExtStat
+	extMutex  sync.Mutex
+
+	//AgeBuckets are the upper bounds, sorted from the youngest, of the histogram of queued file ages by
+	//modification time, see AgeStats. DefaultAgeBuckets are a day, a week, a month and a year.
+	//Every queued file is stated by the walk itself to get its size and modification time.
+	AgeBuckets []time.Duration
+	ages       *ages
+
+	//Owners adds up how many queued files and bytes every user and group owns, see OwnerStats.
+	//The workers stat the files for it, so it is a parallel replacement for find and stat scripts.
+	Owners bool
+	owners *owners
+
+	//TopN keeps the TopN largest and oldest queued files and the directories with the most queued files
+	//directly in them, see Top. Memory is bounded by TopN and the depth of the tree. Every queued file is
+	//stated by the walk itself to get its size and modification time.
+	TopN int
+	top  *topN
+
+	//Segments keeps per segment stats for every immediate child of Root. See SegmentStats.
+	//Useful for finding which subtree is slow or failing.
+	Segments bool
+	segments *segments
+
+	//Results is handed everything returned by a ResultWorker.
+	//The first error from the store, or the Journal, is returned by Walk once the walk is done.
+	Results    ResultStore
+	resultOnce sync.Once
+	resultErr  error
+
+	//OnWorkError is called with every error returned by an ErrorWorker, and every panic of the Worker, as it happens.
+	//It is called concurrently from every worker so make sure it is thread safe.
+	OnWorkError func(path string, err error)
+	workMutex   sync.Mutex
+	workErrs    WorkErrors
+
+	//PanicHandler, if set, is called with every panic of the Worker, e.g. to log it with its stack or count it.
+	//Panics are always recovered and handled like an error returned by an ErrorWorker, so one bad file does not
+	//take the walk, or the process, down with it. It is called concurrently from every worker.
+	PanicHandler func(*PanicError)
+
+	//Record, if set, gets a line of JSON for every path the walk visits with its metadata and what was
+	//decided about it, e.g. queued or filtered out and why. Replay hands the queued paths to a Worker again
+	//later without touching the filesystem. The first error writing it is returned by Walk.
+	Record   io.Writer
+	recorder *recorder
+
+	//Labels are handed along with everything the walk puts out, every Event, EventStream line and Summary and
+	//the WorkItems and Snapshots given to workers and ResultStores, so a service running many walks can tell
+	//their output apart, e.g. by a tenant or job ID. The map must not be changed while walking.
+	Labels map[string]string
+
+	//OnStats is called every StatsInterval, which defaults to DefaultStatsInterval, with the running totals of
+	//the walk and once more with the final ones when it is over, e.g. for drawing a progress bar. It is called
+	//from a goroutine of its own. Stats can be called at any time as well.
+	OnStats       func(Stats)
+	StatsInterval time.Duration
+	stats         walkStats
+
+	//SlowDirs keeps the SlowDirs directories the walk took the longest to read in the Stats.
+	SlowDirs int
+
+	//OnStall is called from a goroutine of its own once the walk went StallTimeout without finding, queuing or
+	//working on anything, with how many paths are queued and what the workers are busy with, e.g. to find the
+	//one file hanging a whole job. It is called again only after the walk moved on. InFlight and Queued can be
+	//called at any time as well.
+	OnStall      func(StallReport)
+	StallTimeout time.Duration
+	flights      flights
+	queueMutex   sync.Mutex
+	queued       func() int
+
+	//WatchDelay is how long WalkAndWatch waits for more changes once something changed, which defaults to
+	//DefaultWatchDelay, and WatchInterval how often it polls the directories where it can not be told about
+	//changes, which defaults to DefaultWatchInterval.
+	WatchDelay    time.Duration
+	WatchInterval time.Duration
+	//WatchBackend is how WalkAndWatch finds out about changes.
+	WatchBackend WatchBackend
+	watcher      watcher
+
+	//OnEvent is called with everything that happens during a walk, see EventKind.
+	//It is called concurrently from the walking goroutine and every worker so make sure it is thread safe.
+	//Use an EventBus to hand the events to more than one consumer.
+	OnEvent func(Event)
+
+	enqueueMutex sync.RWMutex
+	enqueue      func(item)
+
+	//ctx is the context of the running walk. It is nil outside of WalkContext.
+	ctx context.Context
+
+	cancelMutex sync.Mutex
+	canceled    atomic.Value
+
+	matcher *Matcher
 }
 
 //New creates a new Skywalker that can walk through the specified root and calls the Worker on each file and/or directory.
@@ -84,161 +523,936 @@ func New(root string, worker Worker) *Skywalker {
 //Checks the lists specified to check whether it should ignore files or directories.
 //It also handles the creation of workers and queues needed for walking.
 func (sw *Skywalker) Walk() error {
+	return sw.WalkContext(context.Background())
+}
+
+//WalkContext is Walk that stops once ctx is done. Nothing more is found after that and the workers
+//drop whatever is still queued, so it returns soon after with ctx.Err(). Work that already started is
+//not interrupted unless the Worker watches ctx itself.
+func (sw *Skywalker) WalkContext(ctx context.Context) error {
 	if err := sw.init(); err != nil {
 		return err
 	}
-	if _, err := os.Stat(sw.Root); err != nil {
-		return err
+	sw.ctx = ctx
+	defer func() { sw.ctx = nil }()
+	sw.resetCanceled()
+	sw.recorder = nil
+	if sw.Record != nil {
+		sw.recorder = &recorder{enc: json.NewEncoder(sw.Record)}
 	}
-	workerChan := make(chan string, sw.QueueSize)
-	workerWG := new(sync.WaitGroup)
-	workerWG.Add(sw.NumWorkers)
-	for i := 0; i < sw.NumWorkers; i++ {
-		go sw.worker(workerWG, workerChan)
+	started := time.Now()
+	sw.emit(Event{Kind: EKStart, Root: sw.Root})
+	err := sw.walk()
+	if err == nil && sw.recorder != nil {
+		err = sw.recorder.err
 	}
-	err := filepath.Walk(sw.Root, sw.walker(workerChan))
-	close(workerChan)
-	workerWG.Wait()
+	sw.emit(Event{Kind: EKFinish, Root: sw.Root, Err: err, Duration: time.Since(started)})
 	return err
 }
 
-func (sw *Skywalker) init() error {
-	root, err := filepath.Abs(sw.Root)
+//walk does the walk Walk describes once everything is initialized.
+func (sw *Skywalker) walk() error {
+	for _, layer := range sw.layers {
+		if _, err := sw.lstat(layer.root); err != nil {
+			return rootError(layer.root, err)
+		}
+	}
+	unlock, err := sw.lock()
 	if err != nil {
 		return err
 	}
-	sw.Root = root
-	dirMap := make(map[string]bool, len(sw.DirList))
-	for _, dir := range sw.DirList {
-		if sw.DirListType == LTWhitelist {
-			dirs := splitPath(dir)
-			for i := len(dirs); i > 0; i-- {
-				dirMap[filepath.Join(root, filepath.Join(dirs[:i]...))] = i == len(dirs)
-			}
-		} else {
-			dirMap[filepath.Join(root, cleanDir(dir))] = true
+	defer unlock()
+	dispatch, wait, err := sw.pool()
+	if err != nil {
+		return err
+	}
+	if sw.progress != nil {
+		sw.progress.start(sw.ProgressInterval)
+		dispatch = sw.progress.queue(dispatch)
+	}
+	if sw.EnterDir != nil || sw.LeaveDir != nil {
+		runtime.LockOSThread()
+	}
+	err = sw.walkLayers(dispatch)
+	if sw.EnterDir != nil || sw.LeaveDir != nil {
+		runtime.UnlockOSThread()
+	}
+	if sw.segments != nil {
+		sw.segments.done()
+	}
+	if sw.top != nil {
+		sw.top.flush()
+	}
+	if sw.progress != nil {
+		sw.progress.leaveAll()
+	}
+	if werr := wait(); err == nil {
+		err = werr
+	}
+	if err == nil {
+		//Queued paths may have been dropped after everything was found.
+		err = sw.ctxErr()
+	}
+	if sw.progress != nil {
+		if perr := sw.progress.finish(err); err == nil {
+			err = perr
 		}
 	}
-	sw.dirMap = dirMap
-	extMap := make(map[string]struct{}, len(sw.ExtList))
-	for _, ext := range sw.ExtList {
-		extMap[ext] = struct{}{}
+	if err == nil && sw.Fingerprints != nil {
+		for dir, fp := range sw.fingerprints {
+			sw.Fingerprints.Put(dir, fp)
+		}
+	}
+	return err
+}
+
+//Goroutines is how many goroutines a Walk or Redispatch starts with the current configuration.
+//They are all finished before either returns, whether or not there was an error. The only exception are
+//the reads that timed out with ReadTimeout on files without read deadlines, which are left behind until they return.
+//Redispatch does not start the directory readers of ParallelWalkers or the one saving the ProgressFile.
+//FindFirst starts one per root instead.
+//With MinWorkers it is an upper bound, as only the workers the walk scaled up to are started.
+func (sw *Skywalker) Goroutines() int {
+	n := sw.largeWorkers() + sw.prefetchers() + sw.parallelWalkers() - 1
+	if sw.Pool == nil {
+		n += sw.NumWorkers
+		if sw.MinWorkers > 0 {
+			//The one scaling the workers.
+			n++
+		}
+	}
+	if sw.ProgressFile != "" {
+		n++
+	}
+	if sw.OnStats != nil {
+		n++
+	}
+	if sw.OnStall != nil && sw.StallTimeout > 0 {
+		n++
 	}
-	sw.extMap = extMap
-	list := make([]glob.Glob, len(sw.List))
-	for i, g := range sw.List {
-		gl, er := glob.Compile(cleanGlob(g), filepath.Separator)
-		if er != nil {
-			return er
+	if sw.Less != nil {
+		//The one handing the paths held back for Less to the workers.
+		n++
+	}
+	return n
+}
+
+//validate checks the fields that would otherwise block or panic once the workers are started.
+func (sw *Skywalker) validate() error {
+	switch {
+	case sw.NumWorkers < 1:
+		return &ConfigError{Field: "NumWorkers", Msg: "must be at least 1"}
+	case sw.NumWorkers > MaxWorkers:
+		return &ConfigError{Field: "NumWorkers", Msg: "must be at most " + strconv.Itoa(MaxWorkers)}
+	case sw.LargeWorkers < 0:
+		return &ConfigError{Field: "LargeWorkers", Msg: "must not be negative"}
+	case sw.NumWorkers+sw.largeWorkers() > MaxWorkers:
+		return &ConfigError{Field: "LargeWorkers", Msg: "together with NumWorkers must be at most " + strconv.Itoa(MaxWorkers)}
+	case sw.ChunkSize < 0:
+		return &ConfigError{Field: "ChunkSize", Msg: "must not be negative"}
+	case sw.PrefetchBytes < 0:
+		return &ConfigError{Field: "PrefetchBytes", Msg: "must not be negative"}
+	case sw.ParallelWalkers < 0:
+		return &ConfigError{Field: "ParallelWalkers", Msg: "must not be negative"}
+	case sw.ParallelWalkers > MaxWorkers:
+		return &ConfigError{Field: "ParallelWalkers", Msg: "must be at most " + strconv.Itoa(MaxWorkers)}
+	case !ascending(sw.AgeBuckets):
+		return &ConfigError{Field: "AgeBuckets", Msg: "must be positive and sorted from the youngest"}
+	case sw.MaxDepth < 0:
+		return &ConfigError{Field: "MaxDepth", Msg: "must not be negative"}
+	case sw.MinDepth < 0:
+		return &ConfigError{Field: "MinDepth", Msg: "must not be negative"}
+	case sw.MaxDepth > 0 && sw.MinDepth > sw.MaxDepth:
+		return &ConfigError{Field: "MinDepth", Msg: "must not be more than MaxDepth"}
+	case sw.MinSize < 0:
+		return &ConfigError{Field: "MinSize", Msg: "must not be negative"}
+	case sw.MaxSize < 0:
+		return &ConfigError{Field: "MaxSize", Msg: "must not be negative"}
+	case sw.MaxSize > 0 && sw.MinSize > sw.MaxSize:
+		return &ConfigError{Field: "MinSize", Msg: "must not be more than MaxSize"}
+	case !sw.ModifiedAfter.IsZero() && !sw.ModifiedBefore.IsZero() && !sw.ModifiedBefore.After(sw.ModifiedAfter):
+		return &ConfigError{Field: "ModifiedBefore", Msg: "must be after ModifiedAfter"}
+	case !(sw.RateLimit >= 0):
+		return &ConfigError{Field: "RateLimit", Msg: "must not be negative"}
+	case sw.Ordered && sw.ShuffleWindow > 1:
+		return &ConfigError{Field: "Ordered", Msg: "can not be used with ShuffleWindow"}
+	case sw.Less != nil && (sw.Ordered || sw.ShuffleWindow > 1):
+		return &ConfigError{Field: "Less", Msg: "can not be used with Ordered or ShuffleWindow"}
+	case sw.BatchSize < 0:
+		return &ConfigError{Field: "BatchSize", Msg: "must not be negative"}
+	case sw.ScratchBudget < 0:
+		return &ConfigError{Field: "ScratchBudget", Msg: "must not be negative"}
+	case sw.MaxReadBytes < 0:
+		return &ConfigError{Field: "MaxReadBytes", Msg: "must not be negative"}
+	case sw.ReadTimeout < 0:
+		return &ConfigError{Field: "ReadTimeout", Msg: "must not be negative"}
+	case sw.WatchDelay < 0:
+		return &ConfigError{Field: "WatchDelay", Msg: "must not be negative"}
+	case sw.WatchInterval < 0:
+		return &ConfigError{Field: "WatchInterval", Msg: "must not be negative"}
+	case sw.WatchBackend < WBAuto || sw.WatchBackend > WBFSEvents:
+		return &ConfigError{Field: "WatchBackend", Msg: "is not a WatchBackend"}
+	case !validContentTypes(sw.ContentTypeList):
+		return &ConfigError{Field: "ContentTypeList", Msg: "must only hold MIME types like image/jpeg or image/*"}
+	case sw.SlowDirs < 0:
+		return &ConfigError{Field: "SlowDirs", Msg: "must not be negative"}
+	case sw.StallTimeout < 0:
+		return &ConfigError{Field: "StallTimeout", Msg: "must not be negative"}
+	case sw.OnStall != nil && sw.StallTimeout == 0:
+		return &ConfigError{Field: "StallTimeout", Msg: "must be set with OnStall"}
+	case sw.StatsInterval < 0:
+		return &ConfigError{Field: "StatsInterval", Msg: "must not be negative"}
+	case sw.ProgressInterval < 0:
+		return &ConfigError{Field: "ProgressInterval", Msg: "must not be negative"}
+	case sw.ProgressFile != "" && len(sw.Overlays) > 0:
+		return &ConfigError{Field: "ProgressFile", Msg: "can not be used with Overlays"}
+	case sw.MinWorkers < 0:
+		return &ConfigError{Field: "MinWorkers", Msg: "must not be negative"}
+	case sw.MinWorkers > sw.NumWorkers:
+		return &ConfigError{Field: "MinWorkers", Msg: "must be at most NumWorkers"}
+	case sw.ScaleInterval < 0:
+		return &ConfigError{Field: "ScaleInterval", Msg: "must not be negative"}
+	case sw.WorkTimeout < 0:
+		return &ConfigError{Field: "WorkTimeout", Msg: "must not be negative"}
+	case sw.QueueSize < 0:
+		return &ConfigError{Field: "QueueSize", Msg: "must not be negative"}
+	case sw.FS != nil && len(sw.Overlays) > 0:
+		return &ConfigError{Field: "Overlays", Msg: "can not be used with FS"}
+	case sw.FS != nil && len(sw.FollowLinks) > 0:
+		return &ConfigError{Field: "FollowLinks", Msg: "can not be used with FS"}
+	case sw.SymlinkMode < SMHandOn || sw.SymlinkMode > SMReport:
+		return &ConfigError{Field: "SymlinkMode", Msg: "is not a SymlinkMode"}
+	case sw.TreeLock < TLNone || sw.TreeLock > TLTry:
+		return &ConfigError{Field: "TreeLock", Msg: "is not a TreeLock"}
+	case sw.FS != nil && sw.TreeLock != TLNone:
+		return &ConfigError{Field: "TreeLock", Msg: "can not be used with FS"}
+	case sw.FS != nil && (sw.SymlinkMode == SMFollow || sw.SymlinkMode == SMReport):
+		return &ConfigError{Field: "SymlinkMode", Msg: "can only be SMSkip with FS"}
+	case sw.FS != nil && sw.Fingerprints != nil:
+		return &ConfigError{Field: "Fingerprints", Msg: "can not be used with FS"}
+	case sw.Worker == nil:
+		return &ConfigError{Field: "Worker", Msg: "must be set"}
+	}
+	if _, ok := sw.Worker.(SymlinkWorker); !ok && sw.SymlinkMode == SMReport {
+		return &ConfigError{Field: "Worker", Msg: "must be a SymlinkWorker with SMReport"}
+	}
+	if _, ok := sw.Worker.(BatchWorker); ok && sw.Less != nil {
+		return &ConfigError{Field: "Less", Msg: "can not be used with a BatchWorker"}
+	}
+	if _, ok := sw.Worker.(ContextWorker); !ok && sw.WorkTimeout > 0 {
+		return &ConfigError{Field: "Worker", Msg: "must be a ContextWorker with WorkTimeout"}
+	}
+	if sw.HashName != "" {
+		if _, ok := sw.Worker.(HashWorker); !ok {
+			return &ConfigError{Field: "Worker", Msg: "must be a HashWorker with HashName"}
+		}
+		if _, err := LookupHash(sw.HashName); err != nil {
+			return err
 		}
-		list[i] = gl
 	}
-	sw.list = list
 	return nil
 }
 
-func (sw *Skywalker) worker(workerWG *sync.WaitGroup, workerChan chan string) {
-	defer workerWG.Done()
-	for w := range workerChan {
-		sw.Worker.Work(w)
+//init validates and compiles everything a walk needs. Nothing is started until it succeeds.
+func (sw *Skywalker) init() error {
+	if err := sw.validate(); err != nil {
+		return err
+	}
+	matcher, err := sw.Matcher()
+	if err != nil {
+		return err
+	}
+	sw.Root = matcher.root
+	sw.matcher = matcher
+	sw.layers = []*Matcher{matcher}
+	for i, overlay := range sw.Overlays {
+		layer, err := sw.matcherAt(overlay)
+		if err != nil {
+			return err
+		}
+		sw.Overlays[i] = layer.root
+		sw.layers = append(sw.layers, layer)
+	}
+	if err = sw.compileFollowLinks(); err != nil {
+		return err
+	}
+	sw.extStats = make(map[string]ExtStat)
+	sw.ages = nil
+	if len(sw.AgeBuckets) > 0 {
+		sw.ages = newAges(sw.AgeBuckets)
+	}
+	sw.owners = nil
+	if sw.Owners {
+		sw.owners = newOwners()
+	}
+	sw.top = nil
+	if sw.TopN > 0 {
+		sw.top = newTopN(sw.TopN)
+	}
+	sw.writers = nil
+	if sw.SkipOpenForWrite && sw.FS == nil {
+		sw.writers = new(writers)
 	}
+	sw.segments = nil
+	if sw.Segments {
+		sw.segments = newSegments()
+	}
+	sw.fingerprints = nil
+	if sw.Fingerprints != nil && len(sw.Overlays) == 0 {
+		if sw.fingerprints, err = fingerprintTree(matcher, sw.skipDir); err != nil {
+			return err
+		}
+	}
+	return sw.loadProgress()
 }
 
-func (sw *Skywalker) walker(workerChan chan string) func(path string, info os.FileInfo, err error) error {
-	return func(path string, info os.FileInfo, _ error) error {
-		if info.IsDir() {
-			if doSomething, err := sw.skipDir(path); doSomething {
-				return err
+//pool starts the workers. It returns the function that queues an item for them
+//and the function that waits for them to finish everything queued.
+//Waiting returns the first error from the Results store, or every error from an ErrorWorker.
+func (sw *Skywalker) pool() (func(item), func() error, error) {
+	sw.resultOnce = sync.Once{}
+	sw.resultErr = nil
+	sw.workErrs = nil
+	if err := sw.openScratch(); err != nil {
+		return nil, nil, err
+	}
+	if sw.HashName != "" {
+		newHash, err := LookupHash(sw.HashName)
+		if err != nil {
+			return nil, nil, err
+		}
+		sw.Worker.(HashWorker).UseHash(newHash)
+	}
+	sw.startStats()
+	stopStall := sw.startStall()
+	sw.rate = sw.RateLimiter
+	if sw.rate == nil && sw.RateLimit > 0 {
+		sw.rate = NewRateLimiter(sw.RateLimit)
+	}
+	workerWG := new(sync.WaitGroup)
+	var workerChan chan item
+	var dispatch func(item)
+	var queue *poolQueue
+	var scale *scaler
+	if sw.Pool != nil {
+		queue = sw.Pool.join(sw, sw.QueueSize)
+		dispatch = func(w item) {
+			sw.Pool.push(queue, w)
+		}
+	} else {
+		//Paths waiting for Less are held back by the prioritizer instead.
+		size := sw.QueueSize
+		if sw.Less != nil {
+			size = 0
+		}
+		workerChan = make(chan item, size)
+		if sw.MinWorkers > 0 {
+			scale = sw.newScaler(workerWG, workerChan)
+			dispatch = scale.push
+		} else {
+			atomic.StoreInt32(&sw.running, int32(sw.NumWorkers))
+			workerWG.Add(sw.NumWorkers)
+			for i := 0; i < sw.NumWorkers; i++ {
+				go sw.worker(workerWG, workerChan)
 			}
-			if sw.FilesOnly {
-				return nil
+			dispatch = func(w item) {
+				workerChan <- w
 			}
+		}
+	}
+	var lq *largeQueue
+	if n := sw.largeWorkers(); n > 0 {
+		lq = newLargeQueue()
+		workerWG.Add(n)
+		for i := 0; i < n; i++ {
+			go sw.largeWorker(workerWG, lq)
+		}
+		small := dispatch
+		dispatch = func(w item) {
+			if sw.large(w) {
+				lq.push(w)
+				return
+			}
+			small(w)
+		}
+	}
+	if _, ok := sw.Worker.(ChunkWorker); ok && sw.ChunkSize > 0 {
+		whole := dispatch
+		dispatch = func(w item) {
+			if sw.chunked(w) {
+				sw.split(w, whole)
+				return
+			}
+			whole(w)
+		}
+	}
+	var prefetch *prefetcher
+	if sw.prefetchers() > 0 {
+		prefetch = newPrefetcher(sw.QueueSize+sw.NumWorkers, sw.PrefetchBytes)
+		queue := dispatch
+		dispatch = func(w item) {
+			if w.info != nil && fileType(w.info).IsRegular() {
+				prefetch.push(w.path)
+			}
+			queue(w)
+		}
+	}
+	dispatch = sw.countQueued(dispatch)
+	sw.openEnqueue(dispatch)
+	var shuffle *shuffler
+	if sw.ShuffleWindow > 1 {
+		shuffle = newShuffler(sw.ShuffleWindow, dispatch)
+		dispatch = shuffle.push
+	}
+	var prio *prioritizer
+	if sw.Less != nil {
+		prio = newPrioritizer(sw.QueueSize, sw.Less, dispatch)
+		dispatch = prio.push
+	}
+	var batches *batcher
+	if _, ok := sw.Worker.(BatchWorker); ok {
+		batches = sw.newBatcher(dispatch)
+		dispatch = batches.push
+	}
+	sw.order = nil
+	if sw.Ordered {
+		sw.order = newOrderer()
+		dispatch = sw.order.sequence(dispatch)
+	}
+	sw.setQueued(func() int {
+		queued := len(workerChan)
+		if queue != nil {
+			queued = sw.Pool.queued(queue)
+		}
+		if lq != nil {
+			queued += lq.len()
+		}
+		if prio != nil {
+			queued += prio.len()
+		}
+		return queued
+	})
+	return dispatch, func() error {
+		sw.closeEnqueue()
+		if batches != nil {
+			batches.flush()
+		}
+		if prio != nil {
+			prio.flush()
+		}
+		if shuffle != nil {
+			shuffle.flush()
+		}
+		if prefetch != nil {
+			prefetch.stop()
+		}
+		if queue != nil {
+			sw.Pool.leave(queue)
 		} else {
-			if sw.skipFile(path) {
-				return nil
+			if scale != nil {
+				scale.close()
+			} else {
+				close(workerChan)
 			}
 		}
-		if sw.matchPath(path) == (sw.ListType == LTBlacklist) {
-			return nil
+		if lq != nil {
+			lq.close()
+		}
+		workerWG.Wait()
+		if scale != nil {
+			scale.stop()
+		}
+		atomic.StoreInt32(&sw.running, 0)
+		sw.setQueued(nil)
+		stopStall()
+		sw.stopStats()
+		if sw.scratch != nil {
+			if err := sw.scratch.remove(); err != nil {
+				sw.storeErr(err)
+			}
+		}
+		if sw.Results != nil {
+			if err := sw.Results.Flush(); err != nil {
+				sw.storeErr(err)
+			}
+		}
+		if sw.resultErr != nil {
+			return sw.resultErr
+		}
+		if len(sw.workErrs) > 0 {
+			return sw.workErrs
 		}
-		workerChan <- path
 		return nil
+	}, nil
+}
+
+func (sw *Skywalker) worker(workerWG *sync.WaitGroup, workerChan chan item) {
+	defer workerWG.Done()
+	for w := range workerChan {
+		sw.work(w)
 	}
 }
 
-func (sw *Skywalker) skipDir(path string) (bool, error) {
-	switch sw.DirListType {
-	case LTBlacklist:
-		_, inList := sw.dirMap[path]
-		if inList {
-			return true, filepath.SkipDir
+func (sw *Skywalker) work(w item) {
+	defer sw.flights.fly(w)()
+	//Panics of a BatchWorker or ChunkWorker are recovered where they are called, this is for everything else.
+	defer func() {
+		if v := recover(); v != nil {
+			sw.workErr(w.path, sw.panicked(w.path, v))
 		}
-	case LTWhitelist:
-		if path == sw.Root {
-			return false, nil
+	}()
+	if bw, ok := sw.Worker.(BatchWorker); ok && w.chunk == nil {
+		if w.batch == nil {
+			sw.workBatch(bw, sw.dirOf(w.path), []item{w})
+		} else {
+			sw.workBatch(bw, w.path, w.batch)
 		}
-		return sw.whiteListDir(path)
+		return
 	}
-	return false, nil
+	if sw.segments != nil {
+		defer sw.segments.worked(w.root, w.path, w.info.IsDir(), time.Now())
+	}
+	sw.countWalked(w)
+	if w.chunk != nil {
+		sw.workChunk(sw.Worker.(ChunkWorker), w)
+		return
+	}
+	defer sw.countDone(w)
+	if sw.progress != nil && w.walked && !sw.ordered(w) {
+		defer sw.worked(w)
+	}
+	var err error
+	var val interface{}
+	//later is what is delivered in order with the EKDone event if the walk is Ordered.
+	var later []func()
+	if w.future != nil {
+		defer func() {
+			w.future.resolve(Result{Value: val, Err: err})
+		}()
+	}
+	if sw.OnEvent != nil || sw.ordered(w) {
+		defer func(started time.Time) {
+			ev := Event{Kind: EKDone, Path: w.path, Root: w.root, Info: w.info, Err: err, Duration: time.Since(started), Value: val}
+			if !sw.ordered(w) {
+				sw.emit(ev)
+				return
+			}
+			later = append(later, func() { sw.emit(ev) })
+			if sw.progress != nil && w.walked {
+				later = append(later, func() { sw.worked(w) })
+			}
+			sw.order.finish(w.seq, later)
+		}(time.Now())
+	}
+	//key is added to the Journal once the Worker is done with the path, after a panic is recovered.
+	//It is only set once the path is handed to the Worker.
+	var key string
+	defer func() {
+		if key != "" && err == nil {
+			sw.journal(key)
+		}
+	}()
+	defer func() {
+		if v := recover(); v != nil {
+			err = sw.panicked(w.path, v)
+			later = sw.failed(w, err, later)
+		}
+	}()
+	if err = sw.ctxErr(); err != nil {
+		return
+	}
+	if sw.isCanceled(w.path) {
+		err = ErrCanceled
+		return
+	}
+	if sw.SymlinkMode == SMReport && fileType(w.info)&os.ModeSymlink != 0 {
+		sw.workSymlink(w.path)
+		return
+	}
+	if sw.Known != nil && fileType(w.info).IsRegular() && sw.known(w.path) {
+		sw.emit(Event{Kind: EKSkipped, Path: w.path, Root: w.root, Info: w.info, Reason: RKnown})
+		return
+	}
+	if len(sw.ContentTypeList) > 0 && fileType(w.info).IsRegular() && !sw.contentTypeOK(w.path) {
+		sw.emit(Event{Kind: EKSkipped, Path: w.path, Root: w.root, Info: w.info, Reason: RContentType})
+		return
+	}
+	var pending string
+	if sw.Journal != nil && fileType(w.info).IsRegular() {
+		if pending = sw.journalKey(w); pending != "" && sw.Journal.Contains(pending) {
+			sw.emit(Event{Kind: EKSkipped, Path: w.path, Root: w.root, Info: w.info, Reason: RJournaled})
+			return
+		}
+	}
+	if err = sw.rateWait(); err != nil {
+		return
+	}
+	a, ok := sw.annotate(w)
+	if !ok {
+		return
+	}
+	key = pending
+	if rw, ok := sw.Worker.(ResultWorker); ok {
+		wi := WorkItem{Path: w.path, Info: w.info, Root: w.root, Annotations: a, Labels: sw.Labels}
+		val, err = rw.WorkResult(wi)
+		if sw.Results != nil {
+			res := Result{Value: val, Err: err}
+			put := func() {
+				if serr := sw.Results.Put(wi, res); serr != nil {
+					sw.storeErr(serr)
+				}
+			}
+			if sw.ordered(w) {
+				later = append(later, put)
+			} else {
+				put()
+			}
+		}
+		return
+	}
+	if snap, ok := sw.Worker.(SnapshotWorker); ok {
+		s := Snapshot{Path: w.path, Info: w.info, Root: w.root, Annotations: a, Labels: sw.Labels}
+		if sw.Snapshot {
+			s.Changed = sw.changedSince(w.path, w.info)
+		}
+		snap.WorkSnapshot(s)
+		return
+	}
+	if xw, ok := sw.Worker.(ExtendedWorker); ok {
+		meta := w.meta
+		if meta == nil {
+			meta = sw.fileMeta(w)
+		}
+		xw.WorkMeta(*meta)
+		return
+	}
+	if scw, ok := sw.Worker.(ScratchWorker); ok {
+		if err = sw.workScratch(scw, w.path); err != nil {
+			later = sw.failed(w, err, later)
+		}
+		return
+	}
+	if cw, ok := sw.Worker.(ContextWorker); ok {
+		if err = sw.workContext(cw, w.path); err != nil {
+			later = sw.failed(w, err, later)
+		}
+		return
+	}
+	if ew, ok := sw.Worker.(ErrorWorker); ok {
+		if err = ew.WorkErr(w.path); err != nil {
+			later = sw.failed(w, err, later)
+		}
+		return
+	}
+	if dw, ok := sw.Worker.(DirEntryWorker); ok {
+		dw.WorkEntry(w.path, entryOf(w.info))
+		return
+	}
+	if iw, ok := sw.Worker.(InfoWorker); ok {
+		iw.WorkInfo(w.path, w.info)
+		return
+	}
+	sw.Worker.Work(w.path)
 }
 
-func (sw *Skywalker) whiteListDir(path string) (bool, error) {
-	dirs := splitPath(strings.Replace(path, sw.Root, "", 1))
-	for i := 1; i < len(dirs)+1; i++ {
-		try := filepath.Join(sw.Root, filepath.Join(dirs[:i]...))
-		root, found := sw.dirMap[try]
-		if found && root {
-			return false, nil // if it is the root no need to continue. Just use it
+//countWalked adds a path found by the walk to the ExtStats bytes and Owners once a worker has it.
+func (sw *Skywalker) countWalked(w item) {
+	if w.walked && !w.info.IsDir() && (w.chunk == nil || w.chunk.index == 0) {
+		if sw.OnExtStat == nil {
+			sw.addExtBytes(w.path, w.info)
 		}
-		if !found {
-			return true, filepath.SkipDir // if it was not found at all ignore. the order of the search is important
+		if sw.owners != nil {
+			sw.owners.add(w.info)
 		}
 	}
-	return true, nil // if it was found but not the root and was the last iteration
 }
 
-func (sw *Skywalker) skipFile(path string) bool {
-	dir, name := filepath.Split(path)
-	if sw.DirListType == LTWhitelist {
-		if skipDir, _ := sw.whiteListDir(dir); skipDir {
-			return true
+//dirOf returns the directory path is in.
+func (sw *Skywalker) dirOf(path string) string {
+	if sw.FS != nil {
+		return pathpkg.Dir(path)
+	}
+	return filepath.Dir(path)
+}
+
+//storeErr remembers the first error returned by the Results store or the Journal.
+func (sw *Skywalker) storeErr(err error) {
+	sw.resultOnce.Do(func() {
+		sw.resultErr = err
+	})
+}
+
+//failed keeps an error returned by an ErrorWorker, adding it to later if it has to wait for the paths before it.
+func (sw *Skywalker) failed(w item, err error, later []func()) []func() {
+	if !sw.ordered(w) {
+		sw.workErr(w.path, err)
+		return later
+	}
+	return append(later, func() { sw.workErr(w.path, err) })
+}
+
+//workErr keeps an error returned by an ErrorWorker.
+func (sw *Skywalker) workErr(path string, err error) {
+	sw.workMutex.Lock()
+	sw.workErrs = append(sw.workErrs, &WorkerError{Path: path, Err: err})
+	sw.workMutex.Unlock()
+	if sw.OnWorkError != nil {
+		sw.OnWorkError(path, err)
+	}
+}
+
+//panicked returns the PanicError for the Worker panicking with v on path, handing it to PanicHandler.
+func (sw *Skywalker) panicked(path string, v interface{}) *PanicError {
+	pe := &PanicError{Path: path, Value: v, Stack: debug.Stack()}
+	if sw.PanicHandler != nil {
+		sw.PanicHandler(pe)
+	}
+	return pe
+}
+
+//protect calls fn, returning the PanicError if it panics on path.
+func (sw *Skywalker) protect(path string, fn func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = sw.panicked(path, v)
 		}
+	}()
+	fn()
+	return nil
+}
+
+//ctxErr returns the error of the walk's context once it is done.
+func (sw *Skywalker) ctxErr() error {
+	if sw.ctx == nil {
+		return nil
+	}
+	return sw.ctx.Err()
+}
+
+//skipDir reports whether the directory at path should be skipped for reason.
+func (sw *Skywalker) skipDir(path string, reason Reason) bool {
+	if sw.OnSkipDir == nil {
+		return true
 	}
-	_, inList := sw.extMap[filepath.Ext(name)]
-	switch sw.ExtListType {
-	case LTBlacklist:
-		if inList {
-			return true
+	return sw.OnSkipDir(path, reason)
+}
+
+//walkLayers walks Root and then every overlay from the highest down.
+func (sw *Skywalker) walkLayers(dispatch func(item)) error {
+	dt := &dirTimer{sw: sw}
+	defer dt.done()
+	if len(sw.layers) == 1 {
+		return sw.walkRoot(sw.Root, dt.track(sw.walker(sw.matcher, nil, dispatch)))
+	}
+	seen := make(map[string]bool)
+	for i := len(sw.layers) - 1; i >= 0; i-- {
+		if err := sw.walkRoot(sw.layers[i].root, dt.track(sw.walker(sw.layers[i], seen, dispatch))); err != nil {
+			return err
 		}
-	case LTWhitelist:
-		if !inList {
-			return true
+	}
+	return nil
+}
+
+//walker returns the WalkFunc for the root of m.
+//seen holds every relative path already found in a higher root and whether it was a directory.
+//It is nil if there are no Overlays.
+func (sw *Skywalker) walker(m *Matcher, seen map[string]bool, dispatch func(item)) func(path string, info os.FileInfo, err error) error {
+	return sw.walkerWith(m, seen, sw.newIgnores(m.root), dispatch)
+}
+
+//walkerWith is walker with the ignores to use, which is nil without IgnoreFiles.
+func (sw *Skywalker) walkerWith(m *Matcher, seen map[string]bool, ig *ignores, dispatch func(item)) func(path string, info os.FileInfo, err error) error {
+	walkFn := sw.visitor(m, seen, ig, dispatch)
+	if m.maxDepth > 0 {
+		visit := walkFn
+		walkFn = func(path string, info os.FileInfo, err error) error {
+			ret := visit(path, info, err)
+			if ret == nil && err == nil && info.IsDir() && m.lastDepth(path) {
+				//Nothing below can match so it is not even read.
+				return filepath.SkipDir
+			}
+			return ret
 		}
 	}
-	return false
+	if ig != nil {
+		walkFn = ig.track(walkFn)
+	}
+	if sw.progress != nil {
+		walkFn = sw.progress.track(walkFn)
+	}
+	if sw.watcher != nil {
+		walkFn = sw.watchDirs(walkFn)
+	}
+	return walkFn
 }
 
-func (sw *Skywalker) matchPath(path string) bool {
-	path = strings.Replace(path, sw.Root, "", 1)
-	for _, gl := range sw.list {
-		if match := gl.Match(path); match {
-			return true
+//visitor decides what happens to every path the walker is handed. ig is nil without IgnoreFiles.
+func (sw *Skywalker) visitor(m *Matcher, seen map[string]bool, ig *ignores, dispatch func(item)) func(path string, info os.FileInfo, err error) error {
+	return func(path string, info os.FileInfo, err error) error {
+		if err := sw.ctxErr(); err != nil {
+			return err
+		}
+		if sw.isCanceled(path) {
+			if err == nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err != nil {
+			//Anything below a root that can not be read is skipped.
+			if path == m.root {
+				return rootError(path, err)
+			}
+			if sw.segments != nil {
+				sw.segments.failed(sw.segments.visit(m.root, path, info != nil && info.IsDir()))
+			}
+			sw.countError()
+			sw.record(path, m.root, info, decisionError, RMatched, err)
+			sw.emit(Event{Kind: EKError, Path: path, Root: m.root, Info: info, Err: err})
+			return nil
+		}
+		if sw.SymlinkMode == SMSkip && fileType(info)&os.ModeSymlink != 0 {
+			sw.record(path, m.root, info, decisionSkipped, RSymlink, nil)
+			sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: RSymlink})
+			return nil
+		}
+		if info.IsDir() {
+			sw.countDir()
+		}
+		shadowed := false
+		if seen != nil {
+			rel := strings.TrimPrefix(path, m.root)
+			if dir, ok := seen[rel]; ok {
+				if dir != info.IsDir() {
+					sw.record(path, m.root, info, decisionShadowed, RMatched, nil)
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				//Directories are merged so only their contents can still come from this root.
+				shadowed = true
+			}
+			seen[rel] = info.IsDir()
+		}
+		var seg string
+		if sw.segments != nil && path != m.root {
+			seg = sw.segments.visit(m.root, path, info.IsDir())
 		}
+		if info.IsDir() && sw.progress != nil && sw.progress.completed(path) {
+			sw.record(path, m.root, info, decisionSkipped, RCompleted, nil)
+			sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: RCompleted})
+			return filepath.SkipDir
+		}
+		if info.IsDir() && sw.virtualDir(path) {
+			sw.record(path, m.root, info, decisionSkipped, RVirtualFS, nil)
+			sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: RVirtualFS})
+			return filepath.SkipDir
+		}
+		if info.IsDir() && sw.unchanged(path) {
+			sw.record(path, m.root, info, decisionSkipped, RUnchanged, nil)
+			sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: RUnchanged})
+			return filepath.SkipDir
+		}
+		if ig != nil && path != m.root && ig.ignored(path, info.IsDir()) {
+			if !info.IsDir() {
+				sw.record(path, m.root, info, decisionFiltered, RIgnored, nil)
+				return nil
+			}
+			if sw.skipDir(path, RIgnored) {
+				sw.record(path, m.root, info, decisionSkipped, RIgnored, nil)
+				sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: RIgnored})
+				return filepath.SkipDir
+			}
+		}
+		match, reason := m.Match(path, info)
+		if !match {
+			if info.IsDir() && reason.prunes() && sw.skipDir(path, reason) {
+				sw.record(path, m.root, info, decisionSkipped, reason, nil)
+				sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: reason})
+				return filepath.SkipDir
+			}
+			if reason.limit() {
+				//Files over a limit are reported so a tree can be checked before it is moved.
+				sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: reason})
+			}
+			sw.record(path, m.root, info, decisionFiltered, reason, nil)
+			return nil
+		}
+		if shadowed {
+			sw.record(path, m.root, info, decisionShadowed, RMatched, nil)
+			return nil
+		}
+		if !info.IsDir() && sw.openForWrite(info) {
+			sw.record(path, m.root, info, decisionSkipped, ROpenForWrite, nil)
+			sw.emit(Event{Kind: EKSkipped, Path: path, Root: m.root, Info: info, Reason: ROpenForWrite})
+			return nil
+		}
+		if !info.IsDir() {
+			sw.countExt(path, info)
+			if sw.ages != nil {
+				sw.ages.add(info)
+			}
+			if sw.top != nil {
+				sw.top.file(path, info)
+			}
+		}
+		if sw.segments != nil && path != m.root {
+			sw.segments.queued(seg, info)
+		}
+		if _, ok := sw.Worker.(SnapshotWorker); ok || sw.Snapshot {
+			//Snapshots hand on the info from when the path was found, not from when a worker first asks.
+			statNow(info)
+		}
+		sw.record(path, m.root, info, decisionQueued, RMatched, nil)
+		sw.emit(Event{Kind: EKQueued, Path: path, Root: m.root, Info: info})
+		w := item{path: path, info: info, root: m.root, walked: true}
+		if _, ok := sw.Worker.(ExtendedWorker); ok && sw.ExtendedInfo {
+			w.meta = sw.fileMeta(w)
+		}
+		dispatch(w)
+		return nil
 	}
-	return false
 }
 
 func cleanDir(dir string) string {
 	return filepath.Clean(dir)
 }
 
-func cleanGlob(gl string) string {
-	if runtime.GOOS == "windows" {
-		return strings.Replace(gl, `/`, `\\`, -1) //must escape the backslash for windows comparison
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(cleanDir(path), string(filepath.Separator)), string(filepath.Separator))
+}
+
+//rootRel makes paths relative to a root that is only made absolute the first time it is needed.
+type rootRel struct {
+	once sync.Once
+	abs  string
+	err  error
+}
+
+func (rr *rootRel) absolute(root string) (string, error) {
+	rr.once.Do(func() {
+		rr.abs, rr.err = filepath.Abs(root)
+	})
+	return rr.abs, rr.err
+}
+
+func (rr *rootRel) rel(root, path string) (string, error) {
+	abs, err := rr.absolute(root)
+	if err != nil {
+		return "", err
 	}
-	return gl
+	return relPath(abs, path)
 }
 
-func splitPath(path string) []string {
-	return strings.Split(strings.Trim(cleanDir(path), string(filepath.Separator)), string(filepath.Separator))
+//relPath returns path relative to root making sure it does not escape root.
+func relPath(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrNotInRoot
+	}
+	return rel, nil
 }
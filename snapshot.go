@@ -0,0 +1,40 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "os"
+
+//Snapshot is what a path looked like at the moment the walk found it.
+type Snapshot struct {
+	//Path is the path that was found.
+	Path string
+	//Info was captured while walking, not when the worker was called.
+	Info os.FileInfo
+	//Root is the root Path was found in. It is only different from Skywalker.Root when using Overlays.
+	Root string
+	//Annotations are only filled in if the Skywalker was asked for them.
+	Annotations
+	//Changed is true if the path was modified, replaced or removed between being found and being worked on.
+	//Only checked when Skywalker.Snapshot is true.
+	Changed bool
+	//Labels are the Labels of the Skywalker.
+	Labels map[string]string
+}
+
+//SnapshotWorker is a Worker that wants the FileInfo captured while walking.
+//If the Skywalker's Worker is a SnapshotWorker WorkSnapshot is called instead of Work.
+type SnapshotWorker interface {
+	Worker
+	WorkSnapshot(snap Snapshot)
+}
+
+//changedSince reports whether path no longer has the size, mode and modification time in info.
+func (sw *Skywalker) changedSince(path string, info os.FileInfo) bool {
+	now, err := sw.lstat(path)
+	if err != nil {
+		return true
+	}
+	return now.Size() != info.Size() || now.Mode() != info.Mode() || !now.ModTime().Equal(info.ModTime())
+}
@@ -0,0 +1,95 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type SnapshotTestWorker struct {
+	*TestWorker
+	snaps  map[string]skywalker.Snapshot
+	before func(snap skywalker.Snapshot)
+}
+
+func (sw *SnapshotTestWorker) WorkSnapshot(snap skywalker.Snapshot) {
+	if sw.before != nil {
+		sw.before(snap)
+	}
+	sw.Lock()
+	defer sw.Unlock()
+	sw.snaps[filepath.Base(snap.Path)] = snap
+}
+
+func TestSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.a": "a",
+		"b.b": "b",
+		"c.c": "c",
+	})
+
+	enumerated := make(chan struct{})
+	var once sync.Once
+	tw := &SnapshotTestWorker{TestWorker: NewTW(), snaps: make(map[string]skywalker.Snapshot)}
+	tw.before = func(snap skywalker.Snapshot) {
+		if filepath.Base(snap.Path) == "a.a" {
+			<-enumerated
+			assert.NoError(os.WriteFile(filepath.Join(tmp, "b.b"), []byte("changed"), 0666))
+			assert.NoError(os.Remove(filepath.Join(tmp, "c.c")))
+		}
+	}
+	sw := skywalker.New(tmp, tw)
+	sw.NumWorkers = 1
+	sw.Snapshot = true
+	sw.OnExtStat = func(ext string, _ int, _ int64) {
+		if ext == ".c" {
+			once.Do(func() { close(enumerated) })
+		}
+	}
+	assert.NoError(sw.Walk())
+
+	assert.Empty(tw.found, "Work should not be called on a SnapshotWorker")
+	assert.Len(tw.snaps, 3)
+	assert.False(tw.snaps["a.a"].Changed)
+	assert.True(tw.snaps["b.b"].Changed)
+	assert.Equal(int64(1), tw.snaps["b.b"].Info.Size(), "Info should be from when the file was found")
+	assert.True(tw.snaps["c.c"].Changed)
+}
+
+func TestSnapshotInfoFromWalk(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a.a": "a", "b.b": "b"})
+
+	queued := make(chan struct{})
+	var once sync.Once
+	tw := &SnapshotTestWorker{TestWorker: NewTW(), snaps: make(map[string]skywalker.Snapshot)}
+	tw.before = func(snap skywalker.Snapshot) {
+		if filepath.Base(snap.Path) == "a.a" {
+			<-queued
+			assert.NoError(os.WriteFile(filepath.Join(tmp, "b.b"), []byte("changed"), 0666))
+		}
+	}
+	sw := skywalker.New(tmp, tw)
+	sw.NumWorkers = 1
+	sw.Snapshot = true
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKQueued && filepath.Base(ev.Path) == "b.b" {
+			once.Do(func() { close(queued) })
+		}
+	}
+	assert.NoError(sw.Walk())
+
+	assert.True(tw.snaps["b.b"].Changed)
+	assert.Equal(int64(1), tw.snaps["b.b"].Info.Size(), "Info should be from when the file was found")
+}
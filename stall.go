@@ -0,0 +1,165 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+//InFlight is a path a worker is busy with.
+type InFlight struct {
+	Path string
+	Root string
+	//Since is when the worker started on it.
+	Since time.Time
+}
+
+//StallReport is what OnStall is called with.
+type StallReport struct {
+	//Idle is how long the walk went without finding, queuing or working on anything.
+	Idle time.Duration
+	//Queued is how many paths are waiting for a worker.
+	Queued int
+	//InFlight are the paths the workers are busy with, the longest running first.
+	InFlight []InFlight
+	//Stacks are the stack traces of the goroutines working on a path, hinting at where they are stuck.
+	Stacks string
+	Stats  Stats
+}
+
+//flights keeps track of the paths the workers are busy with.
+type flights struct {
+	mutex sync.Mutex
+	next  uint64
+	items map[uint64]InFlight
+}
+
+//fly adds w to the paths in flight until the returned func is called.
+func (fl *flights) fly(w item) func() {
+	fl.mutex.Lock()
+	if fl.items == nil {
+		fl.items = make(map[uint64]InFlight)
+	}
+	fl.next++
+	id := fl.next
+	fl.items[id] = InFlight{Path: w.path, Root: w.root, Since: time.Now()}
+	fl.mutex.Unlock()
+	return func() {
+		fl.mutex.Lock()
+		delete(fl.items, id)
+		fl.mutex.Unlock()
+	}
+}
+
+//InFlight returns the paths the workers are busy with, the longest running first.
+//It is safe to call from any goroutine.
+func (sw *Skywalker) InFlight() []InFlight {
+	fl := &sw.flights
+	fl.mutex.Lock()
+	in := make([]InFlight, 0, len(fl.items))
+	for _, f := range fl.items {
+		in = append(in, f)
+	}
+	fl.mutex.Unlock()
+	sort.Slice(in, func(i, j int) bool {
+		if !in[i].Since.Equal(in[j].Since) {
+			return in[i].Since.Before(in[j].Since)
+		}
+		return in[i].Path < in[j].Path
+	})
+	return in
+}
+
+//Queued returns how many paths are waiting for a worker, or 0 if nothing is running.
+//It is safe to call from any goroutine.
+func (sw *Skywalker) Queued() int {
+	sw.queueMutex.Lock()
+	queued := sw.queued
+	sw.queueMutex.Unlock()
+	if queued == nil {
+		return 0
+	}
+	return queued()
+}
+
+//setQueued sets how Queued counts the paths waiting for a worker.
+func (sw *Skywalker) setQueued(queued func() int) {
+	sw.queueMutex.Lock()
+	sw.queued = queued
+	sw.queueMutex.Unlock()
+}
+
+//startStall watches the Stats and calls OnStall once the walk went StallTimeout without them changing.
+//It is called again only after they changed. The watching stops when stop is called.
+func (sw *Skywalker) startStall() (stop func()) {
+	if sw.OnStall == nil || sw.StallTimeout == 0 {
+		return func() {}
+	}
+	interval := sw.StallTimeout / 4
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	done := make(chan struct{})
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := sw.Stats()
+		moved := time.Now()
+		reported := false
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			stats := sw.Stats()
+			if stats.Dirs != last.Dirs || stats.Files != last.Files || stats.Done != last.Done || stats.Errors != last.Errors {
+				last, moved, reported = stats, time.Now(), false
+				continue
+			}
+			if idle := time.Since(moved); !reported && idle >= sw.StallTimeout {
+				reported = true
+				sw.OnStall(StallReport{
+					Idle:     idle,
+					Queued:   sw.Queued(),
+					InFlight: sw.InFlight(),
+					Stacks:   workerStacks(),
+					Stats:    stats,
+				})
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+//workerStacks returns the stack traces of every goroutine working on a path.
+func workerStacks() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	var stacks [][]byte
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.Contains(stack, []byte("skywalker.(*Skywalker).work(")) {
+			stacks = append(stacks, stack)
+		}
+	}
+	return string(bytes.Join(stacks, []byte("\n\n")))
+}
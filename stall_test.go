@@ -0,0 +1,68 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//hangWorker blocks on files named hang until release is closed.
+type hangWorker struct {
+	release chan struct{}
+}
+
+func (hw hangWorker) Work(path string) {
+	if filepath.Base(path) == "hang" {
+		<-hw.release
+	}
+}
+
+func TestStall(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":    "",
+		"sub/hang": "",
+	})
+	hw := hangWorker{release: make(chan struct{})}
+	sw := skywalker.New(tmp, hw)
+	sw.FilesOnly = true
+	sw.NumWorkers = 1
+	sw.StallTimeout = 20 * time.Millisecond
+	reports := make(chan skywalker.StallReport, 1)
+	sw.OnStall = func(report skywalker.StallReport) {
+		reports <- report
+		close(hw.release)
+	}
+	assert.NoError(sw.Walk())
+	report := <-reports
+	assert.True(report.Idle >= sw.StallTimeout)
+	assert.Equal(0, report.Queued)
+	if assert.Len(report.InFlight, 1) {
+		assert.Equal(filepath.Join(tmp, "sub", "hang"), report.InFlight[0].Path)
+		assert.Equal(tmp, report.InFlight[0].Root)
+	}
+	assert.True(strings.Contains(report.Stacks, "hangWorker"))
+	assert.Equal(int64(2), report.Stats.Files)
+	assert.Equal(int64(1), report.Stats.Done)
+	assert.Empty(sw.InFlight())
+	assert.Equal(0, sw.Queued())
+}
+
+func TestStallConfig(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(t.TempDir(), NewTW())
+	sw.OnStall = func(skywalker.StallReport) {}
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.Walk(), &ce) {
+		assert.Equal("StallTimeout", ce.Field)
+	}
+}
@@ -0,0 +1,148 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"sync"
+	"time"
+)
+
+//DefaultStatsInterval is how often OnStats is called if StatsInterval is not set.
+const DefaultStatsInterval = time.Second
+
+//Stats are the running totals of a walk, for drawing a progress bar.
+type Stats struct {
+	//Dirs is how many directories the walk found.
+	Dirs int64
+	//Files is how many files were queued for the workers and Done how many of them the workers are done with,
+	//including the ones dropped because the walk was canceled.
+	Files int64
+	Done  int64
+	//Bytes is the size of the files Done. They are counted by the workers so the walk does not have to stat them.
+	Bytes int64
+	//Errors is how many paths could not be read.
+	Errors int64
+	//ReadTime is how long the walk spent reading directories and SlowDirs are the SlowDirs directories that took
+	//the longest, the slowest first, e.g. to find the subtrees on slow or failing storage.
+	ReadTime time.Duration
+	SlowDirs []DirTiming
+	//Elapsed is how long the walk has been running, or took once it is over.
+	Elapsed time.Duration
+}
+
+//walkStats counts what goes into Stats.
+type walkStats struct {
+	mutex    sync.Mutex
+	stats    Stats
+	started  time.Time
+	finished bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+//Stats returns the running totals of the walk going on, or of the last one once it is over.
+//It is safe to call from any goroutine.
+func (sw *Skywalker) Stats() Stats {
+	sw.stats.mutex.Lock()
+	defer sw.stats.mutex.Unlock()
+	return sw.stats.snapshot()
+}
+
+//snapshot returns the Stats. The mutex has to be held.
+func (ws *walkStats) snapshot() Stats {
+	stats := ws.stats
+	stats.SlowDirs = append([]DirTiming(nil), ws.stats.SlowDirs...)
+	if !ws.finished && !ws.started.IsZero() {
+		stats.Elapsed = time.Since(ws.started)
+	}
+	return stats
+}
+
+//startStats resets the Stats and calls OnStats every StatsInterval until stopStats.
+func (sw *Skywalker) startStats() {
+	ws := &sw.stats
+	ws.mutex.Lock()
+	ws.stats = Stats{}
+	ws.started = time.Now()
+	ws.finished = false
+	ws.mutex.Unlock()
+	if sw.OnStats == nil {
+		return
+	}
+	interval := sw.StatsInterval
+	if interval == 0 {
+		interval = DefaultStatsInterval
+	}
+	ws.stop = make(chan struct{})
+	ws.wg.Add(1)
+	go func() {
+		defer ws.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				sw.OnStats(sw.Stats())
+			case <-ws.stop:
+				return
+			}
+		}
+	}()
+}
+
+//stopStats stops the clock and calls OnStats one last time with the final Stats.
+func (sw *Skywalker) stopStats() {
+	ws := &sw.stats
+	if ws.stop != nil {
+		close(ws.stop)
+		ws.wg.Wait()
+		ws.stop = nil
+	}
+	ws.mutex.Lock()
+	ws.stats.Elapsed = time.Since(ws.started)
+	ws.finished = true
+	stats := ws.snapshot()
+	ws.mutex.Unlock()
+	if sw.OnStats != nil {
+		sw.OnStats(stats)
+	}
+}
+
+func (sw *Skywalker) countDir() {
+	sw.stats.mutex.Lock()
+	sw.stats.stats.Dirs++
+	sw.stats.mutex.Unlock()
+}
+
+func (sw *Skywalker) countError() {
+	sw.stats.mutex.Lock()
+	sw.stats.stats.Errors++
+	sw.stats.mutex.Unlock()
+}
+
+//countQueued wraps dispatch so every file queued is counted.
+func (sw *Skywalker) countQueued(dispatch func(item)) func(item) {
+	return func(w item) {
+		if w.info != nil && !w.info.IsDir() {
+			sw.stats.mutex.Lock()
+			sw.stats.stats.Files++
+			sw.stats.mutex.Unlock()
+		}
+		dispatch(w)
+	}
+}
+
+//countDone counts a file the workers are done with.
+func (sw *Skywalker) countDone(w item) {
+	if w.info == nil || w.info.IsDir() {
+		return
+	}
+	size := w.info.Size()
+	sw.stats.mutex.Lock()
+	sw.stats.stats.Done++
+	sw.stats.stats.Bytes += size
+	sw.stats.mutex.Unlock()
+}
@@ -0,0 +1,88 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":     "12345",
+		"sub/b.txt": "123",
+		"sub/c.txt": "",
+	})
+	sw := skywalker.New(tmp, NewTW())
+	sw.StatsInterval = time.Millisecond
+	var mutex sync.Mutex
+	var all []skywalker.Stats
+	sw.OnStats = func(stats skywalker.Stats) {
+		mutex.Lock()
+		all = append(all, stats)
+		mutex.Unlock()
+	}
+	assert.NoError(sw.Walk())
+	if !assert.NotEmpty(all) {
+		return
+	}
+	last := all[len(all)-1]
+	assert.Equal(int64(2), last.Dirs)
+	assert.Equal(int64(3), last.Files)
+	assert.Equal(int64(3), last.Done)
+	assert.Equal(int64(8), last.Bytes)
+	assert.Equal(int64(0), last.Errors)
+	assert.True(last.Elapsed > 0)
+	assert.Equal(last, sw.Stats())
+	for _, stats := range all {
+		assert.True(stats.Done <= stats.Files)
+	}
+}
+
+//slowFS takes a while to read the directory named slow.
+type slowFS struct {
+	fstest.MapFS
+}
+
+func (sf slowFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "slow" {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return sf.MapFS.ReadDir(name)
+}
+
+func TestSlowDirs(t *testing.T) {
+	assert := assert.New(t)
+	fsys := slowFS{fstest.MapFS{
+		"a/a.txt":    {},
+		"b/b.txt":    {},
+		"slow/c.txt": {},
+		"empty":      {Mode: fs.ModeDir},
+	}}
+	sw := skywalker.NewFS(fsys, ".", NewTW())
+	sw.SlowDirs = 2
+	assert.NoError(sw.Walk())
+	stats := sw.Stats()
+	if assert.Len(stats.SlowDirs, 2) {
+		assert.Equal("slow", stats.SlowDirs[0].Path)
+		assert.True(stats.SlowDirs[0].Duration >= 20*time.Millisecond)
+		assert.True(stats.SlowDirs[1].Duration <= stats.SlowDirs[0].Duration)
+		assert.True(stats.ReadTime >= stats.SlowDirs[0].Duration)
+	}
+	assert.Contains(skywalker.NewSummary().Report(stats), "Slowest directories")
+
+	sw.SlowDirs = 0
+	assert.NoError(sw.Walk())
+	assert.Empty(sw.Stats().SlowDirs)
+	assert.True(sw.Stats().ReadTime >= 20*time.Millisecond)
+}
@@ -0,0 +1,236 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+//EventStream writes the events of a walk as newline delimited JSON, so a GUI can follow a walk run
+//by a helper process. Every line is an object with a "kind" and whichever of these apply:
+//
+//	{"kind":"start","root":"/data"}
+//	{"kind":"queued","path":"/data/a/b.txt","root":"/data","segment":"a","size":12}
+//	{"kind":"done","path":"/data/a/b.txt","root":"/data","segment":"a","size":12,"duration_ms":0.4,"value":{...}}
+//	{"kind":"skipped","path":"/data/node_modules","root":"/data","segment":"node_modules","dir":true,"reason":"dir list"}
+//	{"kind":"error","path":"/data/secret","root":"/data","segment":"secret","error":"permission denied"}
+//	{"kind":"progress","queued":120,"done":100,"bytes":4096,"done_bytes":2048,"errors":1,"elapsed_ms":500}
+//	{"kind":"finish","root":"/data","duration_ms":1500,"error":"..."}
+//
+//"value" is what a ResultWorker returned, JSON encoded. JSON strings can not hold invalid UTF-8, so for
+//paths that are not valid UTF-8 "path" has the invalid bytes replaced and "path_raw" holds the exact bytes
+//base64 encoded. Progress lines are written every Interval while a
+//walk is running and once more before "finish".
+type EventStream struct {
+	//Paths writes a queued and a done line for every path. Without it only the other kinds are written,
+	//which is enough for a progress bar and much less to parse on big trees.
+	Paths bool
+
+	//Interval is how often a progress line is written. Defaults to 500ms. Set it to less than 0 for none.
+	Interval time.Duration
+
+	mutex  sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	enc    *json.Encoder
+	err    error
+
+	start     time.Time
+	queued    int
+	done      int
+	bytes     int64
+	doneBytes int64
+	errors    int
+	labels    map[string]string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type streamLine struct {
+	Kind      string      `json:"kind"`
+	Path      string      `json:"path,omitempty"`
+	PathRaw   []byte      `json:"path_raw,omitempty"`
+	Root      string      `json:"root,omitempty"`
+	Segment   string      `json:"segment,omitempty"`
+	Size      int64       `json:"size,omitempty"`
+	Dir       bool        `json:"dir,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Duration  float64     `json:"duration_ms,omitempty"`
+	Value     interface{} `json:"value,omitempty"`
+	Queued    int         `json:"queued,omitempty"`
+	Done      int         `json:"done,omitempty"`
+	Bytes     int64       `json:"bytes,omitempty"`
+	DoneBytes int64       `json:"done_bytes,omitempty"`
+	Errors    int         `json:"errors,omitempty"`
+	Elapsed   float64     `json:"elapsed_ms,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+//NewEventStream creates an EventStream writing to w.
+func NewEventStream(w io.Writer) *EventStream {
+	return &EventStream{
+		Interval: 500 * time.Millisecond,
+		w:        w,
+		enc:      json.NewEncoder(w),
+	}
+}
+
+//DialEventStream connects to a GUI listening on address, e.g. "unix" and "/run/user/1000/app.sock",
+//and creates an EventStream writing to it. Close closes the connection.
+func DialEventStream(network, address string) (*EventStream, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	es := NewEventStream(conn)
+	es.closer = conn
+	return es, nil
+}
+
+//Handle writes ev. It is thread safe so it can be used as Skywalker.OnEvent or subscribed to an EventBus.
+func (es *EventStream) Handle(ev Event) {
+	line := streamLine{Kind: ev.Kind.String(), Path: ev.Path, PathRaw: rawPath(ev.Path), Root: ev.Root, Labels: ev.Labels}
+	if ev.Path != "" {
+		line.Segment = ev.Segment()
+	}
+	if ev.Info != nil {
+		line.Dir = ev.Info.IsDir()
+		if !line.Dir {
+			line.Size = ev.Info.Size()
+		}
+	}
+	if ev.Err != nil {
+		line.Error = ev.Err.Error()
+	}
+	if ev.Kind == EKSkipped {
+		line.Reason = ev.Reason.String()
+	}
+	if ev.Kind == EKDone || ev.Kind == EKFinish {
+		line.Duration = float64(ev.Duration) / float64(time.Millisecond)
+		line.Value = ev.Value
+	}
+
+	if ev.Kind == EKFinish {
+		es.stopProgress()
+	}
+	es.mutex.Lock()
+	switch ev.Kind {
+	case EKStart:
+		es.start = time.Now()
+		es.labels = ev.Labels
+		es.queued, es.done, es.bytes, es.doneBytes, es.errors = 0, 0, 0, 0, 0
+	case EKQueued:
+		es.queued++
+		es.bytes += line.Size
+	case EKDone:
+		es.done++
+		es.doneBytes += line.Size
+		if ev.Err != nil {
+			es.errors++
+		}
+	case EKError:
+		es.errors++
+	case EKFinish:
+		es.write(es.progress())
+	}
+	if es.Paths || (ev.Kind != EKQueued && ev.Kind != EKDone) {
+		es.write(line)
+	}
+	es.mutex.Unlock()
+	if ev.Kind == EKStart {
+		es.startProgress()
+	}
+}
+
+//progress returns the running totals. The mutex must be held.
+func (es *EventStream) progress() streamLine {
+	return streamLine{
+		Kind:      "progress",
+		Queued:    es.queued,
+		Done:      es.done,
+		Bytes:     es.bytes,
+		DoneBytes: es.doneBytes,
+		Errors:    es.errors,
+		Elapsed:   float64(time.Since(es.start)) / float64(time.Millisecond),
+		Labels:    es.labels,
+	}
+}
+
+//write encodes line keeping the first error. The mutex must be held.
+func (es *EventStream) write(line streamLine) {
+	if es.err != nil {
+		return
+	}
+	if err := es.enc.Encode(line); err != nil {
+		//Values that can not be encoded should not stop the stream.
+		if _, ok := err.(*json.UnsupportedTypeError); ok && line.Value != nil {
+			line.Value = nil
+			line.Error = err.Error()
+			es.write(line)
+			return
+		}
+		es.err = err
+	}
+}
+
+func (es *EventStream) startProgress() {
+	if es.Interval < 0 {
+		return
+	}
+	interval := es.Interval
+	if interval == 0 {
+		interval = 500 * time.Millisecond
+	}
+	es.stopProgress()
+	es.stop = make(chan struct{})
+	es.wg.Add(1)
+	go func(stop chan struct{}) {
+		defer es.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				es.mutex.Lock()
+				es.write(es.progress())
+				es.mutex.Unlock()
+			}
+		}
+	}(es.stop)
+}
+
+func (es *EventStream) stopProgress() {
+	if es.stop != nil {
+		close(es.stop)
+		es.wg.Wait()
+		es.stop = nil
+	}
+}
+
+//Err returns the first error writing the stream. Nothing more is written after it.
+func (es *EventStream) Err() error {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	return es.err
+}
+
+//Close stops writing progress lines and closes the connection if the stream was dialed.
+//It should only be called once the walk is over.
+func (es *EventStream) Close() error {
+	es.stopProgress()
+	if es.closer != nil {
+		return es.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventStream(t *testing.T) {
+	assert := assert.New(t)
+	sock := filepath.Join(t.TempDir(), "gui.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip("unix sockets are not available:", err)
+	}
+	defer l.Close()
+	lines := make(chan map[string]interface{})
+	go func() {
+		defer close(lines)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			line := make(map[string]interface{})
+			if json.Unmarshal(scanner.Bytes(), &line) == nil {
+				lines <- line
+			}
+		}
+	}()
+
+	es, err := skywalker.DialEventStream("unix", sock)
+	assert.NoError(err)
+	es.Paths = true
+	sw := skywalker.New(root, &SizeWorker{NewTW()})
+	sw.DirList = []string{"sub"}
+	sw.OnEvent = es.Handle
+	assert.NoError(sw.Walk())
+	assert.NoError(es.Err())
+	assert.NoError(es.Close())
+
+	kinds := make(map[string]int)
+	var progress, last map[string]interface{}
+	for line := range lines {
+		kinds[line["kind"].(string)]++
+		if line["kind"] == "progress" {
+			progress = line
+		}
+		if line["kind"] == "done" {
+			assert.True(line["value"] != nil || line["error"] != nil, "ResultWorker results should be in the stream")
+		}
+		last = line
+	}
+	assert.Equal(1, kinds["start"])
+	assert.Equal(8, kinds["queued"])
+	assert.Equal(8, kinds["done"])
+	assert.Equal(1, kinds["skipped"])
+	assert.Equal("finish", last["kind"])
+	if assert.NotNil(progress) {
+		assert.Equal(8.0, progress["done"])
+	}
+}
@@ -0,0 +1,199 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+//Summary collects what a human wants to know once a walk is over: why paths were skipped and which errors
+//happened how often. Report turns it and the final Stats into a short table for the end of a CLI run:
+//
+//	summary := skywalker.NewSummary()
+//	sw.OnEvent = summary.Handle
+//	err := sw.Walk()
+//	fmt.Fprint(os.Stderr, summary.Report(sw.Stats()))
+type Summary struct {
+	//MaxErrors is how many kinds of errors are listed, the most common first. Defaults to 5.
+	MaxErrors int
+
+	mutex    sync.Mutex
+	root     string
+	labels   map[string]string
+	skipped  map[Reason]int
+	errs     map[string]*summaryError
+	errCount int
+	finished bool
+	err      error
+}
+
+//summaryError is an error that happened count times, first for path.
+type summaryError struct {
+	msg   string
+	path  string
+	count int
+}
+
+//NewSummary creates an empty Summary.
+func NewSummary() *Summary {
+	return &Summary{
+		MaxErrors: 5,
+		skipped:   make(map[Reason]int),
+		errs:      make(map[string]*summaryError),
+	}
+}
+
+//Handle adds ev to the Summary. It is thread safe so it can be used as Skywalker.OnEvent or subscribed to an EventBus.
+func (s *Summary) Handle(ev Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch ev.Kind {
+	case EKStart:
+		s.root = ev.Root
+		s.labels = ev.Labels
+	case EKSkipped:
+		s.skipped[ev.Reason]++
+	case EKError:
+		s.failed(ev.Path, ev.Err)
+	case EKDone:
+		if ev.Err != nil {
+			s.failed(ev.Path, ev.Err)
+		}
+	case EKFinish:
+		s.finished = true
+		s.err = ev.Err
+	}
+}
+
+//failed counts err under what it says without the path, so the same problem in many places is listed once.
+//The mutex has to be held.
+func (s *Summary) failed(path string, err error) {
+	if err == nil {
+		return
+	}
+	s.errCount++
+	msg := err.Error()
+	var pe *fs.PathError
+	var le *os.LinkError
+	if errors.As(err, &pe) {
+		msg = pe.Op + ": " + pe.Err.Error()
+	} else if errors.As(err, &le) {
+		msg = le.Op + ": " + le.Err.Error()
+	}
+	se, ok := s.errs[msg]
+	if !ok {
+		se = &summaryError{msg: msg, path: path}
+		s.errs[msg] = se
+	}
+	se.count++
+}
+
+//Report formats stats and everything the Summary collected as a table, ending with a newline.
+func (s *Summary) Report(stats Stats) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var b strings.Builder
+	verb := "Walking"
+	if s.finished {
+		verb = "Walked"
+	}
+	if s.root != "" {
+		verb += " " + s.root
+	}
+	elapsed := stats.Elapsed
+	if elapsed > time.Second {
+		elapsed = elapsed.Round(time.Millisecond)
+	}
+	fmt.Fprintf(&b, "%s in %s\n", verb, elapsed)
+	if len(s.labels) > 0 {
+		labels := make([]string, 0, len(s.labels))
+		for key, value := range s.labels {
+			labels = append(labels, key+"="+value)
+		}
+		sort.Strings(labels)
+		fmt.Fprintf(&b, "  %s\n", strings.Join(labels, " "))
+	}
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  directories\t%d\n", stats.Dirs)
+	fmt.Fprintf(tw, "  files\t%d queued, %d done\n", stats.Files, stats.Done)
+	fmt.Fprintf(tw, "  bytes\t%s\n", humanBytes(stats.Bytes))
+	if stats.Elapsed > 0 && stats.Done > 0 {
+		secs := stats.Elapsed.Seconds()
+		fmt.Fprintf(tw, "  rate\t%.1f files/s, %s/s\n", float64(stats.Done)/secs, humanBytes(int64(float64(stats.Bytes)/secs)))
+	}
+	errCount := s.errCount
+	if int(stats.Errors) > errCount {
+		errCount = int(stats.Errors)
+	}
+	fmt.Fprintf(tw, "  errors\t%d\n", errCount)
+	tw.Flush()
+
+	if len(s.skipped) > 0 {
+		reasons := make([]Reason, 0, len(s.skipped))
+		for reason := range s.skipped {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			if s.skipped[reasons[i]] != s.skipped[reasons[j]] {
+				return s.skipped[reasons[i]] > s.skipped[reasons[j]]
+			}
+			return reasons[i] < reasons[j]
+		})
+		b.WriteString("Skipped\n")
+		for _, reason := range reasons {
+			fmt.Fprintf(tw, "  %s\t%d\n", reason, s.skipped[reason])
+		}
+		tw.Flush()
+	}
+
+	if len(stats.SlowDirs) > 0 {
+		fmt.Fprintf(&b, "Slowest directories (%s reading)\n", stats.ReadTime.Round(time.Millisecond))
+		for _, dt := range stats.SlowDirs {
+			fmt.Fprintf(tw, "  %s\t%s\n", dt.Duration.Round(time.Microsecond), dt.Path)
+		}
+		tw.Flush()
+	}
+
+	if len(s.errs) > 0 {
+		errs := make([]*summaryError, 0, len(s.errs))
+		for _, se := range s.errs {
+			errs = append(errs, se)
+		}
+		sort.Slice(errs, func(i, j int) bool {
+			if errs[i].count != errs[j].count {
+				return errs[i].count > errs[j].count
+			}
+			return errs[i].msg < errs[j].msg
+		})
+		max := s.MaxErrors
+		if max <= 0 {
+			max = 5
+		}
+		b.WriteString("Top errors\n")
+		for i, se := range errs {
+			if i == max {
+				fmt.Fprintf(tw, "  ...\t%d more\n", len(errs)-max)
+				break
+			}
+			fmt.Fprintf(tw, "  %d\t%s\t(e.g. %s)\n", se.count, se.msg, se.path)
+		}
+		tw.Flush()
+	}
+	if s.err != nil {
+		var workErrs WorkErrors
+		if !errors.As(s.err, &workErrs) {
+			fmt.Fprintf(&b, "Failed: %v\n", s.err)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type readWorker struct{}
+
+func (readWorker) Work(path string) {}
+
+func (readWorker) WorkErr(path string) error {
+	if filepath.Ext(path) == ".locked" {
+		return &os.PathError{Op: "open", Path: path, Err: os.ErrPermission}
+	}
+	return nil
+}
+
+func TestSummary(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":                 "hello",
+		"b.locked":              "",
+		"sub/c.locked":          "",
+		"node_modules/dep/d.js": "",
+	})
+	sw := skywalker.New(tmp, readWorker{})
+	sw.DirList = []string{"node_modules"}
+	summary := skywalker.NewSummary()
+	sw.OnEvent = summary.Handle
+	assert.Error(sw.Walk())
+	report := summary.Report(sw.Stats())
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+	assert.True(strings.HasPrefix(lines[0], "Walked "+sw.Root+" in "), lines[0])
+	assert.Contains(report, "files        3 queued, 3 done\n")
+	assert.Contains(report, "bytes        5B\n")
+	assert.Contains(report, "errors       2\n")
+	assert.Contains(report, "Skipped\n  dir list  1\n")
+	assert.Contains(report, "Top errors\n  2  open: permission denied  (e.g. ")
+	assert.NotContains(report, "Failed")
+}
@@ -0,0 +1,86 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "sync"
+
+//largeQueue holds files of at least LargeFileSize for the large workers.
+//It never blocks pushing so a backlog of large files does not hold up the walk or the small files behind them.
+//Only the items are kept, so its memory grows with how many large files are waiting rather than their size.
+type largeQueue struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	items  []item
+	closed bool
+}
+
+func newLargeQueue() *largeQueue {
+	lq := new(largeQueue)
+	lq.cond = sync.NewCond(&lq.mutex)
+	return lq
+}
+
+func (lq *largeQueue) push(w item) {
+	lq.mutex.Lock()
+	lq.items = append(lq.items, w)
+	lq.mutex.Unlock()
+	lq.cond.Signal()
+}
+
+//pop waits for an item. It returns false once the queue is closed and empty.
+func (lq *largeQueue) pop() (item, bool) {
+	lq.mutex.Lock()
+	defer lq.mutex.Unlock()
+	for len(lq.items) == 0 && !lq.closed {
+		lq.cond.Wait()
+	}
+	if len(lq.items) == 0 {
+		return item{}, false
+	}
+	w := lq.items[0]
+	lq.items[0] = item{}
+	lq.items = lq.items[1:]
+	return w, true
+}
+
+func (lq *largeQueue) len() int {
+	lq.mutex.Lock()
+	defer lq.mutex.Unlock()
+	return len(lq.items)
+}
+
+func (lq *largeQueue) close() {
+	lq.mutex.Lock()
+	lq.closed = true
+	lq.mutex.Unlock()
+	lq.cond.Broadcast()
+}
+
+//largeWorkers is how many workers only handle large files.
+func (sw *Skywalker) largeWorkers() int {
+	if sw.LargeFileSize <= 0 {
+		return 0
+	}
+	if sw.LargeWorkers < 1 {
+		return 1
+	}
+	return sw.LargeWorkers
+}
+
+//large reports whether w should go to the large workers.
+func (sw *Skywalker) large(w item) bool {
+	return sw.LargeFileSize > 0 && w.chunk == nil && w.info != nil && !w.info.IsDir() && w.info.Size() >= sw.LargeFileSize
+}
+
+func (sw *Skywalker) largeWorker(workerWG *sync.WaitGroup, lq *largeQueue) {
+	defer workerWG.Done()
+	for {
+		w, ok := lq.pop()
+		if !ok {
+			return
+		}
+		sw.work(w)
+	}
+}
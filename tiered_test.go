@@ -0,0 +1,70 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//HugeFileWorker holds up every large file until all the small files are done.
+type HugeFileWorker struct {
+	small     int
+	mutex     sync.Mutex
+	done      int
+	smallDone chan struct{}
+	blocked   bool
+}
+
+func (hw *HugeFileWorker) Work(path string) {
+	if strings.HasPrefix(filepath.Base(path), "huge") {
+		select {
+		case <-hw.smallDone:
+		case <-time.After(5 * time.Second):
+			hw.mutex.Lock()
+			hw.blocked = true
+			hw.mutex.Unlock()
+		}
+		return
+	}
+	hw.mutex.Lock()
+	defer hw.mutex.Unlock()
+	hw.done++
+	if hw.done == hw.small {
+		close(hw.smallDone)
+	}
+}
+
+func TestLargeFileSize(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	files := map[string]string{"a/huge.bin": strings.Repeat("x", 4096)}
+	for i := 0; i < 30; i++ {
+		files[fmt.Sprintf("b/small%02d.txt", i)] = "small"
+	}
+	writeFiles(t, tmp, files)
+
+	hw := &HugeFileWorker{small: 30, smallDone: make(chan struct{})}
+	sw := skywalker.New(tmp, hw)
+	sw.FilesOnly = true
+	sw.NumWorkers = 1
+	sw.LargeFileSize = 1024
+	assert.Equal(2, sw.Goroutines())
+	assert.NoError(sw.Walk())
+	assert.False(hw.blocked, "The huge file should not have held up the small ones")
+	assert.Equal(30, hw.done)
+
+	sw.LargeWorkers = -1
+	var cfgErr *skywalker.ConfigError
+	assert.True(errors.As(sw.Walk(), &cfgErr))
+}
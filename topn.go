@@ -0,0 +1,142 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"container/heap"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//TopEntry is a path in one of the TopStats lists.
+type TopEntry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	//Files is how many queued files are directly in a directory. It is only set in MostFiles.
+	Files int
+}
+
+//TopStats are the lists kept by a walk with TopN.
+type TopStats struct {
+	//Largest are the largest queued files, largest first.
+	Largest []TopEntry
+	//Oldest are the queued files modified the longest ago, oldest first.
+	Oldest []TopEntry
+	//MostFiles are the directories with the most queued files directly in them, most first.
+	MostFiles []TopEntry
+}
+
+//Top returns the TopN lists of the last Walk. They are empty if TopN was not set.
+//It should not be called while walking.
+func (sw *Skywalker) Top() TopStats {
+	if sw.top == nil {
+		return TopStats{}
+	}
+	return TopStats{
+		Largest:   sw.top.largest.sorted(),
+		Oldest:    sw.top.oldest.sorted(),
+		MostFiles: sw.top.mostFiles.sorted(),
+	}
+}
+
+//topN keeps the top lists during a walk. Only the walking goroutine uses it.
+//Directories are counted while the walk is inside of them, so memory is bounded by n and the depth of the tree.
+type topN struct {
+	largest   *topHeap
+	oldest    *topHeap
+	mostFiles *topHeap
+	dirs      []TopEntry
+}
+
+func newTopN(n int) *topN {
+	return &topN{
+		largest: &topHeap{n: n, better: func(a, b TopEntry) bool {
+			return a.Size > b.Size || a.Size == b.Size && a.Path < b.Path
+		}},
+		oldest: &topHeap{n: n, better: func(a, b TopEntry) bool {
+			return a.ModTime.Before(b.ModTime) || a.ModTime.Equal(b.ModTime) && a.Path < b.Path
+		}},
+		mostFiles: &topHeap{n: n, better: func(a, b TopEntry) bool {
+			return a.Files > b.Files || a.Files == b.Files && a.Path < b.Path
+		}},
+	}
+}
+
+//file adds a queued file.
+func (t *topN) file(path string, info os.FileInfo) {
+	entry := TopEntry{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+	t.largest.offer(entry)
+	t.oldest.offer(entry)
+	dir := filepath.Dir(path)
+	for len(t.dirs) > 0 {
+		top := t.dirs[len(t.dirs)-1].Path
+		if dir == top || strings.HasPrefix(dir, strings.TrimSuffix(top, string(filepath.Separator))+string(filepath.Separator)) {
+			break
+		}
+		t.leave()
+	}
+	if len(t.dirs) > 0 && t.dirs[len(t.dirs)-1].Path == dir {
+		t.dirs[len(t.dirs)-1].Files++
+		return
+	}
+	t.dirs = append(t.dirs, TopEntry{Path: dir, Files: 1})
+}
+
+//leave offers the deepest directory the walk is in once it is done with it.
+func (t *topN) leave() {
+	t.mostFiles.offer(t.dirs[len(t.dirs)-1])
+	t.dirs = t.dirs[:len(t.dirs)-1]
+}
+
+//flush offers every directory still being counted once the walk is done.
+func (t *topN) flush() {
+	for len(t.dirs) > 0 {
+		t.leave()
+	}
+}
+
+//topHeap keeps the n best entries with the worst of them on top so it can be replaced.
+type topHeap struct {
+	n       int
+	better  func(a, b TopEntry) bool
+	entries []TopEntry
+}
+
+func (h *topHeap) Len() int           { return len(h.entries) }
+func (h *topHeap) Less(i, j int) bool { return h.better(h.entries[j], h.entries[i]) }
+func (h *topHeap) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *topHeap) Push(x interface{}) {
+	h.entries = append(h.entries, x.(TopEntry))
+}
+
+func (h *topHeap) Pop() interface{} {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+func (h *topHeap) offer(entry TopEntry) {
+	if len(h.entries) < h.n {
+		heap.Push(h, entry)
+		return
+	}
+	if h.better(entry, h.entries[0]) {
+		h.entries[0] = entry
+		heap.Fix(h, 0)
+	}
+}
+
+//sorted returns a copy of the entries, best first.
+func (h *topHeap) sorted() []TopEntry {
+	entries := make([]TopEntry, len(h.entries))
+	copy(entries, h.entries)
+	sort.Slice(entries, func(i, j int) bool { return h.better(entries[i], entries[j]) })
+	return entries
+}
@@ -0,0 +1,58 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopN(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":         "aaaaa",
+		"b/1":           "1",
+		"b/2":           "22",
+		"b/c/1":         "1",
+		"b/c/2":         "2",
+		"b/c/3":         "3",
+		"b/d/1":         "1",
+		"b/zz-last.txt": "zzzz",
+	})
+	old := time.Now().Add(-time.Hour)
+	for i, rel := range []string{"b/c/2", "b/1"} {
+		when := old.Add(time.Duration(i) * time.Minute)
+		assert.NoError(os.Chtimes(filepath.Join(tmp, filepath.FromSlash(rel)), when, when))
+	}
+	sw := skywalker.New(tmp, NewTW())
+	sw.TopN = 2
+	assert.NoError(sw.Walk())
+
+	paths := func(entries []skywalker.TopEntry) []string {
+		var rels []string
+		for _, entry := range entries {
+			rel, _ := filepath.Rel(sw.Root, entry.Path)
+			rels = append(rels, filepath.ToSlash(rel))
+		}
+		return rels
+	}
+	top := sw.Top()
+	assert.Equal([]string{"a.txt", "b/zz-last.txt"}, paths(top.Largest))
+	assert.Equal(int64(5), top.Largest[0].Size)
+	assert.Equal([]string{"b/c/2", "b/1"}, paths(top.Oldest))
+	assert.Equal([]string{"b", "b/c"}, paths(top.MostFiles))
+	assert.Equal(3, top.MostFiles[0].Files)
+	assert.Equal(3, top.MostFiles[1].Files)
+
+	sw.TopN = 0
+	assert.NoError(sw.Walk())
+	assert.Empty(sw.Top().Largest)
+}
@@ -0,0 +1,152 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+)
+
+//TransformFunc rewrites what it reads from r into w.
+type TransformFunc func(r io.Reader, w io.Writer) error
+
+//TransformResult is what a TransformWorker did with a single file.
+type TransformResult struct {
+	//Path is the file that was transformed.
+	Path string
+	//Changed is true if the transform produced different contents.
+	Changed bool
+	//Diff is a unified diff of the change. Only set on a DryRun.
+	Diff string
+	//Backup is where the original contents were saved. Empty if no backup was made.
+	Backup string
+	//Err is set if the file could not be transformed.
+	Err error
+}
+
+//TransformWorker is a Worker that rewrites every file it is given with Transform.
+//Files are replaced atomically and only if the transform changed them.
+//Directories are ignored so it is best used with FilesOnly.
+type TransformWorker struct {
+	//Transform is called on the contents of every file.
+	Transform TransformFunc
+
+	//DryRun leaves every file alone and fills in TransformResult.Diff instead.
+	//The whole file is held in memory to compute the diff.
+	DryRun bool
+
+	//BackupSuffix is added to a copy of the original file made before it is replaced.
+	//No backup is made if it is empty.
+	BackupSuffix string
+
+	//Sync is how hard to try making each replaced file survive a crash.
+	Sync atomicfile.SyncPolicy
+
+	//OnTransform is called after each file is handled if it is set.
+	//It is called concurrently so make sure it is thread safe.
+	OnTransform func(TransformResult)
+
+	mutex   sync.Mutex
+	results []TransformResult
+}
+
+//NewTransformWorker creates a TransformWorker that rewrites files with fn.
+func NewTransformWorker(fn TransformFunc) *TransformWorker {
+	return &TransformWorker{
+		Transform: fn,
+	}
+}
+
+//Work transforms the file at path.
+func (tw *TransformWorker) Work(path string) {
+	res, ok := tw.transform(path, tw.Transform)
+	if !ok {
+		return
+	}
+	tw.mutex.Lock()
+	tw.results = append(tw.results, res)
+	tw.mutex.Unlock()
+	if tw.OnTransform != nil {
+		tw.OnTransform(res)
+	}
+}
+
+//Results returns what happened to every file handled so far.
+func (tw *TransformWorker) Results() []TransformResult {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+	results := make([]TransformResult, len(tw.results))
+	copy(results, tw.results)
+	return results
+}
+
+func (tw *TransformWorker) transform(path string, fn TransformFunc) (TransformResult, bool) {
+	res := TransformResult{Path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		res.Err = err
+		return res, true
+	}
+	if info.IsDir() {
+		return res, false
+	}
+	if tw.DryRun {
+		res.Changed, res.Diff, res.Err = tw.preview(path, fn)
+		return res, true
+	}
+	res.Changed, res.Backup, res.Err = tw.rewrite(path, info, fn)
+	return res, true
+}
+
+func (tw *TransformWorker) preview(path string, fn TransformFunc) (bool, string, error) {
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return false, "", err
+	}
+	after := new(bytes.Buffer)
+	if err = fn(bytes.NewReader(before), after); err != nil {
+		return false, "", err
+	}
+	diff := unifiedDiff(path, before, after.Bytes())
+	return diff != "", diff, nil
+}
+
+//rewrite streams path through the transform into a temporary file.
+//Both sides are hashed so an unchanged file is never replaced.
+func (tw *TransformWorker) rewrite(path string, info os.FileInfo, fn TransformFunc) (bool, string, error) {
+	in, err := Open(path)
+	if err != nil {
+		return false, "", err
+	}
+	defer in.Close()
+	out, err := atomicfile.Create(path, info.Mode().Perm(), tw.Sync)
+	if err != nil {
+		return false, "", err
+	}
+	defer out.Abort()
+	inHash, outHash := sha256.New(), sha256.New()
+	if err = fn(io.TeeReader(in, inHash), io.MultiWriter(out, outHash)); err != nil {
+		return false, "", err
+	}
+	if _, err = io.Copy(inHash, in); err != nil {
+		return false, "", err
+	}
+	if bytes.Equal(inHash.Sum(nil), outHash.Sum(nil)) {
+		return false, "", nil
+	}
+	var backup string
+	if tw.BackupSuffix != "" {
+		backup = path + tw.BackupSuffix
+		if err = copyFile(path, backup); err != nil {
+			return false, "", err
+		}
+	}
+	return true, backup, out.Commit()
+}
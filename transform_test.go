@@ -0,0 +1,82 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func upperTransform(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.ToUpper(data))
+	return err
+}
+
+func TestTransformWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"lower.txt": "one\ntwo\n",
+		"upper.txt": "ONE\nTWO\n",
+	})
+
+	tw := skywalker.NewTransformWorker(upperTransform)
+	tw.BackupSuffix = ".bak"
+	assert.NoError(skywalker.New(tmp, tw).Walk())
+
+	changed := make(map[string]skywalker.TransformResult)
+	for _, res := range tw.Results() {
+		assert.NoError(res.Err)
+		changed[filepath.Base(res.Path)] = res
+	}
+	assert.True(changed["lower.txt"].Changed)
+	assert.False(changed["upper.txt"].Changed)
+	assert.Equal(filepath.Join(tmp, "lower.txt.bak"), changed["lower.txt"].Backup)
+	assert.Empty(changed["upper.txt"].Backup)
+
+	data, _ := os.ReadFile(filepath.Join(tmp, "lower.txt"))
+	assert.Equal("ONE\nTWO\n", string(data))
+	data, _ = os.ReadFile(filepath.Join(tmp, "lower.txt.bak"))
+	assert.Equal("one\ntwo\n", string(data))
+	_, err := os.Stat(filepath.Join(tmp, "upper.txt.bak"))
+	assert.True(os.IsNotExist(err), "Unchanged files should not be backed up")
+}
+
+func TestTransformWorkerDryRun(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"file.txt": "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n",
+	})
+
+	tw := skywalker.NewTransformWorker(func(r io.Reader, w io.Writer) error {
+		data, _ := io.ReadAll(r)
+		data = bytes.Replace(data, []byte("b\n"), []byte("B\n"), 1)
+		data = bytes.Replace(data, []byte("k\n"), []byte("k"), 1)
+		_, err := w.Write(data)
+		return err
+	})
+	tw.DryRun = true
+	assert.NoError(skywalker.New(tmp, tw).Walk())
+
+	results := tw.Results()
+	assert.Len(results, 1)
+	assert.True(results[0].Changed)
+	assert.Contains(results[0].Diff, "@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n")
+	assert.Contains(results[0].Diff, "@@ -8,4 +8,4 @@\n h\n i\n j\n-k\n+k\n\\ No newline at end of file\n")
+
+	data, _ := os.ReadFile(filepath.Join(tmp, "file.txt"))
+	assert.Equal("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n", string(data), "Dry run should not change files")
+}
@@ -0,0 +1,142 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//ErrTreeLocked is returned by a walk with TLTry when another walk holds a lock on a tree overlapping with its own.
+var ErrTreeLocked = errors.New("tree is locked by another walk")
+
+//ErrLockUnsupported is returned by a walk with a TreeLock on a platform without advisory directory locks.
+var ErrLockUnsupported = errors.New("tree locks are not supported on this platform")
+
+//lockPoll is how often a walk with TLWait tries to take a lock again.
+const lockPoll = 100 * time.Millisecond
+
+//TreeLock is how a walk locks the trees it walks, so walks that change them do not get in each other's way,
+//like an archiving job moving files out of a tree another job is deduplicating. The locks are advisory, flock(2)
+//on the directories, so they only keep out other walks with a TreeLock and processes that flock the same way.
+//A walk holds an exclusive lock on every root and shared locks on every directory above them, so walks of
+//separate trees can run at once but not a walk of a tree and a walk of anything in it or above it.
+//The locks are taken before anything is dispatched and held until every path was worked on.
+type TreeLock int
+
+const (
+	//TLNone does not lock the trees.
+	TLNone TreeLock = iota
+	//TLWait waits for the walks holding locks that get in the way to be done, or for the walk's context.
+	TLWait
+	//TLTry fails with ErrTreeLocked if another walk holds a lock that gets in the way.
+	TLTry
+)
+
+var treeLockNames = [...]string{"none", "wait", "try"}
+
+func (tl TreeLock) String() string {
+	if tl < 0 || int(tl) >= len(treeLockNames) {
+		return "unknown"
+	}
+	return treeLockNames[tl]
+}
+
+//dirLocks are the directories a walk holds locks on.
+type dirLocks []*os.File
+
+//unlock releases the locks, deepest first.
+func (dl dirLocks) unlock() {
+	for i := len(dl) - 1; i >= 0; i-- {
+		dl[i].Close()
+	}
+}
+
+//lockTrees locks the roots of the layers of the walk exclusively and every directory above them shared.
+//Layers can share directories, or be inside of each other, so every directory is locked once, exclusively if
+//any layer is rooted there. Symlinks are resolved first so every walk locks the same directories for the same
+//tree. The directories are locked in sorted order, which puts every directory before the ones inside it, so
+//walks waiting for each other always do so in the same order.
+func (sw *Skywalker) lockTrees() (dirLocks, error) {
+	exclusive := make(map[string]bool)
+	for _, layer := range sw.layers {
+		abs, err := filepath.Abs(layer.root)
+		if err != nil {
+			return nil, err
+		}
+		if abs, err = filepath.EvalSymlinks(abs); err != nil {
+			return nil, err
+		}
+		exclusive[abs] = true
+		for dir := abs; filepath.Dir(dir) != dir; {
+			dir = filepath.Dir(dir)
+			if _, ok := exclusive[dir]; !ok {
+				exclusive[dir] = false
+			}
+		}
+	}
+	dirs := make([]string, 0, len(exclusive))
+	for dir := range exclusive {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	var locks dirLocks
+	for _, dir := range dirs {
+		file, err := sw.lockDir(dir, exclusive[dir])
+		if err != nil {
+			locks.unlock()
+			return nil, err
+		}
+		locks = append(locks, file)
+	}
+	return locks, nil
+}
+
+//lock takes the locks of the TreeLock of the walk, if it has one, returning what releases them.
+func (sw *Skywalker) lock() (unlock func(), err error) {
+	if sw.TreeLock == TLNone {
+		return func() {}, nil
+	}
+	locks, err := sw.lockTrees()
+	if err != nil {
+		return nil, err
+	}
+	return locks.unlock, nil
+}
+
+//lockDir opens dir and locks it, waiting for other walks with TLWait.
+func (sw *Skywalker) lockDir(dir string, exclusive bool) (*os.File, error) {
+	ctx := sw.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	file, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ok, err := tryLockDir(file, exclusive)
+		if err == nil && !ok && sw.TreeLock == TLTry {
+			err = ErrTreeLocked
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if ok {
+			return file, nil
+		}
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPoll):
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package skywalker
+
+import "os"
+
+//tryLockDir fails as directories can only be locked with flock.
+func tryLockDir(*os.File, bool) (bool, error) {
+	return false, ErrLockUnsupported
+}
@@ -0,0 +1,82 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeLock(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("directories can not be locked on " + runtime.GOOS)
+	}
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a/b/c.txt": "c", "a/d.txt": "d", "e/f.txt": "f"})
+	lockedWalk := func(root string, tl skywalker.TreeLock, ctx context.Context) error {
+		sw := skywalker.New(filepath.Join(tmp, root), NewTW())
+		sw.TreeLock = tl
+		return sw.WalkContext(ctx)
+	}
+
+	w := &blockWorker{started: make(chan struct{}), release: make(chan struct{})}
+	sw := skywalker.New(filepath.Join(tmp, "a"), w)
+	sw.TreeLock = skywalker.TLTry
+	done := make(chan error)
+	go func() { done <- sw.Walk() }()
+	<-w.started
+
+	assert.Equal(skywalker.ErrTreeLocked, lockedWalk("a", skywalker.TLTry, context.Background()))
+	assert.Equal(skywalker.ErrTreeLocked, lockedWalk("a/b", skywalker.TLTry, context.Background()))
+	assert.Equal(skywalker.ErrTreeLocked, lockedWalk("", skywalker.TLTry, context.Background()))
+	assert.NoError(lockedWalk("e", skywalker.TLTry, context.Background()))
+	assert.NoError(lockedWalk("a/b", skywalker.TLNone, context.Background()))
+	other := skywalker.New(filepath.Join(tmp, "a", "b"), NewTW())
+	other.TreeLock = skywalker.TLTry
+	assert.Equal(skywalker.ErrTreeLocked, other.Redispatch([]string{"c.txt"}))
+	assert.Equal(skywalker.ErrTreeLocked, other.WalkAndWatch(context.Background()))
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(other.Replay(strings.NewReader("")), &ce) {
+		assert.Equal("TreeLock", ce.Field)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(context.DeadlineExceeded, lockedWalk("a/b", skywalker.TLWait, ctx))
+
+	waited := make(chan error)
+	go func() { waited <- lockedWalk("a/b", skywalker.TLWait, context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	close(w.release)
+	assert.NoError(<-done)
+	assert.NoError(<-waited)
+	assert.NoError(lockedWalk("", skywalker.TLTry, context.Background()))
+}
+
+func TestTreeLockOverlays(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("directories can not be locked on " + runtime.GOOS)
+	}
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a/b/c.txt": "c", "a/d.txt": "d"})
+	for _, overlays := range [][]string{{filepath.Join(tmp, "a", "b")}, {filepath.Join(tmp, "a")}} {
+		for _, tl := range []skywalker.TreeLock{skywalker.TLTry, skywalker.TLWait} {
+			sw := skywalker.New(filepath.Join(tmp, "a"), NewTW())
+			sw.Overlays = overlays
+			sw.TreeLock = tl
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			assert.NoError(sw.WalkContext(ctx), "%v %v", overlays, tl)
+			cancel()
+		}
+	}
+}
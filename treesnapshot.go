@@ -0,0 +1,262 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dixonwille/skywalker/atomicfile"
+)
+
+//FileState is what a file looked like when a TreeSnapshot was recorded.
+type FileState struct {
+	Size    int64
+	ModTime time.Time
+	Mode    os.FileMode
+	//Dev and Ino identify the file on disk. Both are zero where the platform does not expose them.
+	Dev uint64 `json:",omitempty"`
+	Ino uint64 `json:",omitempty"`
+	//Hash is the hex encoded checksum of the contents. Only set if the TreeRecorder has a Hash.
+	Hash string `json:",omitempty"`
+}
+
+func newFileState(info os.FileInfo) FileState {
+	dev, ino := fileID(info)
+	return FileState{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Mode:    info.Mode(),
+		Dev:     dev,
+		Ino:     ino,
+	}
+}
+
+//sameContent reports whether both states look like the same unmodified file.
+//The hashes are trusted over the modification times when both states have one.
+func (fs FileState) sameContent(other FileState) bool {
+	if fs.Hash != "" && other.Hash != "" {
+		return fs.Size == other.Size && fs.Hash == other.Hash
+	}
+	return fs.Size == other.Size && fs.ModTime.Equal(other.ModTime)
+}
+
+//changed reports whether other is a modified version of the file.
+//Without hashes a file replaced by another one, even with the same size and times, counts as modified.
+func (fs FileState) changed(other FileState) bool {
+	if !fs.sameContent(other) || fs.Mode != other.Mode {
+		return true
+	}
+	if fs.Hash != "" && other.Hash != "" {
+		return false
+	}
+	return fs.Dev != other.Dev || fs.Ino != other.Ino
+}
+
+//TreeSnapshot is the state of every file in a tree keyed by its path relative to the root, using "/".
+type TreeSnapshot map[string]FileState
+
+//LoadTreeSnapshot reads a TreeSnapshot written by Save.
+func LoadTreeSnapshot(path string) (TreeSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ts := make(TreeSnapshot)
+	return ts, json.Unmarshal(data, &ts)
+}
+
+//Save atomically writes the TreeSnapshot to path.
+func (ts TreeSnapshot) Save(path string) error {
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0666, atomicfile.SPFile)
+}
+
+//ChangeKind is how a file changed between two TreeSnapshots.
+type ChangeKind int
+
+const (
+	//CKAdded is used for a file that is only in the newer snapshot.
+	CKAdded ChangeKind = iota
+	//CKRemoved is used for a file that is only in the older snapshot.
+	CKRemoved
+	//CKModified is used for a file whose size, modification time, mode or identity changed.
+	CKModified
+	//CKRenamed is used for a file that moved to a new path without being modified.
+	CKRenamed
+)
+
+var changeKindNames = [...]string{"added", "removed", "modified", "renamed"}
+
+func (ck ChangeKind) String() string {
+	if ck < 0 || int(ck) >= len(changeKindNames) {
+		return "unknown"
+	}
+	return changeKindNames[ck]
+}
+
+//Change is a single difference between two TreeSnapshots.
+type Change struct {
+	Kind ChangeKind
+	//Path is where the file is now, or was if it was removed.
+	Path string
+	//OldPath is where a renamed file used to be.
+	OldPath string
+	//Old and New are the states before and after. Old is nil for CKAdded and New is nil for CKRemoved.
+	Old *FileState
+	New *FileState
+}
+
+//Diff returns every change needed to go from older to ts, sorted by path.
+//A file removed from one path and added at another is reported as CKRenamed when both share
+//the same device and inode, size and modification time, or failing that the same Hash.
+func (ts TreeSnapshot) Diff(older TreeSnapshot) []Change {
+	var changes []Change
+	removed := make(map[[2]uint64]string)
+	removedHash := make(map[string][]string)
+	for _, path := range older.paths() {
+		if _, ok := ts[path]; ok {
+			continue
+		}
+		old := older[path]
+		if old.Ino != 0 {
+			removed[[2]uint64{old.Dev, old.Ino}] = path
+		}
+		if old.Hash != "" {
+			removedHash[old.Hash] = append(removedHash[old.Hash], path)
+		}
+	}
+	renamed := make(map[string]struct{})
+	renamedFrom := func(cur FileState) (string, bool) {
+		if from, found := removed[[2]uint64{cur.Dev, cur.Ino}]; found && cur.Ino != 0 {
+			if _, taken := renamed[from]; !taken && older[from].sameContent(cur) {
+				return from, true
+			}
+		}
+		for _, from := range removedHash[cur.Hash] {
+			if _, taken := renamed[from]; !taken && cur.Hash != "" && older[from].Size == cur.Size {
+				return from, true
+			}
+		}
+		return "", false
+	}
+	for _, path := range ts.paths() {
+		cur := ts[path]
+		old, ok := older[path]
+		if ok {
+			if old.changed(cur) {
+				changes = append(changes, Change{Kind: CKModified, Path: path, Old: &old, New: &cur})
+			}
+			continue
+		}
+		if from, found := renamedFrom(cur); found {
+			prev := older[from]
+			renamed[from] = struct{}{}
+			changes = append(changes, Change{Kind: CKRenamed, Path: path, OldPath: from, Old: &prev, New: &cur})
+			continue
+		}
+		changes = append(changes, Change{Kind: CKAdded, Path: path, New: &cur})
+	}
+	for path, old := range older {
+		old := old
+		if _, ok := ts[path]; ok {
+			continue
+		}
+		if _, ok := renamed[path]; ok {
+			continue
+		}
+		changes = append(changes, Change{Kind: CKRemoved, Path: path, Old: &old})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+//paths returns every path in the snapshot, sorted.
+func (ts TreeSnapshot) paths() []string {
+	paths := make([]string, 0, len(ts))
+	for path := range ts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+//TreeRecorder is a SnapshotWorker that records the state of every file it is given into a TreeSnapshot.
+//It uses the FileInfo found while walking so it does not stat anything itself.
+type TreeRecorder struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	//Hash, if set, checksums every regular file into FileState.Hash so Diff compares contents.
+	//Files that can not be read are recorded without a Hash.
+	Hash func() hash.Hash
+
+	root rootRel
+
+	mutex    sync.Mutex
+	snapshot TreeSnapshot
+}
+
+//NewTreeRecorder creates a TreeRecorder for the tree at root.
+func NewTreeRecorder(root string) *TreeRecorder {
+	return &TreeRecorder{
+		Root:     root,
+		snapshot: make(TreeSnapshot),
+	}
+}
+
+//UseHash sets Hash, see Skywalker.HashName.
+func (tr *TreeRecorder) UseHash(newHash func() hash.Hash) {
+	tr.Hash = newHash
+}
+
+//Work records the file at path. It is only used if the recorder is not called as a SnapshotWorker.
+func (tr *TreeRecorder) Work(path string) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+	tr.WorkSnapshot(Snapshot{Path: path, Info: info})
+}
+
+//WorkSnapshot records the file in snap.
+func (tr *TreeRecorder) WorkSnapshot(snap Snapshot) {
+	if snap.Info.IsDir() {
+		return
+	}
+	rel, err := tr.root.rel(tr.Root, snap.Path)
+	if err != nil {
+		return
+	}
+	state := newFileState(snap.Info)
+	if tr.Hash != nil && snap.Info.Mode().IsRegular() {
+		if sum, err := checksum(snap.Path, tr.Hash(), nil); err == nil {
+			state.Hash = hex.EncodeToString(sum)
+		}
+	}
+	tr.mutex.Lock()
+	tr.snapshot[filepath.ToSlash(rel)] = state
+	tr.mutex.Unlock()
+}
+
+//Snapshot returns everything recorded so far.
+func (tr *TreeRecorder) Snapshot() TreeSnapshot {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	ts := make(TreeSnapshot, len(tr.snapshot))
+	for path, state := range tr.snapshot {
+		ts[path] = state
+	}
+	return ts
+}
@@ -0,0 +1,68 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordTree(t *testing.T, root string) skywalker.TreeSnapshot {
+	t.Helper()
+	tr := skywalker.NewTreeRecorder(root)
+	assert.NoError(t, skywalker.New(root, tr).Walk())
+	return tr.Snapshot()
+}
+
+func TestTreeSnapshotDiff(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"keep.txt":    "keep",
+		"modify.txt":  "modify",
+		"remove.txt":  "remove",
+		"old/name.go": "rename",
+	})
+	before := recordTree(t, tmp)
+	path := filepath.Join(tmp, "snapshot.json")
+	assert.NoError(before.Save(path))
+	before, err := skywalker.LoadTreeSnapshot(path)
+	assert.NoError(err)
+	assert.NoError(os.Remove(path))
+
+	later := time.Now().Add(time.Hour)
+	assert.NoError(os.Chtimes(filepath.Join(tmp, "modify.txt"), later, later))
+	assert.NoError(os.Remove(filepath.Join(tmp, "remove.txt")))
+	assert.NoError(os.MkdirAll(filepath.Join(tmp, "new"), 0777))
+	assert.NoError(os.Rename(filepath.Join(tmp, "old/name.go"), filepath.Join(tmp, "new/name.go")))
+	writeFiles(t, tmp, map[string]string{"add.txt": "add"})
+	after := recordTree(t, tmp)
+
+	changes := after.Diff(before)
+	kinds := make(map[string]skywalker.ChangeKind)
+	for _, c := range changes {
+		kinds[c.Path] = c.Kind
+	}
+	assert.Equal(skywalker.CKAdded, kinds["add.txt"])
+	assert.Equal(skywalker.CKModified, kinds["modify.txt"])
+	assert.Equal(skywalker.CKRemoved, kinds["remove.txt"])
+	if runtime.GOOS == "windows" {
+		assert.Len(changes, 5)
+		return
+	}
+	assert.Len(changes, 4)
+	assert.Equal(skywalker.CKRenamed, kinds["new/name.go"])
+	for _, c := range changes {
+		if c.Kind == skywalker.CKRenamed {
+			assert.Equal("old/name.go", c.OldPath)
+		}
+	}
+}
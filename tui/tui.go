@@ -0,0 +1,264 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//Package tui draws the progress of a walk in a terminal: live counters, a progress bar for every
+//immediate child of the root and the latest errors. It is driven by skywalker events:
+//
+//	p := tui.New(os.Stderr)
+//	sw.OnEvent = p.Handle
+//	p.Start()
+//	err := sw.Walk()
+//	p.Stop()
+package tui
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+)
+
+//Progress keeps track of a walk from its events and redraws itself in place.
+type Progress struct {
+	//MaxDirs is how many directories get a bar, the ones with the most paths first. Defaults to 8.
+	MaxDirs int
+	//MaxErrors is how many of the latest errors are shown. Defaults to 3.
+	MaxErrors int
+	//Width is how many characters wide the bars are. Defaults to 30.
+	Width int
+	//Interval is how often Start redraws. Defaults to 100ms.
+	Interval time.Duration
+
+	out io.Writer
+
+	mutex     sync.Mutex
+	root      string
+	start     time.Time
+	elapsed   time.Duration
+	finished  bool
+	queued    int
+	done      int
+	bytes     int64
+	doneBytes int64
+	errCount  int
+	errs      []string
+	dirs      map[string]*dirProgress
+	lines     int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type dirProgress struct {
+	name   string
+	queued int
+	done   int
+}
+
+//New creates a Progress drawing to out, which should be a terminal that understands ANSI escapes.
+func New(out io.Writer) *Progress {
+	return &Progress{
+		MaxDirs:   8,
+		MaxErrors: 3,
+		Width:     30,
+		Interval:  100 * time.Millisecond,
+		out:       out,
+		start:     time.Now(),
+		dirs:      make(map[string]*dirProgress),
+	}
+}
+
+//Handle updates the counters from ev. It is thread safe so it can be used as Skywalker.OnEvent
+//or subscribed to a skywalker.EventBus.
+func (p *Progress) Handle(ev skywalker.Event) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	switch ev.Kind {
+	case skywalker.EKStart:
+		p.root = ev.Root
+		p.start = time.Now()
+	case skywalker.EKQueued:
+		p.queued++
+		if ev.Info != nil && !ev.Info.IsDir() {
+			p.bytes += ev.Info.Size()
+		}
+		p.dir(ev).queued++
+	case skywalker.EKDone:
+		p.done++
+		if ev.Info != nil && !ev.Info.IsDir() {
+			p.doneBytes += ev.Info.Size()
+		}
+		p.dir(ev).done++
+		if ev.Err != nil {
+			p.failed(ev.Path, ev.Err)
+		}
+	case skywalker.EKError:
+		p.failed(ev.Path, ev.Err)
+	case skywalker.EKFinish:
+		p.finished = true
+		p.elapsed = ev.Duration
+		if ev.Err != nil {
+			p.failed(ev.Root, ev.Err)
+		}
+	}
+}
+
+//dir returns the progress of the directory ev is in. The mutex must be held.
+func (p *Progress) dir(ev skywalker.Event) *dirProgress {
+	name := ev.Segment()
+	if name == "" {
+		name = "."
+	}
+	d, ok := p.dirs[name]
+	if !ok {
+		d = &dirProgress{name: name}
+		p.dirs[name] = d
+	}
+	return d
+}
+
+//failed remembers an error. The mutex must be held.
+func (p *Progress) failed(path string, err error) {
+	p.errCount++
+	msg := err.Error()
+	if !strings.Contains(msg, path) {
+		msg = path + ": " + msg
+	}
+	p.errs = append(p.errs, msg)
+	if len(p.errs) > p.MaxErrors {
+		p.errs = p.errs[len(p.errs)-p.MaxErrors:]
+	}
+}
+
+//Render returns the lines Progress would draw right now.
+func (p *Progress) Render() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	elapsed := p.elapsed
+	if !p.finished {
+		elapsed = time.Since(p.start)
+	}
+	state := "Walking"
+	if p.finished {
+		state = "Done"
+	}
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(p.done) / elapsed.Seconds()
+	}
+	lines := []string{fmt.Sprintf("%s %s  %s  %d/%d paths  %s/%s  %.0f/s  %d errors",
+		state, p.root, elapsed.Truncate(100*time.Millisecond), p.done, p.queued,
+		humanBytes(p.doneBytes), humanBytes(p.bytes), rate, p.errCount)}
+
+	dirs := make([]*dirProgress, 0, len(p.dirs))
+	for _, d := range p.dirs {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].queued != dirs[j].queued {
+			return dirs[i].queued > dirs[j].queued
+		}
+		return dirs[i].name < dirs[j].name
+	})
+	if len(dirs) > p.MaxDirs {
+		dirs = dirs[:p.MaxDirs]
+	}
+	nameWidth := 0
+	for _, d := range dirs {
+		if n := len([]rune(d.name)); n > nameWidth {
+			nameWidth = n
+		}
+	}
+	if nameWidth > 24 {
+		nameWidth = 24
+	}
+	for _, d := range dirs {
+		lines = append(lines, fmt.Sprintf("  %-*s [%s] %d/%d", nameWidth, truncate(d.name, nameWidth), bar(d.done, d.queued, p.Width), d.done, d.queued))
+	}
+	for _, msg := range p.errs {
+		lines = append(lines, "  ! "+msg)
+	}
+	return lines
+}
+
+//draw redraws over whatever was drawn last time.
+func (p *Progress) draw() {
+	lines := p.Render()
+	var b strings.Builder
+	if p.lines > 0 {
+		//Back to the start of the first line drawn last time.
+		fmt.Fprintf(&b, "\x1b[%dF", p.lines)
+	}
+	for _, line := range lines {
+		b.WriteString("\x1b[2K")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	//Clear what is left of a taller previous frame.
+	b.WriteString("\x1b[J")
+	io.WriteString(p.out, b.String())
+	p.lines = len(lines)
+}
+
+//Start redraws every Interval until Stop is called.
+func (p *Progress) Start() {
+	p.stop = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			p.draw()
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+//Stop stops redrawing and draws the final state once more.
+func (p *Progress) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		p.wg.Wait()
+		p.stop = nil
+	}
+	p.draw()
+}
+
+func bar(done, total, width int) string {
+	filled := width
+	if total > 0 {
+		filled = done * width / total
+	}
+	return strings.Repeat("#", filled) + strings.Repeat("-", width-filled)
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "~"
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
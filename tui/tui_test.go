@@ -0,0 +1,65 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package tui_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/dixonwille/skywalker/tui"
+	"github.com/stretchr/testify/assert"
+)
+
+type nopWorker struct{}
+
+func (nopWorker) Work(string) {}
+
+func TestProgress(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	for dir, count := range map[string]int{"big": 6, "small": 2} {
+		assert.NoError(os.MkdirAll(filepath.Join(tmp, dir), 0755))
+		for i := 0; i < count; i++ {
+			assert.NoError(os.WriteFile(filepath.Join(tmp, dir, fmt.Sprintf("%d.txt", i)), []byte("1234"), 0644))
+		}
+	}
+	assert.NoError(os.WriteFile(filepath.Join(tmp, "top.txt"), nil, 0644))
+
+	out := new(bytes.Buffer)
+	p := tui.New(out)
+	p.MaxDirs = 2
+	bus := new(skywalker.EventBus)
+	bus.Subscribe(p.Handle)
+	var finished bool
+	bus.Subscribe(func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKFinish {
+			finished = true
+		}
+	})
+	sw := skywalker.New(tmp, nopWorker{})
+	sw.OnEvent = bus.Publish
+	p.Start()
+	assert.NoError(sw.Walk())
+	p.Handle(skywalker.Event{Kind: skywalker.EKError, Path: "/gone", Err: os.ErrPermission})
+	p.Stop()
+	assert.True(finished)
+
+	lines := p.Render()
+	if assert.Len(lines, 4) {
+		assert.True(strings.HasPrefix(lines[0], "Done "+sw.Root), lines[0])
+		assert.Contains(lines[0], "9/9 paths")
+		assert.Contains(lines[0], "32B/32B")
+		assert.Contains(lines[0], "1 errors")
+		assert.Equal("  big   ["+strings.Repeat("#", 30)+"] 6/6", lines[1])
+		assert.Equal("  small ["+strings.Repeat("#", 30)+"] 2/2", lines[2])
+		assert.Equal("  ! /gone: permission denied", lines[3])
+	}
+	assert.Contains(out.String(), "\x1b[2K")
+}
@@ -0,0 +1,89 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//TypeRegistry maps file extensions to logical types like "image" or "telemetry".
+//Extensions are matched without regard to case and include the preceding ".".
+//A TypeRegistry is safe to use concurrently.
+type TypeRegistry struct {
+	mutex sync.RWMutex
+	types map[string]string
+}
+
+//NewTypeRegistry creates an empty TypeRegistry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{types: make(map[string]string)}
+}
+
+//DefaultTypes is used when a Skywalker does not have its own Types.
+//It knows about common image, video, audio, archive, document, text and code extensions
+//and can be added to with Register.
+var DefaultTypes = newDefaultTypes()
+
+func newDefaultTypes() *TypeRegistry {
+	tr := NewTypeRegistry()
+	tr.Register("image", ".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tif", ".tiff", ".webp", ".heic", ".svg", ".ico", ".raw", ".cr2", ".nef")
+	tr.Register("video", ".mp4", ".m4v", ".mov", ".avi", ".mkv", ".webm", ".wmv", ".flv", ".mpg", ".mpeg")
+	tr.Register("audio", ".mp3", ".wav", ".flac", ".aac", ".ogg", ".m4a", ".wma", ".opus")
+	tr.Register("archive", ".zip", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".7z", ".rar", ".zst")
+	tr.Register("document", ".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx", ".odt", ".ods", ".odp", ".rtf")
+	tr.Register("text", ".txt", ".md", ".csv", ".tsv", ".log", ".json", ".xml", ".yaml", ".yml", ".toml", ".ini")
+	tr.Register("code", ".go", ".c", ".h", ".cpp", ".hpp", ".cs", ".java", ".js", ".ts", ".py", ".rb", ".rs", ".php", ".sh", ".swift", ".kt")
+	return tr
+}
+
+//Register maps every one of exts to typ, replacing whatever type they had before.
+func (tr *TypeRegistry) Register(typ string, exts ...string) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	for _, ext := range exts {
+		tr.types[strings.ToLower(ext)] = typ
+	}
+}
+
+//Unregister removes exts from the registry.
+func (tr *TypeRegistry) Unregister(exts ...string) {
+	tr.mutex.Lock()
+	defer tr.mutex.Unlock()
+	for _, ext := range exts {
+		delete(tr.types, strings.ToLower(ext))
+	}
+}
+
+//TypeOf returns the type of path by its extension or "" if it has none.
+func (tr *TypeRegistry) TypeOf(path string) string {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+	return tr.types[strings.ToLower(filepath.Ext(path))]
+}
+
+//Exts returns every extension registered as typ, sorted.
+func (tr *TypeRegistry) Exts(typ string) []string {
+	tr.mutex.RLock()
+	defer tr.mutex.RUnlock()
+	var exts []string
+	for ext, t := range tr.types {
+		if t == typ {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+//types returns the registry the Skywalker uses.
+func (sw *Skywalker) types() *TypeRegistry {
+	if sw.Types != nil {
+		return sw.Types
+	}
+	return DefaultTypes
+}
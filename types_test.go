@@ -0,0 +1,41 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeRegistry(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("image", skywalker.DefaultTypes.TypeOf("/photos/IMG_0001.JPG"))
+	assert.Equal("", skywalker.DefaultTypes.TypeOf("/data/run.dat"))
+
+	types := skywalker.NewTypeRegistry()
+	types.Register("telemetry", ".dat", ".TLM")
+	types.Register("log", ".log")
+	assert.Equal("telemetry", types.TypeOf("run.tlm"))
+	assert.Equal([]string{".dat", ".tlm"}, types.Exts("telemetry"))
+	types.Unregister(".dat")
+	assert.Equal("", types.TypeOf("run.dat"))
+
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.tlm":   "",
+		"b.log":   "",
+		"c.dat":   "",
+		"d.jpg":   "",
+		"sub/e.x": "",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.Types = types
+	sw.Filter = "kind(telemetry, log)"
+	assert.NoError(sw.Walk())
+	assert.Len(tw.found, 2)
+}
@@ -0,0 +1,75 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+type ProvenanceWorker struct {
+	*TestWorker
+	mutex sync.Mutex
+	roots map[string]string
+}
+
+func (pw *ProvenanceWorker) WorkSnapshot(snap skywalker.Snapshot) {
+	rel, _ := filepath.Rel(snap.Root, snap.Path)
+	pw.mutex.Lock()
+	defer pw.mutex.Unlock()
+	if _, ok := pw.roots[filepath.ToSlash(rel)]; ok {
+		pw.roots[filepath.ToSlash(rel)] = "dispatched twice"
+		return
+	}
+	pw.roots[filepath.ToSlash(rel)] = snap.Root
+}
+
+func TestOverlays(t *testing.T) {
+	assert := assert.New(t)
+	base, middle, top := t.TempDir(), t.TempDir(), t.TempDir()
+	writeFiles(t, base, map[string]string{
+		"a.txt":              "base",
+		"b.txt":              "base",
+		"dir/c.txt":          "base",
+		"replaced/d.txt":     "base",
+		"skip/e.txt":         "base",
+		"onlybase/f.txt":     "base",
+		"dir/nested/g.txt":   "base",
+		"dir/nested/h.txt":   "base",
+		"dir/nested/i.other": "base",
+	})
+	writeFiles(t, middle, map[string]string{
+		"b.txt":            "middle",
+		"dir/nested/g.txt": "middle",
+	})
+	writeFiles(t, top, map[string]string{
+		"a.txt":            "top",
+		"replaced":         "top",
+		"dir/nested/h.txt": "top",
+		"skip/e.txt":       "top",
+	})
+
+	pw := &ProvenanceWorker{TestWorker: NewTW(), roots: make(map[string]string)}
+	sw := skywalker.New(base, pw)
+	sw.Overlays = []string{middle, top}
+	sw.DirList = []string{"skip"}
+	sw.ExtList = []string{".other"}
+	assert.NoError(sw.Walk())
+
+	base, middle, top = sw.Root, sw.Overlays[0], sw.Overlays[1]
+	assert.Equal(map[string]string{
+		"a.txt":            top,
+		"b.txt":            middle,
+		"dir/c.txt":        base,
+		"replaced":         top,
+		"onlybase/f.txt":   base,
+		"dir/nested/g.txt": middle,
+		"dir/nested/h.txt": top,
+	}, pw.roots)
+}
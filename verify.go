@@ -0,0 +1,259 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Manifest maps paths relative to a root, using "/", to their hex encoded checksums.
+type Manifest map[string]string
+
+//ReadManifest reads a manifest in the format written by sha256sum and friends:
+//
+//	<hex checksum>  <path relative to the root>
+//
+//A "*" in front of the path, as written for binary mode, and a leading "./" are ignored.
+//Blank lines and lines starting with "#" are skipped. Paths holding a backslash, newline or carriage return
+//are escaped like GNU coreutils does, with the line starting with a backslash, see WriteManifest.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	m := make(Manifest)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		escaped := strings.HasPrefix(text, "\\")
+		if escaped {
+			text = text[1:]
+		} else {
+			//Windows line endings. Escaped lines have any carriage return in the path escaped.
+			text = strings.TrimSuffix(text, "\r")
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.IndexAny(text, " \t")
+		if i <= 0 {
+			return nil, &ManifestError{Line: line, Msg: "expected a checksum and a path"}
+		}
+		sum := strings.ToLower(text[:i])
+		if _, err := hex.DecodeString(sum); err != nil {
+			return nil, &ManifestError{Line: line, Msg: "checksum is not hex encoded"}
+		}
+		path := text[i+1:]
+		if strings.HasPrefix(path, " ") || strings.HasPrefix(path, "*") {
+			path = path[1:]
+		}
+		path = strings.TrimPrefix(path, "./")
+		if escaped {
+			var ok bool
+			if path, ok = unescapeManifestPath(path); !ok {
+				return nil, &ManifestError{Line: line, Msg: "invalid escape in path"}
+			}
+		}
+		if path == "" {
+			return nil, &ManifestError{Line: line, Msg: "missing path"}
+		}
+		m[path] = sum
+	}
+	return m, scanner.Err()
+}
+
+//WriteManifest writes m sorted by path in the format ReadManifest and sha256sum -c read.
+//Paths holding a backslash, newline or carriage return are escaped like GNU coreutils does.
+func WriteManifest(w io.Writer, m Manifest) error {
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	bw := bufio.NewWriter(w)
+	for _, path := range paths {
+		escaped, ok := escapeManifestPath(path)
+		if ok {
+			bw.WriteString("\\")
+		}
+		bw.WriteString(m[path] + "  " + escaped + "\n")
+	}
+	return bw.Flush()
+}
+
+//LoadManifest reads the manifest in the file at path. See ReadManifest.
+func LoadManifest(path string) (Manifest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadManifest(file)
+}
+
+//Hash guesses the hash the manifest was made with from the length of its checksums.
+//md5, sha1, sha256 and sha512 are recognized. It returns nil if the lengths are mixed or unknown.
+func (m Manifest) Hash() func() hash.Hash {
+	var size int
+	for _, sum := range m {
+		if size != 0 && len(sum) != size {
+			return nil
+		}
+		size = len(sum)
+	}
+	switch size / 2 {
+	case md5.Size:
+		return md5.New
+	case sha1.Size:
+		return sha1.New
+	case sha256.Size:
+		return sha256.New
+	case sha512.Size:
+		return sha512.New
+	}
+	return nil
+}
+
+//ErrUnknownHash is returned by a VerifyWorker when it has no Hash and the manifest does not hint at one.
+var ErrUnknownHash = errors.New("can not tell which hash the manifest uses")
+
+//VerifyStatus is how a file compared to its manifest.
+type VerifyStatus int
+
+const (
+	//VSOK is used for a file with the checksum in the manifest.
+	VSOK VerifyStatus = iota
+	//VSMismatch is used for a file with a different checksum than the manifest.
+	VSMismatch
+	//VSMissing is used for a file in the manifest that was not found.
+	VSMissing
+	//VSExtra is used for a file that was found but is not in the manifest.
+	VSExtra
+	//VSError is used for a file that could not be read.
+	VSError
+)
+
+var verifyStatusNames = [...]string{"ok", "mismatch", "missing", "extra", "error"}
+
+func (vs VerifyStatus) String() string {
+	if vs < 0 || int(vs) >= len(verifyStatusNames) {
+		return "unknown"
+	}
+	return verifyStatusNames[vs]
+}
+
+//VerifyResult is how a single file compared to its manifest.
+type VerifyResult struct {
+	//Path is relative to Root using "/", like in the manifest.
+	Path   string
+	Status VerifyStatus
+	//Expected is the checksum in the manifest and Actual the checksum of the file, when they are known.
+	Expected string
+	Actual   string
+	Err      error
+}
+
+//VerifyWorker is a Worker that checks every file it is given against a Manifest.
+//Files the Skywalker filters out are neither extra nor checked, but are reported missing if they are in the manifest.
+//Directories are ignored so it is best used with FilesOnly.
+type VerifyWorker struct {
+	//Root should be the same Root the Skywalker is using.
+	Root string
+
+	Manifest Manifest
+
+	//Hash is the hash the manifest was made with. Defaults to Manifest.Hash.
+	Hash func() hash.Hash
+
+	//Limiter caps how fast files are read if it is set.
+	Limiter *ByteLimiter
+
+	root rootRel
+
+	mutex   sync.Mutex
+	results []VerifyResult
+	seen    map[string]struct{}
+}
+
+//NewVerifyWorker creates a VerifyWorker that checks the tree at root against m.
+func NewVerifyWorker(root string, m Manifest) *VerifyWorker {
+	return &VerifyWorker{
+		Root:     root,
+		Manifest: m,
+		seen:     make(map[string]struct{}),
+	}
+}
+
+//Work checks the file at path.
+func (vw *VerifyWorker) Work(path string) {
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return
+	}
+	rel, rerr := vw.root.rel(vw.Root, path)
+	if rerr != nil {
+		return
+	}
+	res := VerifyResult{Path: filepath.ToSlash(rel)}
+	expected, ok := vw.Manifest[res.Path]
+	switch {
+	case !ok:
+		res.Status = VSExtra
+	case err != nil:
+		res.Status, res.Expected, res.Err = VSError, expected, err
+	default:
+		res.Expected = expected
+		res.Actual, res.Err = vw.checksum(path)
+		res.Status = VSOK
+		if res.Err != nil {
+			res.Status = VSError
+		} else if res.Actual != expected {
+			res.Status = VSMismatch
+		}
+	}
+	vw.mutex.Lock()
+	defer vw.mutex.Unlock()
+	vw.seen[res.Path] = struct{}{}
+	vw.results = append(vw.results, res)
+}
+
+func (vw *VerifyWorker) checksum(path string) (string, error) {
+	newHash := vw.Hash
+	if newHash == nil {
+		newHash = vw.Manifest.Hash()
+	}
+	if newHash == nil {
+		return "", ErrUnknownHash
+	}
+	sum, err := checksum(path, newHash(), vw.Limiter)
+	return hex.EncodeToString(sum), err
+}
+
+//Results returns every file checked so far along with every file in the manifest that was not, sorted by path.
+//It should be called once the walk is done, otherwise files still to come are reported missing.
+func (vw *VerifyWorker) Results() []VerifyResult {
+	vw.mutex.Lock()
+	defer vw.mutex.Unlock()
+	results := make([]VerifyResult, len(vw.results))
+	copy(results, vw.results)
+	for path, sum := range vw.Manifest {
+		if _, ok := vw.seen[path]; !ok {
+			results = append(results, VerifyResult{Path: path, Status: VSMissing, Expected: sum})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results
+}
@@ -0,0 +1,55 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerifyWorker(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"ok.txt":      "ok",
+		"sub/bad.txt": "changed",
+		"extra.txt":   "extra",
+	})
+	manifest := fmt.Sprintf("# made by sha256sum\n%s  ./ok.txt\n%s *sub/bad.txt\n\n%s  gone.txt\n",
+		strings.ToUpper(sha256Hex("ok")), sha256Hex("bad"), sha256Hex("gone"))
+	m, err := skywalker.ReadManifest(strings.NewReader(manifest))
+	assert.NoError(err)
+	assert.Len(m, 3)
+
+	vw := skywalker.NewVerifyWorker(tmp, m)
+	assert.NoError(skywalker.New(tmp, vw).Walk())
+	statuses := make(map[string]skywalker.VerifyStatus)
+	for _, res := range vw.Results() {
+		assert.NoError(res.Err)
+		statuses[res.Path] = res.Status
+	}
+	assert.Equal(map[string]skywalker.VerifyStatus{
+		"ok.txt":      skywalker.VSOK,
+		"sub/bad.txt": skywalker.VSMismatch,
+		"gone.txt":    skywalker.VSMissing,
+		"extra.txt":   skywalker.VSExtra,
+	}, statuses)
+
+	_, err = skywalker.ReadManifest(strings.NewReader("zz  file\n"))
+	var manifestErr *skywalker.ManifestError
+	assert.True(errors.As(err, &manifestErr), "Expected a ManifestError but got %v", err)
+}
@@ -0,0 +1,102 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+//ErrReadLimit is returned by reads of a file opened with Skywalker.Open once MaxReadBytes were read from it.
+var ErrReadLimit = errors.New("read limit reached")
+
+//guardedFile is a file opened with Skywalker.Open that stops at MaxReadBytes and gives up on reads taking
+//longer than ReadTimeout.
+type guardedFile struct {
+	fs.File
+	left    int64
+	limited bool
+	timeout time.Duration
+	//deadline is set if the file supports read deadlines, otherwise reads are waited on in a goroutine.
+	deadline bool
+}
+
+//guard wraps file with the MaxReadBytes and ReadTimeout of the Skywalker, if they are set.
+func (sw *Skywalker) guard(file fs.File) fs.File {
+	if sw.MaxReadBytes == 0 && sw.ReadTimeout == 0 {
+		return file
+	}
+	g := &guardedFile{File: file, left: sw.MaxReadBytes, limited: sw.MaxReadBytes > 0, timeout: sw.ReadTimeout}
+	if f, ok := file.(*os.File); ok && g.timeout > 0 {
+		g.deadline = f.SetReadDeadline(time.Time{}) == nil
+	}
+	return g
+}
+
+func (g *guardedFile) Read(p []byte) (int, error) {
+	if g.limited {
+		if g.left <= 0 {
+			return 0, ErrReadLimit
+		}
+		if int64(len(p)) > g.left {
+			p = p[:g.left]
+		}
+	}
+	n, err := g.read(p)
+	g.left -= int64(n)
+	return n, err
+}
+
+func (g *guardedFile) read(p []byte) (int, error) {
+	if g.timeout == 0 {
+		return g.File.Read(p)
+	}
+	if g.deadline {
+		f := g.File.(*os.File)
+		f.SetReadDeadline(time.Now().Add(g.timeout))
+		return f.Read(p)
+	}
+	//Reads of files the poller can not wait on, like most of /proc, block in the kernel. The goroutine stays
+	//stuck until the read returns, but the worker can go on, so the read goes to a buffer of its own.
+	type result struct {
+		n   int
+		err error
+	}
+	file := g.File
+	buf := make([]byte, len(p))
+	done := make(chan result, 1)
+	go func() {
+		n, err := file.Read(buf)
+		done <- result{n, err}
+	}()
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		copy(p, buf[:res.n])
+		return res.n, res.err
+	case <-timer.C:
+		//Nothing else can be read from the file as the stuck read would race it.
+		g.timeout, g.deadline = 0, false
+		g.File = stuckFile{g.File}
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+//stuckFile is a file with a read that never returned. Every other read fails.
+type stuckFile struct {
+	fs.File
+}
+
+func (s stuckFile) Read([]byte) (int, error) {
+	return 0, os.ErrDeadlineExceeded
+}
+
+//virtualDir reports whether the directory at path should be skipped for being on a virtual filesystem.
+func (sw *Skywalker) virtualDir(path string) bool {
+	return sw.SkipVirtualFS && sw.FS == nil && virtualFS(path)
+}
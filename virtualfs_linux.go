@@ -0,0 +1,48 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"syscall"
+)
+
+//virtualMagic are the statfs magic numbers of filesystems the kernel makes up as they are read.
+//tmpfs, and devtmpfs which shares its magic, hold real files and are walked.
+var virtualMagic = map[uint32]string{
+	0x9fa0:     "proc",
+	0x62656572: "sysfs",
+	0x1cd1:     "devpts",
+	0x64626720: "debugfs",
+	0x74726163: "tracefs",
+	0x73636673: "securityfs",
+	0x27e0eb:   "cgroup",
+	0x63677270: "cgroup2",
+	0x6165676c: "pstore",
+	0xcafe4a11: "bpf",
+	0x62656570: "configfs",
+	0x65735543: "fusectl",
+	0xde5e81e4: "efivarfs",
+	0x42494e4d: "binfmt_misc",
+	0x19800202: "mqueue",
+	0xf97cff8c: "selinuxfs",
+	0x67596969: "rpc_pipefs",
+	0x6e736673: "nsfs",
+}
+
+//virtualFS reports whether the directory at path is on a virtual filesystem like /proc or /sys.
+func virtualFS(path string) bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(osPath(path), &st); err != nil {
+		return false
+	}
+	_, ok := virtualMagic[uint32(st.Type)]
+	return ok
+}
+
+//openGuarded opens path without waiting for a writer if it is a FIFO, so opening it can not hang.
+func openGuarded(path string) (*os.File, error) {
+	return os.OpenFile(osPath(path), os.O_RDONLY|syscall.O_NONBLOCK, 0)
+}
@@ -0,0 +1,69 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipVirtualFS(t *testing.T) {
+	assert := assert.New(t)
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("/proc is not mounted")
+	}
+	tw := NewTW()
+	sw := skywalker.New("/proc", tw)
+	sw.SkipVirtualFS = true
+	var skipped []string
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind == skywalker.EKSkipped && ev.Reason == skywalker.RVirtualFS {
+			skipped = append(skipped, ev.Path)
+		}
+	}
+	assert.NoError(sw.Walk())
+	assert.Empty(tw.found)
+	assert.Equal([]string{"/proc"}, skipped)
+
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"a/b.txt": ""})
+	tw = NewTW()
+	sw = skywalker.New(tmp, tw)
+	sw.SkipVirtualFS = true
+	sw.FilesOnly = true
+	assert.NoError(sw.Walk())
+	assert.Len(tw.found, 1)
+}
+
+func TestReadTimeout(t *testing.T) {
+	assert := assert.New(t)
+	fifo := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(fifo, 0600); err != nil {
+		t.Skip("can not make a fifo:", err)
+	}
+	sw := skywalker.New(filepath.Dir(fifo), NewTW())
+	sw.ReadTimeout = 50 * time.Millisecond
+	//Opening does not wait for a writer.
+	file, err := sw.Open(fifo)
+	if !assert.NoError(err) {
+		return
+	}
+	defer file.Close()
+	writer, err := os.OpenFile(fifo, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if !assert.NoError(err) {
+		return
+	}
+	defer writer.Close()
+	start := time.Now()
+	_, err = file.Read(make([]byte, 10))
+	assert.ErrorIs(err, os.ErrDeadlineExceeded)
+	assert.Less(time.Since(start), 5*time.Second)
+}
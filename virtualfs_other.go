@@ -0,0 +1,19 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package skywalker
+
+import "os"
+
+//virtualFS reports whether the directory at path is on a virtual filesystem. Only Linux is checked.
+func virtualFS(string) bool {
+	return false
+}
+
+func openGuarded(path string) (*os.File, error) {
+	return Open(path)
+}
@@ -0,0 +1,34 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxReadBytes(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{"huge": strings.Repeat("x", 100)})
+	sw := skywalker.New(tmp, NewTW())
+	sw.MaxReadBytes = 10
+	file, err := sw.Open(filepath.Join(tmp, "huge"))
+	if !assert.NoError(err) {
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	assert.ErrorIs(err, skywalker.ErrReadLimit)
+	assert.Equal(strings.Repeat("x", 10), string(data))
+
+	sw.MaxReadBytes = -1
+	assert.Error(sw.Walk())
+}
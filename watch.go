@@ -0,0 +1,371 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+//DefaultWatchDelay is how long WalkAndWatch waits for more changes if WatchDelay is not set.
+const DefaultWatchDelay = 100 * time.Millisecond
+
+//DefaultWatchInterval is how often WalkAndWatch polls for changes if WatchInterval is not set.
+const DefaultWatchInterval = 2 * time.Second
+
+//WatchBackend is how WalkAndWatch finds out about changes.
+type WatchBackend int
+
+const (
+	//WBAuto uses inotify on Linux, FSEvents on macOS and polls elsewhere.
+	WBAuto WatchBackend = iota
+	//WBPoll lists every watched directory each WatchInterval. It works everywhere but costs a stat for every
+	//entry of every directory each time.
+	WBPoll
+	//WBInotify watches every directory with inotify on Linux. Every directory takes one of the user's
+	//fs.inotify.max_user_watches, so large trees may need it raised.
+	WBInotify
+	//WBFanotify watches whole filesystems with fanotify on Linux 5.9 or later on amd64 and arm64, so it needs no
+	//watch per directory and scales to large trees. Every change on the filesystems the tree is on is read and
+	//the ones outside of it thrown away. It needs CAP_SYS_ADMIN and a filesystem that can hand out file handles.
+	WBFanotify
+	//WBUSNJournal reads the changes to the NTFS volume the Root is on from its USN journal on Windows each
+	//WatchInterval, so finding them takes no listing of directories at all however large the tree is. It needs
+	//administrator rights and an active journal. Directories on other volumes mounted in the tree can not be watched.
+	WBUSNJournal
+	//WBFSEvents is told about changes to the whole tree by FSEvents on macOS, so it needs no watch per directory.
+	//It needs cgo. Files are reported while they are written rather than once they are closed, so WatchDelay should
+	//be long enough for writes to finish.
+	WBFSEvents
+)
+
+var watchBackendNames = [...]string{"auto", "poll", "inotify", "fanotify", "usn journal", "fsevents"}
+
+func (wb WatchBackend) String() string {
+	if wb < 0 || int(wb) >= len(watchBackendNames) {
+		return "unknown"
+	}
+	return watchBackendNames[wb]
+}
+
+//ErrWatchOverflow is sent with an EKError event when changes were lost because they came in faster than
+//WalkAndWatch could take them.
+var ErrWatchOverflow = errors.New("too many changes to watch")
+
+//watcher tells WalkAndWatch about changes in the directories it watches by adding them to a changeSet.
+//It reports every file written, created or moved into a watched directory and every directory created or
+//moved into one, but not what is removed.
+type watcher interface {
+	//add starts watching the directory at path, but not the directories in it.
+	add(path string) error
+	close() error
+}
+
+//changeSet collects the paths a watcher found changed until WalkAndWatch takes them, so a watcher never
+//waits on the walk.
+type changeSet struct {
+	mutex sync.Mutex
+	paths map[string]struct{}
+	errs  []error
+	ready chan struct{}
+}
+
+func newChangeSet() *changeSet {
+	return &changeSet{paths: make(map[string]struct{}), ready: make(chan struct{}, 1)}
+}
+
+func (cs *changeSet) add(path string) {
+	cs.mutex.Lock()
+	cs.paths[path] = struct{}{}
+	cs.mutex.Unlock()
+	cs.signal()
+}
+
+func (cs *changeSet) fail(err error) {
+	cs.mutex.Lock()
+	cs.errs = append(cs.errs, err)
+	cs.mutex.Unlock()
+	cs.signal()
+}
+
+func (cs *changeSet) signal() {
+	select {
+	case cs.ready <- struct{}{}:
+	default:
+	}
+}
+
+//take returns and forgets the changed paths, sorted, and the errors.
+func (cs *changeSet) take() ([]string, []error) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	paths := make([]string, 0, len(cs.paths))
+	for path := range cs.paths {
+		paths = append(paths, path)
+	}
+	cs.paths = make(map[string]struct{})
+	errs := cs.errs
+	cs.errs = nil
+	sort.Strings(paths)
+	return paths, errs
+}
+
+//WalkAndWatch walks like WalkContext and then keeps watching the directories it walked, handing every file
+//created or written and everything in a directory created or moved in to the same filters and workers, so a
+//one-shot job like a thumbnailer or an indexer becomes a continuous pipeline. On Linux it is told about changes
+//with inotify, on macOS with FSEvents, elsewhere the directories are polled every WatchInterval, see WatchBackend.
+//A WatchBackend that can not be used falls back to inotify on Linux or FSEvents on macOS and then to polling.
+//Changes are handed on WatchDelay after they were found and only once however often a path changed in the
+//meantime. An EKWatching event is sent once the walk found everything, with the WatchBackend in use as its Value.
+//It runs until ctx is done and then returns nil, or the errors Walk would return for what the workers did.
+//Paths still queued are dropped.
+//It can not be used with FS, Overlays or ProgressFile.
+func (sw *Skywalker) WalkAndWatch(ctx context.Context) error {
+	if err := sw.init(); err != nil {
+		return err
+	}
+	switch {
+	case sw.FS != nil:
+		return &ConfigError{Field: "FS", Msg: "can not be used with WalkAndWatch"}
+	case len(sw.Overlays) > 0:
+		return &ConfigError{Field: "Overlays", Msg: "can not be used with WalkAndWatch"}
+	case sw.ProgressFile != "":
+		return &ConfigError{Field: "ProgressFile", Msg: "can not be used with WalkAndWatch"}
+	}
+	sw.ctx = ctx
+	defer func() { sw.ctx = nil }()
+	sw.resetCanceled()
+	sw.recorder = nil
+	if sw.Record != nil {
+		sw.recorder = &recorder{enc: json.NewEncoder(sw.Record)}
+	}
+	started := time.Now()
+	sw.emit(Event{Kind: EKStart, Root: sw.Root})
+	err := sw.walkAndWatch()
+	if err == nil && sw.recorder != nil {
+		err = sw.recorder.err
+	}
+	sw.emit(Event{Kind: EKFinish, Root: sw.Root, Err: err, Duration: time.Since(started)})
+	return err
+}
+
+//walkAndWatch does what WalkAndWatch describes once everything is initialized.
+func (sw *Skywalker) walkAndWatch() error {
+	if _, err := sw.lstat(sw.Root); err != nil {
+		return rootError(sw.Root, err)
+	}
+	interval := sw.WatchInterval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+	cs := newChangeSet()
+	var backend WatchBackend
+	sw.watcher, backend = newWatcher(cs, sw.Root, sw.WatchBackend, interval)
+	defer func() {
+		sw.watcher.close()
+		sw.watcher = nil
+	}()
+	unlock, err := sw.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	dispatch, wait, err := sw.pool()
+	if err != nil {
+		return err
+	}
+	if sw.EnterDir != nil || sw.LeaveDir != nil {
+		runtime.LockOSThread()
+	}
+	err = sw.walkLayers(dispatch)
+	if sw.EnterDir != nil || sw.LeaveDir != nil {
+		runtime.UnlockOSThread()
+	}
+	if err == nil {
+		sw.emit(Event{Kind: EKWatching, Root: sw.Root, Value: backend})
+		sw.watchChanges(cs, dispatch)
+	} else if sw.ctxErr() != nil {
+		err = nil
+	}
+	if sw.segments != nil {
+		sw.segments.done()
+	}
+	if sw.top != nil {
+		sw.top.flush()
+	}
+	if werr := wait(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+//watchChanges hands on what changed until the walk's context is done.
+func (sw *Skywalker) watchChanges(cs *changeSet, dispatch func(item)) {
+	delay := sw.WatchDelay
+	if delay == 0 {
+		delay = DefaultWatchDelay
+	}
+	for {
+		select {
+		case <-cs.ready:
+		case <-sw.ctx.Done():
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-sw.ctx.Done():
+			return
+		}
+		paths, errs := cs.take()
+		for _, err := range errs {
+			sw.countError()
+			sw.emit(Event{Kind: EKError, Root: sw.Root, Err: err})
+		}
+		for _, path := range paths {
+			if sw.ctxErr() != nil {
+				return
+			}
+			sw.walkChanged(path, dispatch)
+		}
+	}
+}
+
+//walkChanged walks path, which changed since the walk, with the filters of the walk. The ignore files of the
+//directories above it are read again as they may have changed as well.
+func (sw *Skywalker) walkChanged(path string, dispatch func(item)) {
+	if _, err := os.Lstat(path); err != nil {
+		//It is gone already.
+		return
+	}
+	ig := sw.newIgnores(sw.Root)
+	if ig != nil && !ig.enterAbove(path) {
+		return
+	}
+	sw.dirWalker()(path, sw.followLinks(sw.Root, sw.walkerWith(sw.matcher, nil, ig, dispatch)))
+}
+
+//watchDirs wraps walkFn so every directory the walk goes into is watched.
+func (sw *Skywalker) watchDirs(walkFn filepath.WalkFunc) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		ret := walkFn(path, info, err)
+		if ret == nil && err == nil && info.IsDir() {
+			if werr := sw.watcher.add(path); werr != nil {
+				sw.countError()
+				sw.emit(Event{Kind: EKError, Path: path, Root: sw.Root, Info: info, Err: werr})
+			}
+		}
+		return ret
+	}
+}
+
+//pollEntry is what a pollWatcher saw of a directory entry.
+type pollEntry struct {
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+//pollWatcher finds changes by listing every watched directory each interval.
+type pollWatcher struct {
+	cs *changeSet
+
+	mutex sync.Mutex
+	dirs  map[string]map[string]pollEntry
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPollWatcher(cs *changeSet, interval time.Duration) *pollWatcher {
+	pw := &pollWatcher{cs: cs, dirs: make(map[string]map[string]pollEntry), stop: make(chan struct{})}
+	pw.wg.Add(1)
+	go func() {
+		defer pw.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pw.poll()
+			case <-pw.stop:
+				return
+			}
+		}
+	}()
+	return pw
+}
+
+//list returns the entries of the directory at path.
+func (pw *pollWatcher) list(path string) (map[string]pollEntry, error) {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]pollEntry, len(dirEntries))
+	for _, entry := range dirEntries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		entries[entry.Name()] = pollEntry{size: info.Size(), modTime: info.ModTime(), dir: info.IsDir()}
+	}
+	return entries, nil
+}
+
+func (pw *pollWatcher) add(path string) error {
+	entries, err := pw.list(path)
+	if err != nil {
+		return err
+	}
+	pw.mutex.Lock()
+	pw.dirs[path] = entries
+	pw.mutex.Unlock()
+	return nil
+}
+
+//poll lists every watched directory and reports what is new or changed. Directories that are gone are forgotten.
+func (pw *pollWatcher) poll() {
+	pw.mutex.Lock()
+	dirs := make([]string, 0, len(pw.dirs))
+	for dir := range pw.dirs {
+		dirs = append(dirs, dir)
+	}
+	pw.mutex.Unlock()
+	for _, dir := range dirs {
+		entries, err := pw.list(dir)
+		pw.mutex.Lock()
+		old, ok := pw.dirs[dir]
+		if !ok {
+			pw.mutex.Unlock()
+			continue
+		}
+		if err != nil {
+			delete(pw.dirs, dir)
+			pw.mutex.Unlock()
+			continue
+		}
+		pw.dirs[dir] = entries
+		pw.mutex.Unlock()
+		for name, entry := range entries {
+			prev, seen := old[name]
+			if !seen || !entry.dir && (prev.size != entry.size || !prev.modTime.Equal(entry.modTime)) {
+				pw.cs.add(filepath.Join(dir, name))
+			}
+		}
+	}
+}
+
+func (pw *pollWatcher) close() error {
+	close(pw.stop)
+	pw.wg.Wait()
+	return nil
+}
@@ -0,0 +1,18 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import "time"
+
+//newWatcher returns a watcher told about changes by FSEvents unless backend is WBPoll, falling back to polling
+//every interval.
+func newWatcher(cs *changeSet, root string, backend WatchBackend, interval time.Duration) (watcher, WatchBackend) {
+	if backend != WBPoll {
+		if fw, err := newFSEventsWatcher(cs, root); err == nil {
+			return fw, WBFSEvents
+		}
+	}
+	return newPollWatcher(cs, interval), WBPoll
+}
@@ -0,0 +1,120 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+//inotifyMask are the inotify events a watched directory reports.
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_ONLYDIR
+
+//newWatcher returns a watcher for backend, falling back to inotify and then to polling every interval.
+func newWatcher(cs *changeSet, _ string, backend WatchBackend, interval time.Duration) (watcher, WatchBackend) {
+	if backend == WBFanotify {
+		if fw, err := newFanotifyWatcher(cs); err == nil {
+			return fw, WBFanotify
+		}
+	}
+	if backend != WBPoll {
+		if iw, err := newInotifyWatcher(cs); err == nil {
+			return iw, WBInotify
+		}
+	}
+	return newPollWatcher(cs, interval), WBPoll
+}
+
+//inotifyWatcher is told about changes by inotify. Every watched directory takes one of the user's
+//fs.inotify.max_user_watches, adding more fails with ENOSPC.
+type inotifyWatcher struct {
+	cs   *changeSet
+	fd   int
+	file *os.File
+
+	mutex sync.Mutex
+	dirs  map[int32]string
+
+	wg sync.WaitGroup
+}
+
+func newInotifyWatcher(cs *changeSet) (*inotifyWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	//A non-blocking file is read through the runtime's poller, so closing it stops the read.
+	iw := &inotifyWatcher{cs: cs, fd: fd, file: os.NewFile(uintptr(fd), "inotify"), dirs: make(map[int32]string)}
+	iw.wg.Add(1)
+	go iw.read()
+	return iw, nil
+}
+
+func (iw *inotifyWatcher) add(path string) error {
+	wd, err := syscall.InotifyAddWatch(iw.fd, path, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+	}
+	iw.mutex.Lock()
+	iw.dirs[int32(wd)] = path
+	iw.mutex.Unlock()
+	return nil
+}
+
+//read adds what the kernel reports to the changeSet until the watcher is closed.
+func (iw *inotifyWatcher) read() {
+	defer iw.wg.Done()
+	var buf [64 * 1024]byte
+	for {
+		n, err := iw.file.Read(buf[:])
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			off = start + int(ev.Len)
+			if off > n {
+				break
+			}
+			iw.event(ev, strings.TrimRight(string(buf[start:off]), "\x00"))
+		}
+	}
+}
+
+func (iw *inotifyWatcher) event(ev *syscall.InotifyEvent, name string) {
+	if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		iw.cs.fail(ErrWatchOverflow)
+		return
+	}
+	iw.mutex.Lock()
+	dir, ok := iw.dirs[ev.Wd]
+	if ev.Mask&syscall.IN_IGNORED != 0 {
+		delete(iw.dirs, ev.Wd)
+	}
+	iw.mutex.Unlock()
+	if !ok || name == "" {
+		return
+	}
+	path := filepath.Join(dir, name)
+	if ev.Mask&syscall.IN_CREATE != 0 && ev.Mask&syscall.IN_ISDIR == 0 {
+		//New files are reported once they are written, anything else right away.
+		if info, err := os.Lstat(path); err != nil || info.Mode().IsRegular() {
+			return
+		}
+	}
+	iw.cs.add(path)
+}
+
+func (iw *inotifyWatcher) close() error {
+	err := iw.file.Close()
+	iw.wg.Wait()
+	return err
+}
@@ -0,0 +1,15 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//go:build !linux && !windows && !darwin
+// +build !linux,!windows,!darwin
+
+package skywalker
+
+import "time"
+
+//newWatcher returns a watcher polling every interval, as every other WatchBackend only works on Linux, Windows or macOS.
+func newWatcher(cs *changeSet, _ string, _ WatchBackend, interval time.Duration) (watcher, WatchBackend) {
+	return newPollWatcher(cs, interval), WBPoll
+}
@@ -0,0 +1,129 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkAndWatch(t *testing.T) {
+	assert := assert.New(t)
+	tmp := t.TempDir()
+	writeFiles(t, tmp, map[string]string{
+		"a.txt":      "",
+		"sub/b.txt":  "",
+		"skip/c.txt": "",
+		".gitignore": "*.tmp\n",
+	})
+	tw := NewTW()
+	sw := skywalker.New(tmp, tw)
+	sw.FilesOnly = true
+	sw.DirList = []string{"skip"}
+	sw.ExtList = []string{".log"}
+	sw.IgnoreFiles = []string{".gitignore"}
+	sw.WatchDelay = 10 * time.Millisecond
+	sw.WatchInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sw.OnEvent = func(ev skywalker.Event) {
+		if ev.Kind != skywalker.EKWatching {
+			return
+		}
+		writeFiles(t, sw.Root, map[string]string{
+			"sub/d.log":      "",
+			"sub/e.tmp":      "",
+			"skip/f.txt":     "",
+			"sub/g.txt":      "",
+			"new/deep/h.txt": "",
+		})
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- sw.WalkAndWatch(ctx)
+	}()
+	want := []string{".gitignore", "a.txt", "sub/b.txt", "sub/g.txt", "new/deep/h.txt"}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		tw.Lock()
+		n := len(tw.found)
+		tw.Unlock()
+		if n >= len(want) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	//Give anything that should not be handed on the chance to be.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.NoError(<-done)
+	tw.Lock()
+	defer tw.Unlock()
+	assert.Len(tw.found, len(want))
+	for _, rel := range want {
+		assert.Contains(tw.found, filepath.Join(sw.Root, filepath.FromSlash(rel)))
+	}
+}
+
+func TestWalkAndWatchBackends(t *testing.T) {
+	for _, backend := range []skywalker.WatchBackend{skywalker.WBPoll, skywalker.WBInotify, skywalker.WBFanotify, skywalker.WBUSNJournal, skywalker.WBFSEvents} {
+		t.Run(backend.String(), func(t *testing.T) {
+			assert := assert.New(t)
+			tmp := t.TempDir()
+			writeFiles(t, tmp, map[string]string{"a.txt": "a"})
+			written := make(chan string, 10)
+			sw := skywalker.New(tmp, pathWorker(written))
+			sw.FilesOnly = true
+			sw.WatchBackend = backend
+			sw.WatchDelay = 10 * time.Millisecond
+			sw.WatchInterval = 10 * time.Millisecond
+			sw.OnEvent = func(ev skywalker.Event) {
+				if ev.Kind == skywalker.EKWatching {
+					t.Log("watching with", ev.Value)
+					//Changing the size is all polling needs to notice.
+					assert.NoError(os.WriteFile(filepath.Join(sw.Root, "a.txt"), []byte("changed"), 0666))
+				}
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- sw.WalkAndWatch(ctx)
+			}()
+			for i := 0; i < 2; i++ {
+				select {
+				case path := <-written:
+					assert.Equal(filepath.Join(sw.Root, "a.txt"), path)
+				case <-time.After(5 * time.Second):
+					assert.Fail("change was not handed on")
+				}
+			}
+			cancel()
+			assert.NoError(<-done)
+		})
+	}
+}
+
+func TestWalkAndWatchConfig(t *testing.T) {
+	assert := assert.New(t)
+	sw := skywalker.New(t.TempDir(), NewTW())
+	sw.Overlays = []string{t.TempDir()}
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.WalkAndWatch(context.Background()), &ce) {
+		assert.Equal("Overlays", ce.Field)
+	}
+}
+
+//pathWorker sends every path it is handed.
+type pathWorker chan string
+
+func (pw pathWorker) Work(path string) {
+	pw <- path
+}
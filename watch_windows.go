@@ -0,0 +1,271 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+)
+
+//The parts of winioctl.h and winerror.h the syscall package does not have.
+const (
+	fsctlQueryUSNJournal = 0x000900f4
+	fsctlReadUSNJournal  = 0x000900bb
+
+	usnReasonDataOverwrite  = 0x1
+	usnReasonDataExtend     = 0x2
+	usnReasonDataTruncation = 0x4
+	usnReasonFileCreate     = 0x100
+	usnReasonRenameNewName  = 0x2000
+	usnReasonClose          = 0x80000000
+
+	errorJournalEntryDeleted syscall.Errno = 1181
+
+	//usnRecordLen is the size of a USN_RECORD_V2 without its file name.
+	usnRecordLen = 60
+)
+
+//usnChanged are the reasons for a USN record that make a path changed.
+const usnChanged = usnReasonDataOverwrite | usnReasonDataExtend | usnReasonDataTruncation | usnReasonFileCreate | usnReasonRenameNewName
+
+//errOtherVolume is returned when watching a directory that is not on the volume the journal is read from.
+var errOtherVolume = errors.New("not on the volume of the root")
+
+//errNoDrive is returned when the journal of a volume without a drive letter is read.
+var errNoDrive = errors.New("not on a volume with a drive letter")
+
+//newWatcher returns a watcher reading the USN journal of the volume root is on for WBUSNJournal, falling back
+//to polling every interval.
+func newWatcher(cs *changeSet, root string, backend WatchBackend, interval time.Duration) (watcher, WatchBackend) {
+	if backend == WBUSNJournal {
+		if uw, err := newUSNWatcher(cs, root, interval); err == nil {
+			return uw, WBUSNJournal
+		}
+	}
+	return newPollWatcher(cs, interval), WBPoll
+}
+
+//usnJournalData is USN_JOURNAL_DATA_V0.
+type usnJournalData struct {
+	UsnJournalID    uint64
+	FirstUsn        int64
+	NextUsn         int64
+	LowestValidUsn  int64
+	MaxUsn          int64
+	MaximumSize     uint64
+	AllocationDelta uint64
+}
+
+//readUSNJournalData is READ_USN_JOURNAL_DATA_V0.
+type readUSNJournalData struct {
+	StartUsn          int64
+	ReasonMask        uint32
+	ReturnOnlyOnClose uint32
+	Timeout           uint64
+	BytesToWaitFor    uint64
+	UsnJournalID      uint64
+}
+
+//usnWatcher reads what changed on a volume from its USN journal every interval. Records name the directory
+//they happened in by its file reference number, so the number of every watched directory is kept to find its path.
+type usnWatcher struct {
+	cs     *changeSet
+	volume syscall.Handle
+	//serial is the serial number of the volume.
+	serial uint32
+	//journal is the ID of the journal and next the USN of the first record not read yet.
+	journal uint64
+	next    int64
+
+	mutex sync.Mutex
+	//dirs are the watched directories by their file reference number.
+	dirs map[uint64]string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newUSNWatcher(cs *changeSet, root string, interval time.Duration) (*usnWatcher, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	_, serial, err := fileRef(abs)
+	if err != nil {
+		return nil, err
+	}
+	vol := filepath.VolumeName(abs)
+	if len(vol) != 2 || vol[1] != ':' {
+		return nil, &os.PathError{Op: "open volume", Path: abs, Err: errNoDrive}
+	}
+	p, err := syscall.UTF16PtrFromString(`\\.\` + vol)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(p, syscall.GENERIC_READ, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open volume", Path: vol, Err: err}
+	}
+	uw := &usnWatcher{
+		cs:     cs,
+		volume: h,
+		serial: serial,
+		dirs:   make(map[uint64]string),
+		stop:   make(chan struct{}),
+	}
+	if err := uw.query(); err != nil {
+		syscall.CloseHandle(h)
+		return nil, err
+	}
+	uw.wg.Add(1)
+	go func() {
+		defer uw.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := uw.read(); err != nil {
+					//The journal was deleted or can not be read anymore.
+					uw.cs.fail(err)
+					return
+				}
+			case <-uw.stop:
+				return
+			}
+		}
+	}()
+	return uw, nil
+}
+
+//query reads from the end of the journal on.
+func (uw *usnWatcher) query() error {
+	var data usnJournalData
+	var n uint32
+	err := syscall.DeviceIoControl(uw.volume, fsctlQueryUSNJournal, nil, 0,
+		(*byte)(unsafe.Pointer(&data)), uint32(unsafe.Sizeof(data)), &n, nil)
+	if err != nil {
+		return &os.SyscallError{Syscall: "FSCTL_QUERY_USN_JOURNAL", Err: err}
+	}
+	uw.journal, uw.next = data.UsnJournalID, data.NextUsn
+	return nil
+}
+
+//fileRef returns the file reference number of path and the serial number of its volume.
+func fileRef(path string) (uint64, uint32, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	//Directories can only be opened with backup semantics.
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return 0, 0, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	defer syscall.CloseHandle(h)
+	var fi syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &fi); err != nil {
+		return 0, 0, &os.PathError{Op: "GetFileInformationByHandle", Path: path, Err: err}
+	}
+	return uint64(fi.FileIndexHigh)<<32 | uint64(fi.FileIndexLow), fi.VolumeSerialNumber, nil
+}
+
+func (uw *usnWatcher) add(path string) error {
+	ref, serial, err := fileRef(path)
+	if err != nil {
+		return err
+	}
+	if serial != uw.serial {
+		return &os.PathError{Op: "watch", Path: path, Err: errOtherVolume}
+	}
+	uw.mutex.Lock()
+	uw.dirs[ref] = path
+	uw.mutex.Unlock()
+	return nil
+}
+
+//read adds what the journal recorded since it was read last to the changeSet. Records are only read once the
+//file was closed, so files are reported once they are written.
+func (uw *usnWatcher) read() error {
+	var buf [64 * 1024]byte
+	for {
+		in := readUSNJournalData{
+			StartUsn:          uw.next,
+			ReasonMask:        usnChanged | usnReasonClose,
+			ReturnOnlyOnClose: 1,
+			UsnJournalID:      uw.journal,
+		}
+		var n uint32
+		err := syscall.DeviceIoControl(uw.volume, fsctlReadUSNJournal, (*byte)(unsafe.Pointer(&in)), uint32(unsafe.Sizeof(in)),
+			&buf[0], uint32(len(buf)), &n, nil)
+		if err == errorJournalEntryDeleted {
+			//The journal wrapped around before the records were read.
+			uw.cs.fail(ErrWatchOverflow)
+			if err := uw.query(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return &os.SyscallError{Syscall: "FSCTL_READ_USN_JOURNAL", Err: err}
+		}
+		//The records follow the USN to read from next time.
+		if n < 8 {
+			return nil
+		}
+		uw.next = int64(binary.LittleEndian.Uint64(buf[:]))
+		if n == 8 {
+			return nil
+		}
+		for off := uint32(8); off+usnRecordLen <= n; {
+			size := binary.LittleEndian.Uint32(buf[off:])
+			if size < usnRecordLen || off+size > n {
+				break
+			}
+			uw.record(buf[off : off+size])
+			off += size
+		}
+	}
+}
+
+//record handles a USN_RECORD_V2.
+func (uw *usnWatcher) record(rec []byte) {
+	if binary.LittleEndian.Uint16(rec[4:]) != 2 {
+		return
+	}
+	if binary.LittleEndian.Uint32(rec[40:])&usnChanged == 0 {
+		return
+	}
+	nameLen := int(binary.LittleEndian.Uint16(rec[56:]))
+	nameOff := int(binary.LittleEndian.Uint16(rec[58:]))
+	if nameLen == 0 || nameOff+nameLen > len(rec) {
+		return
+	}
+	uw.mutex.Lock()
+	dir, ok := uw.dirs[binary.LittleEndian.Uint64(rec[16:])]
+	uw.mutex.Unlock()
+	if !ok {
+		return
+	}
+	name := make([]uint16, nameLen/2)
+	for i := range name {
+		name[i] = binary.LittleEndian.Uint16(rec[nameOff+2*i:])
+	}
+	uw.cs.add(filepath.Join(dir, string(utf16.Decode(name))))
+}
+
+func (uw *usnWatcher) close() error {
+	close(uw.stop)
+	uw.wg.Wait()
+	return syscall.CloseHandle(uw.volume)
+}
@@ -0,0 +1,38 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker
+
+import (
+	"context"
+	"time"
+)
+
+//ContextWorker is a Worker that stops when its context is done, e.g. an upload or a request to a slow server.
+//It is up to WorkContext to watch the context, nothing stops it otherwise. A plain file read does not.
+//WorkContext is called instead of Work with the walk's context, limited to WorkTimeout if it is set, and every
+//error it returns is handed to OnWorkError and returned by Walk in WorkErrors like the errors of an ErrorWorker.
+type ContextWorker interface {
+	Worker
+	WorkContext(ctx context.Context, path string) error
+}
+
+//workContext hands path to cw, turning running out of WorkTimeout into a WorkTimeoutError once cw returns.
+func (sw *Skywalker) workContext(cw ContextWorker, path string) error {
+	ctx := sw.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if sw.WorkTimeout <= 0 {
+		return cw.WorkContext(ctx, path)
+	}
+	tctx, cancel := context.WithTimeout(ctx, sw.WorkTimeout)
+	defer cancel()
+	started := time.Now()
+	err := cw.WorkContext(tctx, path)
+	if tctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return &WorkTimeoutError{Path: path, Timeout: sw.WorkTimeout, Took: time.Since(started)}
+	}
+	return err
+}
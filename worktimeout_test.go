@@ -0,0 +1,55 @@
+//Copyright (c) 2017, Will Dixon. All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+package skywalker_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dixonwille/skywalker"
+	"github.com/stretchr/testify/assert"
+)
+
+//HangWorker blocks on .pdf files until its context is done.
+type HangWorker struct {
+	*TestWorker
+}
+
+func (hw *HangWorker) WorkContext(ctx context.Context, path string) error {
+	hw.Work(path)
+	if filepath.Ext(path) == ".pdf" {
+		<-ctx.Done()
+	}
+	return nil
+}
+
+func TestWorkTimeout(t *testing.T) {
+	assert := assert.New(t)
+	w := &HangWorker{TestWorker: NewTW()}
+	sw := skywalker.New(root, w)
+	sw.WorkTimeout = 10 * time.Millisecond
+	err := sw.Walk()
+	var workErrs skywalker.WorkErrors
+	if assert.True(errors.As(err, &workErrs), "Expected WorkErrors but got %v", err) {
+		assert.Len(workErrs, 4)
+	}
+	var te *skywalker.WorkTimeoutError
+	if assert.ErrorAs(err, &te) {
+		assert.Equal(".pdf", filepath.Ext(te.Path))
+		assert.Equal(sw.WorkTimeout, te.Timeout)
+	}
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+	assert.Len(w.found, 16)
+
+	sw = skywalker.New(root, NewTW())
+	sw.WorkTimeout = time.Second
+	var ce *skywalker.ConfigError
+	if assert.ErrorAs(sw.Walk(), &ce) {
+		assert.Equal("Worker", ce.Field)
+	}
+}